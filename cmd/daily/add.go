@@ -0,0 +1,87 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/max-pantom/daily/internal/daemon"
+	"github.com/max-pantom/daily/internal/i18n"
+	"github.com/max-pantom/daily/internal/state"
+)
+
+// cmdAdd logs a session after the fact, from one clock time to another on
+// --day, today by default. An end before the start runs past midnight. Time
+// already logged is refused, trimmed off or merged per --on-overlap.
+func cmdAdd(c *cmdContext, args []string) error {
+	fs := newFlagSet("add")
+	var tags multiString
+	fs.Var(&tags, "tag", "tag for the session (repeatable)")
+	note := fs.String("note", "", "note for the session")
+	project := fs.String("project", "", "project (e.g. client) the session belongs to")
+	dayArg := fs.String("day", "today", "day the session started: YYYY-MM-DD, today or yesterday")
+	policy := fs.String("on-overlap", state.OverlapRefuse, "when it overlaps logged sessions: refuse, trim (add only the free time) or merge (join them into one)")
+	rest := parseInterspersed(fs, args)
+	if len(rest) != 2 {
+		return errors.New("usage: daily add <start> <end> [--day YYYY-MM-DD|yesterday] [--tag t] [--note n] [--project p] [--on-overlap refuse|trim|merge]")
+	}
+	day, err := parseDayArg(*dayArg, c.now)
+	if err != nil {
+		return err
+	}
+	start, err := clockOn(day, rest[0])
+	if err != nil {
+		return err
+	}
+	end, err := clockOn(day, rest[1])
+	if err != nil {
+		return err
+	}
+	if !end.After(start) {
+		end = end.AddDate(0, 0, 1) // past midnight
+	}
+
+	st := c.st
+	if err := st.CheckTags(tags); err != nil {
+		return err
+	}
+	warnTagTypos(st, tags)
+	sess := state.Session{Tags: tags, Note: *note, Project: *project}
+	added, err := st.AddSession(sess, start, end, c.now, *policy)
+	var overlap *state.OverlapError
+	if errors.As(err, &overlap) {
+		return fmt.Errorf("%w; use --on-overlap trim or merge", err)
+	}
+	if err != nil {
+		return err
+	}
+	if err := daemon.Save(statePath(), st); err != nil {
+		return err
+	}
+	fmt.Print(i18n.T("Added %s from %s to %s", state.HumanSeconds(int(added.Seconds())), i18n.Time(start), i18n.Time(end)))
+	if len(tags) > 0 {
+		fmt.Printf(" [tags: %s]", strings.Join(tags, ","))
+	}
+	if *note != "" {
+		fmt.Printf(" note: %s", *note)
+	}
+	if *project != "" {
+		fmt.Printf(" project: %s", *project)
+	}
+	if trimmed := end.Sub(start) - added; *policy == state.OverlapTrim && trimmed > 0 {
+		fmt.Print(i18n.T(" (%s already logged left out)", state.HumanSeconds(int(trimmed.Seconds()))))
+	}
+	fmt.Println()
+	return nil
+}
+
+// clockOn is the clock time v on day, in local time.
+func clockOn(day time.Time, v string) (time.Time, error) {
+	hour, min, err := parseClock(v)
+	if err != nil {
+		return time.Time{}, err
+	}
+	y, m, d := day.Date()
+	return time.Date(y, m, d, hour, min, 0, 0, time.Local), nil
+}
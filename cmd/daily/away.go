@@ -0,0 +1,166 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/max-pantom/daily/internal/daemon"
+	"github.com/max-pantom/daily/internal/i18n"
+	"github.com/max-pantom/daily/internal/idle"
+	"github.com/max-pantom/daily/internal/notify"
+	"github.com/max-pantom/daily/internal/state"
+)
+
+// away is a session watch paused for idleness, remembered until the user
+// comes back so they can decide what the idle time was.
+type away struct {
+	since time.Time     // last input before going idle
+	sess  state.Session // the paused session's labels
+}
+
+// Answers to the idle prompt.
+const (
+	idleKeep    = "work"
+	idleBreak   = "break"
+	idleDiscard = "discard"
+)
+
+// askAboutIdle asks whether the time between a.since and back was work, a
+// break or neither, records the answer and resumes the session at back. A
+// dismissed prompt leaves the session paused and the idle time discarded.
+// env is what tag rules see for the resumed session.
+func askAboutIdle(a away, back time.Time, env state.RuleEnv, log *slog.Logger) {
+	gone := state.HumanMinutes(int(back.Sub(a.since).Minutes()))
+	choice := notify.SendActions("Daily", fmt.Sprintf("Welcome back. You were idle for %s since %s.", gone, i18n.Time(a.since)), []notify.Action{
+		{Key: idleKeep, Label: "Keep as work"},
+		{Key: idleBreak, Label: "Count as break"},
+		{Key: idleDiscard, Label: "Discard"},
+	})
+	log.Info("idle prompt", "since", a.since, "back", back, "choice", choice)
+	if choice == "" {
+		return
+	}
+	err := daemon.Update(statePath(), func(st *state.State) error {
+		st.RuleEnv = env
+		switch choice {
+		case idleKeep:
+			st.AddWork(a.sess, a.since, back)
+		case idleBreak:
+			st.AddBreak(a.since, back)
+		}
+		// Someone already started something else by hand; leave it be.
+		if st.ActiveSession != nil || st.ActiveBreak != nil {
+			return nil
+		}
+		if err := st.StartSession(back, a.sess.Tags, a.sess.Note); err != nil {
+			return err
+		}
+		st.ActiveSession.Project = a.sess.Project
+		return nil
+	})
+	if err != nil {
+		log.Error("record idle choice", "err", err)
+		return
+	}
+	fmt.Printf("Idle time since %s: %s; session resumed\n", i18n.Time(a.since), choice)
+}
+
+// idleTracker turns idle-time samples into idle stretches for watch. Input
+// lasting no longer than grace inside a stretch, like nudging the mouse, does
+// not end it, and the user only counts as back after polls samples in a row
+// with input.
+type idleTracker struct {
+	grace time.Duration
+	polls int
+
+	since   time.Time // start of the current idle stretch
+	quietAt time.Time // last sample without new input
+	lastAt  time.Time // last sample
+	backAt  time.Time // first input seen after the last quiet sample
+	active  int       // samples in a row with input that counted
+}
+
+// observe records idleNow, the system idle time sampled at now. It returns
+// how long the user has been idle, zero while input is coming in, and when
+// they came back once enough active samples have been seen.
+func (t *idleTracker) observe(now time.Time, idleNow time.Duration) (idleFor time.Duration, back time.Time) {
+	last := now.Add(-idleNow) // last input
+	input := !t.lastAt.IsZero() && last.After(t.lastAt)
+	if input && t.quietAt.Equal(t.lastAt) {
+		t.backAt = last
+	}
+	t.lastAt = now
+	switch {
+	case !input:
+		if t.since.IsZero() || t.active > 0 {
+			t.since = last
+		}
+		t.quietAt, t.active = now, 0
+		return now.Sub(t.since), time.Time{}
+	case t.active == 0 && last.Sub(t.quietAt) <= t.grace:
+		// A blip; the stretch goes on unless input keeps coming past grace.
+		return 0, time.Time{}
+	}
+	t.active++
+	t.since = last
+	if t.active >= t.polls {
+		return 0, t.backAt
+	}
+	return 0, time.Time{}
+}
+
+// maxIdleBackoff caps how long watch waits before asking a failing idle
+// backend again.
+const maxIdleBackoff = 5 * time.Minute
+
+// idleProbe samples idle time for watch. While the idle backend fails it is
+// retried with growing pauses, and a locked screen stands in for idle time.
+type idleProbe struct {
+	interval time.Duration
+	lock     bool // fall back to the screen lock
+	log      *slog.Logger
+
+	failures int
+	retryAt  time.Time
+	lockedAt time.Time
+}
+
+// sample returns the idle time at now, or false when neither the backend nor
+// the lock fallback can tell; watch then leaves the session alone and relies
+// on its heartbeat and sleep detection.
+func (p *idleProbe) sample(now time.Time) (time.Duration, bool) {
+	if !now.Before(p.retryAt) {
+		d, err := idle.Duration()
+		if err == nil {
+			if p.failures > 0 {
+				fmt.Println("watch: idle detection works again")
+				p.log.Info("idle detection recovered", "failures", p.failures)
+			}
+			p.failures, p.lockedAt = 0, time.Time{}
+			return d, true
+		}
+		p.failures++
+		backoff := min(p.interval<<min(p.failures, 8), maxIdleBackoff)
+		p.retryAt = now.Add(backoff)
+		if p.failures == 1 {
+			fmt.Println("watch: idle check failed; retrying with backoff:", err)
+		}
+		p.log.Warn("idle check failed", "err", err, "failures", p.failures, "retry_in", backoff)
+	}
+	if !p.lock {
+		return 0, false
+	}
+	locked, err := idle.Locked()
+	if err != nil {
+		return 0, false
+	}
+	if !locked {
+		p.lockedAt = time.Time{}
+		return 0, true
+	}
+	if p.lockedAt.IsZero() {
+		p.lockedAt = now
+	}
+	return now.Sub(p.lockedAt), true
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/max-pantom/daily/internal/idle"
+)
+
+// mockIdle points the idle package at a file the test rewrites between polls,
+// the way DAILY_IDLE_MOCK drives a running watch.
+func mockIdle(t *testing.T) func(string) {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "idle")
+	t.Setenv(idle.MockEnv, file)
+	return func(v string) {
+		t.Helper()
+		if err := os.WriteFile(file, []byte(v), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// TestWatchIdle polls the way watch does: the probe reads the mocked idle
+// time and the tracker turns it into an idle stretch and a return.
+func TestWatchIdle(t *testing.T) {
+	set := mockIdle(t)
+	probe := idleProbe{interval: time.Second, log: slog.New(slog.DiscardHandler)}
+	tracker := idleTracker{grace: 5 * time.Second, polls: 2}
+	start := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+
+	steps := []struct {
+		idle    string
+		wantFor time.Duration
+		back    bool
+	}{
+		{"0s", 0, false},
+		{"1m", time.Minute, false},
+		{"2m", 2 * time.Minute, false},
+		{"0s", 0, false}, // input, but one poll is not back yet
+		{"0s", 0, true},  // second poll in a row with input
+		{"1m", time.Minute, false},
+	}
+	for i, s := range steps {
+		set(s.idle)
+		now := start.Add(time.Duration(i) * time.Minute)
+		d, ok := probe.sample(now)
+		if !ok {
+			t.Fatalf("poll %d: no idle reading", i)
+		}
+		idleFor, back := tracker.observe(now, d)
+		if idleFor != s.wantFor || back.IsZero() == s.back {
+			t.Errorf("poll %d: idle for %v, back %v; want %v, back %v", i, idleFor, back, s.wantFor, s.back)
+		}
+	}
+}
+
+// TestWatchIdleBlip checks that brief input inside an idle stretch, shorter
+// than grace, does not end it.
+func TestWatchIdleBlip(t *testing.T) {
+	set := mockIdle(t)
+	probe := idleProbe{interval: time.Second, log: slog.New(slog.DiscardHandler)}
+	tracker := idleTracker{grace: 10 * time.Second, polls: 1}
+	start := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+
+	for i, v := range []string{"0s", "1m", "2m"} {
+		set(v)
+		now := start.Add(time.Duration(i) * time.Minute)
+		d, _ := probe.sample(now)
+		tracker.observe(now, d)
+	}
+	// The mouse was nudged 55s ago, 5s after the last quiet poll.
+	set("55s")
+	now := start.Add(3 * time.Minute)
+	d, _ := probe.sample(now)
+	if idleFor, back := tracker.observe(now, d); idleFor != 0 || !back.IsZero() {
+		t.Errorf("blip: idle for %v, back %v; want the stretch held", idleFor, back)
+	}
+	set("1m55s")
+	now = start.Add(4 * time.Minute)
+	d, _ = probe.sample(now)
+	if idleFor, _ := tracker.observe(now, d); idleFor != 4*time.Minute {
+		t.Errorf("after blip: idle for %v, want 4m", idleFor)
+	}
+}
+
+// TestWatchIdleLockFallback checks that a failing idle backend is retried with
+// backoff and that a locked screen stands in for idle time meanwhile.
+func TestWatchIdleLockFallback(t *testing.T) {
+	set := mockIdle(t)
+	start := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+
+	probe := idleProbe{interval: time.Second, log: slog.New(slog.DiscardHandler)}
+	set("locked") // fails as idle time
+	if _, ok := probe.sample(start); ok {
+		t.Fatal("without the lock fallback a failing backend should give no reading")
+	}
+	if probe.failures != 1 || !probe.retryAt.After(start) {
+		t.Fatalf("failures %d, retry at %v; want a backoff", probe.failures, probe.retryAt)
+	}
+
+	probe = idleProbe{interval: time.Second, lock: true, log: slog.New(slog.DiscardHandler)}
+	if d, ok := probe.sample(start); !ok || d != 0 {
+		t.Fatalf("just locked: %v, %v; want 0, true", d, ok)
+	}
+	if d, ok := probe.sample(start.Add(3 * time.Minute)); !ok || d != 3*time.Minute {
+		t.Errorf("locked 3m: %v, %v; want 3m, true", d, ok)
+	}
+	set("unlocked")
+	if d, ok := probe.sample(start.Add(4 * time.Minute)); !ok || d != 0 {
+		t.Errorf("unlocked: %v, %v; want 0, true", d, ok)
+	}
+	// Once the backend answers again the backoff is forgotten.
+	set("30s")
+	later := probe.retryAt.Add(time.Second)
+	if d, ok := probe.sample(later); !ok || d != 30*time.Second || probe.failures != 0 {
+		t.Errorf("recovered: %v, %v, %d failures; want 30s, true, 0", d, ok, probe.failures)
+	}
+}
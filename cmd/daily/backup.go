@@ -0,0 +1,269 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/max-pantom/daily/internal/audit"
+	"github.com/max-pantom/daily/internal/backup"
+	"github.com/max-pantom/daily/internal/daemon"
+	"github.com/max-pantom/daily/internal/logging"
+	"github.com/max-pantom/daily/internal/state"
+	"github.com/max-pantom/daily/internal/update"
+)
+
+const backupUsage = "usage: daily backup create [bundle.tar.gz] | daily backup restore <bundle.tar.gz> [--force]"
+
+func runBackup(args []string, now time.Time) error {
+	if len(args) > 0 {
+		switch args[0] {
+		case "create":
+			return runBackupCreate(args[1:], now)
+		case "restore":
+			return runBackupRestore(args[1:], now)
+		}
+	}
+	return errors.New(backupUsage)
+}
+
+// backupFiles lists, in bundle order, the names files take in a bundle and
+// where they live for the current state file. Names are those of the default
+// profile, so a bundle restores into any profile.
+func backupFiles() [][2]string {
+	p := statePath()
+	return [][2]string{
+		{"state.json", p},
+		{"config.toml", state.ConfigPath(p)},
+		{audit.FileName, audit.Path(p)},
+		{"sync.json", syncMetaPath()},
+		{"sync.base.json", syncBasePath()},
+		{"team.json", filepath.Join(filepath.Dir(p), "team.json")},
+	}
+}
+
+// backupTarget is where the bundle entry name is restored to, or "" for
+// names this build does not know.
+func backupTarget(name string) string {
+	for _, f := range backupFiles() {
+		if f[0] == name {
+			return f[1]
+		}
+	}
+	if dir, file := path.Split(name); dir == "logs/" && file != "" {
+		return filepath.Join(logging.Dir(statePath()), file)
+	}
+	return ""
+}
+
+// collectBackup reads every file a bundle holds that exists, with the
+// rotated daemon logs.
+func collectBackup() ([]backup.File, error) {
+	var files []backup.File
+	add := func(name, p string) error {
+		info, err := os.Stat(p)
+		if errors.Is(err, os.ErrNotExist) || err == nil && !info.Mode().IsRegular() {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		files = append(files, backup.File{Name: name, Data: data, ModTime: info.ModTime()})
+		return nil
+	}
+	for _, f := range backupFiles() {
+		if err := add(f[0], f[1]); err != nil {
+			return nil, err
+		}
+	}
+	logs, err := os.ReadDir(logging.Dir(statePath()))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	for _, e := range logs {
+		if err := add("logs/"+e.Name(), filepath.Join(logging.Dir(statePath()), e.Name())); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// writeBackup writes the current files to out, refusing to replace an
+// existing file unless overwrite is set.
+func writeBackup(out string, overwrite bool, now time.Time) (backup.Manifest, error) {
+	files, err := collectBackup()
+	if err != nil {
+		return backup.Manifest{}, err
+	}
+	if len(files) == 0 {
+		return backup.Manifest{}, fmt.Errorf("nothing to back up: no state at %s", statePath())
+	}
+	m := backup.Manifest{Version: update.Current(), Created: now}
+	m.Host, _ = os.Hostname()
+	var buf bytes.Buffer
+	if err := backup.Write(&buf, m, files); err != nil {
+		return m, err
+	}
+	mode := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	if overwrite {
+		mode = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+	f, err := os.OpenFile(out, mode, 0o600)
+	if errors.Is(err, os.ErrExist) {
+		return m, fmt.Errorf("%s already exists (--force to replace it)", out)
+	}
+	if err != nil {
+		return m, err
+	}
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		f.Close()
+		return m, err
+	}
+	m.Files = make([]string, len(files))
+	for i, file := range files {
+		m.Files[i] = file.Name
+	}
+	return m, f.Close()
+}
+
+func runBackupCreate(args []string, now time.Time) error {
+	fs := newFlagSet("backup")
+	force := fs.Bool("force", false, "replace the bundle if it exists")
+	rest := parseInterspersed(fs, args)
+	out := "daily-backup-" + now.Format("2006-01-02") + ".tar.gz"
+	switch len(rest) {
+	case 0:
+	case 1:
+		out = expandHome(rest[0])
+	default:
+		return errors.New(backupUsage)
+	}
+	m, err := writeBackup(out, *force, now)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Backed up %d file(s) to %s\n", len(m.Files), out)
+	return nil
+}
+
+// runBackupRestore replaces the local data with a bundle's. The state goes
+// through the daemon when one is running, so it serves the restored history
+// at once; the other files are written in place. Data already tracked here
+// is only replaced with --force, after saving it to a bundle of its own.
+func runBackupRestore(args []string, now time.Time) error {
+	fs := newFlagSet("backup")
+	force := fs.Bool("force", false, "replace the history tracked here (it is saved to a bundle first)")
+	rest := parseInterspersed(fs, args)
+	if len(rest) != 1 {
+		return errors.New(backupUsage)
+	}
+	f, err := os.Open(expandHome(rest[0]))
+	if err != nil {
+		return err
+	}
+	m, files, err := backup.Read(f)
+	f.Close()
+	if err != nil {
+		return err
+	}
+	restored, err := loadBackupState(files)
+	if err != nil {
+		return err
+	}
+	if m.Version != update.Current() {
+		fmt.Fprintf(os.Stderr, "note: the backup was made by daily %s, this is %s\n", m.Version, update.Current())
+	}
+
+	p := statePath()
+	cur, err := daemon.Load(p)
+	if err != nil && !*force {
+		return fmt.Errorf("cannot read the current state (%v); restore over it with --force", err)
+	}
+	if cur == nil || len(cur.Days) > 0 {
+		if !*force {
+			return fmt.Errorf("%s already holds %d day(s) of history; restore over it with --force (it is saved to a bundle first)", p, len(cur.Days))
+		}
+		keep := filepath.Join(filepath.Dir(p), "daily-before-restore-"+now.Format("20060102-150405")+".tar.gz")
+		if _, err := writeBackup(keep, false, now); err != nil {
+			return fmt.Errorf("saving the current data: %w", err)
+		}
+		fmt.Printf("Saved the current data to %s\n", keep)
+	}
+
+	for _, file := range files {
+		if file.Name == "state.json" || file.Name == "config.toml" {
+			continue
+		}
+		target := backupTarget(file.Name)
+		if target == "" {
+			fmt.Fprintf(os.Stderr, "warning: skipped %s, unknown to this version\n", file.Name)
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(target, file.Data, 0o600); err != nil {
+			return err
+		}
+	}
+	if cur == nil {
+		err = daemon.Save(p, restored)
+	} else {
+		err = daemon.Update(p, func(st *state.State) error {
+			rev := st.Revision
+			*st = *restored
+			st.Revision = rev
+			return nil
+		})
+	}
+	if err != nil {
+		return err
+	}
+	host := ""
+	if m.Host != "" {
+		host = " from " + m.Host
+	}
+	fmt.Printf("Restored %d file(s) and %d day(s) of history%s, backed up %s\n", len(files), len(restored.Days), host, m.Created.Local().Format("2006-01-02 15:04"))
+	return nil
+}
+
+// loadBackupState reads the bundle's state and config the way a state file
+// is loaded, migrating older layouts, by unpacking them to a scratch
+// directory.
+func loadBackupState(files []backup.File) (*state.State, error) {
+	dir, err := os.MkdirTemp("", "daily-restore-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	p := filepath.Join(dir, "state.json")
+	found := false
+	for _, file := range files {
+		switch file.Name {
+		case "state.json":
+			found = true
+		case "config.toml":
+		default:
+			continue
+		}
+		if err := os.WriteFile(filepath.Join(dir, file.Name), file.Data, 0o600); err != nil {
+			return nil, err
+		}
+	}
+	if !found {
+		return nil, errors.New("the backup holds no state.json")
+	}
+	st, err := state.Load(p)
+	if err != nil {
+		return nil, fmt.Errorf("the backup's state: %w", err)
+	}
+	return st, nil
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/max-pantom/daily/internal/chart"
+	"github.com/max-pantom/daily/internal/state"
+)
+
+func runChart(st *state.State, args []string, now time.Time) error {
+	fs := newFlagSet("chart")
+	heatmap := fs.Bool("heatmap", false, "yearly heatmap of daily work (default)")
+	weekly := fs.Bool("weekly", false, "bar chart of weekly totals")
+	weeks := fs.Int("weeks", 12, "weeks shown by --weekly")
+	out := fs.String("out", "", "output file, .png or .svg")
+	var ff filterFlags
+	ff.register(fs)
+	fs.Parse(args)
+	filter, err := ff.filter()
+	if err != nil {
+		return err
+	}
+	if *out == "" {
+		return errors.New("usage: daily chart [--heatmap|--weekly] --out chart.png|chart.svg")
+	}
+	if *heatmap && *weekly {
+		return errors.New("pick one of --heatmap and --weekly")
+	}
+	format, err := chart.FormatForPath(*out)
+	if err != nil {
+		return err
+	}
+
+	// Only the plotted weeks are read, not the whole history.
+	if filter.From == "" {
+		n := 53
+		if *weekly {
+			n = *weeks
+		}
+		filter.From = now.AddDate(0, 0, -7*(n+1)).Format("2006-01-02")
+	}
+	seconds := make(map[string]int)
+	for _, key := range st.DayKeys(filter) {
+		seconds[key] = st.FilteredWorkSeconds(key, filter)
+	}
+
+	f, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+	if *weekly {
+		err = chart.Weekly(f, format, seconds, *weeks, now)
+	} else {
+		err = chart.Heatmap(f, format, seconds, st.DayGoal, now)
+	}
+	if err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	fmt.Printf("wrote %s\n", *out)
+	return nil
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/max-pantom/daily/internal/state"
+)
+
+// palette colours history bars and status lines. lipgloss drops the colours
+// when stdout is not a terminal, NO_COLOR is set or --no-color is given.
+type palette struct {
+	bar  lipgloss.Style // work
+	goal lipgloss.Style // work on a day that met its goal
+	mark lipgloss.Style // goal markers and other quiet detail
+	run  lipgloss.Style // a running session
+	brk  lipgloss.Style // a break
+	warn lipgloss.Style // over break, interrupted
+}
+
+func colors(st *state.State) palette {
+	fg := func(c string) lipgloss.Style { return lipgloss.NewStyle().Foreground(lipgloss.Color(c)) }
+	if st != nil && st.Theme == state.ThemeColorblind {
+		// Okabe–Ito: blue and orange stay apart for red-green colour blindness.
+		return palette{bar: fg("#56B4E9"), goal: fg("#0072B2"), mark: fg("#8a8a8a"), run: fg("#E69F00"), brk: fg("#56B4E9"), warn: fg("#D55E00")}
+	}
+	return palette{bar: fg("#8aa788"), goal: fg("#5faf5f"), mark: fg("#656D65"), run: fg("#FFA132"), brk: fg("#7fb3ff"), warn: fg("#ff4d4d")}
+}
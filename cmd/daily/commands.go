@@ -0,0 +1,411 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/max-pantom/daily/internal/audit"
+	"github.com/max-pantom/daily/internal/calendar"
+	"github.com/max-pantom/daily/internal/daemon"
+	"github.com/max-pantom/daily/internal/i18n"
+	"github.com/max-pantom/daily/internal/notify"
+	"github.com/max-pantom/daily/internal/state"
+)
+
+// command is one `daily` subcommand.
+type command struct {
+	name    string
+	aliases []string
+	args    string // synopsis after the name, e.g. "[--for d]"
+	summary string
+	flags   bool // parses flags with newFlagSet, which prints its help
+	json    bool // honours --json
+	noState bool // runs without loading the state first
+	run     func(c *cmdContext, args []string) error
+}
+
+// cmdContext is what a command runs with. st is nil for noState commands.
+type cmdContext struct {
+	st  *state.State
+	now time.Time
+}
+
+// global holds the flags every command accepts, before or after its name.
+var global struct {
+	state   string // state file to use instead of the default
+	profile string // named state kept apart from the default one
+	json    bool   // machine-readable output
+	quiet   bool   // no output except errors
+	noColor bool   // plain output; NO_COLOR does the same
+}
+
+// commands lists every subcommand in the order `daily help` shows them.
+var commands []*command
+
+func init() {
+	commands = []*command{
+		{name: "start", args: "[--for d]", summary: "Start tracking (optionally stop after d, e.g. 90m; --force past the cap)", flags: true, run: cmdStart},
+		{name: "stop", summary: "Stop current session", run: cmdStop},
+		{name: "add", args: "<start> <end>", summary: "Log a session after the fact, e.g. 9:00 11:30 --day yesterday (--on-overlap refuse|trim|merge)", flags: true, run: cmdAdd},
+		{name: "toggle", summary: "Start tracking, or stop if a session is running", run: func(c *cmdContext, args []string) error {
+			return runToggle(c.st, c.now)
+		}},
+		{name: "break", summary: "Start a break, or end the current one", run: func(c *cmdContext, args []string) error {
+			return runBreak(c.st, c.now)
+		}},
+		{name: "interrupt", args: "<reason>", summary: "Pause the session for an interruption, e.g. \"phone call\"; run again without a reason to resume", run: func(c *cmdContext, args []string) error {
+			return runInterrupt(c.st, args, c.now)
+		}},
+		{name: "url", args: "<daily://…>", summary: "Run a daily://start?tag=x, stop, toggle or break URL (x-success/x-error callbacks)", run: func(c *cmdContext, args []string) error {
+			return runURL(c.st, args, c.now)
+		}},
+		{name: "url-handler", args: "install", summary: "Register daily:// URLs for Shortcuts, Focus Filters and NFC tags (uninstall, status)", noState: true, run: func(c *cmdContext, args []string) error {
+			return runURLHandler(args)
+		}},
+		{name: "status", args: "[--forecast]", summary: "Show today status (--forecast: will this week reach its goal?)", flags: true, json: true, run: cmdStatus},
+		{name: "prompt", args: "[--format f]", summary: "Print a short segment for a shell prompt, e.g. \"⏱ 3h12m/8h ▶\" (cached, for starship/powerlevel10k)", flags: true, noState: true, run: func(c *cmdContext, args []string) error {
+			return runPrompt(args, c.now)
+		}},
+		{name: "today", args: "[--apps] [--timeline]", summary: "Show today sessions (and per-app time or a timeline)", flags: true, json: true, run: cmdDay},
+		{name: "day", args: "[date]", summary: "Show one day: YYYY-MM-DD, yesterday or -N days ago", flags: true, json: true, run: cmdDay},
+		{name: "history", args: "[days]", summary: "Show recent days summary with bars (default 7; --goal-line, --bars=false, --monthly for month totals)", flags: true, json: true, run: cmdHistory},
+		{name: "stats", args: "[days]", summary: "Session lengths, sessions per day, longest focus stretch and fragmentation (default 30 days)", flags: true, json: true, run: cmdStats},
+		{name: "search", args: "<text>", summary: "Find sessions by note, tag or project", flags: true, json: true, run: cmdSearch},
+		{name: "report", args: "[--month]", summary: "Weekly/monthly summary (--format md|csv, --output f.html, --email addr)", flags: true, run: func(c *cmdContext, args []string) error {
+			return runReport(c.st, args, c.now)
+		}},
+		{name: "standup", summary: "Yesterday / today so far from notes and tags, ready to paste into Slack", run: cmdStandup},
+		{name: "review", summary: "Walk through last week: goals met, untagged time, re-tag/annotate sessions", flags: true, run: cmdReview},
+		{name: "chart", args: "--out f", summary: "Heatmap of the last year (--weekly for weekly bars) as .png or .svg", flags: true, run: func(c *cmdContext, args []string) error {
+			return runChart(c.st, args, c.now)
+		}},
+		{name: "export", args: "--obsidian|--format f|--gsheet id", summary: "Day's time log into its Markdown daily note, an invoicing CSV (harvest, freshbooks) or a Google Sheets tab", flags: true, run: func(c *cmdContext, args []string) error {
+			return runExport(c.st, args, c.now)
+		}},
+		{name: "set-rate", args: "<p> <r>", summary: "Hourly rate for project p (default for all others; off to remove)", run: func(c *cmdContext, args []string) error {
+			return runSetRate(c.st, args)
+		}},
+		{name: "set-obsidian", summary: "Default vault, note path pattern and template for export --obsidian", flags: true, run: func(c *cmdContext, args []string) error {
+			return runSetObsidian(c.st, args)
+		}},
+		{name: "enrich", args: "--github", summary: "Add a summary of your commits, PRs and reviews to each session note", flags: true, run: func(c *cmdContext, args []string) error {
+			return runEnrich(c.st, args, c.now)
+		}},
+		{name: "push", args: "--to jira", summary: "Log time from issue-tagged sessions (PROJ-123) to Jira or Linear, or daily totals to a team server (--dry-run)", flags: true, run: func(c *cmdContext, args []string) error {
+			return runPush(c.st, args, c.now)
+		}},
+		{name: "team", args: "serve|report", summary: "Share anonymous daily totals with a team server (push --team url) and report team capacity", flags: true, json: true, run: func(c *cmdContext, args []string) error {
+			return runTeam(c.st, args, c.now)
+		}},
+		{name: "sync", args: "[setup|status]", summary: "Sync the history with other machines through S3, WebDAV or HTTP, encrypted (setup configures it)", flags: true, run: func(c *cmdContext, args []string) error {
+			return runSync(c.st, args, c.now)
+		}},
+		{name: "backup", args: "create|restore <f>", summary: "Pack the history, config, audit log and logs into one .tar.gz for another machine, or restore one (--force over existing data)", flags: true, noState: true, run: func(c *cmdContext, args []string) error {
+			return runBackup(args, c.now)
+		}},
+		{name: "set-jira", summary: "Jira site and account for push (--url --email; token from DAILY_JIRA_TOKEN)", flags: true, run: func(c *cmdContext, args []string) error {
+			return runSetJira(c.st, args)
+		}},
+		{name: "set-smtp", summary: "Configure SMTP for report --email (--host --port --user --from)", flags: true, run: func(c *cmdContext, args []string) error {
+			return runSetSMTP(c.st, args)
+		}},
+		{name: "sprint", args: "[skip | extend <m>]", summary: "Run work/break cycles with notifications; skip or extend the running phase", flags: true, run: func(c *cmdContext, args []string) error {
+			startDaemon()
+			return runSprint(args, c.st.RuleEnv)
+		}},
+		{name: "watch", args: "[--apps]", summary: "Auto-pause when idle; --apps samples the foreground app", flags: true, run: func(c *cmdContext, args []string) error {
+			startDaemon()
+			return runWatch(args, c.st.RuleEnv)
+		}},
+		{name: "focus", args: "--block d", summary: "Block sites (comma list) while a session runs (needs sudo)", flags: true, run: func(c *cmdContext, args []string) error {
+			return runFocus(args)
+		}},
+		{name: "set-goal", args: "<h|m>", summary: "Set daily goal in hours (<=24) or minutes", run: cmdSetGoal},
+		{name: "set-breaks", args: "<m>", summary: "Set break reminder interval (minutes)", run: cmdSetBreaks},
+		{name: "set-tray-title", args: "<auto|total>", summary: "Tray shows countdown mm:ss (auto) or daily total", run: cmdSetTrayTitle},
+		{name: "set-cap", args: "<h|m|off>", summary: "Hard daily limit with escalating alerts (--strict blocks start)", flags: true, run: cmdSetCap},
+		{name: "set-rounding", args: "<nearest|up|down> [m]", summary: "Round durations in today/history/report (off to disable)", run: cmdSetRounding},
+		{name: "set-min-session", args: "<d> [discard|merge]", summary: "Drop or merge sessions shorter than d, e.g. 60s (off to disable)", run: cmdSetMinSession},
+		{name: "set-checkpoint", args: "<m|off>", summary: "Autosave the running session every m minutes (default 5)", run: cmdSetCheckpoint},
+		{name: "set-auto-stop", args: "<HH:MM|off>", summary: "Stop a session left running past this time and flag the day", run: cmdSetAutoStop},
+		{name: "set-hotkey", args: "<toggle|break> <keys|off>", summary: "Global shortcut, e.g. cmd+shift+d (Super off macOS)", run: func(c *cmdContext, args []string) error {
+			return runSetHotkey(c.st, args)
+		}},
+		{name: "set-sound", args: "<e> <on|off|f>", summary: "Play a sound on work_end, break_end or goal", run: cmdSetSound},
+		{name: "set-calendar", args: "<f>", summary: "Use an .ics file/URL to tag meetings (off to disable)", run: cmdSetCalendar},
+		{name: "config", args: "get|set|unset|list|edit", summary: "View or change any setting by key, e.g. config set goal 7h30m (edit opens $EDITOR)", json: true, run: func(c *cmdContext, args []string) error {
+			return runConfig(c.st, args)
+		}},
+		{name: "ui", summary: "Open live terminal dashboard", noState: true, run: func(c *cmdContext, args []string) error {
+			runUI()
+			return nil
+		}},
+		{name: "tray", summary: "Launch macOS/Linux tray menu", run: cmdTray},
+		{name: "daemon", summary: "Serve state to ui/tray/watch/sprint (started by them if needed)", run: cmdDaemon},
+		{name: "install", args: "[--user | --path dir]", summary: "Copy binary to /usr/local/bin/daily (--user: ~/.local/bin or $GOBIN, no sudo)", flags: true, noState: true, run: cmdInstall},
+		{name: "audit", args: "[-n 50]", summary: "Show who changed what: every write with its frontend (--source, --day)", flags: true, noState: true, run: func(c *cmdContext, args []string) error {
+			return runAudit(args)
+		}},
+		{name: "notify", args: "[message]", summary: "Send a test notification to the desktop and any push service (push.service), saying how each fared", run: func(c *cmdContext, args []string) error {
+			return runNotify(c.st, args)
+		}},
+		{name: "logs", args: "[-f] [c]", summary: "Show daemon/watch/tray/sprint logs (-n lines, --level warn)", flags: true, noState: true, run: func(c *cmdContext, args []string) error {
+			return runLogs(args)
+		}},
+		{name: "recalc", args: "[--dry-run]", summary: "Rebuild every day's work and break totals from its sessions and breaks", flags: true, run: func(c *cmdContext, args []string) error {
+			return runRecalc(c.st, args)
+		}},
+		{name: "doctor", args: "[--fix]", summary: "Check setup (state file, tools, daemon, clock) and suggest fixes; --fix repairs totals", flags: true, noState: true, run: func(c *cmdContext, args []string) error {
+			return runDoctor(args)
+		}},
+		{name: "update", args: "[--version vX] [--user | --path dir]", summary: "Fetch/install from GitHub (default latest; --check only reports, --rollback undoes the last one)", flags: true, json: true, noState: true, run: func(c *cmdContext, args []string) error {
+			return runUpdate(args)
+		}},
+		{name: "version", summary: "Show the build version", json: true, noState: true, run: cmdVersion},
+		{name: "help", aliases: []string{"-h", "--help"}, args: "[command]", summary: "Show this help, or one command's flags", noState: true, run: cmdHelp},
+	}
+}
+
+// lookup finds a command by name or alias.
+func lookup(name string) *command {
+	for _, c := range commands {
+		if c.name == name {
+			return c
+		}
+		for _, a := range c.aliases {
+			if a == name {
+				return c
+			}
+		}
+	}
+	return nil
+}
+
+// dispatch runs the named command: it rejects flags the command does not
+// take, answers -h/--help, and loads the state for commands that need it.
+func dispatch(name string, args []string) error {
+	cmd := lookup(name)
+	if cmd == nil {
+		return fmt.Errorf("unknown command %q (see daily help)", name)
+	}
+	if global.json && !cmd.json {
+		return fmt.Errorf("daily %s does not support --json", cmd.name)
+	}
+	c := &cmdContext{now: time.Now()}
+	if wantsHelp(args) {
+		if !cmd.flags {
+			cmd.printHelp()
+			return nil
+		}
+		// The command's flag set prints its help and exits before it does
+		// anything; flags that default to a setting show it empty.
+		c.st = &state.State{Days: map[string]*state.DayLog{}}
+		return cmd.run(c, []string{"-h"})
+	}
+	if !cmd.flags {
+		for _, a := range args {
+			if isFlag(a) {
+				return fmt.Errorf("unknown flag %s for daily %s (see daily help %s)", a, cmd.name, cmd.name)
+			}
+		}
+	}
+
+	audit.SetSource(auditSource(cmd.name))
+	if !cmd.noState {
+		st, err := daemon.Load(statePath())
+		if err != nil {
+			return err
+		}
+		if n := len(st.Anomalies); n > 0 && cmd.name != "daemon" {
+			fmt.Fprintf(os.Stderr, "warning: %d problem(s) in the state file; see daily doctor (--fix repairs totals)\n", n)
+		}
+		st.Normalize(c.now)
+		i18n.Use(st.Language, st.Clock)
+		notify.Use(st.NotifyConfig())
+		if cmd.name != "tray" && cmd.name != "daemon" {
+			st.RuleEnv = ruleEnv(st)
+		}
+		c.st = st
+	}
+	return cmd.run(c, args)
+}
+
+// ruleEnv gives tag rules the directory daily runs in and the calendar,
+// fetched the first time a rule needs it. The tray and the daemon run
+// detached, so their directory means nothing and they go without.
+func ruleEnv(st *state.State) state.RuleEnv {
+	dir, _ := os.Getwd()
+	var events []calendar.Event
+	fetched := false
+	return state.RuleEnv{Dir: dir, Events: func() []calendar.Event {
+		if !fetched && st.CalendarSource != "" {
+			events, _ = calendar.Load(st.CalendarSource)
+		}
+		fetched = true
+		return events
+	}}
+}
+
+// parseGlobalFlags takes the global flags out of args, wherever they appear
+// before a "--".
+func parseGlobalFlags(args []string) ([]string, error) {
+	var rest []string
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		if a == "--" {
+			return append(rest, args[i:]...), nil
+		}
+		name, value, hasValue := strings.Cut(strings.TrimLeft(a, "-"), "=")
+		if !strings.HasPrefix(a, "-") {
+			rest = append(rest, a)
+			continue
+		}
+		switch name {
+		case "state", "profile":
+			if !hasValue {
+				if i+1 >= len(args) {
+					return nil, fmt.Errorf("flag --%s needs a value", name)
+				}
+				i++
+				value = args[i]
+			}
+			if name == "state" {
+				global.state = value
+			} else {
+				global.profile = value
+			}
+		case "json", "quiet", "no-color":
+			on := true
+			if hasValue {
+				b, err := strconv.ParseBool(value)
+				if err != nil {
+					return nil, fmt.Errorf("invalid value %q for --%s", value, name)
+				}
+				on = b
+			}
+			switch name {
+			case "json":
+				global.json = on
+			case "quiet":
+				global.quiet = on
+			default:
+				global.noColor = on
+			}
+		default:
+			rest = append(rest, a)
+		}
+	}
+	if global.state != "" && global.profile != "" {
+		return nil, errors.New("use either --state or --profile, not both")
+	}
+	if strings.ContainsAny(global.profile, `/\`) || global.profile == "." || global.profile == ".." {
+		return nil, fmt.Errorf("invalid profile name %q", global.profile)
+	}
+	return rest, nil
+}
+
+// globalArgs repeats the state-selecting global flags for a child process,
+// such as the daemon, so it works on the same state.
+func globalArgs(args ...string) []string {
+	switch {
+	case global.state != "":
+		return append([]string{"--state", global.state}, args...)
+	case global.profile != "":
+		return append([]string{"--profile", global.profile}, args...)
+	}
+	return args
+}
+
+// silence sends standard output to the null device for --quiet.
+func silence() {
+	if null, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0); err == nil {
+		os.Stdout = null
+	}
+}
+
+func wantsHelp(args []string) bool {
+	for _, a := range args {
+		if a == "--" {
+			return false
+		}
+		if a == "-h" || a == "-help" || a == "--help" {
+			return true
+		}
+	}
+	return false
+}
+
+// isFlag reports whether a looks like a flag rather than a negative number.
+func isFlag(a string) bool {
+	if len(a) < 2 || a[0] != '-' {
+		return false
+	}
+	_, err := strconv.Atoi(a)
+	return err != nil
+}
+
+// newFlagSet returns a flag set for the named command whose help shows the
+// command's synopsis above its flags. Unknown flags are an error.
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	fs.SetOutput(os.Stdout)
+	fs.Usage = func() {
+		if cmd := lookup(name); cmd != nil {
+			cmd.printHelp()
+		} else {
+			fmt.Printf("usage: daily %s\n", name)
+		}
+		fmt.Println("\nFlags:")
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+func (c *command) synopsis() string {
+	return strings.TrimSpace("daily " + c.name + " " + c.args)
+}
+
+func (c *command) printHelp() {
+	fmt.Printf("usage: %s\n\n%s\n", c.synopsis(), c.summary)
+}
+
+func usage() {
+	fmt.Println("daily - track your work hours")
+	fmt.Println("Usage:")
+	for _, c := range commands {
+		fmt.Printf("  %-21s %s\n", c.synopsis(), c.summary)
+		if c.name == "history" {
+			fmt.Printf("  %-21s %s\n", "", "today/history filters: --tag t --project p --from/--to YYYY-MM-DD")
+		}
+	}
+	fmt.Println("Global flags (before or after the command):")
+	fmt.Println("  --state f             Use state file f instead of the default")
+	fmt.Println("  --profile name        Use a separate named state, e.g. for a second job")
+	fmt.Println("  --json                Print status, today, day, history and search as JSON")
+	fmt.Println("  --quiet               Print nothing but errors")
+	fmt.Println("  --no-color            Draw without colour, as when NO_COLOR is set")
+}
+
+func cmdHelp(c *cmdContext, args []string) error {
+	if len(args) == 0 {
+		usage()
+		return nil
+	}
+	if len(args) > 1 {
+		return errors.New("usage: daily help [command]")
+	}
+	return dispatch(args[0], []string{"-h"})
+}
+
+// printJSON writes v indented, for --json.
+func printJSON(v any) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/max-pantom/daily/internal/daemon"
+	"github.com/max-pantom/daily/internal/hotkey"
+	"github.com/max-pantom/daily/internal/sound"
+	"github.com/max-pantom/daily/internal/state"
+)
+
+const configUsage = "usage: daily config get <key> | set <key> <value> | unset <key> | list | edit"
+
+func runConfig(st *state.State, args []string) error {
+	if len(args) == 0 {
+		return errors.New(configUsage)
+	}
+	switch sub, args := args[0], args[1:]; {
+	case sub == "get" && len(args) == 1:
+		v, err := st.GetSetting(args[0])
+		if err != nil {
+			return err
+		}
+		if global.json {
+			return printJSON(map[string]string{args[0]: v})
+		}
+		fmt.Println(v)
+		return nil
+	case sub == "set" && len(args) >= 2:
+		key, value := args[0], strings.Join(args[1:], " ")
+		value, err := checkSetting(key, value)
+		if err != nil {
+			return err
+		}
+		if err := st.SetSetting(key, value); err != nil {
+			return err
+		}
+		if err := daemon.Save(statePath(), st); err != nil {
+			return err
+		}
+		v, _ := st.GetSetting(key)
+		fmt.Printf("%s = %s\n", key, v)
+		if strings.HasPrefix(key, "hotkey.") && runtime.GOOS != "windows" {
+			if err := registerHotkeys(st); err != nil {
+				fmt.Fprintf(os.Stderr, "warning: could not install the shortcut: %v\n", err)
+			}
+		}
+		return nil
+	case sub == "unset" && len(args) == 1:
+		if err := st.SetSetting(args[0], ""); err != nil {
+			return err
+		}
+		if err := daemon.Save(statePath(), st); err != nil {
+			return err
+		}
+		v, _ := st.GetSetting(args[0])
+		fmt.Printf("%s reset to %q\n", args[0], v)
+		return nil
+	case sub == "list" && len(args) == 0:
+		values := st.SettingValues()
+		if global.json {
+			out := map[string]string{}
+			for _, v := range values {
+				out[v.Key] = v.Value
+			}
+			return printJSON(out)
+		}
+		for _, v := range values {
+			fmt.Printf("%-18s %s\n", v.Key, v.Value)
+		}
+		return nil
+	case sub == "edit" && len(args) == 0:
+		return editConfig(st)
+	}
+	return errors.New(configUsage)
+}
+
+// checkSetting validates the values state cannot check by itself and returns
+// them in their stored form.
+func checkSetting(key, value string) (string, error) {
+	event, ok := strings.CutPrefix(key, "sound.")
+	if ok && !sound.Valid(event) {
+		return "", fmt.Errorf("unknown sound event %q (want %s)", event, strings.Join(sound.Events, ", "))
+	}
+	if ok {
+		switch value {
+		case "on":
+			return sound.Default, nil
+		case "off", "":
+			return "", nil
+		case sound.Default:
+			return value, nil
+		}
+		if _, err := os.Stat(expandHome(value)); err != nil {
+			return "", err
+		}
+		return expandHome(value), nil
+	}
+	if action, ok := strings.CutPrefix(key, "hotkey."); ok {
+		valid := false
+		for _, a := range hotkey.Actions {
+			valid = valid || a == action
+		}
+		if !valid {
+			return "", fmt.Errorf("unknown hotkey action %q (want %s)", action, strings.Join(hotkey.Actions, ", "))
+		}
+		if value == "" || value == "off" {
+			return value, nil
+		}
+		c, err := hotkey.Parse(strings.ToLower(value))
+		if err != nil {
+			return "", err
+		}
+		return c.String(), nil
+	}
+	return value, nil
+}
+
+// editConfig opens the settings in $VISUAL or $EDITOR and applies the file
+// once the editor exits, reopening it while it does not parse.
+func editConfig(st *state.State) error {
+	var buf bytes.Buffer
+	buf.WriteString("# daily settings; save and quit to apply. Remove a line to restore its default.\n")
+	if err := st.EncodeSettings(&buf); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp("", "daily-config-*.toml")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	for {
+		if err := runEditor(f.Name()); err != nil {
+			return err
+		}
+		data, err := os.ReadFile(f.Name())
+		if err != nil {
+			return err
+		}
+		if bytes.Equal(data, buf.Bytes()) {
+			fmt.Println("No changes")
+			return nil
+		}
+		next := *st
+		next.Rates, next.Sounds, next.Hotkeys = maps.Clone(st.Rates), maps.Clone(st.Sounds), maps.Clone(st.Hotkeys)
+		err = next.DecodeSettings(bytes.NewReader(data))
+		for _, v := range next.SettingValues() {
+			if err != nil {
+				break
+			}
+			var checked string
+			if checked, err = checkSetting(v.Key, v.Value); err == nil && checked != v.Value {
+				err = next.SetSetting(v.Key, checked)
+			}
+		}
+		if err == nil {
+			*st = next
+			if err := daemon.Save(statePath(), st); err != nil {
+				return err
+			}
+			fmt.Println("Settings saved")
+			return nil
+		}
+		fmt.Fprintf(os.Stderr, "%v\nPress Enter to edit again or Ctrl-C to discard. ", err)
+		if _, readErr := bufio.NewReader(os.Stdin).ReadString('\n'); readErr != nil {
+			return err
+		}
+	}
+}
+
+func runEditor(path string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+		if runtime.GOOS == "windows" {
+			editor = "notepad"
+		}
+	}
+	// EDITOR may carry arguments, e.g. "code --wait".
+	parts := strings.Fields(editor)
+	cmd := exec.Command(parts[0], append(parts[1:], path)...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s: %w", editor, err)
+	}
+	return nil
+}
@@ -0,0 +1,317 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/max-pantom/daily/internal/daemon"
+	"github.com/max-pantom/daily/internal/focus"
+	"github.com/max-pantom/daily/internal/state"
+)
+
+// tool is an external program daily shells out to.
+type tool struct {
+	name    string
+	purpose string
+	fix     string
+}
+
+var doctorTools = map[string][]tool{
+	"darwin": {
+		{"osascript", "notifications", "part of macOS; check your PATH"},
+		{"ioreg", "idle detection", "part of macOS; check your PATH"},
+		{"terminal-notifier", "break reminder buttons", "brew install terminal-notifier"},
+		{"afplay", "sounds", "part of macOS; check your PATH"},
+	},
+	"linux": {
+		{"xprintidle", "idle detection on X11", "sudo apt install xprintidle (or your distro's package)"},
+		{"gdbus", "idle detection on GNOME Wayland", "install glib2 / libglib2.0-bin"},
+		{"notify-send", "notifications", "sudo apt install libnotify-bin"},
+		{"pactl", "call detection in watch", "install pulseaudio-utils or pipewire-pulse"},
+		{"paplay", "sounds", "install pulseaudio-utils"},
+		{"xdotool", "watch --apps", "sudo apt install xdotool"},
+	},
+}
+
+// doctor collects check results.
+type doctor struct {
+	problems int
+}
+
+func (d *doctor) ok(format string, args ...any) {
+	fmt.Printf("  ok    %s\n", fmt.Sprintf(format, args...))
+}
+
+func (d *doctor) warn(msg, fix string) {
+	fmt.Printf("  warn  %s\n", msg)
+	if fix != "" {
+		fmt.Printf("        fix: %s\n", fix)
+	}
+}
+
+func (d *doctor) fail(msg, fix string) {
+	d.problems++
+	fmt.Printf("  FAIL  %s\n", msg)
+	if fix != "" {
+		fmt.Printf("        fix: %s\n", fix)
+	}
+}
+
+// runDoctor checks the environment daily depends on and prints fixes. It runs
+// before the state is loaded so it still works when the file is broken. With
+// --fix it first recomputes day totals that disagree with their sessions.
+func runDoctor(args []string) error {
+	fs := newFlagSet("doctor")
+	fix := fs.Bool("fix", false, "repair day totals that disagree with their sessions")
+	fs.Parse(args)
+
+	path := statePath()
+	d := &doctor{}
+
+	fmt.Println("State")
+	d.checkWritable(filepath.Dir(path))
+	if *fix {
+		d.repairTotals(path)
+	}
+	d.checkStateFile(path)
+	d.checkConfigFile(path)
+	d.checkLeftovers(path)
+
+	fmt.Println("Processes")
+	d.checkDaemon(path)
+	d.checkProcesses()
+
+	fmt.Println("Tools")
+	d.checkTools()
+
+	fmt.Println("Clock")
+	d.checkClock()
+
+	if d.problems > 0 {
+		return fmt.Errorf("%d problem(s) found", d.problems)
+	}
+	fmt.Println("No problems found")
+	return nil
+}
+
+func (d *doctor) checkWritable(dir string) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		d.fail(fmt.Sprintf("cannot create %s: %v", dir, err), "check permissions on the parent directory")
+		return
+	}
+	f, err := os.CreateTemp(dir, ".doctor-*")
+	if err != nil {
+		d.fail(fmt.Sprintf("%s is not writable: %v", dir, err), fmt.Sprintf("sudo chown -R %s %s", os.Getenv("USER"), dir))
+		return
+	}
+	f.Close()
+	os.Remove(f.Name())
+	d.ok("%s is writable", dir)
+}
+
+func (d *doctor) checkStateFile(path string) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		d.ok("%s does not exist yet; it is created on first use", path)
+		return
+	}
+	if err != nil {
+		d.fail(fmt.Sprintf("cannot read %s: %v", path, err), "check the file's permissions")
+		return
+	}
+	var st state.State
+	if err := json.Unmarshal(data, &st); err != nil {
+		d.fail(fmt.Sprintf("%s is not valid JSON: %v", path, err), fmt.Sprintf("restore a backup, or move it aside: mv %s %s.broken", path, path))
+		return
+	}
+	problems := st.Check(time.Now())
+	if len(problems) == 0 {
+		d.ok("%s is consistent (%d days)", path, len(st.Days))
+		return
+	}
+	for _, p := range problems {
+		d.warn(p, "")
+	}
+	d.warn(fmt.Sprintf("%d inconsistencies in the state file", len(problems)), "daily doctor --fix repairs totals; edit other listed days in "+path+" while no daily process is running")
+}
+
+func (d *doctor) repairTotals(path string) {
+	if _, err := os.Stat(path); err != nil {
+		return
+	}
+	var fixed []string
+	err := daemon.Update(path, func(st *state.State) error {
+		fixed = st.RepairTotals()
+		return nil
+	})
+	if err != nil {
+		d.fail(fmt.Sprintf("could not repair totals: %v", err), "")
+		return
+	}
+	for _, day := range fixed {
+		d.ok("recomputed the total of %s from its sessions", day)
+	}
+}
+
+func (d *doctor) checkConfigFile(path string) {
+	config := state.ConfigPath(path)
+	f, err := os.Open(config)
+	if errors.Is(err, os.ErrNotExist) {
+		d.ok("%s does not exist yet; it is created on first use", config)
+		return
+	}
+	if err != nil {
+		d.fail(fmt.Sprintf("cannot read %s: %v", config, err), "check the file's permissions")
+		return
+	}
+	defer f.Close()
+	var st state.State
+	if err := st.DecodeSettings(f); err != nil {
+		d.fail(fmt.Sprintf("%s: %v", config, err), "fix the line, or run daily config edit")
+		return
+	}
+	d.ok("%s is valid", config)
+}
+
+func (d *doctor) checkLeftovers(path string) {
+	for _, tmp := range []string{path + ".tmp", state.ConfigPath(path) + ".tmp"} {
+		if _, err := os.Stat(tmp); err == nil {
+			d.warn(tmp+" was left behind by an interrupted save", "rm "+tmp)
+		}
+	}
+	if active, err := focus.Active(); err == nil && active && !processRunning("focus") {
+		d.fail("a focus block is still in the hosts file but focus is not running", "sudo daily focus --off")
+	}
+}
+
+func (d *doctor) checkDaemon(path string) {
+	sock := daemon.SocketPath(path)
+	_, err := os.Stat(sock)
+	switch {
+	case daemon.Running(path):
+		d.ok("daemon is serving %s", sock)
+	case err == nil:
+		d.warn(sock+" exists but no daemon answers (stale socket)", "rm "+sock+" (the next daemon replaces it anyway)")
+	default:
+		d.ok("daemon is not running; ui, tray, watch and sprint start it")
+	}
+}
+
+func (d *doctor) checkProcesses() {
+	if runtime.GOOS == "windows" {
+		return
+	}
+	for _, name := range []string{"watch", "tray"} {
+		n := processCount(name)
+		switch {
+		case n > 1:
+			d.warn(fmt.Sprintf("%d `daily %s` processes are running", n, name), fmt.Sprintf("pkill -f 'daily %s' and start one again", name))
+		case n == 1:
+			d.ok("daily %s is running", name)
+		}
+	}
+}
+
+func (d *doctor) checkTools() {
+	tools := doctorTools[runtime.GOOS]
+	if len(tools) == 0 {
+		d.warn("idle detection and notifications are limited on "+runtime.GOOS, "")
+		return
+	}
+	for _, t := range tools {
+		if p, err := exec.LookPath(t.name); err == nil {
+			d.ok("%s (%s): %s", t.name, t.purpose, p)
+		} else {
+			d.warn(fmt.Sprintf("%s not found; needed for %s", t.name, t.purpose), t.fix)
+		}
+	}
+}
+
+// checkClock compares the local clock with an HTTP Date header, since skew
+// makes sessions land on the wrong day or appear to start in the future.
+func (d *doctor) checkClock() {
+	client := http.Client{Timeout: 3 * time.Second}
+	resp, err := client.Head("https://www.google.com")
+	if err != nil {
+		d.warn("could not reach the network to check the clock", "")
+		return
+	}
+	resp.Body.Close()
+	remote, err := http.ParseTime(resp.Header.Get("Date"))
+	if err != nil {
+		d.warn("could not read the server time", "")
+		return
+	}
+	skew := time.Since(remote).Round(time.Second)
+	if skew < 0 {
+		skew = -skew
+	}
+	if skew > 2*time.Minute {
+		d.fail(fmt.Sprintf("clock is off by %s", skew), "enable automatic time (NTP) in your system settings")
+		return
+	}
+	d.ok("clock is within %s of network time", skew)
+}
+
+func processRunning(name string) bool {
+	return processCount(name) > 0
+}
+
+// processCount counts running `daily <name>` processes other than this one.
+func processCount(name string) int {
+	out, err := exec.Command("pgrep", "-f", "daily "+name).Output()
+	if err != nil {
+		return 0
+	}
+	n := 0
+	for _, pid := range strings.Fields(string(out)) {
+		if pid != fmt.Sprint(os.Getpid()) {
+			n++
+		}
+	}
+	return n
+}
+
+// runRecalc rebuilds the day totals from the session and break lists.
+func runRecalc(st *state.State, args []string) error {
+	fs := newFlagSet("recalc")
+	dryRun := fs.Bool("dry-run", false, "show the changes without saving them")
+	fs.Parse(args)
+
+	var changed []state.Recalc
+	var kept int
+	if *dryRun {
+		changed, kept = st.RecalcTotals()
+	} else {
+		err := daemon.Update(statePath(), func(st *state.State) error {
+			changed, kept = st.RecalcTotals()
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	for _, r := range changed {
+		fmt.Printf("%s  work %s -> %s  breaks %d (%s) -> %d (%s)\n", r.Day,
+			state.HumanSeconds(r.WorkBefore), state.HumanSeconds(r.WorkAfter),
+			r.CountBefore, state.HumanSeconds(r.BreakBefore), r.CountAfter, state.HumanSeconds(r.BreakAfter))
+	}
+	verb := "recalculated"
+	if *dryRun {
+		verb = "would recalculate"
+	}
+	fmt.Printf("%s %d of %d days", verb, len(changed), len(st.Days))
+	if kept > 0 {
+		fmt.Printf("; kept the recorded totals of %d days logged before sessions or breaks were listed", kept)
+	}
+	fmt.Println()
+	return nil
+}
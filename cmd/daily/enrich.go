@@ -0,0 +1,96 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/max-pantom/daily/internal/daemon"
+	"github.com/max-pantom/daily/internal/github"
+	"github.com/max-pantom/daily/internal/i18n"
+	"github.com/max-pantom/daily/internal/state"
+)
+
+// enrichWindow is how far back enrich looks when no --from is given.
+const enrichWindow = 7
+
+func runEnrich(st *state.State, args []string, now time.Time) error {
+	fs := newFlagSet("enrich")
+	gh := fs.Bool("github", false, "attach GitHub activity (commits, PRs, reviews) to session notes")
+	user := fs.String("user", "", "GitHub login (default: owner of DAILY_GITHUB_TOKEN)")
+	dryRun := fs.Bool("dry-run", false, "show the summaries without saving them")
+	var ff filterFlags
+	ff.register(fs)
+	fs.Parse(args)
+	if !*gh {
+		return errors.New("usage: daily enrich --github [--user login] [--dry-run] [--from YYYY-MM-DD --to YYYY-MM-DD]")
+	}
+	filter, err := ff.filter()
+	if err != nil {
+		return err
+	}
+	if filter.From == "" {
+		filter.From = now.AddDate(0, 0, -enrichWindow).Format("2006-01-02")
+	}
+
+	// Sessions to enrich, keyed by day and start so they can be found again
+	// under the write lock.
+	type target struct {
+		day        string
+		start, end time.Time
+	}
+	var targets []target
+	for _, day := range st.DayKeys(filter) {
+		for _, sess := range st.Days[day].Sessions {
+			if sess.End != nil && filter.Match(sess) && !github.Enriched(sess.Note) {
+				targets = append(targets, target{day, sess.Start, *sess.End})
+			}
+		}
+	}
+	if len(targets) == 0 {
+		fmt.Printf("no sessions to enrich since %s\n", filter.From)
+		return nil
+	}
+	since := targets[0].start
+	for _, t := range targets {
+		if t.start.Before(since) {
+			since = t.start
+		}
+	}
+
+	client := &github.Client{Token: os.Getenv("DAILY_GITHUB_TOKEN"), User: *user}
+	events, err := client.Events(since)
+	if err != nil {
+		return err
+	}
+	summaries := map[target]string{}
+	for _, t := range targets {
+		if s := github.Summary(github.Between(events, t.start, t.end)); s != "" {
+			summaries[t] = s
+			fmt.Printf("%s %s-%s  %s\n", t.day, i18n.Time(t.start), i18n.Time(t.end), s)
+		}
+	}
+	if len(summaries) == 0 {
+		fmt.Println("no GitHub activity during these sessions")
+		return nil
+	}
+	if *dryRun {
+		return nil
+	}
+	return daemon.Update(statePath(), func(st *state.State) error {
+		for t, s := range summaries {
+			log, ok := st.Days[t.day]
+			if !ok {
+				continue
+			}
+			for i := range log.Sessions {
+				sess := &log.Sessions[i]
+				if sess.Start.Equal(t.start) && !github.Enriched(sess.Note) {
+					sess.Note = github.Attach(sess.Note, s)
+				}
+			}
+		}
+		return nil
+	})
+}
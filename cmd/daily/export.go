@@ -0,0 +1,201 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/max-pantom/daily/internal/daemon"
+	"github.com/max-pantom/daily/internal/export"
+	"github.com/max-pantom/daily/internal/gsheet"
+	"github.com/max-pantom/daily/internal/state"
+)
+
+func runExport(st *state.State, args []string, now time.Time) error {
+	fs := newFlagSet("export")
+	obsidian := fs.Bool("obsidian", false, "write the day's time log into its daily note")
+	vault := fs.String("vault", st.ObsidianVault, "notes folder (default from set-obsidian)")
+	pattern := fs.String("pattern", st.ObsidianPattern, "daily note path inside the vault, YYYY/MM/DD replaced")
+	tmpl := fs.String("template", st.ObsidianTemplate, "text/template file for the time log block")
+	dayArg := fs.String("day", "today", "day to export: YYYY-MM-DD, today or yesterday")
+	format := fs.String("format", "", "invoicing CSV: harvest or freshbooks")
+	output := fs.String("output", "", "write the CSV to this file instead of stdout")
+	sheet := fs.String("gsheet", "", "append or update rows in this Google Sheets spreadsheet (the id from its URL)")
+	tab := fs.String("tab", st.GSheetTab, "spreadsheet tab (default Daily)")
+	per := fs.String("per", export.SheetSessions, "spreadsheet rows: session or day")
+	var opt export.InvoiceOptions
+	fs.StringVar(&opt.Client, "client", "", "client for every row (default: the session's project)")
+	fs.StringVar(&opt.Task, "task", "Development", "Harvest task or FreshBooks service")
+	fs.StringVar(&opt.Person, "person", "", "your first and last name (Harvest team accounts)")
+	fs.StringVar(&opt.Project, "default-project", "General", "project for sessions without one")
+	var ff filterFlags
+	ff.register(fs)
+	fs.Parse(args)
+
+	if *sheet != "" {
+		return exportSheet(st, *sheet, *tab, *per, ff, now)
+	}
+	if *format != "" {
+		return exportInvoice(st, *format, *output, ff, opt, now)
+	}
+	if !*obsidian {
+		return errors.New("usage: daily export --obsidian [--vault dir] [--day yesterday] | --format harvest|freshbooks [--output f.csv] | --gsheet <sheet-id> [--per day]")
+	}
+	if *vault == "" {
+		return errors.New("no vault; pass --vault or run daily set-obsidian --vault <dir>")
+	}
+	day, err := parseDayArg(*dayArg, now)
+	if err != nil {
+		return err
+	}
+	var text string
+	if *tmpl != "" {
+		data, err := os.ReadFile(expandHome(*tmpl))
+		if err != nil {
+			return err
+		}
+		text = string(data)
+	}
+	block, err := export.Note(st, day, now, text)
+	if err != nil {
+		return err
+	}
+	path := export.NotePath(expandHome(*vault), *pattern, day)
+	if err := export.WriteNote(path, block); err != nil {
+		return err
+	}
+	fmt.Printf("wrote time log to %s\n", path)
+	return nil
+}
+
+// exportInvoice writes the sessions in the history filters' range, by default
+// the last 7 days, as a Harvest or FreshBooks import CSV.
+func exportInvoice(st *state.State, format, output string, ff filterFlags, opt export.InvoiceOptions, now time.Time) error {
+	filter, err := ff.filter()
+	if err != nil {
+		return err
+	}
+	if filter.From == "" {
+		filter.From = now.AddDate(0, 0, -6).Format("2006-01-02")
+	}
+	rows := export.InvoiceRows(st, filter, opt)
+	if output == "" {
+		return export.WriteInvoiceCSV(os.Stdout, format, rows, opt)
+	}
+	f, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+	if err := export.WriteInvoiceCSV(f, format, rows, opt); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	fmt.Printf("wrote %d entries to %s\n", len(rows), output)
+	return nil
+}
+
+// exportSheet brings a Google Sheets tab up to date with the finished sessions
+// in the history filters' range, by default the last 7 days. Rows already in
+// the tab are updated in place, so exporting again is safe.
+func exportSheet(st *state.State, sheetID, tab, per string, ff filterFlags, now time.Time) error {
+	if per != export.SheetSessions && per != export.SheetDays {
+		return fmt.Errorf("unknown --per %q (want %s or %s)", per, export.SheetSessions, export.SheetDays)
+	}
+	if st.GSheetCredentials == "" {
+		return errors.New("no Google credentials; run daily config set gsheet.credentials <service-account.json>")
+	}
+	creds, err := gsheet.LoadCredentials(expandHome(st.GSheetCredentials))
+	if err != nil {
+		return err
+	}
+	filter, err := ff.filter()
+	if err != nil {
+		return err
+	}
+	if filter.From == "" {
+		filter.From = now.AddDate(0, 0, -6).Format("2006-01-02")
+	}
+	if tab == "" {
+		tab = "Daily"
+	}
+	header, rows := export.SheetRows(st, filter, per)
+	client := &gsheet.Client{Creds: creds}
+	added, updated, err := client.Sync(sheetID, tab, header, rows)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s: %d row(s) added, %d updated, %d unchanged\n", tab, added, updated, len(rows)-added-updated)
+	return nil
+}
+
+func runSetRate(st *state.State, args []string) error {
+	if len(args) != 2 {
+		return errors.New("usage: daily set-rate <project|default> <hourly rate|off>")
+	}
+	project := args[0]
+	if project == "default" {
+		project = ""
+	}
+	if st.Rates == nil {
+		st.Rates = map[string]float64{}
+	}
+	if args[1] == "off" {
+		delete(st.Rates, project)
+	} else {
+		rate, err := strconv.ParseFloat(args[1], 64)
+		if err != nil || rate < 0 {
+			return fmt.Errorf("invalid rate %q", args[1])
+		}
+		st.Rates[project] = rate
+	}
+	if err := daemon.Save(statePath(), st); err != nil {
+		return err
+	}
+	if args[1] == "off" {
+		fmt.Printf("Rate for %s removed\n", args[0])
+	} else {
+		fmt.Printf("Rate for %s set to %s/h\n", args[0], args[1])
+	}
+	return nil
+}
+
+func runSetObsidian(st *state.State, args []string) error {
+	fs := newFlagSet("set-obsidian")
+	vault := fs.String("vault", st.ObsidianVault, "notes folder")
+	pattern := fs.String("pattern", st.ObsidianPattern, "daily note path inside the vault (default "+export.DefaultNotePattern+")")
+	tmpl := fs.String("template", st.ObsidianTemplate, "text/template file for the time log block")
+	fs.Parse(args)
+
+	if *vault != "" {
+		abs, err := filepath.Abs(expandHome(*vault))
+		if err != nil {
+			return err
+		}
+		*vault = abs
+	}
+	st.ObsidianVault, st.ObsidianPattern, st.ObsidianTemplate = *vault, *pattern, *tmpl
+	if err := daemon.Save(statePath(), st); err != nil {
+		return err
+	}
+	fmt.Printf("Daily notes go to %s\n", export.NotePath(st.ObsidianVault, st.ObsidianPattern, time.Now()))
+	return nil
+}
+
+// expandHome resolves a leading ~/ for paths that did not pass through a shell.
+func expandHome(path string) string {
+	if !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[2:])
+}
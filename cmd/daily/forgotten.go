@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/max-pantom/daily/internal/i18n"
+	"github.com/max-pantom/daily/internal/state"
+)
+
+// checkForgottenStop asks, before a session starts, when a session that looks
+// left running really ended, and fixes it in st. Without a terminal to ask on
+// it only warns.
+func checkForgottenStop(st *state.State, now time.Time) {
+	f, ok := st.FindForgottenStop(now)
+	if !ok {
+		return
+	}
+	length := state.HumanMinutes(int(f.End.Sub(f.Start).Minutes()))
+	when := f.Start.Format("Mon Jan 2") + " " + i18n.Time(f.Start)
+	if f.Running {
+		fmt.Fprintf(os.Stderr, "The session started %s is still running (%s).\n", when, length)
+	} else {
+		fmt.Fprintf(os.Stderr, "The session started %s ran until %s (%s).\n", when, i18n.Time(f.End), length)
+	}
+	if !stdinIsTerminal() {
+		fmt.Fprintln(os.Stderr, "warning: it may have been left running; run daily start in a terminal to fix its end")
+		return
+	}
+	in := bufio.NewReader(os.Stdin)
+	for {
+		fmt.Fprint(os.Stderr, "When did you stop? Enter a time (HH:MM), or press Enter to keep it: ")
+		line, err := in.ReadString('\n')
+		line = strings.TrimSpace(line)
+		if line == "" {
+			return
+		}
+		end, perr := parseStopTime(line, f)
+		if perr == nil {
+			perr = st.FixForgottenStop(f, end)
+		}
+		if perr == nil {
+			fmt.Printf("Ended it at %s; %s logged.\n", i18n.Time(end), state.HumanMinutes(int(end.Sub(f.Start).Minutes())))
+			return
+		}
+		fmt.Fprintln(os.Stderr, perr)
+		if err != nil {
+			return
+		}
+	}
+}
+
+// parseClock reads a clock time, 17:30 or 5:30PM, as hour and minute.
+func parseClock(v string) (hour, min int, err error) {
+	for _, layout := range []string{"15:04", time.Kitchen, "3:04pm", "3PM", "3pm"} {
+		if clock, err := time.Parse(layout, v); err == nil {
+			return clock.Hour(), clock.Minute(), nil
+		}
+	}
+	return 0, 0, fmt.Errorf("invalid time %q (want HH:MM, e.g. 18:30)", v)
+}
+
+// parseStopTime reads a clock time as the first such time after the session
+// started.
+func parseStopTime(v string, f state.ForgottenStop) (time.Time, error) {
+	hour, min, err := parseClock(v)
+	if err != nil {
+		return time.Time{}, err
+	}
+	start := f.Start.In(time.Local)
+	y, m, d := start.Date()
+	end := time.Date(y, m, d, hour, min, 0, 0, time.Local)
+	if !end.After(start) {
+		end = end.AddDate(0, 0, 1) // past midnight
+	}
+	if end.After(f.End) {
+		return time.Time{}, fmt.Errorf("%s is after the session's current end", i18n.Time(end))
+	}
+	return end, nil
+}
+
+func stdinIsTerminal() bool {
+	fi, err := os.Stdin.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
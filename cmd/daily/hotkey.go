@@ -0,0 +1,157 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/max-pantom/daily/internal/daemon"
+	"github.com/max-pantom/daily/internal/hotkey"
+	"github.com/max-pantom/daily/internal/i18n"
+	"github.com/max-pantom/daily/internal/notify"
+	"github.com/max-pantom/daily/internal/state"
+)
+
+// runToggle starts a session, ending a break first, or stops the running one.
+// Global hotkeys run it, so the result is also sent as a notification when
+// there is no terminal to print to.
+func runToggle(st *state.State, now time.Time) error {
+	msg, err := toggle(st, now)
+	if err != nil {
+		return err
+	}
+	announce(st, msg)
+	return nil
+}
+
+// runBreak starts a break, stopping the running session, or ends the current
+// one.
+func runBreak(st *state.State, now time.Time) error {
+	msg, err := toggleBreak(st, now)
+	if err != nil {
+		return err
+	}
+	announce(st, msg)
+	return nil
+}
+
+// toggle saves the started or stopped session and describes it.
+func toggle(st *state.State, now time.Time) (string, error) {
+	var msg string
+	if st.ActiveSession != nil {
+		seconds := st.ActiveSession.Seconds(now)
+		if _, err := st.StopSession(now); err != nil {
+			return "", err
+		}
+		msg = i18n.T("Stopped session. Logged %s.", state.HumanSeconds(seconds))
+	} else {
+		if st.ActiveBreak != nil {
+			if _, err := st.StopBreak(now); err != nil {
+				return "", err
+			}
+		}
+		if err := st.CheckCap(now); err != nil {
+			return "", err
+		}
+		if err := st.StartSession(now, nil, ""); err != nil {
+			return "", err
+		}
+		msg = i18n.T("Started session at %s", i18n.Time(now)) + "."
+	}
+	return msg, daemon.Save(statePath(), st)
+}
+
+// toggleBreak saves the started or ended break and describes it.
+func toggleBreak(st *state.State, now time.Time) (string, error) {
+	var msg string
+	if st.ActiveBreak != nil {
+		mins, err := st.StopBreak(now)
+		if err != nil {
+			return "", err
+		}
+		msg = i18n.T("Break over after %s.", state.HumanMinutes(mins))
+	} else {
+		if st.ActiveSession != nil {
+			if _, err := st.StopSession(now); err != nil {
+				return "", err
+			}
+		}
+		if err := st.StartBreak(now); err != nil {
+			return "", err
+		}
+		msg = i18n.T("Break started at %s.", i18n.Time(now))
+	}
+	return msg, daemon.Save(statePath(), st)
+}
+
+// announce prints msg and, when stdout is not a terminal, as when a desktop
+// shortcut runs the command, also shows it as a notification on this machine.
+func announce(st *state.State, msg string) {
+	fmt.Println(msg)
+	if !stdoutIsTerminal() && st.NotificationsOn() {
+		notify.Local("Daily", msg)
+	}
+}
+
+func stdoutIsTerminal() bool {
+	fi, err := os.Stdout.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+func runSetHotkey(st *state.State, args []string) error {
+	usage := fmt.Errorf("usage: daily set-hotkey <%s> <shortcut|off>, e.g. cmd+shift+d", strings.Join(hotkey.Actions, "|"))
+	if len(args) != 2 {
+		return usage
+	}
+	action, combo := args[0], strings.ToLower(args[1])
+	valid := false
+	for _, a := range hotkey.Actions {
+		valid = valid || a == action
+	}
+	if !valid {
+		return usage
+	}
+	if combo != "off" {
+		c, err := hotkey.Parse(combo)
+		if err != nil {
+			return err
+		}
+		combo = c.String()
+	}
+	if st.Hotkeys == nil {
+		st.Hotkeys = map[string]string{}
+	}
+	st.Hotkeys[action] = combo
+	if err := daemon.Save(statePath(), st); err != nil {
+		return err
+	}
+	if combo == "off" {
+		fmt.Printf("Hotkey for %s off\n", action)
+	} else {
+		fmt.Printf("Hotkey for %s: %s\n", action, combo)
+	}
+	// Windows shortcuts live in the tray process; elsewhere they belong to the
+	// desktop and can be updated now.
+	if runtime.GOOS == "windows" {
+		fmt.Println("Restart daily tray to apply it.")
+		return nil
+	}
+	if err := registerHotkeys(st); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: could not install the shortcut: %v\n", err)
+	}
+	return nil
+}
+
+// registerHotkeys installs the configured desktop shortcuts.
+func registerHotkeys(st *state.State) error {
+	bindings, errs := hotkey.Bindings(st.Hotkeys)
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	_, err = hotkey.Register(bindings, exe, nil)
+	return errors.Join(append(errs, err)...)
+}
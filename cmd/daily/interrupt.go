@@ -0,0 +1,52 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/max-pantom/daily/internal/daemon"
+	"github.com/max-pantom/daily/internal/i18n"
+	"github.com/max-pantom/daily/internal/state"
+)
+
+// runInterrupt pauses the running session for reason, or resumes it when
+// called without one during an interruption.
+func runInterrupt(st *state.State, args []string, now time.Time) error {
+	reason := strings.Join(args, " ")
+	if reason == "" {
+		if st.ActiveInterruption == nil {
+			return errors.New(`usage: daily interrupt "<reason>" (again without a reason to resume)`)
+		}
+		in, err := st.Resume(now)
+		if err != nil {
+			return err
+		}
+		if err := daemon.Save(statePath(), st); err != nil {
+			return err
+		}
+		fmt.Printf("Resumed after %s (%s)\n", state.HumanSeconds(in.Elapsed), in.Reason)
+		return nil
+	}
+	if err := st.Interrupt(now, reason); err != nil {
+		return err
+	}
+	if err := daemon.Save(statePath(), st); err != nil {
+		return err
+	}
+	fmt.Printf("Paused for %s at %s; run daily interrupt to resume\n", st.ActiveInterruption.Reason, i18n.Time(now))
+	return nil
+}
+
+// showInterruptions prints a day's interruptions under its sessions.
+func showInterruptions(list []state.Interruption) {
+	total := 0
+	for _, in := range list {
+		total += in.Elapsed
+	}
+	fmt.Printf("  interruptions: %d (%s)\n", len(list), state.HumanSeconds(total))
+	for _, in := range list {
+		fmt.Printf("    %s  %-6s %s\n", i18n.Time(in.Start), state.HumanSeconds(in.Elapsed), in.Reason)
+	}
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/max-pantom/daily/internal/audit"
+	"github.com/max-pantom/daily/internal/logging"
+)
+
+// runLogs prints the daemon/watch/tray/sprint log, optionally following it.
+func runLogs(args []string) error {
+	fs := newFlagSet("logs")
+	follow := fs.Bool("f", false, "keep printing new lines as they are written")
+	lines := fs.Int("n", 50, "number of recent lines to show (0 for all)")
+	level := fs.String("level", "info", "minimum level: info, warn or error")
+	rest := parseInterspersed(fs, args)
+
+	min, ok := logLevels[strings.ToLower(*level)]
+	if !ok {
+		return errors.New("level must be info, warn or error")
+	}
+	component := ""
+	if len(rest) > 0 {
+		component = rest[0]
+	}
+	match := func(line string) bool {
+		if component != "" && !strings.Contains(line, "component="+component) {
+			return false
+		}
+		for name, rank := range logLevels {
+			if strings.Contains(line, "level="+strings.ToUpper(name)) {
+				return rank >= min
+			}
+		}
+		return true
+	}
+
+	path := logging.Path(statePath())
+	var recent []string
+	for _, p := range []string{path + ".1", path} {
+		f, err := os.Open(p)
+		if err != nil {
+			continue
+		}
+		sc := bufio.NewScanner(f)
+		for sc.Scan() {
+			if match(sc.Text()) {
+				recent = append(recent, sc.Text())
+			}
+		}
+		f.Close()
+	}
+	if len(recent) == 0 && !*follow {
+		fmt.Printf("no log entries in %s\n", path)
+		return nil
+	}
+	if *lines > 0 && len(recent) > *lines {
+		recent = recent[len(recent)-*lines:]
+	}
+	for _, l := range recent {
+		fmt.Println(l)
+	}
+	if !*follow {
+		return nil
+	}
+	return followLog(path, match)
+}
+
+var logLevels = map[string]int{"debug": 0, "info": 1, "warn": 2, "error": 3}
+
+// followLog prints lines appended to path, reopening it after rotation.
+func followLog(path string, match func(string) bool) error {
+	var f *os.File
+	var reader *bufio.Reader
+	var pending string
+	for {
+		if f == nil {
+			var err error
+			if f, err = os.Open(path); err != nil {
+				time.Sleep(500 * time.Millisecond)
+				continue
+			}
+			f.Seek(0, io.SeekEnd)
+			reader = bufio.NewReader(f)
+		}
+		// A line may arrive in pieces; hold the start until its newline shows up.
+		chunk, err := reader.ReadString('\n')
+		pending += chunk
+		if err == nil {
+			if line := strings.TrimSuffix(pending, "\n"); match(line) {
+				fmt.Println(line)
+			}
+			pending = ""
+			continue
+		}
+		time.Sleep(500 * time.Millisecond)
+		held, err1 := f.Stat()
+		onDisk, err2 := os.Stat(path)
+		if err1 != nil || err2 != nil || !os.SameFile(held, onDisk) {
+			// Rotated: read the new file from the top.
+			f.Close()
+			pending = ""
+			if f, err = os.Open(path); err != nil {
+				f = nil
+				continue
+			}
+			reader = bufio.NewReader(f)
+		}
+	}
+}
+
+// auditSource names this process in the audit log: the long-running frontends
+// by name, everything else as the CLI command that ran.
+func auditSource(cmd string) string {
+	switch cmd {
+	case "ui", "tray", "watch", "sprint", "daemon", "review":
+		return cmd
+	}
+	return "cli " + cmd
+}
+
+// runAudit prints the audit log, newest last.
+func runAudit(args []string) error {
+	fs := newFlagSet("audit")
+	lines := fs.Int("n", 50, "number of recent entries to show (0 for all)")
+	source := fs.String("source", "", "only entries from this frontend, e.g. tray or cli")
+	day := fs.String("day", "", "only entries written on this day (YYYY-MM-DD)")
+	fs.Parse(args)
+
+	entries, err := audit.Read(statePath())
+	if err != nil {
+		return err
+	}
+	var shown []audit.Entry
+	for _, e := range entries {
+		if *source != "" && !strings.HasPrefix(e.Source, *source) {
+			continue
+		}
+		if *day != "" && e.Time.Local().Format("2006-01-02") != *day {
+			continue
+		}
+		shown = append(shown, e)
+	}
+	if *lines > 0 && len(shown) > *lines {
+		shown = shown[len(shown)-*lines:]
+	}
+	if len(shown) == 0 {
+		fmt.Printf("no audit entries in %s\n", audit.Path(statePath()))
+		return nil
+	}
+	for _, e := range shown {
+		fmt.Printf("%s  %-14s %-11s %s\n", e.Time.Local().Format("2006-01-02 15:04:05"), e.Source, e.Op, e.Detail)
+	}
+	return nil
+}
@@ -1,318 +1,1040 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
+	"os/signal"
 	"path/filepath"
+	"runtime"
 	"sort"
+	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
-	"github.com/max-pantom/daily/internal/idle"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+
+	"github.com/max-pantom/daily/internal/apps"
+	"github.com/max-pantom/daily/internal/audit"
+	"github.com/max-pantom/daily/internal/calendar"
+	"github.com/max-pantom/daily/internal/daemon"
+	"github.com/max-pantom/daily/internal/focus"
+	"github.com/max-pantom/daily/internal/i18n"
+	"github.com/max-pantom/daily/internal/logging"
+	"github.com/max-pantom/daily/internal/media"
 	"github.com/max-pantom/daily/internal/notify"
+	"github.com/max-pantom/daily/internal/power"
+	"github.com/max-pantom/daily/internal/report"
+	"github.com/max-pantom/daily/internal/sound"
 	"github.com/max-pantom/daily/internal/state"
+	"github.com/max-pantom/daily/internal/stats"
 	"github.com/max-pantom/daily/internal/tray"
 	"github.com/max-pantom/daily/internal/tui"
 	"github.com/max-pantom/daily/internal/update"
+	"github.com/max-pantom/daily/pkg/daily"
 )
 
 func main() {
-	if len(os.Args) < 2 {
+	args, err := parseGlobalFlags(os.Args[1:])
+	if err != nil {
+		exitErr(err)
+	}
+	if global.quiet {
+		silence()
+	}
+	if global.noColor {
+		lipgloss.SetColorProfile(termenv.Ascii)
+	}
+	if len(args) == 0 {
 		runUI()
 		return
 	}
+	if err := dispatch(args[0], args[1:]); err != nil {
+		exitErr(err)
+	}
+}
 
-	cmd := os.Args[1]
-	args := os.Args[2:]
-
-	now := time.Now()
-	st, err := state.Load(statePath())
+func cmdStart(c *cmdContext, args []string) error {
+	opts := parseStartFlags(args)
+	st, now := c.st, c.now
+	tags, note, project, countdown := opts.tags, opts.note, opts.project, opts.countdown
+	if opts.label != "" && (countdown <= 0 || note != "") {
+		return errors.New("--label needs --for and takes the place of --note")
+	}
+	checkForgottenStop(st, now)
+	if !opts.force {
+		if err := st.CheckCap(now); err != nil {
+			return err
+		}
+	}
+	warnTagTypos(st, tags)
+	var err error
+	switch {
+	case opts.label != "":
+		err = st.StartFocus(now, opts.label, countdown, tags)
+	case countdown > 0:
+		err = st.StartCountdown(now, countdown, tags, note)
+	default:
+		err = st.StartSession(now, tags, note)
+	}
 	if err != nil {
-		exitErr(err)
+		return err
+	}
+	st.ActiveSession.Project = project
+	if err := daemon.Save(statePath(), st); err != nil {
+		return err
+	}
+	if countdown > 0 {
+		// The daemon stops the session and says so when the time is up.
+		startDaemon()
+	}
+	if opts.label != "" {
+		fmt.Println(i18n.T("Started focus block %q at %s for %s (ends at %s)", opts.label, i18n.Time(now), state.HumanMinutes(int(countdown.Minutes())), i18n.Time(now.Add(countdown))))
+		return nil
+	}
+	fmt.Print(i18n.T("Started session at %s", i18n.Time(now)))
+	if tags := st.ActiveSession.Tags; len(tags) > 0 { // with any from tag rules
+		fmt.Printf(" [tags: %s]", strings.Join(tags, ","))
 	}
-	st.Normalize(now)
+	if note != "" {
+		fmt.Printf(" note: %s", note)
+	}
+	if project != "" {
+		fmt.Printf(" project: %s", project)
+	}
+	if countdown > 0 {
+		fmt.Print(i18n.T(" for %s (stops at %s)", state.HumanMinutes(int(countdown.Minutes())), i18n.Time(now.Add(countdown))))
+	}
+	fmt.Println()
+	return nil
+}
 
-	switch cmd {
-	case "start":
-		tags, note := parseTagsAndNote(args)
-		if err := st.StartSession(now, tags, note); err != nil {
-			exitErr(err)
+func cmdStop(c *cmdContext, args []string) error {
+	st, now := c.st, c.now
+	if st.ActiveSession == nil && st.ActiveInterruption != nil {
+		in, err := st.EndInterruption(now)
+		if err != nil {
+			return err
 		}
-		if err := st.Save(statePath()); err != nil {
-			exitErr(err)
+		if err := daemon.Save(statePath(), st); err != nil {
+			return err
 		}
-		fmt.Printf("Started session at %s", now.Format(time.Kitchen))
-		if len(tags) > 0 {
-			fmt.Printf(" [tags: %s]", strings.Join(tags, ","))
+		fmt.Printf("Ended interruption (%s, %s); not resuming.\n", in.Reason, state.HumanSeconds(in.Elapsed))
+		return nil
+	}
+	var seconds, sessions, work int
+	if st.ActiveSession != nil {
+		seconds = st.ActiveSession.Seconds(now)
+	}
+	if log := st.Days[now.Format("2006-01-02")]; log != nil {
+		sessions, work = len(log.Sessions), log.WorkSeconds()
+	}
+	if _, err := st.StopSession(now); err != nil {
+		return err
+	}
+	if err := daemon.Save(statePath(), st); err != nil {
+		return err
+	}
+	log := st.Days[now.Format("2006-01-02")]
+	switch {
+	case log == nil || len(log.Sessions) > sessions:
+		fmt.Println(i18n.T("Stopped session. Logged %s.", state.HumanSeconds(seconds)))
+	case log.WorkSeconds() > work:
+		fmt.Println(i18n.T("Stopped session. %s is under the minimum, added to the previous session.", state.HumanSeconds(seconds)))
+	default:
+		fmt.Println(i18n.T("Stopped session. %s is under the minimum, discarded.", state.HumanSeconds(seconds)))
+	}
+	return nil
+}
+
+// statusJSON is `daily status --json`.
+type statusJSON struct {
+	WorkMinutes    int                 `json:"work_minutes"`
+	ActiveMinutes  int                 `json:"active_minutes"`
+	RunningSince   *time.Time          `json:"running_since,omitempty"`
+	OnBreakSince   *time.Time          `json:"on_break_since,omitempty"`
+	OverBreak      int                 `json:"over_break_seconds,omitempty"`
+	Interrupted    *state.Interruption `json:"interrupted,omitempty"`
+	StopsAt        *time.Time          `json:"stops_at,omitempty"`
+	GoalETA        *time.Time          `json:"goal_eta,omitempty"`
+	GoalMinutes    int                 `json:"goal_minutes"`              // after carry-over
+	CarriedMinutes int                 `json:"carried_minutes,omitempty"` // banked (+) or owed (-) from earlier this week
+	BreakInterval  int                 `json:"break_interval_minutes"`
+	Forecast       *stats.Forecast     `json:"forecast,omitempty"`
+}
+
+func cmdStatus(c *cmdContext, args []string) error {
+	fs := newFlagSet("status")
+	forecast := fs.Bool("forecast", false, "project this week's work and say what each workday left needs to reach the weekly goal (week_goal)")
+	if rest := parseInterspersed(fs, args); len(rest) > 0 {
+		return errors.New("usage: daily status [--forecast]")
+	}
+	st, now := c.st, c.now
+	work, active := st.TodaySummary(now)
+	goal, carried := st.Goal(now)
+	if global.json {
+		out := statusJSON{WorkMinutes: work, ActiveMinutes: active, GoalMinutes: goal, CarriedMinutes: carried, BreakInterval: st.BreakIntervalMinutes}
+		if st.ActiveSession != nil {
+			out.RunningSince = &st.ActiveSession.Start
+			out.StopsAt = st.ActiveSession.Until
 		}
-		if note != "" {
-			fmt.Printf(" note: %s", note)
+		if st.ActiveBreak != nil {
+			out.OnBreakSince = &st.ActiveBreak.Start
 		}
-		fmt.Println()
-
-	case "stop":
-		minutes, err := st.StopSession(now)
-		if err != nil {
-			exitErr(err)
+		if over, ok := st.OverBreak(now); ok {
+			out.OverBreak = int(over.Seconds())
 		}
-		if err := st.Save(statePath()); err != nil {
-			exitErr(err)
+		out.Interrupted = st.ActiveInterruption
+		if eta, ok := st.GoalETA(now); ok {
+			out.GoalETA = &eta
 		}
-		fmt.Printf("Stopped session. Logged %s.\n", state.HumanMinutes(minutes))
-
-	case "status":
-		work, active := st.TodaySummary(now)
-		fmt.Printf("Today: %s logged", state.HumanMinutes(work))
-		if active > 0 {
-			fmt.Printf(" (active %s)", state.HumanMinutes(active))
+		if *forecast {
+			f := stats.ForecastWeek(st, now)
+			out.Forecast = &f
+		}
+		return printJSON(out)
+	}
+	fmt.Print(i18n.T("Today: %s logged", state.HumanMinutes(work)))
+	if active > 0 {
+		fmt.Print(i18n.T(" (active %s)", state.HumanMinutes(active)))
+	}
+	fmt.Println()
+	p := colors(st)
+	if st.ActiveSession != nil {
+		fmt.Println(p.run.Render(i18n.T("Running since %s", i18n.Time(st.ActiveSession.Start))))
+	}
+	if st.ActiveBreak != nil {
+		fmt.Print(p.brk.Render(i18n.T("On break since %s", i18n.Time(st.ActiveBreak.Start))))
+		if over, ok := st.OverBreak(now); ok {
+			fmt.Print(p.warn.Render(i18n.T(" (%s over)", state.HumanMinutes(int(over.Minutes())))))
 		}
 		fmt.Println()
-		if st.ActiveSession != nil {
-			fmt.Printf("Running since %s\n", st.ActiveSession.Start.Format(time.Kitchen))
+	}
+	if in := st.ActiveInterruption; in != nil {
+		fmt.Println(p.warn.Render(i18n.T("Interrupted by %s since %s", in.Reason, i18n.Time(in.Start))) + i18n.T(" (daily interrupt to resume)"))
+	}
+	if left, ok := st.Remaining(now); ok {
+		if label, ok := st.FocusLabel(); ok {
+			fmt.Println(i18n.T("Focus block %q: %s left (ends at %s)", label, state.HumanRemaining(left), i18n.Time(*st.ActiveSession.Until)))
+		} else {
+			fmt.Println(i18n.T("Countdown: %s left (stops at %s)", state.HumanRemaining(left), i18n.Time(*st.ActiveSession.Until)))
 		}
-		fmt.Printf("Goal: %s | Break interval: %s\n", state.HumanMinutes(st.GoalMinutes), state.HumanMinutes(st.BreakIntervalMinutes))
-
-	case "today":
-		showToday(st, now)
+	}
+	if eta, ok := st.GoalETA(now); ok {
+		fmt.Println(i18n.T("Goal at ~%s if you keep going", i18n.Time(eta)))
+	}
+	fmt.Println(i18n.T("Goal: %s | Break interval: %s", state.HumanMinutes(goal)+state.CarriedLabel(carried), state.HumanMinutes(st.BreakIntervalMinutes)))
+	if *forecast {
+		showForecast(stats.ForecastWeek(st, now))
+	}
+	return nil
+}
 
-	case "history":
-		days := 7
-		if len(args) == 1 {
-			if v := parseSingleInt(args); v > 0 {
-				days = v
-			}
+// showForecast prints where the week is heading against its goal.
+func showForecast(f stats.Forecast) {
+	fmt.Println()
+	if f.Goal <= 0 {
+		fmt.Printf("This week: %s (no weekly goal: daily config set week_goal 40h)\n", state.HumanMinutes(f.Done))
+		return
+	}
+	fmt.Printf("This week: %s of %s (%d%%)\n", state.HumanMinutes(f.Done), state.HumanMinutes(f.Goal), f.Done*100/f.Goal)
+	if f.Met() {
+		fmt.Println("Weekly goal reached")
+		return
+	}
+	var basis []string
+	if f.Pace > 0 {
+		basis = append(basis, fmt.Sprintf("%s a day this week", state.HumanMinutes(f.Pace)))
+	}
+	if f.History > 0 {
+		basis = append(basis, fmt.Sprintf("%s over the last %d weeks", state.HumanMinutes(f.History), stats.HistoryWeeks))
+	}
+	if len(basis) > 0 {
+		verdict := "short of the goal"
+		if f.Projected >= f.Goal {
+			verdict = "on track"
 		}
-		showHistory(st, days)
+		fmt.Printf("Forecast: ~%s by Sunday, %s (%s)\n", state.HumanMinutes(f.Projected), verdict, strings.Join(basis, ", "))
+	}
+	if f.DaysLeft == 0 {
+		fmt.Printf("No workdays left: %s short\n", state.HumanMinutes(f.Goal-f.Done))
+		return
+	}
+	fmt.Printf("To reach it: %s a day over the %d workday(s) left, today included\n", state.HumanMinutes(f.PerDay), f.DaysLeft)
+}
 
-	case "sprint":
-		if err := runSprint(args); err != nil {
-			exitErr(err)
+func cmdDay(c *cmdContext, args []string) error {
+	st, now := c.st, c.now
+	fs := newFlagSet("day")
+	showApps := fs.Bool("apps", false, "show time per foreground app (recorded by watch --apps)")
+	yesterday := fs.Bool("yesterday", false, "show yesterday instead of today")
+	timeline := fs.Bool("timeline", false, "draw the day as a timeline of sessions, breaks and gaps")
+	var ff filterFlags
+	ff.register(fs)
+	offset, args := takeDayOffset(args)
+	rest := parseInterspersed(fs, args)
+	filter, err := ff.filter()
+	if err != nil {
+		return err
+	}
+	day := now.AddDate(0, 0, offset)
+	if *yesterday {
+		day = now.AddDate(0, 0, -1)
+	}
+	if len(rest) > 1 {
+		return errors.New("usage: daily day [YYYY-MM-DD|yesterday|-N]")
+	}
+	if len(rest) == 1 {
+		if day, err = parseDayArg(rest[0], now); err != nil {
+			return err
 		}
+	}
+	if global.json {
+		return printJSON(dayJSON(st, day, now, filter))
+	}
+	showDay(st, day, now, filter)
+	if *timeline {
+		showTimeline(st, day, now)
+	}
+	if *showApps {
+		showAppTotals(st, day)
+	}
+	return nil
+}
 
-	case "set-goal":
-		goalMinutes := parseSingleInt(args)
-		st.GoalMinutes = state.ParseGoalMinutes(goalMinutes)
-		if err := st.Save(statePath()); err != nil {
-			exitErr(err)
-		}
-		fmt.Printf("Daily goal set to %s\n", state.HumanMinutes(st.GoalMinutes))
+// timelineGlyphs draws each kind of timeline slot.
+var timelineGlyphs = map[state.Slot]string{
+	state.SlotFree:        "·",
+	state.SlotWork:        "█",
+	state.SlotBreak:       "░",
+	state.SlotInterrupted: "▒",
+}
 
-	case "set-breaks":
-		interval := parseSingleInt(args)
-		if interval <= 0 {
-			exitErr(errors.New("break interval must be > 0 minutes"))
-		}
-		st.BreakIntervalMinutes = interval
-		if err := st.Save(statePath()); err != nil {
-			exitErr(err)
-		}
-		fmt.Printf("Break reminder set to every %s\n", state.HumanMinutes(interval))
+// timelineCellsPerHour gives each slot of the printed timeline 15 minutes.
+const timelineCellsPerHour = 4
 
-	case "ui":
-		runUI()
+// showTimeline prints the day as one row of 15-minute slots under an hour
+// ruler, so gaps and fragmented stretches stand out.
+func showTimeline(st *state.State, day, now time.Time) {
+	from, to := st.TimelineHours(day, now)
+	var ruler, row strings.Builder
+	for h := from; h < to; h++ {
+		fmt.Fprintf(&ruler, "%-*s", timelineCellsPerHour, fmt.Sprintf("%02d", h))
+	}
+	p := colors(st)
+	styles := map[state.Slot]lipgloss.Style{state.SlotFree: p.mark, state.SlotWork: p.bar, state.SlotBreak: p.brk, state.SlotInterrupted: p.warn}
+	for _, slot := range st.Timeline(day, from, to, (to-from)*timelineCellsPerHour, now) {
+		row.WriteString(styles[slot].Render(timelineGlyphs[slot]))
+	}
+	fmt.Println()
+	fmt.Println("  " + ruler.String())
+	fmt.Println("  " + row.String())
+	fmt.Printf("  %s work  %s break  %s interrupted  %s free\n",
+		timelineGlyphs[state.SlotWork], timelineGlyphs[state.SlotBreak], timelineGlyphs[state.SlotInterrupted], timelineGlyphs[state.SlotFree])
+}
 
-	case "tray":
-		if maybeDetachTray() {
-			fmt.Println("tray launched in background")
-			return
+func cmdHistory(c *cmdContext, args []string) error {
+	fs := newFlagSet("history")
+	bars := fs.Bool("bars", true, "draw a bar chart of each day's work")
+	goalLine := fs.Bool("goal-line", false, "mark each day's goal on its bar")
+	monthly := fs.Bool("monthly", false, "totals per month instead of per day (default all months)")
+	var ff filterFlags
+	ff.register(fs)
+	rest := parseInterspersed(fs, args)
+	filter, err := ff.filter()
+	if err != nil {
+		return err
+	}
+	days := 7
+	if filter.HasRange() {
+		days = 0
+	}
+	if len(rest) > 1 {
+		return errors.New("usage: daily history [days]")
+	}
+	if len(rest) == 1 {
+		v, err := strconv.Atoi(rest[0])
+		if err != nil || v <= 0 {
+			return fmt.Errorf("days must be a positive number, not %q", rest[0])
 		}
-		if err := tray.Run(statePath()); err != nil {
-			exitErr(err)
+		days = v
+	}
+	if *monthly {
+		months := c.st.MonthTotals(filter)
+		if len(rest) == 1 && days < len(months) {
+			months = months[:days]
 		}
-
-	case "install":
-		target := installPath()
-		if err := buildLatest(target); err != nil {
-			fmt.Printf("build failed (%v); falling back to copying current binary\n", err)
-			if err2 := copySelf(target); err2 != nil {
-				exitErr(fmt.Errorf("build error: %v; copy error: %w", err, err2))
+		if global.json {
+			if months == nil {
+				months = []state.MonthTotal{}
 			}
+			return printJSON(months)
 		}
-		fmt.Printf("installed daily to %s\n", target)
+		showMonths(c.st, months, filter)
+		return nil
+	}
+	if global.json {
+		return printJSON(historyJSON(c.st, days, filter))
+	}
+	showHistory(c.st, days, filter, historyOptions{bars: *bars, goalLine: *goalLine})
+	return nil
+}
 
-	case "watch":
-		if err := runWatch(args); err != nil {
-			exitErr(err)
+func cmdSearch(c *cmdContext, args []string) error {
+	fs := newFlagSet("search")
+	limit := fs.Int("limit", 50, "maximum matches to print (0 for all)")
+	rest := parseInterspersed(fs, args)
+	if len(rest) == 0 {
+		return errors.New("usage: daily search <text> [--limit n]")
+	}
+	query := strings.Join(rest, " ")
+	if global.json {
+		hits := c.st.Search(query, *limit)
+		if hits == nil {
+			hits = []state.SearchHit{}
 		}
+		return printJSON(hits)
+	}
+	showSearch(c.st, query, *limit, c.now)
+	return nil
+}
 
-	case "update":
-		if err := runUpdate(args); err != nil {
-			exitErr(err)
-		}
+func cmdStandup(c *cmdContext, args []string) error {
+	if len(args) > 0 {
+		return errors.New("usage: daily standup")
+	}
+	fmt.Print(report.Standup(c.st, c.now))
+	return nil
+}
 
-	case "help", "-h", "--help":
-		usage()
-	default:
-		fmt.Printf("unknown command: %s\n", cmd)
-		usage()
-		os.Exit(1)
-	}
-}
-
-func usage() {
-	fmt.Println("daily - track your work hours")
-	fmt.Println("Usage:")
-	fmt.Println("  daily start           Start tracking")
-	fmt.Println("  daily stop            Stop current session")
-	fmt.Println("  daily status          Show today status")
-	fmt.Println("  daily today           Show today sessions")
-	fmt.Println("  daily history [days]  Show recent days summary (default 7)")
-	fmt.Println("  daily sprint          Run work/break cycles with notifications")
-	fmt.Println("  daily watch           Auto-pause active session when idle (macOS/Linux)")
-	fmt.Println("  daily set-goal <h|m>  Set daily goal in hours (<=24) or minutes")
-	fmt.Println("  daily set-breaks <m>  Set break reminder interval (minutes)")
-	fmt.Println("  daily ui              Open live terminal dashboard")
-	fmt.Println("  daily tray            Launch macOS/Linux tray menu")
-	fmt.Println("  daily install         Copy binary to /usr/local/bin/daily")
-	fmt.Println("  daily update [--version vX] Fetch/install from GitHub (default latest)")
+func cmdReview(c *cmdContext, args []string) error {
+	fs := newFlagSet("review")
+	days := fs.Int("days", 7, "number of days before today to review")
+	fs.Parse(args)
+	return tui.RunReview(statePath(), *days, c.now)
 }
 
-func runUI() {
-	if err := tui.Run(statePath()); err != nil {
-		exitErr(err)
+func cmdSetGoal(c *cmdContext, args []string) error {
+	goalMinutes, err := oneInt(args, "usage: daily set-goal <hours|minutes>")
+	if err != nil {
+		return err
+	}
+	c.st.GoalMinutes = state.ParseGoalMinutes(goalMinutes)
+	if err := daemon.Save(statePath(), c.st); err != nil {
+		return err
 	}
+	fmt.Printf("Daily goal set to %s\n", state.HumanMinutes(c.st.GoalMinutes))
+	return nil
 }
 
-func parseSingleInt(args []string) int {
-	fs := flag.NewFlagSet("", flag.ExitOnError)
-	fs.SetOutput(os.Stdout)
-	if err := fs.Parse(args); err != nil {
-		return 0
+func cmdSetBreaks(c *cmdContext, args []string) error {
+	interval, err := oneInt(args, "usage: daily set-breaks <minutes>")
+	if err != nil {
+		return err
 	}
-	if fs.NArg() != 1 {
-		fmt.Println("expected one integer argument")
-		os.Exit(1)
+	if interval <= 0 {
+		return errors.New("break interval must be > 0 minutes")
 	}
-	var val int
-	_, err := fmt.Sscanf(fs.Arg(0), "%d", &val)
-	if err != nil {
-		fmt.Println("argument must be an integer")
-		os.Exit(1)
+	c.st.BreakIntervalMinutes = interval
+	if err := daemon.Save(statePath(), c.st); err != nil {
+		return err
 	}
-	return val
+	fmt.Printf("Break reminder set to every %s\n", state.HumanMinutes(interval))
+	return nil
 }
 
-func parseTagsAndNote(args []string) ([]string, string) {
-	fs := flag.NewFlagSet("start", flag.ContinueOnError)
-	fs.SetOutput(os.Stdout)
-	var tags multiString
-	var note string
-	fs.Var(&tags, "tag", "tag for the session (repeatable)")
-	fs.StringVar(&note, "note", "", "note for the session")
-	fs.Parse(args)
-	return tags, note
+func cmdSetTrayTitle(c *cmdContext, args []string) error {
+	if len(args) != 1 || (args[0] != "auto" && args[0] != "total") {
+		return errors.New("usage: daily set-tray-title <auto|total>")
+	}
+	c.st.TrayTitle = args[0]
+	if err := daemon.Save(statePath(), c.st); err != nil {
+		return err
+	}
+	if args[0] == "auto" {
+		fmt.Println("Tray shows the countdown mm:ss during sprints and timed sessions")
+	} else {
+		fmt.Println("Tray always shows the daily total")
+	}
+	return nil
 }
 
-func runSprint(args []string) error {
-	fs := flag.NewFlagSet("sprint", flag.ExitOnError)
-	fs.SetOutput(os.Stdout)
-	work := fs.Int("work", 50, "work minutes")
-	brk := fs.Int("break", 10, "break minutes")
-	cycles := fs.Int("cycles", 4, "cycles")
-	var tags multiString
-	var note string
-	fs.Var(&tags, "tag", "tag for sprint sessions")
-	fs.StringVar(&note, "note", "", "note for sprint sessions")
-	fs.Parse(args)
-
-	if *work <= 0 || *brk <= 0 || *cycles <= 0 {
-		return errors.New("work, break, and cycles must be > 0")
+func cmdSetCap(c *cmdContext, args []string) error {
+	st := c.st
+	fs := newFlagSet("set-cap")
+	strict := fs.Bool("strict", false, "refuse new sessions past the cap unless started with --force")
+	rest := parseInterspersed(fs, args)
+	if len(rest) != 1 {
+		return errors.New("usage: daily set-cap <h|m|off> [--strict]")
+	}
+	if rest[0] == "off" {
+		st.CapMinutes, st.CapStrict = 0, false
+	} else {
+		var v int
+		if _, err := fmt.Sscanf(rest[0], "%d", &v); err != nil || v <= 0 {
+			return errors.New("cap must be hours (<=24) or minutes, or off")
+		}
+		st.CapMinutes, st.CapStrict = state.ParseGoalMinutes(v), *strict
+	}
+	if err := daemon.Save(statePath(), st); err != nil {
+		return err
+	}
+	switch {
+	case st.CapMinutes == 0:
+		fmt.Println("Daily cap off")
+	case st.CapStrict:
+		fmt.Printf("Daily cap set to %s; new sessions past it need --force\n", state.HumanMinutes(st.CapMinutes))
+	default:
+		fmt.Printf("Daily cap set to %s\n", state.HumanMinutes(st.CapMinutes))
 	}
+	return nil
+}
 
-	for i := 1; i <= *cycles; i++ {
-		now := time.Now()
-		st, err := state.Load(statePath())
-		if err != nil {
-			return err
+func cmdSetRounding(c *cmdContext, args []string) error {
+	st := c.st
+	if len(args) == 1 && args[0] == "off" {
+		st.Rounding = nil
+	} else {
+		if len(args) < 1 || len(args) > 2 {
+			return errors.New("usage: daily set-rounding <nearest|up|down> [minutes] | off")
+		}
+		step := 1
+		if len(args) == 2 {
+			if _, err := fmt.Sscanf(args[1], "%d", &step); err != nil {
+				return errors.New("rounding step must be a number of minutes")
+			}
 		}
-		if err := st.StartSession(now, tags, note); err != nil {
+		r, err := state.ParseRounding(args[0], step)
+		if err != nil {
 			return err
 		}
-		_ = st.Save(statePath())
-		fmt.Printf("Cycle %d/%d: work %d min\n", i, *cycles, *work)
-		if shouldNotify(st) {
-			notify.Send("Daily Sprint", fmt.Sprintf("Cycle %d work started", i))
+		st.Rounding = r
+	}
+	if err := daemon.Save(statePath(), st); err != nil {
+		return err
+	}
+	fmt.Printf("Rounding: %s\n", st.Rounding)
+	return nil
+}
+
+func cmdSetCheckpoint(c *cmdContext, args []string) error {
+	st := c.st
+	if len(args) != 1 {
+		return errors.New("usage: daily set-checkpoint <minutes|off>")
+	}
+	if args[0] == "off" {
+		st.CheckpointMinutes, st.Checkpoint = 0, nil
+	} else {
+		var v int
+		if _, err := fmt.Sscanf(args[0], "%d", &v); err != nil || v <= 0 || v > 24*60 {
+			return errors.New("checkpoint interval must be 1-1440 minutes, or off")
 		}
-		time.Sleep(time.Duration(*work) * time.Minute)
+		st.CheckpointMinutes = v
+	}
+	if err := daemon.Save(statePath(), st); err != nil {
+		return err
+	}
+	if st.CheckpointMinutes == 0 {
+		fmt.Println("Checkpoints off")
+	} else {
+		fmt.Printf("Running sessions are checkpointed every %s while the daemon, ui, tray or watch runs\n", state.HumanMinutes(st.CheckpointMinutes))
+	}
+	return nil
+}
 
-		st, _ = state.Load(statePath())
-		if st.ActiveSession != nil {
-			if _, err := st.StopSession(time.Now()); err != nil {
-				return err
-			}
-			_ = st.Save(statePath())
+func cmdSetMinSession(c *cmdContext, args []string) error {
+	st := c.st
+	if len(args) == 1 && args[0] == "off" {
+		st.MinSession = nil
+	} else {
+		if len(args) < 1 || len(args) > 2 {
+			return errors.New("usage: daily set-min-session <duration> [discard|merge] | off")
 		}
-		if shouldNotify(st) {
-			notify.Send("Daily Sprint", fmt.Sprintf("Cycle %d break", i))
+		policy := state.ShortDiscard
+		if len(args) == 2 {
+			policy = args[1]
 		}
+		m, err := state.ParseMinSession(args[0], policy)
+		if err != nil {
+			return err
+		}
+		st.MinSession = m
+	}
+	if err := daemon.Save(statePath(), st); err != nil {
+		return err
+	}
+	fmt.Printf("Minimum session: %s\n", st.MinSession)
+	return nil
+}
 
-		// Break
-		st, _ = state.Load(statePath())
-		if err := st.StartBreak(time.Now()); err != nil {
+func cmdSetAutoStop(c *cmdContext, args []string) error {
+	st := c.st
+	if len(args) != 1 {
+		return errors.New("usage: daily set-auto-stop <HH:MM|off>")
+	}
+	if args[0] == "off" {
+		st.AutoStop = ""
+	} else {
+		at, err := state.ParseAutoStop(args[0])
+		if err != nil {
 			return err
 		}
-		_ = st.Save(statePath())
-		time.Sleep(time.Duration(*brk) * time.Minute)
-		st, _ = state.Load(statePath())
-		if st.ActiveBreak != nil {
-			if _, err := st.StopBreak(time.Now()); err != nil {
-				return err
-			}
-			_ = st.Save(statePath())
+		st.AutoStop = at
+	}
+	if err := daemon.Save(statePath(), st); err != nil {
+		return err
+	}
+	if st.AutoStop == "" {
+		fmt.Println("Auto-stop off")
+	} else {
+		fmt.Printf("Sessions still running at %s will be stopped by the daemon\n", st.AutoStop)
+	}
+	return nil
+}
+
+func cmdSetSound(c *cmdContext, args []string) error {
+	st := c.st
+	if len(args) != 2 || !sound.Valid(args[0]) {
+		return fmt.Errorf("usage: daily set-sound <%s> <on|off|file>", strings.Join(sound.Events, "|"))
+	}
+	event, setting := args[0], args[1]
+	switch setting {
+	case "on":
+		setting = sound.Default
+	case "off":
+		setting = ""
+	default:
+		if _, err := os.Stat(setting); err != nil {
+			return err
 		}
 	}
+	if st.Sounds == nil {
+		st.Sounds = map[string]string{}
+	}
+	if setting == "" {
+		delete(st.Sounds, event)
+	} else {
+		st.Sounds[event] = setting
+	}
+	if err := daemon.Save(statePath(), st); err != nil {
+		return err
+	}
+	if setting == "" {
+		fmt.Printf("Sound for %s off\n", event)
+	} else {
+		fmt.Printf("Sound for %s: %s\n", event, setting)
+		sound.Play(event, setting)
+	}
+	return nil
+}
 
-	if st, err := state.Load(statePath()); err == nil {
-		if shouldNotify(st) {
-			notify.Send("Daily Sprint", "Sprint finished")
+func cmdSetCalendar(c *cmdContext, args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: daily set-calendar <file.ics|url|off>")
+	}
+	source := args[0]
+	if source == "off" {
+		source = ""
+	} else if _, err := calendar.Load(source); err != nil {
+		var zerr *calendar.ZoneError
+		if !errors.As(err, &zerr) {
+			return fmt.Errorf("cannot read calendar: %w", err)
 		}
+		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+	}
+	c.st.CalendarSource = source
+	if err := daemon.Save(statePath(), c.st); err != nil {
+		return err
+	}
+	if source == "" {
+		fmt.Println("Calendar disabled")
+	} else {
+		fmt.Printf("Calendar set to %s\n", source)
 	}
-	fmt.Println("Sprint finished")
 	return nil
 }
 
-func runWatch(args []string) error {
-	fs := flag.NewFlagSet("watch", flag.ExitOnError)
-	fs.SetOutput(os.Stdout)
+func cmdDaemon(c *cmdContext, args []string) error {
+	fmt.Printf("daily daemon listening on %s\n", daemon.SocketPath(statePath()))
+	return daemon.Serve(statePath())
+}
+
+func cmdTray(c *cmdContext, args []string) error {
+	if maybeDetachTray() {
+		fmt.Println("tray launched in background")
+		return nil
+	}
+	startDaemon()
+	return tray.Run(statePath())
+}
+
+func cmdInstall(c *cmdContext, args []string) error {
+	fs := newFlagSet("install")
+	var inf installFlags
+	inf.register(fs)
+	fs.Parse(args)
+	target, err := inf.target()
+	if err != nil {
+		return err
+	}
+	if err := buildLatest(target); err != nil {
+		fmt.Printf("build failed (%v); falling back to copying current binary\n", err)
+		if err2 := copySelf(target); err2 != nil {
+			return installErr(fmt.Errorf("build error: %v; copy error: %w", err, err2))
+		}
+	}
+	fmt.Printf("installed daily to %s\n", target)
+	checkOnPath(target)
+	return nil
+}
+
+func runUI() {
+	audit.SetSource("ui")
+	startDaemon()
+	if err := tui.Run(statePath()); err != nil {
+		exitErr(err)
+	}
+}
+
+// oneInt reads the single integer argument of a command.
+func oneInt(args []string, usage string) (int, error) {
+	if len(args) != 1 {
+		return 0, errors.New(usage)
+	}
+	v, err := strconv.Atoi(args[0])
+	if err != nil {
+		return 0, fmt.Errorf("argument must be an integer, not %q", args[0])
+	}
+	return v, nil
+}
+
+// startOptions are the flags accepted by `daily start`.
+type startOptions struct {
+	tags      []string
+	note      string
+	project   string
+	countdown time.Duration
+	label     string
+	force     bool
+}
+
+func parseStartFlags(args []string) startOptions {
+	fs := newFlagSet("start")
+	var tags multiString
+	var opts startOptions
+	fs.Var(&tags, "tag", "tag for the session (repeatable)")
+	fs.StringVar(&opts.note, "note", "", "note for the session")
+	fs.StringVar(&opts.project, "project", "", "project (e.g. client) the session belongs to")
+	fs.DurationVar(&opts.countdown, "for", 0, "stop automatically after this long (e.g. 90m)")
+	fs.StringVar(&opts.label, "label", "", "run a focus block with this label, shown in the tray and TUI and kept as the note (needs --for)")
+	fs.BoolVar(&opts.force, "force", false, "start even when the strict daily cap is reached")
+	fs.Parse(args)
+	opts.tags = tags
+	return opts
+}
+
+// warnTagTypos points out new tags that look like misspellings of known ones,
+// unless strict tags will refuse them anyway.
+func warnTagTypos(st *state.State, tags []string) {
+	if st.CheckTags(tags) != nil {
+		return
+	}
+	for _, t := range tags {
+		if hint := st.SuggestTag(t); hint != "" {
+			fmt.Fprintf(os.Stderr, "warning: %q is a new tag; did you mean %q?\n", t, hint)
+		}
+	}
+}
+
+const (
+	meetingTag        = "meeting"
+	calendarRefresh   = 15 * time.Minute
+	appSampleInterval = time.Minute
+)
+
+func runWatch(args []string, env state.RuleEnv) error {
+	fs := newFlagSet("watch")
 	idleMin := fs.Int("idle", 10, "idle minutes before auto-pause")
 	interval := fs.Duration("interval", 30*time.Second, "poll interval")
+	sampleApps := fs.Bool("apps", false, "record the foreground app every minute")
+	calls := fs.Bool("calls", true, "treat a camera or microphone in use as activity")
+	idleAsk := fs.Bool("idle-ask", false, "pause at the start of idle time and ask on return whether it was work, a break or neither")
+	endOnSleep := fs.Bool("sleep", true, "end the running session when the machine goes to sleep")
+	sleepBreak := fs.String("sleep-break", "no", "count time asleep as a break: no, yes or ask")
+	grace := fs.Duration("grace", 0, "ignore input lasting up to this long inside an idle stretch, e.g. 20s for a nudged mouse")
+	resumePolls := fs.Int("resume-polls", 1, "polls in a row with input before --idle-ask counts you as back")
+	lockFallback := fs.Bool("lock-fallback", true, "while idle time cannot be read, count time with the screen locked as idle")
 	fs.Parse(args)
 
 	if *idleMin <= 0 {
 		return errors.New("idle minutes must be > 0")
 	}
+	if *sleepBreak != "no" && *sleepBreak != "yes" && *sleepBreak != "ask" {
+		return errors.New("--sleep-break must be no, yes or ask")
+	}
+	if *grace < 0 || *resumePolls < 1 {
+		return errors.New("--grace must be >= 0 and --resume-polls >= 1")
+	}
 	idleDur := time.Duration(*idleMin) * time.Minute
+	log := logging.New(statePath(), "watch")
+	log.Info("started", "idle", idleDur, "interval", *interval, "apps", *sampleApps, "calls", *calls, "grace", *grace, "resume_polls", *resumePolls)
+	var events []calendar.Event
+	var eventsAt time.Time
+	// Tag rules see the calendar watch already keeps for the meeting tag.
+	env.Events = func() []calendar.Event { return events }
+	var lastAppSample time.Time
+	var wasOnCall bool
+	var paused *away // session paused for idleness, with --idle-ask
+	idling := idleTracker{grace: *grace, polls: *resumePolls}
+	probe := idleProbe{interval: *interval, lock: *lockFallback, log: log}
+	pm := power.NewMonitor()
+	// Changes go through update, so one made elsewhere since the poll's load
+	// is built on rather than overwritten, and only reported once saved.
+	update := func(fn func(st *state.State) error) error {
+		return daemon.Update(statePath(), func(st *state.State) error {
+			st.RuleEnv = env
+			return fn(st)
+		})
+	}
 	for {
 		time.Sleep(*interval)
-		st, err := state.Load(statePath())
+		if slept, ok := pm.Check(time.Now()); ok && *endOnSleep {
+			handleSleep(slept, *sleepBreak, env, log)
+		}
+		if err := daemon.Beat(statePath(), time.Now()); err != nil {
+			log.Warn("heartbeat", "err", err)
+		}
+		st, err := daemon.Load(statePath())
 		if err != nil {
 			fmt.Println("watch: load error", err)
+			log.Error("load state", "err", err)
 			continue
 		}
+		st.RuleEnv = env
 		now := time.Now()
+		if a := st.ActiveSession; a != nil && a.Until != nil && !now.Before(*a.Until) {
+			// Normally the daemon has stopped it already; this covers its absence.
+			notice, start, stopped := st.CountdownNotice(), a.Start, false
+			err := update(func(st *state.State) error {
+				// Update's Normalize stops it at its deadline.
+				stopped = st.ActiveSession == nil || !st.ActiveSession.Start.Equal(start)
+				return nil
+			})
+			if err != nil {
+				fmt.Println("watch: countdown error", err)
+				log.Error("finish countdown", "err", err)
+			} else if stopped {
+				if shouldNotify(st) {
+					notify.Send("Daily", notice)
+				}
+				fmt.Println("Countdown finished; session stopped")
+				log.Info("countdown finished")
+			}
+		}
 		st.Normalize(now)
+		idleNow, idleOK := probe.sample(now)
+		var idleFor time.Duration
+		if idleOK {
+			var back time.Time
+			idleFor, back = idling.observe(now, idleNow)
+			if paused != nil && !back.IsZero() {
+				go askAboutIdle(*paused, back, env, log)
+				paused = nil
+			}
+		}
 		if st.ActiveSession == nil {
 			continue
 		}
-		idleDurNow, err := idle.Duration()
-		if err != nil {
-			fmt.Println("watch: idle check unsupported", err)
-			return err
+		inMeeting := false
+		if st.CalendarSource != "" {
+			if time.Since(eventsAt) >= calendarRefresh {
+				evs, err := calendar.Load(st.CalendarSource)
+				if err != nil {
+					fmt.Println("watch: calendar error", err)
+					log.Warn("load calendar", "source", st.CalendarSource, "err", err)
+				}
+				if err == nil || evs != nil {
+					events = evs
+				}
+				eventsAt = time.Now()
+			}
+			if ev, ok := calendar.At(events, now); ok {
+				inMeeting = true
+				if st.TagActive(meetingTag, ev.Summary) {
+					err := update(func(st *state.State) error {
+						st.TagActive(meetingTag, ev.Summary)
+						return nil
+					})
+					if err != nil {
+						fmt.Println("watch: tag error", err)
+						log.Error("tag meeting", "err", err)
+					} else {
+						fmt.Printf("Tagged session as %s: %s\n", meetingTag, ev.Summary)
+						log.Info("tagged meeting", "event", ev.Summary)
+					}
+				}
+			}
+		}
+		// Sitting in a scheduled meeting is not idle time, and without an idle
+		// reading there is nothing to decide.
+		if !inMeeting && idleOK {
+			// A running camera or microphone means a call, not an empty desk.
+			onCall := idleFor >= idleDur && *calls && mediaInUse()
+			if onCall && !wasOnCall {
+				fmt.Println("Camera or microphone in use; not pausing")
+				log.Info("call in progress; not pausing")
+			}
+			wasOnCall = onCall
+			if idleFor >= idleDur && !onCall {
+				stopAt := now
+				if *idleAsk {
+					// Leave the idle time out until the user says what it was.
+					stopAt = now.Add(-idleFor)
+					if stopAt.Before(st.ActiveSession.Start) {
+						stopAt = st.ActiveSession.Start
+					}
+					paused = &away{since: stopAt, sess: *st.ActiveSession}
+				}
+				// A sprint controls its session: ask it to pause rather than
+				// stopping the session under it.
+				if holder := st.Holder(now); holder != "" {
+					if st.Lease.Pause == nil {
+						err := daemon.Update(statePath(), func(st *state.State) error {
+							st.RequestPause(now, stopAt)
+							return nil
+						})
+						if err != nil {
+							log.Error("ask to pause", "holder", holder, "err", err)
+						} else {
+							fmt.Printf("Idle %s during a sprint; asked %s to pause\n", idleDur, holder)
+							log.Info("asked to pause", "holder", holder, "idle", idleFor.Round(time.Second))
+						}
+					}
+					continue
+				}
+				start := st.ActiveSession.Start
+				err := update(func(st *state.State) error {
+					if st.ActiveSession == nil || !st.ActiveSession.Start.Equal(start) {
+						return errors.New("the session changed meanwhile")
+					}
+					_, err := st.StopSessionAt(stopAt)
+					return err
+				})
+				if err != nil {
+					paused = nil
+					fmt.Println("watch: stop error", err)
+					log.Error("stop session", "err", err)
+					continue
+				}
+				if shouldNotify(st) {
+					notify.Send("Daily", fmt.Sprintf("Auto-paused after %s idle", idleDur))
+				}
+				fmt.Printf("Auto-paused session after idle %s\n", idleDur)
+				log.Info("auto-paused", "idle", idleFor.Round(time.Second))
+				continue
+			}
 		}
-		if idleDurNow >= idleDur {
-			if _, err := st.StopSession(now); err != nil {
-				fmt.Println("watch: stop error", err)
+		if *sampleApps && now.Sub(lastAppSample) >= appSampleInterval {
+			elapsed := appSampleInterval
+			if !lastAppSample.IsZero() && now.Sub(lastAppSample) < 2*appSampleInterval {
+				elapsed = now.Sub(lastAppSample)
+			}
+			lastAppSample = now
+			name, err := apps.Frontmost()
+			if err != nil {
+				fmt.Println("watch: app sample error", err)
+				log.Warn("sample app", "err", err)
 				continue
 			}
-			_ = st.Save(statePath())
-			if shouldNotify(st) {
-				notify.Send("Daily", fmt.Sprintf("Auto-paused after %s idle", idleDur))
+			err = update(func(st *state.State) error {
+				st.RecordApp(name, int(elapsed.Seconds()))
+				return nil
+			})
+			if err != nil {
+				fmt.Println("watch: app sample error", err)
+				log.Warn("record app", "err", err)
 			}
-			fmt.Printf("Auto-paused session after idle %s\n", idleDur)
+		}
+	}
+}
+
+// mediaInUse reports whether a camera or microphone is busy; detection errors
+// count as not in use so watch keeps its normal idle behavior.
+func mediaInUse() bool {
+	busy, err := media.InUse()
+	return err == nil && busy
+}
+
+func runFocus(args []string) error {
+	fs := newFlagSet("focus")
+	block := fs.String("block", "", "comma-separated domains to block while a session runs")
+	interval := fs.Duration("interval", 15*time.Second, "poll interval")
+	off := fs.Bool("off", false, "remove a leftover block (e.g. after a crash) and exit")
+	fs.Parse(args)
+
+	if *off {
+		if err := focus.Unblock(); err != nil {
+			return err
+		}
+		fmt.Println("Focus block removed")
+		return nil
+	}
+	domains := focus.ParseDomains(*block)
+	if len(domains) == 0 {
+		return errors.New("usage: daily focus --block twitter.com,youtube.com")
+	}
+	if err := focus.CheckWritable(); err != nil {
+		return err
+	}
+	// Clear leftovers from a previous run that did not exit cleanly.
+	if err := focus.Unblock(); err != nil {
+		return err
+	}
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+
+	fmt.Printf("Focus mode: blocking %s while a session runs (Ctrl+C to quit)\n", strings.Join(domains, ", "))
+	blocked := false
+	for {
+		st, err := daemon.Load(statePath())
+		if err != nil {
+			fmt.Println("focus: load error", err)
+		} else {
+			want := st.ActiveSession != nil && st.ActiveBreak == nil
+			switch {
+			case want && !blocked:
+				if err := focus.Block(domains); err != nil {
+					fmt.Println("focus: block error", err)
+				} else {
+					blocked = true
+					fmt.Println("Session running: sites blocked")
+				}
+			case !want && blocked:
+				if err := focus.Unblock(); err != nil {
+					fmt.Println("focus: unblock error", err)
+				} else {
+					blocked = false
+					fmt.Println("No session running: sites unblocked")
+				}
+			}
+		}
+		select {
+		case <-sig:
+			if err := focus.Unblock(); err != nil {
+				return fmt.Errorf("could not lift block, run daily focus --off: %w", err)
+			}
+			fmt.Println("Focus mode off")
+			return nil
+		case <-ticker.C:
 		}
 	}
 }
@@ -324,94 +1046,364 @@ func shouldNotify(st *state.State) bool {
 	return st.NotificationsOn()
 }
 
-func showToday(st *state.State, now time.Time) {
-	dayKey := now.Format("2006-01-02")
-	fmt.Printf("Today: %s\n", dayKey)
-	log, ok := st.Days[dayKey]
-	if !ok || len(log.Sessions) == 0 {
-		fmt.Println("  no logged sessions yet")
+// showDay lists day's sessions and breaks, or every day in the filter's range.
+func showDay(st *state.State, day, now time.Time, filter state.Filter) {
+	if filter.HasRange() {
+		keys := st.DayKeys(filter)
+		if len(keys) == 0 {
+			fmt.Println("no days in range")
+			return
+		}
+		for i := len(keys) - 1; i >= 0; i-- {
+			fmt.Printf("%s\n", keys[i])
+			showSessions(st.Days[keys[i]], now, filter, st.Rounding)
+		}
+		return
+	}
+
+	dayKey := day.Format("2006-01-02")
+	today := dayKey == now.Format("2006-01-02")
+	if today {
+		fmt.Printf("Today: %s\n", dayKey)
 	} else {
-		for i, sess := range log.Sessions {
-			end := "--"
-			if sess.End != nil {
-				end = sess.End.Format(time.Kitchen)
-			}
-			note := ""
-			if sess.Note != "" {
-				note = fmt.Sprintf(" note:%s", sess.Note)
-			}
-			tags := ""
-			if len(sess.Tags) > 0 {
-				tags = fmt.Sprintf(" tags:%s", strings.Join(sess.Tags, ","))
+		fmt.Printf("%s: %s\n", day.Format("Monday"), dayKey)
+	}
+	log := st.Days[dayKey]
+	showSessions(log, now, filter, st.Rounding)
+	if today && st.ActiveSession != nil && filter.Match(*st.ActiveSession) {
+		fmt.Printf("  active since %s (%s so far)\n", i18n.Time(st.ActiveSession.Start), state.HumanSeconds(st.ActiveSession.Seconds(now)))
+	}
+	if log != nil && log.BreakCount > 0 && !filter.HasSessionFilter() {
+		fmt.Printf("  breaks: %d (%s)\n", log.BreakCount, state.HumanSeconds(log.BreakSeconds()))
+	}
+	if today && st.ActiveBreak != nil {
+		fmt.Printf("  on break since %s\n", i18n.Time(st.ActiveBreak.Start))
+	}
+	if log != nil && len(log.Interruptions) > 0 && !filter.HasSessionFilter() {
+		showInterruptions(log.Interruptions)
+	}
+	if in := st.ActiveInterruption; today && in != nil {
+		fmt.Printf("  interrupted by %s since %s\n", in.Reason, i18n.Time(in.Start))
+	}
+	if log != nil && log.AutoStopped != nil {
+		fmt.Printf("  ⚑ a session was still running at %s and was auto-stopped; check it with daily review\n", i18n.Time(*log.AutoStopped))
+	}
+}
+
+// dayReport is one day in --json output.
+type dayReport struct {
+	Date          string               `json:"date"`
+	WorkSeconds   int                  `json:"work_seconds"`
+	BreakSeconds  int                  `json:"break_seconds"`
+	BreakCount    int                  `json:"break_count"`
+	GoalMinutes   int                  `json:"goal_minutes"`
+	Sessions      []state.Session      `json:"sessions"`
+	Interruptions []state.Interruption `json:"interruptions,omitempty"`
+	Active        *state.Session       `json:"active,omitempty"`
+	AutoStopped   *time.Time           `json:"auto_stopped,omitempty"`
+}
+
+func newDayReport(st *state.State, key string, filter state.Filter, withSessions bool) dayReport {
+	out := dayReport{Date: key, WorkSeconds: st.FilteredWorkSeconds(key, filter), GoalMinutes: st.DayGoal(key), Sessions: []state.Session{}}
+	log := st.Days[key]
+	if log == nil {
+		return out
+	}
+	if !filter.HasSessionFilter() {
+		out.BreakSeconds, out.BreakCount = log.BreakSeconds(), log.BreakCount
+		out.Interruptions = log.Interruptions
+	}
+	out.AutoStopped = log.AutoStopped
+	if withSessions {
+		for _, sess := range log.Sessions {
+			if filter.Match(sess) {
+				out.Sessions = append(out.Sessions, sess)
 			}
-			fmt.Printf("  #%d %s -> %s (%s)%s%s\n", i+1, sess.Start.Format(time.Kitchen), end, sessionDuration(sess, now), tags, note)
 		}
-		fmt.Printf("  total: %s\n", state.HumanMinutes(log.TotalWorkMinutes))
 	}
-	if st.ActiveSession != nil {
-		fmt.Printf("  active since %s (%s so far)\n", st.ActiveSession.Start.Format(time.Kitchen), state.HumanMinutes(int(now.Sub(st.ActiveSession.Start).Minutes())))
+	return out
+}
+
+// dayJSON is what showDay prints, for --json: one day, or every day in the
+// filter's range, oldest first.
+func dayJSON(st *state.State, day, now time.Time, filter state.Filter) any {
+	if filter.HasRange() {
+		keys := st.DayKeys(filter)
+		out := make([]dayReport, 0, len(keys))
+		for i := len(keys) - 1; i >= 0; i-- {
+			out = append(out, newDayReport(st, keys[i], filter, true))
+		}
+		return out
+	}
+	key := day.Format("2006-01-02")
+	out := newDayReport(st, key, filter, true)
+	if key == now.Format("2006-01-02") && st.ActiveSession != nil && filter.Match(*st.ActiveSession) {
+		out.Active = st.ActiveSession
 	}
+	return out
 }
 
-func showHistory(st *state.State, days int) {
-	if days <= 0 {
-		days = 7
+func showSessions(log *state.DayLog, now time.Time, filter state.Filter, r *state.Rounding) {
+	if log == nil || len(log.Sessions) == 0 {
+		fmt.Println("  no logged sessions yet")
+		return
 	}
-	keys := make([]string, 0, len(st.Days))
-	for k := range st.Days {
-		keys = append(keys, k)
+	shown := 0
+	seconds := 0
+	for i, sess := range log.Sessions {
+		if !filter.Match(sess) {
+			continue
+		}
+		shown++
+		seconds += sess.Seconds(now)
+		end := "--"
+		if sess.End != nil {
+			end = i18n.Time(*sess.End)
+		}
+		note := ""
+		if sess.Note != "" {
+			note = fmt.Sprintf(" note:%s", sess.Note)
+		}
+		tags := ""
+		if len(sess.Tags) > 0 {
+			tags = fmt.Sprintf(" tags:%s", strings.Join(sess.Tags, ","))
+		}
+		project := ""
+		if sess.Project != "" {
+			project = fmt.Sprintf(" project:%s", sess.Project)
+		}
+		fmt.Printf("  #%d %s -> %s (%s)%s%s%s\n", i+1, i18n.Time(sess.Start), end, sessionDuration(sess, now, r), project, tags, note)
+	}
+	if shown == 0 {
+		fmt.Println("  no matching sessions")
+		return
 	}
-	sort.Slice(keys, func(i, j int) bool { return keys[i] > keys[j] })
+	if filter.HasSessionFilter() {
+		fmt.Printf("  total: %s (filtered)\n", state.HumanMinutes(r.Apply(seconds)))
+		return
+	}
+	fmt.Printf("  total: %s\n", state.HumanMinutes(r.Apply(log.WorkSeconds())))
+}
 
+func showSearch(st *state.State, query string, limit int, now time.Time) {
+	hits := st.Search(query, limit)
+	if len(hits) == 0 {
+		fmt.Printf("no sessions matching %q\n", query)
+		return
+	}
+	for _, hit := range hits {
+		sess := hit.Session
+		line := fmt.Sprintf("%s %s (%s)", hit.Day, i18n.Time(sess.Start), sessionDuration(sess, now, st.Rounding))
+		if sess.End == nil {
+			line += " [running]"
+		}
+		if sess.Project != "" {
+			line += " project:" + sess.Project
+		}
+		if len(sess.Tags) > 0 {
+			line += " tags:" + strings.Join(sess.Tags, ",")
+		}
+		if sess.Note != "" {
+			line += " note:" + sess.Note
+		}
+		fmt.Println(line)
+	}
+	if limit > 0 && len(hits) == limit {
+		fmt.Printf("(showing first %d matches; use --limit 0 for all)\n", limit)
+	}
+}
+
+func showAppTotals(st *state.State, day time.Time) {
+	totals := st.AppTotals(day.Format("2006-01-02"))
+	if len(totals) == 0 {
+		fmt.Println("  apps: none recorded (run daily watch --apps)")
+		return
+	}
+	names := make([]string, 0, len(totals))
+	width := 0
+	for name := range totals {
+		names = append(names, name)
+		if len(name) > width {
+			width = len(name)
+		}
+	}
+	sort.Slice(names, func(i, j int) bool { return totals[names[i]] > totals[names[j]] })
+	fmt.Println("  apps:")
+	for _, name := range names {
+		fmt.Printf("    %-*s  %s\n", width, name, state.HumanMinutes(totals[name]/60))
+	}
+}
+
+// historyOptions controls the inline charts drawn by showHistory.
+type historyOptions struct {
+	bars     bool // draw a bar per day
+	goalLine bool // mark each day's goal on its bar
+}
+
+// historyBarWidth matches the TUI week view.
+const historyBarWidth = 24
+
+// showHistory prints per-day totals, newest first. days <= 0 shows every day in the filter range.
+func showHistory(st *state.State, days int, filter state.Filter, opts historyOptions) {
+	keys := st.RecentDayKeys(filter, days)
 	if len(keys) == 0 {
-		fmt.Println("no history yet")
+		if filter.HasRange() {
+			fmt.Println("no days in range")
+		} else {
+			fmt.Println("no history yet")
+		}
 		return
 	}
 
-	if days < len(keys) {
-		keys = keys[:days]
+	scale := 0
+	for _, k := range keys {
+		scale = max(scale, st.FilteredWorkSeconds(k, filter))
+		if opts.goalLine {
+			scale = max(scale, st.DayGoal(k)*60)
+		}
 	}
+	total, p := 0, colors(st)
 	for _, k := range keys {
 		log := st.Days[k]
-		fmt.Printf("%s  work: %s  breaks: %s (%d)\n",
+		secs := st.FilteredWorkSeconds(k, filter)
+		if filter.HasSessionFilter() && secs == 0 {
+			continue
+		}
+		total += secs
+		bar := ""
+		if opts.bars {
+			goal := 0
+			if opts.goalLine {
+				goal = st.DayGoal(k) * 60
+			}
+			met := st.DayGoal(k) > 0 && secs >= st.DayGoal(k)*60
+			bar = historyBar(secs, goal, scale, met, p) + "  "
+		}
+		if filter.HasSessionFilter() {
+			fmt.Printf("%s  %swork: %s\n", k, bar, state.HumanMinutes(st.Rounding.Apply(secs)))
+			continue
+		}
+		fmt.Printf("%s  %swork: %s  breaks: %s (%d)\n",
 			k,
-			state.HumanMinutes(log.TotalWorkMinutes),
+			bar,
+			state.HumanMinutes(st.Rounding.Apply(secs)),
 			state.HumanMinutes(log.TotalBreakMinutes),
 			log.BreakCount,
 		)
 	}
+	if filter != (state.Filter{}) {
+		fmt.Printf("total: %s\n", state.HumanMinutes(st.Rounding.Apply(total)))
+	}
+}
+
+// showMonths prints per-month totals, newest first.
+func showMonths(st *state.State, months []state.MonthTotal, filter state.Filter) {
+	if len(months) == 0 {
+		fmt.Println("no history yet")
+		return
+	}
+	for _, m := range months {
+		if filter.HasSessionFilter() {
+			fmt.Printf("%s  work: %s  days: %d\n", m.Month, state.HumanMinutes(st.Rounding.Apply(m.WorkSeconds)), m.DaysWorked)
+			continue
+		}
+		fmt.Printf("%s  work: %s  breaks: %s  days: %d\n", m.Month, state.HumanMinutes(st.Rounding.Apply(m.WorkSeconds)), state.HumanMinutes(m.BreakSeconds/60), m.DaysWorked)
+	}
+}
+
+// historyJSON is what showHistory prints, for --json, newest first.
+func historyJSON(st *state.State, days int, filter state.Filter) []dayReport {
+	keys := st.RecentDayKeys(filter, days)
+	out := make([]dayReport, 0, len(keys))
+	for _, k := range keys {
+		if filter.HasSessionFilter() && st.FilteredWorkSeconds(k, filter) == 0 {
+			continue
+		}
+		out = append(out, newDayReport(st, k, filter, false))
+	}
+	return out
 }
 
-func sessionDuration(s state.Session, now time.Time) string {
-	end := s.End
-	if end == nil {
-		tmp := now
-		end = &tmp
+// historyBar draws secs as a bar of historyBarWidth cells scaled to scale
+// seconds, with a goal marker when goal > 0, in the goal colour when met.
+func historyBar(secs, goal, scale int, met bool, p palette) string {
+	cells := []rune(strings.Repeat(" ", historyBarWidth))
+	if scale <= 0 {
+		return string(cells)
+	}
+	filled := secs * historyBarWidth / scale
+	if filled == 0 && secs > 0 {
+		filled = 1
+	}
+	for i := 0; i < filled; i++ {
+		cells[i] = '█'
+	}
+	at := -1
+	if goal > 0 {
+		at = min(goal*historyBarWidth/scale, historyBarWidth-1)
+		if at < filled {
+			cells[at] = '╂'
+		} else {
+			cells[at] = '│'
+		}
+	}
+	fill := p.bar
+	if met {
+		fill = p.goal
 	}
-	mins := int(end.Sub(s.Start).Minutes())
-	if mins < 1 {
-		mins = 1
+	var b strings.Builder
+	for i := 0; i < len(cells); {
+		j, style := i+1, fill
+		switch {
+		case i == at:
+			style = p.mark
+		case i >= filled:
+			for j < len(cells) && j != at {
+				j++
+			}
+			b.WriteString(string(cells[i:j]))
+			i = j
+			continue
+		default:
+			for j < filled && j != at {
+				j++
+			}
+		}
+		b.WriteString(style.Render(string(cells[i:j])))
+		i = j
 	}
-	return state.HumanMinutes(mins)
+	return b.String()
 }
 
+func sessionDuration(s state.Session, now time.Time, r *state.Rounding) string {
+	if r == nil {
+		return state.HumanSeconds(s.Seconds(now))
+	}
+	return state.HumanMinutes(r.Apply(s.Seconds(now)))
+}
+
+// statePath is the state file selected by --state or --profile, or the
+// default one in the user's config directory.
 func statePath() string {
-	cfgDir, err := os.UserConfigDir()
-	if err != nil || cfgDir == "" {
-		home, hErr := os.UserHomeDir()
-		if hErr != nil {
-			exitErr(errors.New("cannot determine config directory"))
+	if global.state != "" {
+		path, err := filepath.Abs(expandHome(global.state))
+		if err != nil {
+			exitErr(err)
 		}
-		cfgDir = filepath.Join(home, ".config")
+		return path
+	}
+	path, err := daily.DefaultPath(global.profile)
+	if err != nil {
+		exitErr(err)
 	}
-	return filepath.Join(cfgDir, "daily", "state.json")
+	return path
 }
 
 func maybeDetachTray() bool {
 	if os.Getenv("DAILY_TRAY_DETACHED") == "1" {
 		return false
 	}
-	cmd := exec.Command(os.Args[0], "tray")
+	cmd := exec.Command(os.Args[0], globalArgs("tray")...)
 	cmd.Env = append(os.Environ(), "DAILY_TRAY_DETACHED=1")
 	cmd.Stdout = io.Discard
 	cmd.Stderr = io.Discard
@@ -421,10 +1413,98 @@ func maybeDetachTray() bool {
 	return true
 }
 
+// startDaemon launches the state daemon in the background unless one is
+// already running, so long-lived frontends share one copy of the state.
+func startDaemon() {
+	if daemon.Running(statePath()) {
+		return
+	}
+	cmd := exec.Command(os.Args[0], globalArgs("daemon")...)
+	cmd.Stdout = io.Discard
+	cmd.Stderr = io.Discard
+	if err := cmd.Start(); err != nil {
+		return
+	}
+	// Wait briefly for the socket so this process talks to the daemon too.
+	for i := 0; i < 20 && !daemon.Running(statePath()); i++ {
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
 func installPath() string {
 	return "/usr/local/bin/daily"
 }
 
+// installFlags picks where install and update put the binary.
+type installFlags struct {
+	user bool
+	dir  string
+}
+
+func (f *installFlags) register(fs *flag.FlagSet) {
+	fs.BoolVar(&f.user, "user", false, "install for this user only, into $GOBIN or ~/.local/bin (no sudo)")
+	fs.StringVar(&f.dir, "path", "", "directory to install into instead of /usr/local/bin")
+}
+
+// target is the binary's path: in --path, the user's bin directory for
+// --user, or installPath.
+func (f installFlags) target() (string, error) {
+	name := "daily"
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	switch {
+	case f.dir != "" && f.user:
+		return "", errors.New("pick one of --user and --path")
+	case f.dir != "":
+		dir, err := filepath.Abs(expandHome(f.dir))
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(dir, name), nil
+	case f.user:
+		if dir := os.Getenv("GOBIN"); dir != "" {
+			return filepath.Join(dir, name), nil
+		}
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(home, ".local", "bin", name), nil
+	}
+	return installPath(), nil
+}
+
+// installErr points at --user when the system directory is not writable.
+func installErr(err error) error {
+	if errors.Is(err, os.ErrPermission) {
+		return fmt.Errorf("%w\nrun it with sudo, or install for this user only with --user (or --path <dir>)", err)
+	}
+	return err
+}
+
+// checkOnPath warns when the installed binary will not be found by name:
+// its directory is missing from PATH, or another daily comes first.
+func checkOnPath(target string) {
+	dir := filepath.Dir(target)
+	onPath := false
+	for _, d := range filepath.SplitList(os.Getenv("PATH")) {
+		if d != "" && filepath.Clean(expandHome(d)) == dir {
+			onPath = true
+			break
+		}
+	}
+	if !onPath {
+		fmt.Fprintf(os.Stderr, "note: %s is not on your PATH; add it to your shell profile, e.g.\n  export PATH=\"%s:$PATH\"\n", dir, dir)
+		return
+	}
+	if found, err := exec.LookPath(filepath.Base(target)); err == nil {
+		if abs, err := filepath.Abs(found); err == nil && abs != target {
+			fmt.Fprintf(os.Stderr, "note: %s comes first on your PATH, so daily still runs that one\n", abs)
+		}
+	}
+}
+
 func copySelf(dest string) error {
 	src, err := os.Executable()
 	if err != nil {
@@ -444,10 +1524,31 @@ func copySelf(dest string) error {
 }
 
 func runUpdate(args []string) error {
-	fs := flag.NewFlagSet("update", flag.ExitOnError)
-	fs.SetOutput(os.Stdout)
+	fs := newFlagSet("update")
 	version := fs.String("version", "latest", "version or tag to install (e.g. v0.1.3 or latest)")
+	insecure := fs.Bool("insecure", false, "install a release binary even when its checksums cannot be checked")
+	check := fs.Bool("check", false, "only report whether a newer release is out")
+	rollback := fs.Bool("rollback", false, "go back to the binary the last update replaced")
+	var inf installFlags
+	inf.register(fs)
 	fs.Parse(args)
+	// Ctrl-C stops a download or build cleanly instead of killing it midway.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if *check {
+		return checkUpdate(ctx)
+	}
+	target, err := inf.target()
+	if err != nil {
+		return err
+	}
+	if *rollback {
+		if err := update.Rollback(target); err != nil {
+			return installErr(err)
+		}
+		fmt.Printf("rolled %s back to the previous binary (the one it replaced is now %s; --rollback again to undo)\n", target, update.BackupPath(target))
+		return nil
+	}
 
 	binDir := os.Getenv("GOBIN")
 	if binDir == "" {
@@ -458,34 +1559,70 @@ func runUpdate(args []string) error {
 		}
 		binDir = filepath.Join(gopath, "bin")
 	}
+	src := filepath.Join(binDir, "daily")
+	if src == target {
+		// go install replaces it in place; keep a copy for --rollback.
+		if err := update.Keep(target); err != nil {
+			return installErr(err)
+		}
+	}
 	// go install latest from GitHub
 	// Prefer go install when Go is available; fallback to binary download if go fails.
-	if err := update.GoInstall(*version, os.Stdout, os.Stderr); err == nil {
-		src := filepath.Join(binDir, "daily")
+	if err := update.GoInstall(ctx, *version, os.Stdout, os.Stderr); err == nil {
 		if _, err := os.Stat(src); err != nil {
 			return fmt.Errorf("did not find built binary at %s", src)
 		}
-		if err := copyFile(src, installPath()); err != nil {
-			return err
+		if src != target {
+			if err := update.Install(src, target); err != nil {
+				return installErr(err)
+			}
 		}
-		fmt.Printf("updated daily from GitHub to %s\n", installPath())
+		fmt.Printf("updated daily from GitHub to %s\n", target)
+		checkOnPath(target)
 		return nil
 	}
 
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
 	// Fallback: download release binary.
 	fmt.Println("go install failed or unavailable; downloading release binary...")
-	return update.BinaryInstall(*version, installPath(), os.Stdout, os.Stderr)
+	if err := update.BinaryInstall(ctx, *version, target, *insecure, os.Stdout, os.Stderr); err != nil {
+		return installErr(err)
+	}
+	checkOnPath(target)
+	return nil
 }
 
-func copyFile(src, dest string) error {
-	data, err := os.ReadFile(src)
+// checkUpdate reports whether a newer release than this build is out,
+// without installing it.
+func checkUpdate(ctx context.Context) error {
+	cur := update.Current()
+	latest, newer, err := update.Check(ctx)
 	if err != nil {
-		return err
+		return fmt.Errorf("checking for updates: %w", err)
 	}
-	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
-		return err
+	if global.json {
+		return printJSON(map[string]any{"current": cur, "latest": latest, "update_available": newer})
+	}
+	switch {
+	case cur == "devel":
+		fmt.Printf("this is a development build; the latest release is %s (daily update installs it)\n", latest)
+	case newer:
+		fmt.Printf("daily %s is out, this is %s: run daily update\n", latest, cur)
+	default:
+		fmt.Printf("daily %s is up to date\n", cur)
+	}
+	return nil
+}
+
+// cmdVersion prints the build version with the Go toolchain and platform.
+func cmdVersion(c *cmdContext, args []string) error {
+	if global.json {
+		return printJSON(map[string]string{"version": update.Current(), "go": runtime.Version(), "os": runtime.GOOS, "arch": runtime.GOARCH})
 	}
-	return os.WriteFile(dest, data, 0o755)
+	fmt.Printf("daily %s (%s, %s/%s)\n", update.Current(), runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	return nil
 }
 
 func buildLatest(dest string) error {
@@ -519,6 +1656,83 @@ func findModuleRoot(start string) (string, error) {
 	return "", fmt.Errorf("go.mod not found from %s", start)
 }
 
+// filterFlags holds the shared --tag/--project/--from/--to flags.
+type filterFlags struct {
+	tag, project, from, to string
+}
+
+func (f *filterFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.tag, "tag", "", "only sessions with this tag")
+	fs.StringVar(&f.project, "project", "", "only sessions for this project")
+	fs.StringVar(&f.from, "from", "", "first day to include (YYYY-MM-DD)")
+	fs.StringVar(&f.to, "to", "", "last day to include (YYYY-MM-DD)")
+}
+
+func (f filterFlags) filter() (state.Filter, error) {
+	out := state.Filter{Tag: f.tag, Project: f.project}
+	var err error
+	if f.from != "" {
+		if out.From, err = state.ParseDay(f.from); err != nil {
+			return out, err
+		}
+	}
+	if f.to != "" {
+		if out.To, err = state.ParseDay(f.to); err != nil {
+			return out, err
+		}
+	}
+	if out.From != "" && out.To != "" && out.From > out.To {
+		return out, errors.New("--from must not be after --to")
+	}
+	return out, nil
+}
+
+// parseDayArg resolves a day given as YYYY-MM-DD, today or yesterday; "-N"
+// offsets are taken out earlier by takeDayOffset.
+func parseDayArg(v string, now time.Time) (time.Time, error) {
+	switch v {
+	case "today":
+		return now, nil
+	case "yesterday":
+		return now.AddDate(0, 0, -1), nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", v, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid day %q (want YYYY-MM-DD, yesterday or -N)", v)
+	}
+	return t, nil
+}
+
+// takeDayOffset pulls a "-N" day offset out of args before flag parsing, which
+// would otherwise reject it as an unknown flag.
+func takeDayOffset(args []string) (int, []string) {
+	offset := 0
+	rest := make([]string, 0, len(args))
+	for _, a := range args {
+		var n int
+		if _, err := fmt.Sscanf(a, "-%d", &n); err == nil && fmt.Sprintf("-%d", n) == a {
+			offset = -n
+			continue
+		}
+		rest = append(rest, a)
+	}
+	return offset, rest
+}
+
+// parseInterspersed parses fs while allowing positional args between flags.
+func parseInterspersed(fs *flag.FlagSet, args []string) []string {
+	var positional []string
+	for {
+		fs.Parse(args)
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
 type multiString []string
 
 func (m *multiString) String() string {
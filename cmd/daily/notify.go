@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/max-pantom/daily/internal/notify"
+	"github.com/max-pantom/daily/internal/state"
+)
+
+// runNotify sends a notification through every configured provider and says
+// how each fared, to check a push service is set up right.
+func runNotify(st *state.State, args []string) error {
+	msg := strings.Join(args, " ")
+	if msg == "" {
+		msg = "Test notification. If you can read this, notifications work."
+	}
+	providers, err := notify.Providers(st.NotifyConfig(), os.Getenv)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "push: %v\n", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	errs := make([]error, len(providers))
+	var wg sync.WaitGroup
+	for i, p := range providers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = p.Notify(ctx, "Daily", msg)
+		}()
+	}
+	wg.Wait()
+	failed := err != nil
+	for i, p := range providers {
+		if errs[i] != nil {
+			fmt.Printf("%-9s failed: %v\n", p.Name(), errs[i])
+			failed = true
+		} else {
+			fmt.Printf("%-9s sent\n", p.Name())
+		}
+	}
+	if failed {
+		return fmt.Errorf("some notifications were not delivered")
+	}
+	return nil
+}
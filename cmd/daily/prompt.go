@@ -0,0 +1,118 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/max-pantom/daily/internal/state"
+)
+
+// promptTTL is how long a cached prompt segment is extrapolated before the
+// state is read again even though its file did not change, which catches a
+// countdown ending or a session capped for a missing heartbeat.
+const promptTTL = time.Minute
+
+// promptCache is today's figures as of At, kept beside the state file so a
+// prompt only parses the state when it changed.
+type promptCache struct {
+	Stamp   [2]time.Time `json:"stamp"` // state.ModTimes when it was made
+	At      time.Time    `json:"at"`
+	Day     string       `json:"day"`
+	Work    int          `json:"work"` // seconds worked today as of At
+	Running bool         `json:"running"`
+	OnBreak bool         `json:"on_break"`
+	Until   *time.Time   `json:"until,omitempty"` // end of a countdown session
+	Goal    int          `json:"goal"`            // minutes
+}
+
+// promptCachePath is state.prompt next to state.json, or work.prompt next
+// to work.json.
+func promptCachePath(p string) string {
+	dir, name := filepath.Split(p)
+	return filepath.Join(dir, strings.TrimSuffix(name, filepath.Ext(name))+".prompt")
+}
+
+// runPrompt prints a one-line segment for a shell prompt, such as
+// "⏱ 3h12m/8h ▶", or nothing when there is no work today. It reads the state
+// file directly, not through the daemon, and only when it changed.
+func runPrompt(args []string, now time.Time) error {
+	fs := newFlagSet("prompt")
+	format := fs.String("format", "⏱ {work}/{goal} {state}", "layout: {work}, {goal}, {percent} and {state} (▶ running, ⏸ on break)")
+	fs.Parse(args)
+
+	p := statePath()
+	c, ok := readPromptCache(p, now)
+	if !ok {
+		st, err := state.Load(p)
+		if err != nil {
+			return err
+		}
+		st.Normalize(now)
+		work, _ := st.TodaySeconds(now)
+		goal, _ := st.Goal(now)
+		c = promptCache{Stamp: state.ModTimes(p), At: now, Day: now.Format("2006-01-02"), Work: work,
+			Running: st.ActiveSession != nil, OnBreak: st.ActiveBreak != nil, Goal: goal}
+		if st.ActiveSession != nil {
+			c.Until = st.ActiveSession.Until
+		}
+		writePromptCache(p, c)
+	}
+
+	work := c.Work
+	if c.Running {
+		work += int(now.Sub(c.At).Seconds())
+	}
+	icon := ""
+	switch {
+	case c.Running:
+		icon = "▶"
+	case c.OnBreak:
+		icon = "⏸"
+	}
+	if work <= 0 && icon == "" {
+		return nil
+	}
+	layout, goal, percent := *format, "", ""
+	if c.Goal > 0 {
+		goal = state.HumanMinutes(c.Goal)
+		percent = strconv.Itoa(work*100/(c.Goal*60)) + "%"
+	} else {
+		layout = strings.ReplaceAll(layout, "/{goal}", "")
+	}
+	out := strings.NewReplacer("{work}", state.HumanMinutes(work/60), "{goal}", goal, "{percent}", percent, "{state}", icon).Replace(layout)
+	os.Stdout.WriteString(strings.TrimSpace(out) + "\n")
+	return nil
+}
+
+// readPromptCache returns the cached figures if they still describe the state
+// at now: same files, same day, fresh, and no countdown ended since.
+func readPromptCache(p string, now time.Time) (promptCache, bool) {
+	var c promptCache
+	data, err := os.ReadFile(promptCachePath(p))
+	if err != nil || json.Unmarshal(data, &c) != nil {
+		return c, false
+	}
+	fresh := now.Sub(c.At) >= 0 && now.Sub(c.At) < promptTTL
+	ended := c.Until != nil && !now.Before(*c.Until)
+	mod := state.ModTimes(p)
+	same := c.Stamp[0].Equal(mod[0]) && c.Stamp[1].Equal(mod[1])
+	return c, same && fresh && !ended && c.Day == now.Format("2006-01-02")
+}
+
+// writePromptCache saves c for the next prompt. Failing to is harmless: the
+// next prompt reads the state again.
+func writePromptCache(p string, c promptCache) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return
+	}
+	path := promptCachePath(p)
+	tmp := path + "." + strconv.Itoa(os.Getpid())
+	if os.WriteFile(tmp, data, 0o600) == nil && os.Rename(tmp, path) != nil {
+		os.Remove(tmp)
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/max-pantom/daily/internal/daemon"
+	"github.com/max-pantom/daily/internal/i18n"
+	"github.com/max-pantom/daily/internal/state"
+	"github.com/max-pantom/daily/internal/worklog"
+)
+
+// pushWindow is how far back push looks when no --from is given.
+const pushWindow = 7
+
+func runPush(st *state.State, args []string, now time.Time) error {
+	fs := newFlagSet("push")
+	to := fs.String("to", "", "jira or linear")
+	teamURL := fs.String("team", "", "push anonymous daily totals to this team server instead")
+	dryRun := fs.Bool("dry-run", false, "show the worklogs without sending them")
+	// --to names the tracker here, so the last day is --until.
+	var ff filterFlags
+	fs.StringVar(&ff.tag, "tag", "", "only sessions with this tag")
+	fs.StringVar(&ff.project, "project", "", "only sessions for this project")
+	fs.StringVar(&ff.from, "from", "", "first day to include (YYYY-MM-DD, default a week ago)")
+	fs.StringVar(&ff.to, "until", "", "last day to include (YYYY-MM-DD)")
+	fs.Parse(args)
+	filter, err := ff.filter()
+	if err != nil {
+		return err
+	}
+	if filter.From == "" {
+		filter.From = now.AddDate(0, 0, -pushWindow).Format("2006-01-02")
+	}
+	if *teamURL != "" {
+		return pushTeam(st, *teamURL, filter, *dryRun)
+	}
+
+	var pusher worklog.Pusher
+	switch *to {
+	case worklog.Jira:
+		pusher = worklog.JiraClient{BaseURL: st.JiraURL, Email: st.JiraEmail, Token: os.Getenv("DAILY_JIRA_TOKEN")}
+	case worklog.Linear:
+		pusher = worklog.LinearClient{APIKey: os.Getenv("DAILY_LINEAR_TOKEN")}
+	default:
+		return errors.New("usage: daily push --to jira|linear | --team <url> [--dry-run] [--from YYYY-MM-DD] [--until YYYY-MM-DD]")
+	}
+
+	entries := worklog.Collect(st, filter, *to)
+	if len(entries) == 0 {
+		fmt.Printf("nothing to push since %s (tag sessions with issue keys like PROJ-123)\n", filter.From)
+		return nil
+	}
+	var sent []worklog.Entry
+	var pushErr error
+	for _, e := range entries {
+		line := fmt.Sprintf("%-12s %s  %-6s %s", e.Issue, e.Start.Format("2006-01-02 15:04"), state.HumanMinutes(e.Seconds/60), e.Comment)
+		if *dryRun {
+			fmt.Println("would log", line)
+			continue
+		}
+		if pushErr = pusher.Push(e); pushErr != nil {
+			pushErr = fmt.Errorf("%s: %w", e.Issue, pushErr)
+			break
+		}
+		fmt.Println("logged", line)
+		sent = append(sent, e)
+	}
+	if len(sent) > 0 {
+		var lost []worklog.Entry
+		err := daemon.Update(statePath(), func(st *state.State) error {
+			lost = worklog.MarkPushed(st, sent, *to)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, e := range lost {
+			fmt.Fprintf(os.Stderr, "warning: the session on %s at %s changed while pushing; its %s worklog was sent but not recorded, so check %s before pushing again\n", e.Day, i18n.Time(e.Start), e.Issue, e.Issue)
+		}
+	}
+	return pushErr
+}
+
+func runSetJira(st *state.State, args []string) error {
+	fs := newFlagSet("set-jira")
+	url := fs.String("url", st.JiraURL, "Jira site, e.g. https://acme.atlassian.net")
+	email := fs.String("email", st.JiraEmail, "Atlassian account email (API token comes from DAILY_JIRA_TOKEN)")
+	fs.Parse(args)
+
+	st.JiraURL, st.JiraEmail = *url, *email
+	if err := daemon.Save(statePath(), st); err != nil {
+		return err
+	}
+	fmt.Printf("Jira set to %s as %s\n", st.JiraURL, st.JiraEmail)
+	return nil
+}
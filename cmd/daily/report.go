@@ -0,0 +1,119 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/max-pantom/daily/internal/daemon"
+	"github.com/max-pantom/daily/internal/report"
+	"github.com/max-pantom/daily/internal/state"
+)
+
+func runReport(st *state.State, args []string, now time.Time) error {
+	fs := newFlagSet("report")
+	month := fs.Bool("month", false, "summarize the current month instead of the last 7 days")
+	output := fs.String("output", "", "write the report to this file (format from extension unless --format)")
+	format := fs.String("format", "", "text, md, csv or html (default text, or from --output extension)")
+	email := fs.String("email", "", "send the HTML report to this address")
+	var ff filterFlags
+	ff.register(fs)
+	fs.Parse(args)
+	filter, err := ff.filter()
+	if err != nil {
+		return err
+	}
+
+	r, err := buildReport(st, now, *month, filter)
+	if err != nil {
+		return err
+	}
+
+	if *output != "" {
+		outFormat := *format
+		if outFormat == "" {
+			outFormat = formatForPath(*output)
+		}
+		f, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		if err := r.Write(f, outFormat); err != nil {
+			f.Close()
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+		fmt.Printf("wrote %s\n", *output)
+	}
+	if *email != "" {
+		cfg := report.SMTPConfig{
+			Host:     st.SMTPHost,
+			Port:     st.SMTPPort,
+			User:     st.SMTPUser,
+			Password: os.Getenv("DAILY_SMTP_PASSWORD"),
+			From:     st.SMTPFrom,
+		}
+		if err := r.Email(cfg, *email); err != nil {
+			return fmt.Errorf("email report: %w", err)
+		}
+		fmt.Printf("emailed report to %s\n", *email)
+	}
+	if *output == "" && *email == "" {
+		return r.Write(os.Stdout, *format)
+	}
+	return nil
+}
+
+func formatForPath(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".md", ".markdown":
+		return "md"
+	case ".csv":
+		return "csv"
+	case ".txt":
+		return "text"
+	default:
+		return "html"
+	}
+}
+
+func buildReport(st *state.State, now time.Time, month bool, filter state.Filter) (report.Report, error) {
+	if !filter.HasRange() {
+		if month {
+			return report.Month(st, now, filter), nil
+		}
+		return report.Week(st, now, filter), nil
+	}
+	from, to := now.AddDate(0, 0, -6), now
+	if filter.From != "" {
+		from, _ = time.ParseInLocation("2006-01-02", filter.From, time.Local)
+	}
+	if filter.To != "" {
+		to, _ = time.ParseInLocation("2006-01-02", filter.To, time.Local)
+	}
+	if from.After(to) {
+		return report.Report{}, errors.New("--from must not be after --to")
+	}
+	return report.Build(st, "Summary", from, to, filter), nil
+}
+
+func runSetSMTP(st *state.State, args []string) error {
+	fs := newFlagSet("set-smtp")
+	host := fs.String("host", st.SMTPHost, "SMTP server host")
+	port := fs.Int("port", st.SMTPPort, "SMTP server port (default 587)")
+	user := fs.String("user", st.SMTPUser, "SMTP username (password comes from DAILY_SMTP_PASSWORD)")
+	from := fs.String("from", st.SMTPFrom, "sender address")
+	fs.Parse(args)
+
+	st.SMTPHost, st.SMTPPort, st.SMTPUser, st.SMTPFrom = *host, *port, *user, *from
+	if err := daemon.Save(statePath(), st); err != nil {
+		return err
+	}
+	fmt.Printf("SMTP set to %s:%d as %s\n", st.SMTPHost, st.SMTPPort, st.SMTPUser)
+	return nil
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/max-pantom/daily/internal/daemon"
+	"github.com/max-pantom/daily/internal/i18n"
+	"github.com/max-pantom/daily/internal/notify"
+	"github.com/max-pantom/daily/internal/power"
+	"github.com/max-pantom/daily/internal/state"
+)
+
+// sleepMatch is how close to the sleep time a session must have ended for
+// watch to treat it as cut short by the sleep, e.g. by a heartbeat cap that ran
+// first.
+const sleepMatch = 2 * time.Minute
+
+// handleSleep ends a session left running across a suspend at the moment the
+// machine went to sleep, and optionally books the time asleep as a break.
+// breakMode is "no", "yes" or "ask"; env is what tag rules see at the stop.
+func handleSleep(slept power.Sleep, breakMode string, env state.RuleEnv, log *slog.Logger) {
+	asleep := state.HumanMinutes(int(slept.End.Sub(slept.Start).Minutes()))
+	working := false
+	err := daemon.Update(statePath(), func(st *state.State) error {
+		working = false
+		st.RuleEnv = env
+		if st.ActiveSession != nil && st.ActiveSession.Start.Before(slept.Start) {
+			if _, err := st.StopSessionAt(slept.Start); err != nil {
+				return err
+			}
+			working = true
+		} else if st.ActiveSession == nil && endedNear(st, slept.Start) {
+			working = true
+		}
+		if working && breakMode == "yes" {
+			st.AddBreak(slept.Start, slept.End)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Error("end session at sleep", "err", err)
+		return
+	}
+	log.Info("slept", "start", slept.Start, "end", slept.End, "working", working)
+	if !working {
+		return
+	}
+	fmt.Printf("Machine slept at %s for %s; session ended then\n", i18n.Time(slept.Start), asleep)
+	if breakMode != "ask" {
+		return
+	}
+	go func() {
+		choice := notify.SendActions("Daily", fmt.Sprintf("You were away for %s. Count it as a break?", asleep), []notify.Action{
+			{Key: "yes", Label: "Count as break"},
+			{Key: "no", Label: "Ignore"},
+		})
+		if choice != "yes" {
+			return
+		}
+		err := daemon.Update(statePath(), func(st *state.State) error {
+			st.AddBreak(slept.Start, slept.End)
+			return nil
+		})
+		if err != nil {
+			log.Error("record sleep as break", "err", err)
+		}
+	}()
+}
+
+// endedNear reports whether the last session of t's day ended close to t.
+func endedNear(st *state.State, t time.Time) bool {
+	log, ok := st.Days[t.Format("2006-01-02")]
+	if !ok || len(log.Sessions) == 0 {
+		return false
+	}
+	end := log.Sessions[len(log.Sessions)-1].End
+	if end == nil {
+		return false
+	}
+	d := end.Sub(t)
+	return d > -sleepMatch-state.HeartbeatInterval && d < sleepMatch
+}
@@ -0,0 +1,334 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/max-pantom/daily/internal/daemon"
+	"github.com/max-pantom/daily/internal/i18n"
+	"github.com/max-pantom/daily/internal/logging"
+	"github.com/max-pantom/daily/internal/notify"
+	"github.com/max-pantom/daily/internal/sound"
+	"github.com/max-pantom/daily/internal/state"
+)
+
+// sprintTick is how often a sprint phase re-reads the wall clock, so a
+// suspend or clock change is noticed within a second of waking.
+const sprintTick = time.Second
+
+// leaseRenew is how often a running sprint renews its hold on the session,
+// well within state.LeaseTTL.
+const leaseRenew = time.Minute
+
+func runSprint(args []string, env state.RuleEnv) (err error) {
+	if len(args) > 0 {
+		switch args[0] {
+		case "skip", "extend":
+			return runSprintControl(args)
+		}
+	}
+	fs := newFlagSet("sprint")
+	work := fs.Int("work", 50, "work minutes")
+	brk := fs.Int("break", 10, "break minutes")
+	cycles := fs.Int("cycles", 4, "cycles")
+	var tags multiString
+	var note, project string
+	fs.Var(&tags, "tag", "tag for sprint sessions")
+	fs.StringVar(&note, "note", "", "note for sprint sessions")
+	fs.StringVar(&project, "project", "", "project for sprint sessions")
+	force := fs.Bool("force", false, "keep cycling past the strict daily cap")
+	fs.Parse(args)
+
+	if *work <= 0 || *brk <= 0 || *cycles <= 0 {
+		return errors.New("work, break, and cycles must be > 0")
+	}
+	log := logging.New(statePath(), "sprint")
+	log.Info("started", "work", *work, "break", *brk, "cycles", *cycles)
+	defer func() {
+		if err != nil {
+			log.Error("sprint stopped", "err", err)
+		}
+	}()
+
+	p := statePath()
+	owner := fmt.Sprintf("sprint %d", os.Getpid())
+	if st, err := daemon.Load(p); err == nil {
+		warnTagTypos(st, tags)
+	}
+	defer releaseSprint(p, owner)
+
+	for i := 1; i <= *cycles; i++ {
+		now := wallNow()
+		var st *state.State
+		err := daemon.Update(p, func(s *state.State) error {
+			st = s
+			s.RuleEnv = env
+			if err := s.Acquire(owner, now); err != nil {
+				return fmt.Errorf("%w; stop it first", err)
+			}
+			if !*force {
+				if err := s.CheckCap(now); err != nil {
+					return err
+				}
+			}
+			if s.ActiveBreak != nil {
+				if _, err := s.StopBreak(now); err != nil {
+					return err
+				}
+			}
+			// A session started by hand meanwhile becomes this cycle's.
+			if s.ActiveSession == nil {
+				if err := s.StartSession(now, tags, note); err != nil {
+					return err
+				}
+				s.ActiveSession.Project = project
+			}
+			s.SprintPhaseEnd = phaseEnd(now, *work)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Cycle %d/%d: work %d min\n", i, *cycles, *work)
+		log.Info("work phase", "cycle", i, "minutes", *work)
+		if shouldNotify(st) {
+			notify.Send("Daily Sprint", fmt.Sprintf("Cycle %d work started", i))
+		}
+		workEnd, ok := waitPhase(*st.SprintPhaseEnd, owner, env, log)
+		if !ok {
+			return nil
+		}
+
+		// Break. After a suspend it began when the work phase was due to end,
+		// since the time asleep was time away.
+		err = daemon.Update(p, func(s *state.State) error {
+			st = s
+			s.RuleEnv = env
+			if err := s.Acquire(owner, wallNow()); err != nil {
+				return err
+			}
+			at := workEnd
+			if a := s.ActiveSession; a != nil {
+				at = later(at, a.Start)
+				if _, err := s.StopSessionAt(at); err != nil {
+					return err
+				}
+			}
+			if s.ActiveBreak == nil {
+				if err := s.StartBreak(at); err != nil {
+					return err
+				}
+			}
+			s.SprintPhaseEnd = phaseEnd(at, *brk)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if shouldNotify(st) {
+			notify.Send("Daily Sprint", fmt.Sprintf("Cycle %d break", i))
+		}
+		sound.Play(sound.WorkEnd, st.Sounds[sound.WorkEnd])
+		breakEnd, ok := waitPhase(*st.SprintPhaseEnd, owner, env, log)
+		if !ok {
+			return nil
+		}
+		err = daemon.Update(p, func(s *state.State) error {
+			st = s
+			if a := s.ActiveBreak; a != nil {
+				if _, err := s.StopBreak(later(breakEnd, a.Start)); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		sound.Play(sound.BreakEnd, st.Sounds[sound.BreakEnd])
+	}
+
+	if st, err := daemon.Load(p); err == nil && shouldNotify(st) {
+		notify.Send("Daily Sprint", "Sprint finished")
+	}
+	fmt.Println("Sprint finished")
+	log.Info("sprint finished")
+	return nil
+}
+
+// releaseSprint gives up the session on the way out, however the sprint
+// ended, unless something else took it over meanwhile.
+func releaseSprint(p, owner string) {
+	if st, err := daemon.Load(p); err != nil || st.Holder(wallNow()) != owner {
+		return
+	}
+	err := daemon.Update(p, func(st *state.State) error {
+		if st.Holder(wallNow()) == owner {
+			st.Release(owner)
+			st.SprintPhaseEnd = nil
+		}
+		return nil
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "sprint: could not release the session:", err)
+	}
+}
+
+// waitPhase sleeps until the wall clock reaches end and returns when the phase
+// ended: end itself, even if the machine was asleep then. time.Sleep alone
+// runs on the monotonic clock, which stands still during suspend, so a long
+// sleep would stretch the phase by the time the lid was closed.
+//
+// The deadline is the state's sprint_phase_end, so `daily sprint skip` and
+// `extend` from elsewhere move it; ok is false once it has been cleared, which
+// stops the sprint. Meanwhile it renews owner's lease, and ends the sprint
+// when watch asks for the session to be paused for idleness, stopping it
+// with env for tag rules.
+func waitPhase(end time.Time, owner string, env state.RuleEnv, log *slog.Logger) (ended time.Time, ok bool) {
+	var changes <-chan struct{}
+	if w, err := daemon.Watch(statePath()); err == nil {
+		defer w.Close()
+		changes = w.Changes()
+	}
+	end = end.Round(0)
+	renewed := wallNow()
+	for {
+		left := end.Sub(wallNow())
+		if left <= 0 {
+			break
+		}
+		timer := time.NewTimer(min(left, sprintTick))
+		select {
+		case <-timer.C:
+			if wallNow().Sub(renewed) < leaseRenew {
+				continue
+			}
+		case _, open := <-changes:
+			timer.Stop()
+			if !open {
+				changes = nil
+				continue
+			}
+		}
+		st, err := daemon.Load(statePath())
+		if err != nil {
+			continue
+		}
+		now := wallNow()
+		if st.SprintPhaseEnd == nil {
+			fmt.Println("Sprint stopped")
+			log.Info("sprint stopped elsewhere")
+			return now, false
+		}
+		if h := st.Holder(now); h != "" && h != owner {
+			fmt.Printf("Sprint stopped: %s took over the session\n", h)
+			log.Warn("lost the lease", "holder", h)
+			return now, false
+		}
+		if st.Lease != nil && st.Lease.Pause != nil || now.Sub(renewed) >= leaseRenew {
+			var pausedAt time.Time
+			err := daemon.Update(statePath(), func(st *state.State) error {
+				st.RuleEnv = env
+				if err := st.Acquire(owner, now); err != nil {
+					return err
+				}
+				at, ok := st.TakePause(owner)
+				if !ok {
+					return nil
+				}
+				pausedAt = at
+				if a := st.ActiveSession; a != nil {
+					if _, err := st.StopSessionAt(later(at, a.Start)); err != nil {
+						return err
+					}
+				}
+				st.SprintPhaseEnd = nil
+				return nil
+			})
+			if err != nil {
+				log.Warn("renew lease", "err", err)
+				continue
+			}
+			renewed = now
+			if !pausedAt.IsZero() {
+				fmt.Printf("Idle since %s: session paused and sprint stopped\n", i18n.Time(pausedAt))
+				log.Info("stopped for idleness", "since", pausedAt)
+				return now, false
+			}
+		}
+		if next := st.SprintPhaseEnd.Round(0); !next.Equal(end) {
+			log.Info("phase end moved", "from", end, "to", next)
+			end = next
+		}
+	}
+	if late := wallNow().Sub(end); late > time.Minute {
+		fmt.Printf("Phase ended at %s while the machine was asleep or the clock moved (%s ago)\n", i18n.Time(end), state.HumanMinutes(int(late.Minutes())))
+		log.Info("phase ended late", "end", end, "late", late.Round(time.Second))
+	}
+	return end, true
+}
+
+// runSprintControl is `daily sprint skip` and `daily sprint extend <m>`, which
+// act on the sprint running in another terminal or the TUI.
+func runSprintControl(args []string) error {
+	usage := errors.New("usage: daily sprint skip | daily sprint extend <minutes>")
+	var minutes int
+	switch {
+	case args[0] == "skip" && len(args) == 1:
+	case args[0] == "extend" && len(args) == 2:
+		m, err := strconv.Atoi(args[1])
+		if err != nil || m <= 0 {
+			return usage
+		}
+		minutes = m
+	default:
+		return usage
+	}
+	now := wallNow()
+	var end time.Time
+	err := daemon.Update(statePath(), func(st *state.State) error {
+		var err error
+		if minutes > 0 {
+			err = st.ExtendPhase(now, time.Duration(minutes)*time.Minute)
+		} else {
+			err = st.SkipPhase(now)
+		}
+		if err == nil {
+			end = *st.SprintPhaseEnd
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if minutes > 0 {
+		fmt.Printf("Sprint phase extended by %d min; it now ends at %s\n", minutes, i18n.Time(end))
+	} else {
+		fmt.Println("Skipped to the next sprint phase")
+	}
+	return nil
+}
+
+// later is the later of a and b.
+func later(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
+
+// wallNow is the current time without its monotonic reading, so comparisons
+// and differences use the wall clock.
+func wallNow() time.Time {
+	return time.Now().Round(0)
+}
+
+// phaseEnd is the deadline of a sprint phase lasting minutes, shown by the tray.
+func phaseEnd(start time.Time, minutes int) *time.Time {
+	end := start.Round(0).Add(time.Duration(minutes) * time.Minute)
+	return &end
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/max-pantom/daily/internal/state"
+	"github.com/max-pantom/daily/internal/stats"
+)
+
+// cmdStats measures deep work over the last days days, 30 by default, or the
+// --from/--to range: session lengths, sessions per day, the longest focus
+// stretch and how much work came in short stretches, or with --hours when in
+// the week the work was done.
+func cmdStats(c *cmdContext, args []string) error {
+	fs := newFlagSet("stats")
+	hours := fs.Bool("hours", false, "work by weekday and hour of day, and the most focused hours")
+	var ff filterFlags
+	ff.register(fs)
+	rest := parseInterspersed(fs, args)
+	filter, err := ff.filter()
+	if err != nil {
+		return err
+	}
+	days := 30
+	switch len(rest) {
+	case 0:
+	case 1:
+		if days, err = strconv.Atoi(rest[0]); err != nil || days <= 0 {
+			return fmt.Errorf("days must be a positive number, not %q", rest[0])
+		}
+	default:
+		return errors.New("usage: daily stats [days]")
+	}
+	to, from := c.now, c.now.AddDate(0, 0, -(days-1))
+	if filter.To != "" {
+		to, _ = time.ParseInLocation("2006-01-02", filter.To, time.Local)
+	}
+	if filter.From != "" {
+		from, _ = time.ParseInLocation("2006-01-02", filter.From, time.Local)
+	} else if filter.To != "" {
+		from = to.AddDate(0, 0, -(days - 1))
+	}
+	if *hours {
+		return showHours(c.st, stats.Hours(c.st, from, to, filter), from, to)
+	}
+	f := stats.FocusRange(c.st, from, to, filter)
+	if global.json {
+		return printJSON(f)
+	}
+
+	fmt.Printf("%s to %s: %d days, %d worked\n", f.From, f.To, f.Days, f.DaysWorked)
+	if f.Sessions == 0 {
+		fmt.Println("no finished sessions")
+		return nil
+	}
+	longest := state.HumanSeconds(f.Longest)
+	if f.LongestDay != "" {
+		longest += " on " + f.LongestDay
+	}
+	rows := [][2]string{
+		{"sessions", fmt.Sprintf("%d, %.1f per day worked", f.Sessions, f.SessionsPerDay)},
+		{"avg session", state.HumanSeconds(f.AvgSession)},
+		{"median session", state.HumanSeconds(f.MedianSession)},
+		{"focus stretches", fmt.Sprintf("%d (sessions less than %s apart with no break)", f.Stretches, state.HumanMinutes(int(state.ContinuousGap.Minutes())))},
+		{"longest stretch", longest},
+		{"fragmentation", fmt.Sprintf("%d%% of work in stretches under %s", f.Fragmentation, state.HumanMinutes(int(stats.ShortStretch.Minutes())))},
+	}
+	for _, r := range rows {
+		fmt.Printf("%-16s %s\n", r[0], r[1])
+	}
+	return nil
+}
+
+// hourShades draw a Profile from no work to the busiest hour.
+var hourShades = []string{"·", "░", "▒", "▓", "█"}
+
+// hoursJSON is the profile for --json, a row of seconds per hour for each
+// weekday.
+type hoursJSON struct {
+	From string    `json:"from"`
+	To   string    `json:"to"`
+	Days []hourRow `json:"days"`
+	Peak *peakJSON `json:"peak,omitempty"`
+}
+
+type peakJSON struct {
+	Label   string `json:"label"` // e.g. "Tue 09–12"
+	Weekday string `json:"weekday"`
+	From    int    `json:"from_hour"`
+	To      int    `json:"to_hour"`
+	Seconds int    `json:"seconds"`
+}
+
+type hourRow struct {
+	Weekday string  `json:"weekday"`
+	Seconds [24]int `json:"seconds"`
+}
+
+func showHours(st *state.State, p stats.Profile, from, to time.Time) error {
+	peak, ok := p.Peak()
+	if global.json {
+		out := hoursJSON{From: from.Format("2006-01-02"), To: to.Format("2006-01-02")}
+		for i, row := range p {
+			out.Days = append(out.Days, hourRow{Weekday: p.Day(i).String()[:3], Seconds: row})
+		}
+		if ok {
+			out.Peak = &peakJSON{Label: peak.String(), Weekday: peak.Weekday.String()[:3], From: peak.From, To: peak.From + stats.PeakHours, Seconds: peak.Seconds}
+		}
+		return printJSON(out)
+	}
+
+	fmt.Printf("%s to %s\n", from.Format("2006-01-02"), to.Format("2006-01-02"))
+	if !ok {
+		fmt.Println("no finished sessions")
+		return nil
+	}
+	pal := colors(st)
+	fmt.Print("    ")
+	for h := 0; h < 24; h += 3 {
+		fmt.Printf("%-6s", fmt.Sprintf("%02d", h))
+	}
+	fmt.Println()
+	for i := range p {
+		fmt.Print(p.Day(i).String()[:3], " ")
+		for h := range p[i] {
+			lvl := p.Level(i, h, len(hourShades))
+			style := pal.bar
+			if lvl == 0 {
+				style = pal.mark
+			}
+			fmt.Print(style.Render(strings.Repeat(hourShades[lvl], 2)))
+		}
+		fmt.Println()
+	}
+	fmt.Printf("\nYou log the most focused time %s (%s over the period)\n", peak, state.HumanSeconds(peak.Seconds))
+	return nil
+}
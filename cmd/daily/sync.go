@@ -0,0 +1,269 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/max-pantom/daily/internal/daemon"
+	"github.com/max-pantom/daily/internal/i18n"
+	"github.com/max-pantom/daily/internal/remote"
+	"github.com/max-pantom/daily/internal/state"
+)
+
+const syncUsage = "usage: daily sync [setup --backend s3|webdav|http --url <object url> | status]"
+
+// syncAttempts bounds the retries when another machine uploads in between.
+const syncAttempts = 3
+
+// syncMeta remembers the last sync: the remote version and the hash of the
+// history as it was then, to tell which side changed since. The history
+// itself is kept in the base file, for merging when both did.
+type syncMeta struct {
+	Version string    `json:"version"`
+	Hash    string    `json:"hash"`
+	At      time.Time `json:"at"`
+}
+
+// syncMetaPath is sync.json next to state.json, or work.sync.json next to
+// work.json.
+func syncMetaPath() string {
+	dir, name := filepath.Split(statePath())
+	if name == "state.json" {
+		return filepath.Join(dir, "sync.json")
+	}
+	return filepath.Join(dir, strings.TrimSuffix(name, filepath.Ext(name))+".sync.json")
+}
+
+// syncBasePath is the history as of the last sync, sync.base.json next to
+// sync.json.
+func syncBasePath() string {
+	return strings.TrimSuffix(syncMetaPath(), ".json") + ".base.json"
+}
+
+// loadSyncBase returns the days as of the last sync, or nil when they were not
+// kept or do not match the sync, which makes a merge only add.
+func loadSyncBase(meta syncMeta) map[string]*state.DayLog {
+	data, err := os.ReadFile(syncBasePath())
+	if err != nil || historyHash(data) != meta.Hash {
+		return nil
+	}
+	days, err := state.ParseHistory(data)
+	if err != nil {
+		return nil
+	}
+	return days
+}
+
+func loadSyncMeta() syncMeta {
+	var m syncMeta
+	if data, err := os.ReadFile(syncMetaPath()); err == nil {
+		_ = json.Unmarshal(data, &m)
+	}
+	return m
+}
+
+func saveSyncMeta(m syncMeta) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(syncMetaPath(), append(data, '\n'), 0o600)
+}
+
+func historyHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func runSync(st *state.State, args []string, now time.Time) error {
+	if len(args) > 0 {
+		switch args[0] {
+		case "setup":
+			return runSyncSetup(st, args[1:])
+		case "status":
+			return runSyncStatus(st)
+		}
+		return errors.New(syncUsage)
+	}
+	store, err := syncStore(st)
+	if err != nil {
+		return err
+	}
+	pass := os.Getenv("DAILY_SYNC_PASSPHRASE")
+	for i := 0; i < syncAttempts; i++ {
+		err = syncOnce(store, pass, now)
+		if !errors.Is(err, remote.ErrConflict) {
+			return err
+		}
+	}
+	return fmt.Errorf("sync gave up: the remote kept changing (%w)", err)
+}
+
+// syncOnce reads the remote history and reconciles it with the local one.
+// When only one side changed since the last sync, that side wins; when both
+// did, the days are merged against the history of the last sync, so each
+// side's additions, edits and deletions since then are kept, and the result
+// is saved locally and uploaded.
+func syncOnce(store remote.Store, pass string, now time.Time) error {
+	meta := loadSyncMeta()
+	blob, version, err := store.Get()
+	found := err == nil
+	if err != nil && !errors.Is(err, remote.ErrNotFound) {
+		return err
+	}
+	var theirs map[string]*state.DayLog
+	if found {
+		plain, err := remote.Open(blob, pass)
+		if err != nil {
+			return err
+		}
+		if theirs, err = state.ParseHistory(plain); err != nil {
+			return fmt.Errorf("remote history: %w", err)
+		}
+	}
+
+	var upload []byte
+	var result string
+	err = daemon.Update(statePath(), func(st *state.State) error {
+		upload, result = nil, ""
+		ours, err := st.HistoryJSON()
+		if err != nil {
+			return err
+		}
+		localChanged := historyHash(ours) != meta.Hash
+		switch {
+		case !found:
+			upload, result = ours, "uploaded the history (nothing on the remote yet)"
+		case version != "" && version == meta.Version:
+			if localChanged {
+				upload, result = ours, "uploaded local changes"
+			}
+		case !localChanged:
+			st.SetDays(theirs)
+			result = "took the remote history (changed on another machine)"
+		default:
+			changed := st.MergeDays(loadSyncBase(meta), theirs)
+			if upload, err = st.HistoryJSON(); err != nil {
+				return err
+			}
+			if bytes.Equal(upload, ours) && len(changed) == 0 {
+				result = "merged: nothing new from the remote"
+			} else {
+				result = fmt.Sprintf("merged both sides (%d day(s) updated from the remote)", len(changed))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	next := syncMeta{Version: version, At: now}
+	synced := upload
+	if upload != nil {
+		sealed, err := remote.Seal(upload, pass)
+		if err != nil {
+			return err
+		}
+		putVersion := version
+		if !found {
+			putVersion = ""
+		}
+		if next.Version, err = store.Put(sealed, putVersion); err != nil {
+			return err
+		}
+		next.Hash = historyHash(upload)
+	} else {
+		st, err := daemon.Load(statePath())
+		if err != nil {
+			return err
+		}
+		if synced, err = st.HistoryJSON(); err != nil {
+			return err
+		}
+		next.Hash = historyHash(synced)
+	}
+	if err := os.WriteFile(syncBasePath(), synced, 0o600); err != nil {
+		return err
+	}
+	if err := saveSyncMeta(next); err != nil {
+		return err
+	}
+	if result == "" {
+		result = "already in sync"
+	}
+	fmt.Println("Sync: " + result)
+	return nil
+}
+
+func syncStore(st *state.State) (remote.Store, error) {
+	if st.SyncBackend == "" {
+		return nil, errors.New("sync is not set up; run daily sync setup --backend s3|webdav|http --url <object url>")
+	}
+	return remote.New(remote.Config{Backend: st.SyncBackend, URL: st.SyncURL, User: st.SyncUser, Region: st.SyncRegion}, os.Getenv)
+}
+
+// runSyncSetup stores the backend settings and checks that the remote can be
+// read with them and the passphrase.
+func runSyncSetup(st *state.State, args []string) error {
+	fs := newFlagSet("sync")
+	backend := fs.String("backend", st.SyncBackend, "s3, webdav or http")
+	url := fs.String("url", st.SyncURL, "URL of the synced file, e.g. https://bucket.s3.eu-west-1.amazonaws.com/daily.bin")
+	user := fs.String("user", st.SyncUser, "WebDAV/HTTP user; the password comes from DAILY_SYNC_PASSWORD")
+	region := fs.String("region", st.SyncRegion, "S3 region (default us-east-1)")
+	fs.Parse(args)
+
+	st.SyncBackend, st.SyncURL, st.SyncUser, st.SyncRegion = *backend, *url, *user, *region
+	store, err := syncStore(st)
+	if err != nil {
+		return err
+	}
+	if err := daemon.Save(statePath(), st); err != nil {
+		return err
+	}
+	fmt.Printf("Sync set to %s at %s\n", st.SyncBackend, st.SyncURL)
+	blob, _, err := store.Get()
+	switch {
+	case errors.Is(err, remote.ErrNotFound):
+		fmt.Println("The remote is empty; daily sync will upload this machine's history.")
+	case err != nil:
+		return fmt.Errorf("cannot read the remote: %w", err)
+	default:
+		if _, err := remote.Open(blob, os.Getenv("DAILY_SYNC_PASSPHRASE")); err != nil {
+			return err
+		}
+		fmt.Println("The remote holds a history this passphrase opens; daily sync will merge it.")
+	}
+	return nil
+}
+
+func runSyncStatus(st *state.State) error {
+	if st.SyncBackend == "" {
+		fmt.Println("Sync is not set up (daily sync setup).")
+		return nil
+	}
+	fmt.Printf("Backend: %s\nURL: %s\n", st.SyncBackend, st.SyncURL)
+	meta := loadSyncMeta()
+	if meta.At.IsZero() {
+		fmt.Println("Never synced")
+		return nil
+	}
+	data, err := st.HistoryJSON()
+	if err != nil {
+		return err
+	}
+	changed := ""
+	if historyHash(data) != meta.Hash {
+		changed = " (local changes since)"
+	}
+	fmt.Printf("Last sync: %s %s%s\n", meta.At.Format("2006-01-02"), i18n.Time(meta.At), changed)
+	return nil
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/max-pantom/daily/internal/daemon"
+	"github.com/max-pantom/daily/internal/state"
+	"github.com/max-pantom/daily/internal/team"
+)
+
+const teamUsage = "usage: daily team serve [--addr :8787] [--data team.json] | report [--team url] [--days 7]"
+
+func runTeam(st *state.State, args []string, now time.Time) error {
+	if len(args) == 0 {
+		return errors.New(teamUsage)
+	}
+	switch args[0] {
+	case "serve":
+		return runTeamServe(args[1:])
+	case "report":
+		return runTeamReport(st, args[1:], now)
+	}
+	return errors.New(teamUsage)
+}
+
+// runTeamServe runs the team server in the foreground, keeping the pushed
+// totals next to the state file unless --data says otherwise. It serves
+// plain HTTP, so it stays on this machine unless given a token.
+func runTeamServe(args []string) error {
+	fs := newFlagSet("team")
+	addr := fs.String("addr", "127.0.0.1:8787", "address to listen on; another than loopback needs --token")
+	data := fs.String("data", filepath.Join(filepath.Dir(statePath()), "team.json"), "file the pushed totals are kept in")
+	token := fs.String("token", os.Getenv("DAILY_TEAM_TOKEN"), "token members must send (default from DAILY_TEAM_TOKEN)")
+	fs.Parse(args)
+
+	srv, err := team.NewServer(expandHome(*data), *token)
+	if err != nil {
+		return err
+	}
+	if *token == "" && !loopback(*addr) {
+		return fmt.Errorf("listening on %s needs --token or DAILY_TEAM_TOKEN; without one anyone on the network can push and read totals", *addr)
+	}
+	if *token == "" {
+		fmt.Fprintln(os.Stderr, "warning: no token; anyone who can reach the server can push and read totals")
+	} else if !loopback(*addr) {
+		fmt.Fprintln(os.Stderr, "warning: the server speaks plain HTTP, so the token and totals travel in clear text; put a TLS proxy in front of it")
+	}
+	fmt.Printf("Serving team totals on %s (data in %s)\n", *addr, *data)
+	hs := &http.Server{Addr: *addr, Handler: srv.Handler(), ReadHeaderTimeout: 10 * time.Second}
+	return hs.ListenAndServe()
+}
+
+// loopback reports whether addr only listens on this machine.
+func loopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func runTeamReport(st *state.State, args []string, now time.Time) error {
+	fs := newFlagSet("team")
+	url := fs.String("team", st.TeamURL, "team server (default from team.url)")
+	days := fs.Int("days", 7, "days to cover, ending today")
+	fs.Parse(args)
+	if *days < 1 {
+		return fmt.Errorf("invalid --days %d", *days)
+	}
+
+	client := team.Client{URL: *url, Token: os.Getenv("DAILY_TEAM_TOKEN")}
+	from, to := now.AddDate(0, 0, 1-*days).Format("2006-01-02"), now.Format("2006-01-02")
+	rep, err := client.Report(from, to)
+	if err != nil {
+		return err
+	}
+	if global.json {
+		return printJSON(rep)
+	}
+	fmt.Printf("Team %s to %s: %d member(s), %s worked\n", rep.From, rep.To, rep.Members, state.HumanSeconds(rep.WorkSeconds))
+	if len(rep.Days) == 0 {
+		fmt.Println("No totals pushed for these days yet (daily push --team <url>).")
+		return nil
+	}
+	fmt.Printf("\n%-14s %7s %9s %9s %9s %9s\n", "day", "members", "total", "avg", "min", "max")
+	for _, d := range rep.Days {
+		t, _ := time.ParseInLocation("2006-01-02", d.Date, time.Local)
+		fmt.Printf("%-14s %7d %9s %9s %9s %9s\n", t.Format("Mon 2006-01-02"), d.Members,
+			state.HumanSeconds(d.WorkSeconds), state.HumanSeconds(d.AvgSeconds),
+			state.HumanSeconds(d.MinSeconds), state.HumanSeconds(d.MaxSeconds))
+	}
+	fmt.Println("\nby member")
+	for _, m := range rep.ByMember {
+		mark := ""
+		if st.TeamMember != "" && m.Member == team.Label(st.TeamMember) {
+			mark = "  (you)"
+		}
+		fmt.Printf("  %-10s %9s over %d day(s)%s\n", m.Member, state.HumanSeconds(m.WorkSeconds), m.DaysWorked, mark)
+	}
+	return nil
+}
+
+// pushTeam sends the daily totals of the days in filter's range to a team
+// server under the member secret, making one up on the first push, or in
+// place of a member ID from before secrets, whose totals the server then
+// moves over. Sessions themselves never leave the machine.
+func pushTeam(st *state.State, url string, filter state.Filter, dryRun bool) error {
+	var previous string
+	if len(st.TeamMember) < team.MinSecret && !dryRun {
+		secret := team.NewMemberSecret()
+		err := daemon.Update(statePath(), func(st *state.State) error {
+			if len(st.TeamMember) < team.MinSecret {
+				previous, st.TeamMember = st.TeamMember, secret
+			}
+			secret = st.TeamMember
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		st.TeamMember = secret
+	}
+	totals := team.Totals{Member: st.TeamMember, Previous: previous}
+	keys := st.DayKeys(state.Filter{From: filter.From, To: filter.To})
+	for i := len(keys) - 1; i >= 0; i-- {
+		log := st.Days[keys[i]]
+		d := team.Day{Date: keys[i], WorkSeconds: log.WorkSeconds(), BreakSeconds: log.BreakSeconds()}
+		for _, sess := range log.Sessions {
+			if sess.End != nil {
+				d.Sessions++
+			}
+		}
+		totals.Days = append(totals.Days, d)
+	}
+	if len(totals.Days) == 0 {
+		fmt.Printf("nothing to push since %s\n", filter.From)
+		return nil
+	}
+	verb := "would push"
+	if !dryRun {
+		if err := (team.Client{URL: url, Token: os.Getenv("DAILY_TEAM_TOKEN")}).Push(totals); err != nil {
+			return err
+		}
+		verb = "pushed"
+	}
+	for _, d := range totals.Days {
+		fmt.Printf("%s %s  %-7s %d session(s)\n", verb, d.Date, state.HumanSeconds(d.WorkSeconds), d.Sessions)
+	}
+	return nil
+}
@@ -0,0 +1,22 @@
+package main
+
+import "testing"
+
+func TestLoopback(t *testing.T) {
+	for addr, want := range map[string]bool{
+		"127.0.0.1:8787": true,
+		"localhost:8787": true,
+		"[::1]:8787":     true,
+		"127.0.0.2:80":   true,
+		":8787":          false,
+		"0.0.0.0:8787":   false,
+		"[::]:8787":      false,
+		"10.0.0.5:8787":  false,
+		"team.lan:8787":  false,
+		"127.0.0.1":      false,
+	} {
+		if got := loopback(addr); got != want {
+			t.Errorf("loopback(%q) = %v, want %v", addr, got, want)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/max-pantom/daily/internal/daemon"
+	"github.com/max-pantom/daily/internal/i18n"
+	"github.com/max-pantom/daily/internal/notify"
+	"github.com/max-pantom/daily/internal/state"
+	"github.com/max-pantom/daily/internal/urlscheme"
+)
+
+// runURL performs the action of a daily:// URL, then opens its x-success or
+// x-error callback. Failures are also announced, since the caller is usually
+// Shortcuts or a browser rather than a terminal.
+func runURL(st *state.State, args []string, now time.Time) error {
+	if len(args) != 1 {
+		return errors.New("usage: daily url 'daily://start?tag=x&note=...&for=25m'")
+	}
+	req, err := urlscheme.Parse(args[0])
+	if err != nil {
+		return err
+	}
+	msg, err := urlAction(st, req, now)
+	switch {
+	case err == nil:
+		announce(st, msg)
+	case !stdoutIsTerminal() && st.NotificationsOn():
+		notify.Local("Daily", err.Error())
+	}
+	if cb := req.Callback(err); cb != "" {
+		if oerr := openURL(cb); oerr != nil && err == nil {
+			return fmt.Errorf("open callback: %w", oerr)
+		}
+	}
+	return err
+}
+
+func urlAction(st *state.State, req urlscheme.Request, now time.Time) (string, error) {
+	switch req.Action {
+	case "toggle":
+		return toggle(st, now)
+	case "break":
+		return toggleBreak(st, now)
+	case "stop":
+		if st.ActiveSession == nil {
+			return "No session running.", nil
+		}
+		seconds := st.ActiveSession.Seconds(now)
+		if _, err := st.StopSession(now); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Stopped session. Logged %s.", state.HumanSeconds(seconds)), daemon.Save(statePath(), st)
+	}
+
+	if !req.Force {
+		if err := st.CheckCap(now); err != nil {
+			return "", err
+		}
+	}
+	if st.ActiveBreak != nil {
+		if _, err := st.StopBreak(now); err != nil {
+			return "", err
+		}
+	}
+	var err error
+	if req.For > 0 {
+		err = st.StartCountdown(now, req.For, req.Tags, req.Note)
+	} else {
+		err = st.StartSession(now, req.Tags, req.Note)
+	}
+	if err != nil {
+		return "", err
+	}
+	st.ActiveSession.Project = req.Project
+	msg := i18n.T("Started session at %s", i18n.Time(now))
+	if len(req.Tags) > 0 {
+		msg += fmt.Sprintf(" [tags: %s]", strings.Join(req.Tags, ","))
+	}
+	if req.For > 0 {
+		msg += fmt.Sprintf(" for %s", state.HumanMinutes(int(req.For.Minutes())))
+	}
+	return msg + ".", daemon.Save(statePath(), st)
+}
+
+// openURL hands u to the desktop, which routes it to the app that asked for
+// the callback. urlscheme only lets through http, https and shortcuts URLs,
+// which cannot pass for an option; xdg-open takes no -- to make sure.
+func openURL(u string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", "--", u)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", u)
+	default:
+		cmd = exec.Command("xdg-open", u)
+	}
+	return cmd.Run()
+}
+
+func runURLHandler(args []string) error {
+	if len(args) != 1 || (args[0] != "install" && args[0] != "uninstall" && args[0] != "status") {
+		return errors.New("usage: daily url-handler <install|uninstall|status>")
+	}
+	path, err := urlscheme.Path()
+	if err != nil {
+		return err
+	}
+	switch args[0] {
+	case "install":
+		exe, err := os.Executable()
+		if err != nil {
+			return err
+		}
+		if err := urlscheme.Install(exe); err != nil {
+			return err
+		}
+		fmt.Printf("daily:// URLs now run %s (handler: %s)\n", exe, path)
+		fmt.Println("Try: open 'daily://start?tag=review&for=25m'")
+	case "uninstall":
+		if err := urlscheme.Uninstall(); err != nil {
+			return err
+		}
+		fmt.Println("URL handler removed")
+	case "status":
+		if urlscheme.Installed() {
+			fmt.Printf("URL handler installed: %s\n", path)
+		} else {
+			fmt.Println("URL handler not installed; run daily url-handler install")
+		}
+	}
+	return nil
+}
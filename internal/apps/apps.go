@@ -0,0 +1,47 @@
+package apps
+
+import (
+	"errors"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// Frontmost returns the name of the application owning the focused window.
+// Supports macOS (osascript) and Linux/X11 (xdotool). Returns error if unavailable.
+func Frontmost() (string, error) {
+	switch runtime.GOOS {
+	case "darwin":
+		return frontmostDarwin()
+	case "linux":
+		return frontmostLinux()
+	default:
+		return "", errors.New("app sampling not supported")
+	}
+}
+
+func frontmostDarwin() (string, error) {
+	script := `tell application "System Events" to get name of first application process whose frontmost is true`
+	out, err := exec.Command("osascript", "-e", script).Output()
+	if err != nil {
+		return "", err
+	}
+	return clean(string(out))
+}
+
+func frontmostLinux() (string, error) {
+	// Class names ("firefox", "Code") group windows better than titles.
+	out, err := exec.Command("xdotool", "getactivewindow", "getwindowclassname").Output()
+	if err != nil {
+		return "", err
+	}
+	return clean(string(out))
+}
+
+func clean(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return "", errors.New("no focused application")
+	}
+	return s, nil
+}
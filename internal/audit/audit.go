@@ -0,0 +1,269 @@
+// Package audit keeps an append-only record of every change written to the
+// state, with the frontend that made it, so odd numbers in a report can be
+// traced back to "stop via tray" or "edit via review".
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/max-pantom/daily/internal/state"
+)
+
+// FileName is the audit log next to the state file.
+const FileName = "audit.log"
+
+// Entry is one line of the audit log.
+type Entry struct {
+	Time   time.Time `json:"time"`
+	Source string    `json:"source"` // frontend, e.g. "tray", "cli stop", "daemon"
+	Op     string    `json:"op"`     // start, stop, break_start, break_end, edit, settings
+	Detail string    `json:"detail"`
+	PID    int       `json:"pid"`
+}
+
+// Path returns the audit log for the given state file.
+func Path(statePath string) string {
+	return filepath.Join(filepath.Dir(statePath), FileName)
+}
+
+var (
+	mu     sync.Mutex
+	source = "cli"
+)
+
+// SetSource names the frontend this process records changes as.
+func SetSource(s string) {
+	mu.Lock()
+	defer mu.Unlock()
+	source = s
+}
+
+// Source returns the name set by SetSource.
+func Source() string {
+	mu.Lock()
+	defer mu.Unlock()
+	return source
+}
+
+// Record appends the change from before to after, made by src, to the audit
+// log. Changes that only move the heartbeat are not recorded. A nil before
+// means the previous state is unknown. Failing to write the log never fails
+// the save it describes.
+func Record(statePath, src string, before, after *state.State) {
+	entries := Describe(before, after)
+	if len(entries) == 0 {
+		return
+	}
+	f, err := os.OpenFile(Path(statePath), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	enc := json.NewEncoder(f)
+	now := time.Now()
+	for _, e := range entries {
+		e.Time, e.Source, e.PID = now, src, os.Getpid()
+		_ = enc.Encode(e)
+	}
+}
+
+// Snapshot reads the state file as it is on disk, or returns nil.
+func Snapshot(statePath string) *state.State {
+	if _, err := os.Stat(statePath); err != nil {
+		return nil // state.Load would create it
+	}
+	st, err := state.Load(statePath)
+	if err != nil {
+		return nil
+	}
+	return st
+}
+
+// Describe lists what changed from before to after, without time, source or
+// PID.
+func Describe(before, after *state.State) []Entry {
+	if after == nil {
+		return nil
+	}
+	if before == nil {
+		before = &state.State{}
+	}
+	var out []Entry
+	add := func(op, format string, args ...any) {
+		out = append(out, Entry{Op: op, Detail: fmt.Sprintf(format, args...)})
+	}
+
+	bi, ai := before.ActiveInterruption, after.ActiveInterruption
+	if bi != nil && (ai == nil || !ai.Start.Equal(bi.Start)) {
+		add("interrupt_end", "ended interruption from %s (%s)", bi.Start.Format("2006-01-02 15:04"), bi.Reason)
+	}
+	b, a := before.ActiveBreak, after.ActiveBreak
+	if b != nil && (a == nil || !a.Start.Equal(b.Start)) {
+		add("break_end", "ended break from %s", b.Start.Format("2006-01-02 15:04"))
+	}
+	s, t := before.ActiveSession, after.ActiveSession
+	if s != nil && (t == nil || !t.Start.Equal(s.Start)) {
+		add("stop", "stopped session from %s%s", s.Start.Format("2006-01-02 15:04"), label(*s))
+	}
+	if t != nil && (s == nil || !s.Start.Equal(t.Start)) {
+		add("start", "started session at %s%s", t.Start.Format("2006-01-02 15:04"), label(*t))
+	} else if t != nil && s != nil && !sameJSON(withoutApps(*s), withoutApps(*t)) {
+		add("edit", "changed running session%s", label(*t))
+	}
+	if a != nil && (b == nil || !b.Start.Equal(a.Start)) {
+		add("break_start", "started break at %s", a.Start.Format("2006-01-02 15:04"))
+	}
+	if ai != nil && (bi == nil || !bi.Start.Equal(ai.Start)) {
+		add("interrupt", "interrupted at %s (%s)", ai.Start.Format("2006-01-02 15:04"), ai.Reason)
+	}
+
+	// A stop appends the finished session, break or interruption to its day,
+	// which the entries above already describe.
+	stopped := false
+	for _, e := range out {
+		stopped = stopped || e.Op == "stop" || e.Op == "break_end" || e.Op == "interrupt_end"
+	}
+	if days := changedDays(before, after, stopped); len(days) > 0 {
+		add("edit", "changed %s", strings.Join(days, ", "))
+	}
+	if keys := changedSettings(before, after); len(keys) > 0 {
+		add("settings", "changed %s", strings.Join(keys, ", "))
+	}
+	return out
+}
+
+// changedDays lists the day logs that differ. After a stop, days that only
+// gained sessions or breaks are left out.
+func changedDays(before, after *state.State, stopped bool) []string {
+	var days []string
+	seen := map[string]bool{}
+	for key := range before.Days {
+		seen[key] = true
+	}
+	for key := range after.Days {
+		seen[key] = true
+	}
+	for key := range seen {
+		b, a := before.Days[key], after.Days[key]
+		if b == nil {
+			b = &state.DayLog{}
+		}
+		if a == nil {
+			a = &state.DayLog{}
+		}
+		if sameJSON(b, a) || (stopped && appendedOnly(b, a)) {
+			continue
+		}
+		days = append(days, key)
+	}
+	sort.Strings(days)
+	return days
+}
+
+// appendedOnly reports whether after only adds sessions, breaks or
+// interruptions to before, which is how stopping one records it.
+func appendedOnly(before, after *state.DayLog) bool {
+	if len(after.Sessions) < len(before.Sessions) || len(after.Breaks) < len(before.Breaks) ||
+		len(after.Interruptions) < len(before.Interruptions) {
+		return false
+	}
+	if (len(before.Sessions) > 0 && !sameJSON(before.Sessions, after.Sessions[:len(before.Sessions)])) ||
+		(len(before.Breaks) > 0 && !sameJSON(before.Breaks, after.Breaks[:len(before.Breaks)])) ||
+		(len(before.Interruptions) > 0 && !sameJSON(before.Interruptions, after.Interruptions[:len(before.Interruptions)])) {
+		return false
+	}
+	return len(after.Sessions) > len(before.Sessions) || len(after.Breaks) > len(before.Breaks) ||
+		len(after.Interruptions) > len(before.Interruptions)
+}
+
+// volatile fields change on their own and are not settings.
+var volatile = map[string]bool{
+	"days": true, "active_session": true, "active_break": true, "active_interruption": true,
+	"last_seen": true, "last_break_end": true, "sprint_phase_end": true,
+	"checkpoint": true,
+}
+
+// changedSettings lists the top-level state fields that differ.
+func changedSettings(before, after *state.State) []string {
+	bm, am := fields(before), fields(after)
+	var keys []string
+	for k := range bm {
+		if _, ok := am[k]; !ok && !volatile[k] {
+			keys = append(keys, k+" unset")
+		}
+	}
+	for k, v := range am {
+		if !volatile[k] && string(bm[k]) != string(v) {
+			keys = append(keys, fmt.Sprintf("%s=%s", k, v))
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func fields(st *state.State) map[string]json.RawMessage {
+	data, _ := json.Marshal(st)
+	var m map[string]json.RawMessage
+	_ = json.Unmarshal(data, &m)
+	return m
+}
+
+// withoutApps drops the per-app seconds that watch --apps updates every minute.
+func withoutApps(sess state.Session) state.Session {
+	sess.Apps = nil
+	return sess
+}
+
+func sameJSON(a, b any) bool {
+	x, _ := json.Marshal(a)
+	y, _ := json.Marshal(b)
+	return string(x) == string(y)
+}
+
+func label(sess state.Session) string {
+	var parts []string
+	if sess.Project != "" {
+		parts = append(parts, "["+sess.Project+"]")
+	}
+	if len(sess.Tags) > 0 {
+		parts = append(parts, "#"+strings.Join(sess.Tags, " #"))
+	}
+	if sess.Note != "" {
+		parts = append(parts, fmt.Sprintf("%q", sess.Note))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return " " + strings.Join(parts, " ")
+}
+
+// Read returns the entries of the audit log, oldest first. Lines that do not
+// parse are skipped.
+func Read(statePath string) ([]Entry, error) {
+	f, err := os.Open(Path(statePath))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var out []Entry
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 1<<20)
+	for sc.Scan() {
+		var e Entry
+		if json.Unmarshal(sc.Bytes(), &e) == nil {
+			out = append(out, e)
+		}
+	}
+	return out, sc.Err()
+}
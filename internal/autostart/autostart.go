@@ -0,0 +1,145 @@
+// Package autostart installs or removes the per-user entry that launches
+// `daily tray` at login: a LaunchAgent on macOS, an XDG autostart file on
+// Linux and the Run registry key on Windows.
+package autostart
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+const (
+	label   = "com.max-pantom.daily.tray"
+	runName = "DailyTray"
+	runKey  = `HKCU\Software\Microsoft\Windows\CurrentVersion\Run`
+)
+
+// ErrUnsupported is returned on systems without a known login mechanism.
+var ErrUnsupported = errors.New("launch at login is not supported on " + runtime.GOOS)
+
+// Path returns the file that launches the tray at login, or "" on Windows,
+// which keeps it in the registry.
+func Path() (string, error) {
+	switch runtime.GOOS {
+	case "darwin":
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(home, "Library", "LaunchAgents", label+".plist"), nil
+	case "linux", "freebsd", "openbsd", "netbsd":
+		dir, err := os.UserConfigDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(dir, "autostart", "daily-tray.desktop"), nil
+	case "windows":
+		return "", nil
+	}
+	return "", ErrUnsupported
+}
+
+// Enabled reports whether the tray is set to launch at login.
+func Enabled() bool {
+	if runtime.GOOS == "windows" {
+		return exec.Command("reg", "query", runKey, "/v", runName).Run() == nil
+	}
+	path, err := Path()
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(path)
+	return err == nil
+}
+
+// Enable makes exe's tray start at login.
+func Enable(exe string) error {
+	if runtime.GOOS == "windows" {
+		value := fmt.Sprintf(`"%s" tray`, exe)
+		return run("reg", "add", runKey, "/v", runName, "/t", "REG_SZ", "/d", value, "/f")
+	}
+	path, err := Path()
+	if err != nil {
+		return err
+	}
+	var content string
+	if runtime.GOOS == "darwin" {
+		content = plist(exe)
+	} else {
+		content = desktopEntry(exe)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(content), 0o644)
+}
+
+// Disable removes the login entry; it is not an error if there is none.
+func Disable() error {
+	if runtime.GOOS == "windows" {
+		if !Enabled() {
+			return nil
+		}
+		return run("reg", "delete", runKey, "/v", runName, "/f")
+	}
+	path, err := Path()
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// plist is a LaunchAgent that runs the tray once per login. KeepAlive is left
+// off so quitting from the menu stays quit.
+func plist(exe string) string {
+	return `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Label</key>
+	<string>` + label + `</string>
+	<key>ProgramArguments</key>
+	<array>
+		<string>` + xmlEscape(exe) + `</string>
+		<string>tray</string>
+	</array>
+	<key>RunAtLoad</key>
+	<true/>
+	<key>ProcessType</key>
+	<string>Interactive</string>
+</dict>
+</plist>
+`
+}
+
+func desktopEntry(exe string) string {
+	return `[Desktop Entry]
+Type=Application
+Name=Daily
+Comment=Work hours tracker in the system tray
+Exec="` + strings.ReplaceAll(exe, `"`, `\"`) + `" tray
+Terminal=false
+X-GNOME-Autostart-enabled=true
+`
+}
+
+func xmlEscape(s string) string {
+	r := strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+	return r.Replace(s)
+}
+
+func run(name string, args ...string) error {
+	out, err := exec.Command(name, args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s: %v: %s", name, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+// Package backup packs everything kept next to a state file (the history,
+// config, audit and daemon logs, sync and team data) into one .tar.gz for
+// moving to another machine, and reads it back.
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+)
+
+// Format is the bundle layout this build writes. Restoring refuses bundles
+// of a newer format, which may hold data this build would drop.
+const Format = 1
+
+// ManifestName is the first entry of every bundle.
+const ManifestName = "manifest.json"
+
+// Manifest describes a bundle.
+type Manifest struct {
+	Format  int       `json:"format"`
+	Version string    `json:"version"` // daily build that wrote it
+	Created time.Time `json:"created"`
+	Host    string    `json:"host,omitempty"`
+	Files   []string  `json:"files"`
+}
+
+// File is one entry of a bundle, named by its slash-separated path relative
+// to the state directory, e.g. "logs/daily.log".
+type File struct {
+	Name    string
+	Data    []byte
+	ModTime time.Time
+}
+
+// Write packs files into a gzip-compressed tar behind a manifest, filling in
+// m.Format and m.Files.
+func Write(w io.Writer, m Manifest, files []File) error {
+	m.Format = Format
+	m.Files = m.Files[:0]
+	for _, f := range files {
+		m.Files = append(m.Files, f.Name)
+	}
+	manifest, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(w)
+	tw := tar.NewWriter(zw)
+	all := append([]File{{Name: ManifestName, Data: append(manifest, '\n'), ModTime: m.Created}}, files...)
+	for _, f := range all {
+		hdr := &tar.Header{Name: f.Name, Mode: 0o600, Size: int64(len(f.Data)), ModTime: f.ModTime, Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if _, err := tw.Write(f.Data); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// Read unpacks a bundle written by Write. It checks the manifest comes first
+// and is of a format this build knows, and that every entry is a plain file
+// listed in it, staying inside the state directory.
+func Read(r io.Reader) (Manifest, []File, error) {
+	var m Manifest
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return m, nil, fmt.Errorf("not a daily backup: %w", err)
+	}
+	defer zr.Close()
+	tr := tar.NewReader(zr)
+	hdr, err := tr.Next()
+	if err != nil || hdr.Name != ManifestName {
+		return m, nil, errors.New("not a daily backup: no manifest")
+	}
+	if err := json.NewDecoder(tr).Decode(&m); err != nil {
+		return m, nil, fmt.Errorf("not a daily backup: %w", err)
+	}
+	if m.Format < 1 {
+		return m, nil, errors.New("not a daily backup: no format version")
+	}
+	if m.Format > Format {
+		return m, nil, fmt.Errorf("the backup was made by a newer daily (%s, format %d; this one reads up to %d): update daily first", m.Version, m.Format, Format)
+	}
+	listed := make(map[string]bool, len(m.Files))
+	for _, name := range m.Files {
+		listed[name] = true
+	}
+	var files []File
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return m, nil, fmt.Errorf("reading backup: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg || !listed[hdr.Name] || !Clean(hdr.Name) {
+			return m, nil, fmt.Errorf("backup holds an unexpected entry %q", hdr.Name)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return m, nil, fmt.Errorf("reading backup: %w", err)
+		}
+		files = append(files, File{Name: hdr.Name, Data: data, ModTime: hdr.ModTime})
+		delete(listed, hdr.Name)
+	}
+	if len(listed) > 0 {
+		return m, nil, errors.New("backup is truncated: files listed in its manifest are missing")
+	}
+	return m, files, nil
+}
+
+// Clean reports whether name is a relative path that stays inside the
+// directory it is restored to.
+func Clean(name string) bool {
+	return name != "" && path.Clean(name) == name && !path.IsAbs(name) && name != ".." && !strings.HasPrefix(name, "../") && !strings.Contains(name, "\\")
+}
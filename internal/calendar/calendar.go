@@ -0,0 +1,378 @@
+package calendar
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Event is a single busy block read from an .ics calendar.
+type Event struct {
+	Summary string
+	Start   time.Time
+	End     time.Time
+}
+
+// Recurring events are expanded from Lookback before now to Lookahead after
+// it; one-off events are returned whenever they are.
+const (
+	Lookback  = 92 * 24 * time.Hour
+	Lookahead = 366 * 24 * time.Hour
+)
+
+// ZoneError reports TZIDs that name no time zone this machine or the
+// calendar's own VTIMEZONE blocks know. Their events are left out rather than
+// shifted by guessing, and the rest are returned along with the error.
+type ZoneError struct {
+	Zones []string
+}
+
+func (e *ZoneError) Error() string {
+	return fmt.Sprintf("unknown time zone %s; its events are left out", strings.Join(e.Zones, ", "))
+}
+
+// Load reads events from an .ics file path or an http(s) URL, expanding
+// recurring ones. A *ZoneError comes with the events that could be read.
+func Load(source string) ([]Event, error) {
+	source = strings.TrimSpace(source)
+	if source == "" {
+		return nil, errors.New("no calendar source configured")
+	}
+	if strings.HasPrefix(source, "webcal://") {
+		source = "https://" + strings.TrimPrefix(source, "webcal://")
+	}
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		client := &http.Client{Timeout: 15 * time.Second}
+		req, err := http.NewRequest("GET", source, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("User-Agent", "daily-calendar")
+		res, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer res.Body.Close()
+		if res.StatusCode < 200 || res.StatusCode >= 300 {
+			return nil, fmt.Errorf("calendar fetch failed: %s", res.Status)
+		}
+		return Parse(res.Body)
+	}
+	f, err := os.Open(source)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// Parse reads VEVENT blocks from r, expanding daily, weekly, monthly and
+// yearly RRULEs with their EXDATEs and moved occurrences (RECURRENCE-ID).
+// All-day events are skipped since they rarely represent busy time, and so
+// are cancelled events and those marked free (TRANSP:TRANSPARENT).
+func Parse(r io.Reader) ([]Event, error) {
+	return parse(r, time.Now())
+}
+
+// vevent is a VEVENT as read, before its recurrences are expanded.
+type vevent struct {
+	summary    string
+	uid        string
+	start      time.Time // wall clock of DTSTART, in zone
+	zone       zone
+	end        time.Time // DTEND, zero when not given
+	duration   time.Duration
+	rule       string
+	exdates    []time.Time
+	recurrence time.Time // RECURRENCE-ID: the occurrence this one replaces
+	skip       bool      // cancelled, free, all-day or in an unknown zone
+}
+
+func parse(r io.Reader, now time.Time) ([]Event, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+	comps := components(lines)
+
+	zones := map[string]zone{}
+	for _, c := range comps {
+		if c.name == "VTIMEZONE" {
+			if id, z, ok := timezoneOf(c); ok {
+				zones[id] = z
+			}
+		}
+	}
+	unknown := map[string]bool{}
+	var vevents []vevent
+	for _, c := range comps {
+		if c.name == "VEVENT" {
+			vevents = append(vevents, readEvent(c, zones, unknown))
+		}
+	}
+
+	// Occurrences moved or cancelled by an event of their own.
+	moved := map[string]bool{}
+	for _, ev := range vevents {
+		if !ev.recurrence.IsZero() {
+			moved[occurrenceKey(ev.uid, ev.recurrence)] = true
+		}
+	}
+	var events []Event
+	from, until := now.Add(-Lookback), now.Add(Lookahead)
+	for _, ev := range vevents {
+		if ev.skip || ev.start.IsZero() {
+			continue
+		}
+		begin := ev.zone(ev.start)
+		length := ev.duration
+		if !ev.end.IsZero() {
+			length = ev.end.Sub(begin)
+		}
+		if length <= 0 {
+			continue
+		}
+		rule, ok := parseRule(ev.rule, ev.zone)
+		if ev.rule == "" || !ok || !ev.recurrence.IsZero() {
+			// Rules this does not understand keep their first occurrence.
+			events = append(events, Event{ev.summary, begin.Local(), begin.Add(length).Local()})
+			continue
+		}
+		rule.each(ev.start, func(wall time.Time) bool {
+			at := ev.zone(wall)
+			if at.After(until) || (!rule.until.IsZero() && at.After(rule.until)) {
+				return false
+			}
+			if at.Add(length).After(from) && !moved[occurrenceKey(ev.uid, at)] && !excluded(ev.exdates, at) {
+				events = append(events, Event{ev.summary, at.Local(), at.Add(length).Local()})
+			}
+			return true
+		})
+	}
+	if len(unknown) > 0 {
+		names := make([]string, 0, len(unknown))
+		for name := range unknown {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return events, &ZoneError{Zones: names}
+	}
+	return events, nil
+}
+
+func readEvent(c *component, zones map[string]zone, unknown map[string]bool) vevent {
+	var ev vevent
+	for _, p := range c.props {
+		switch p.name {
+		case "SUMMARY":
+			ev.summary = unescape(p.value)
+		case "UID":
+			ev.uid = p.value
+		case "STATUS":
+			ev.skip = ev.skip || strings.EqualFold(p.value, "CANCELLED")
+		case "TRANSP":
+			ev.skip = ev.skip || strings.EqualFold(p.value, "TRANSPARENT")
+		case "DTSTART":
+			wall, z, ok := parseTime(p.params, p.value, zones, unknown)
+			if !ok {
+				ev.skip = true
+				continue
+			}
+			ev.start, ev.zone = wall, z
+		case "DTEND":
+			if wall, z, ok := parseTime(p.params, p.value, zones, unknown); ok {
+				ev.end = z(wall)
+			}
+		case "DURATION":
+			ev.duration = parseDuration(p.value)
+		case "RRULE":
+			ev.rule = p.value
+		case "EXDATE":
+			for _, v := range strings.Split(p.value, ",") {
+				if wall, z, ok := parseTime(p.params, v, zones, unknown); ok {
+					ev.exdates = append(ev.exdates, z(wall))
+				}
+			}
+		case "RECURRENCE-ID":
+			if wall, z, ok := parseTime(p.params, p.value, zones, unknown); ok {
+				ev.recurrence = z(wall)
+			}
+		}
+	}
+	return ev
+}
+
+func occurrenceKey(uid string, at time.Time) string {
+	return fmt.Sprintf("%s|%d", uid, at.Unix())
+}
+
+func excluded(exdates []time.Time, at time.Time) bool {
+	for _, t := range exdates {
+		if t.Equal(at) {
+			return true
+		}
+	}
+	return false
+}
+
+// At returns the event in progress at t, if any.
+func At(events []Event, t time.Time) (Event, bool) {
+	for _, ev := range events {
+		if !t.Before(ev.Start) && t.Before(ev.End) {
+			return ev, true
+		}
+	}
+	return Event{}, false
+}
+
+// Upcoming returns events that start between now and now+within, earliest first.
+func Upcoming(events []Event, now time.Time, within time.Duration) []Event {
+	var out []Event
+	limit := now.Add(within)
+	for _, ev := range events {
+		if ev.End.After(now) && ev.Start.Before(limit) {
+			out = append(out, ev)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Start.Before(out[j].Start) })
+	return out
+}
+
+// unfold joins RFC 5545 continuation lines (those starting with a space or tab).
+func unfold(r io.Reader) ([]string, error) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	var lines []string
+	for sc.Scan() {
+		line := strings.TrimRight(sc.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, sc.Err()
+}
+
+// component is a BEGIN/END block with its properties and nested blocks.
+type component struct {
+	name  string
+	props []property
+	subs  []*component
+}
+
+type property struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// components returns every block in lines, nested ones included, in the
+// order they begin.
+func components(lines []string) []*component {
+	var all, stack []*component
+	for _, line := range lines {
+		name, params, value := splitProperty(line)
+		switch {
+		case name == "BEGIN":
+			c := &component{name: strings.ToUpper(value)}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.subs = append(parent.subs, c)
+			}
+			all = append(all, c)
+			stack = append(stack, c)
+		case name == "END":
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		case name != "" && len(stack) > 0:
+			c := stack[len(stack)-1]
+			c.props = append(c.props, property{name, params, value})
+		}
+	}
+	return all
+}
+
+func splitProperty(line string) (string, map[string]string, string) {
+	colon := strings.Index(line, ":")
+	if colon < 0 {
+		return "", nil, ""
+	}
+	head, value := line[:colon], line[colon+1:]
+	parts := strings.Split(head, ";")
+	params := make(map[string]string, len(parts)-1)
+	for _, p := range parts[1:] {
+		if k, v, ok := strings.Cut(p, "="); ok {
+			params[strings.ToUpper(k)] = strings.Trim(v, `"`)
+		}
+	}
+	return strings.ToUpper(parts[0]), params, value
+}
+
+// parseTime reads a DATE-TIME as its wall clock, held in UTC fields, and the
+// zone that turns it into an instant. All-day dates are not ok, and neither
+// are times in a zone that cannot be resolved, which go into unknown.
+func parseTime(params map[string]string, value string, zones map[string]zone, unknown map[string]bool) (time.Time, zone, bool) {
+	if params["VALUE"] == "DATE" || len(value) == len("20060102") {
+		return time.Time{}, nil, false
+	}
+	if v, ok := strings.CutSuffix(value, "Z"); ok {
+		t, err := time.Parse("20060102T150405", v)
+		return t, inLocation(time.UTC), err == nil
+	}
+	t, err := time.Parse("20060102T150405", value)
+	if err != nil {
+		return time.Time{}, nil, false
+	}
+	tz := params["TZID"]
+	if tz == "" {
+		return t, inLocation(time.Local), true
+	}
+	z, ok := resolveZone(tz, zones)
+	if !ok {
+		unknown[tz] = true
+		return time.Time{}, nil, false
+	}
+	return t, z, true
+}
+
+// parseDuration handles the common PT#H#M#S subset of ISO 8601 durations.
+func parseDuration(value string) time.Duration {
+	value = strings.TrimPrefix(strings.ToUpper(value), "P")
+	var total time.Duration
+	num := 0
+	for _, r := range value {
+		switch {
+		case r >= '0' && r <= '9':
+			num = num*10 + int(r-'0')
+		case r == 'W':
+			total += time.Duration(num) * 7 * 24 * time.Hour
+			num = 0
+		case r == 'D':
+			total += time.Duration(num) * 24 * time.Hour
+			num = 0
+		case r == 'H':
+			total += time.Duration(num) * time.Hour
+			num = 0
+		case r == 'M':
+			total += time.Duration(num) * time.Minute
+			num = 0
+		case r == 'S':
+			total += time.Duration(num) * time.Second
+			num = 0
+		}
+	}
+	return total
+}
+
+func unescape(s string) string {
+	r := strings.NewReplacer(`\n`, " ", `\N`, " ", `\,`, ",", `\;`, ";", `\\`, `\`)
+	return r.Replace(s)
+}
@@ -0,0 +1,202 @@
+package calendar
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// now is Monday 2 March 2026, noon UTC.
+var now = time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+
+const ics = `BEGIN:VCALENDAR
+BEGIN:VTIMEZONE
+TZID:Custom Berlin
+BEGIN:STANDARD
+DTSTART:16011028T030000
+TZOFFSETFROM:+0200
+TZOFFSETTO:+0100
+RRULE:FREQ=YEARLY;BYDAY=-1SU;BYMONTH=10
+END:STANDARD
+BEGIN:DAYLIGHT
+DTSTART:16010325T020000
+TZOFFSETFROM:+0100
+TZOFFSETTO:+0200
+RRULE:FREQ=YEARLY;BYDAY=5SU;BYMONTH=3
+END:DAYLIGHT
+END:VTIMEZONE
+BEGIN:VEVENT
+UID:standup
+SUMMARY:Standup
+DTSTART;TZID=America/New_York:20260105T093000
+DTEND;TZID=America/New_York:20260105T094500
+RRULE:FREQ=WEEKLY;BYDAY=MO,WE,FR;UNTIL=20260317T000000Z
+EXDATE;TZID=America/New_York:20260304T093000
+END:VEVENT
+BEGIN:VEVENT
+UID:standup
+RECURRENCE-ID;TZID=America/New_York:20260306T093000
+SUMMARY:Standup (moved)
+DTSTART;TZID=America/New_York:20260306T110000
+DTEND;TZID=America/New_York:20260306T111500
+END:VEVENT
+BEGIN:VEVENT
+UID:standup
+RECURRENCE-ID;TZID=America/New_York:20260313T093000
+SUMMARY:Standup
+STATUS:CANCELLED
+DTSTART;TZID=America/New_York:20260313T093000
+DTEND;TZID=America/New_York:20260313T094500
+END:VEVENT
+BEGIN:VEVENT
+UID:review
+SUMMARY:Review
+DTSTART:20260302T150000Z
+DURATION:PT1H
+RRULE:FREQ=DAILY;COUNT=3
+END:VEVENT
+BEGIN:VEVENT
+UID:planning
+SUMMARY:Planning
+DTSTART;TZID=Europe/Berlin:20260106T100000
+DTEND;TZID=Europe/Berlin:20260106T110000
+RRULE:FREQ=MONTHLY;BYDAY=1TU;COUNT=4
+END:VEVENT
+BEGIN:VEVENT
+UID:one-on-one
+SUMMARY:1:1
+DTSTART;TZID=Pacific Standard Time:20260302T090000
+DTEND;TZID=Pacific Standard Time:20260302T093000
+RRULE:FREQ=WEEKLY;INTERVAL=2;COUNT=3
+END:VEVENT
+BEGIN:VEVENT
+UID:custom
+SUMMARY:Custom zone
+DTSTART;TZID=Custom Berlin:20260330T090000
+DTEND;TZID=Custom Berlin:20260330T100000
+RRULE:FREQ=WEEKLY;COUNT=1
+END:VEVENT
+BEGIN:VEVENT
+UID:custom-winter
+SUMMARY:Custom zone winter
+DTSTART;TZID=Custom Berlin:20260323T090000
+DTEND;TZID=Custom Berlin:20260323T100000
+END:VEVENT
+BEGIN:VEVENT
+UID:old
+SUMMARY:Since 2020
+DTSTART:20200101T080000Z
+DTEND:20200101T081500Z
+RRULE:FREQ=DAILY
+END:VEVENT
+BEGIN:VEVENT
+UID:cancelled
+SUMMARY:Cancelled
+STATUS:CANCELLED
+DTSTART:20260302T160000Z
+DTEND:20260302T170000Z
+END:VEVENT
+BEGIN:VEVENT
+UID:free
+SUMMARY:Free
+TRANSP:TRANSPARENT
+DTSTART:20260302T160000Z
+DTEND:20260302T170000Z
+END:VEVENT
+BEGIN:VEVENT
+UID:allday
+SUMMARY:Holiday
+DTSTART;VALUE=DATE:20260302
+DTEND;VALUE=DATE:20260303
+END:VEVENT
+BEGIN:VEVENT
+UID:mars
+SUMMARY:Mars
+DTSTART;TZID=Mars/Olympus_Mons:20260302T090000
+DTEND;TZID=Mars/Olympus_Mons:20260302T100000
+END:VEVENT
+END:VCALENDAR
+`
+
+func TestParse(t *testing.T) {
+	for _, name := range []string{"America/New_York", "Europe/Berlin", "America/Los_Angeles"} {
+		if _, err := time.LoadLocation(name); err != nil {
+			t.Skipf("no time zone data for %s: %v", name, err)
+		}
+	}
+	events, err := parse(strings.NewReader(ics), now)
+	var zerr *ZoneError
+	if !errors.As(err, &zerr) || !slices.Equal(zerr.Zones, []string{"Mars/Olympus_Mons"}) {
+		t.Fatalf("error %v, want the Mars zone reported", err)
+	}
+
+	got := map[string][]string{}
+	for _, ev := range events {
+		got[ev.Summary] = append(got[ev.Summary], ev.Start.UTC().Format("2006-01-02 15:04")+"+"+ev.End.Sub(ev.Start).String())
+	}
+	for summary, want := range map[string][]string{
+		// Mondays, Wednesdays and Fridays until the 17th, but not the 4th,
+		// nor the 13th, and on the 6th later; 09:30 in New York moves to
+		// 13:30 UTC with daylight saving time on the 8th.
+		"Standup": {
+			"2026-01-05 14:30+15m0s", "2026-01-07 14:30+15m0s", "2026-01-09 14:30+15m0s",
+			"2026-01-12 14:30+15m0s", "2026-01-14 14:30+15m0s", "2026-01-16 14:30+15m0s",
+			"2026-01-19 14:30+15m0s", "2026-01-21 14:30+15m0s", "2026-01-23 14:30+15m0s",
+			"2026-01-26 14:30+15m0s", "2026-01-28 14:30+15m0s", "2026-01-30 14:30+15m0s",
+			"2026-02-02 14:30+15m0s", "2026-02-04 14:30+15m0s", "2026-02-06 14:30+15m0s",
+			"2026-02-09 14:30+15m0s", "2026-02-11 14:30+15m0s", "2026-02-13 14:30+15m0s",
+			"2026-02-16 14:30+15m0s", "2026-02-18 14:30+15m0s", "2026-02-20 14:30+15m0s",
+			"2026-02-23 14:30+15m0s", "2026-02-25 14:30+15m0s", "2026-02-27 14:30+15m0s",
+			"2026-03-02 14:30+15m0s", "2026-03-09 13:30+15m0s", "2026-03-11 13:30+15m0s", "2026-03-16 13:30+15m0s",
+		},
+		"Standup (moved)": {"2026-03-06 16:00+15m0s"},
+		"Review":          {"2026-03-02 15:00+1h0m0s", "2026-03-03 15:00+1h0m0s", "2026-03-04 15:00+1h0m0s"},
+		// First Tuesdays, 10:00 in Berlin.
+		"Planning": {"2026-01-06 09:00+1h0m0s", "2026-02-03 09:00+1h0m0s", "2026-03-03 09:00+1h0m0s", "2026-04-07 08:00+1h0m0s"},
+		// Every other Monday, 09:00 Pacific time.
+		"1:1":                {"2026-03-02 17:00+30m0s", "2026-03-16 16:00+30m0s", "2026-03-30 16:00+30m0s"},
+		"Custom zone":        {"2026-03-30 07:00+1h0m0s"},
+		"Custom zone winter": {"2026-03-23 08:00+1h0m0s"},
+	} {
+		if !slices.Equal(got[summary], want) {
+			t.Errorf("%s:\n got %q\nwant %q", summary, got[summary], want)
+		}
+	}
+	for _, summary := range []string{"Cancelled", "Free", "Holiday", "Mars"} {
+		if len(got[summary]) > 0 {
+			t.Errorf("%s: got %q, want it skipped", summary, got[summary])
+		}
+	}
+	old := got["Since 2020"]
+	if len(old) == 0 {
+		t.Fatal("Since 2020: no occurrences")
+	}
+	first, _ := time.Parse("2006-01-02 15:04", strings.Split(old[0], "+")[0])
+	last, _ := time.Parse("2006-01-02 15:04", strings.Split(old[len(old)-1], "+")[0])
+	if first.Before(now.Add(-Lookback)) || last.After(now.Add(Lookahead)) || now.Sub(first) < Lookback-48*time.Hour {
+		t.Errorf("Since 2020: occurrences from %v to %v, want the window around %v", first, last, now)
+	}
+}
+
+func TestParseRule(t *testing.T) {
+	for _, tc := range []struct {
+		rule string
+		ok   bool
+	}{
+		{"FREQ=WEEKLY;BYDAY=MO,TU", true},
+		{"FREQ=DAILY;INTERVAL=2;UNTIL=20260401", true},
+		{"FREQ=MONTHLY;BYDAY=-1FR", true},
+		{"FREQ=MONTHLY;BYMONTHDAY=15", true},
+		{"FREQ=YEARLY", true},
+		{"FREQ=WEEKLY;BYDAY=1MO", false},
+		{"FREQ=MONTHLY;BYSETPOS=1;BYDAY=MO", false},
+		{"FREQ=HOURLY", false},
+		{"FREQ=DAILY;INTERVAL=0", false},
+	} {
+		if _, ok := parseRule(tc.rule, inLocation(time.UTC)); ok != tc.ok {
+			t.Errorf("parseRule(%q) ok = %v, want %v", tc.rule, ok, tc.ok)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package calendar
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxPeriods bounds how many days, weeks, months or years a rule is followed
+// from its start, in case it has neither an end nor a start anywhere near now.
+const maxPeriods = 100000
+
+// rule is the subset of an RRULE that is expanded: a frequency with an
+// interval, weekdays, a day of the month, and a count or end.
+type rule struct {
+	freq       string
+	interval   int
+	count      int
+	until      time.Time // instant; zero when the rule has no end
+	byDay      []weekdayNum
+	byMonthDay int // negative counts from the end of the month
+	wkst       time.Weekday
+}
+
+// weekdayNum is a BYDAY entry such as MO, or 1MO and -1FR in monthly rules.
+type weekdayNum struct {
+	n   int
+	day time.Weekday
+}
+
+var weekdays = map[string]time.Weekday{
+	"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+	"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+}
+
+// parseRule reads an RRULE value whose times are in z. It is not ok for
+// frequencies and parts it does not expand.
+func parseRule(v string, z zone) (rule, bool) {
+	r := rule{interval: 1, wkst: time.Monday}
+	for _, part := range strings.Split(v, ";") {
+		key, val, _ := strings.Cut(part, "=")
+		var err error
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			r.freq = strings.ToUpper(val)
+		case "INTERVAL":
+			r.interval, err = strconv.Atoi(val)
+			if r.interval < 1 {
+				return r, false
+			}
+		case "COUNT":
+			r.count, err = strconv.Atoi(val)
+		case "UNTIL":
+			var ok bool
+			if r.until, ok = parseUntil(val, z); !ok {
+				return r, false
+			}
+		case "BYDAY":
+			for _, d := range strings.Split(strings.ToUpper(val), ",") {
+				wd, ok := parseWeekdayNum(d)
+				if !ok {
+					return r, false
+				}
+				r.byDay = append(r.byDay, wd)
+			}
+		case "BYMONTHDAY":
+			r.byMonthDay, err = strconv.Atoi(val)
+		case "WKST":
+			wd, ok := weekdays[strings.ToUpper(val)]
+			if !ok {
+				return r, false
+			}
+			r.wkst = wd
+		default:
+			return r, false
+		}
+		if err != nil {
+			return r, false
+		}
+	}
+	switch r.freq {
+	case "DAILY", "WEEKLY":
+		return r, r.byMonthDay == 0 && !slices.ContainsFunc(r.byDay, func(d weekdayNum) bool { return d.n != 0 })
+	case "MONTHLY":
+		return r, r.byMonthDay == 0 || len(r.byDay) == 0
+	case "YEARLY":
+		return r, r.byMonthDay == 0 && len(r.byDay) == 0
+	}
+	return r, false
+}
+
+func parseWeekdayNum(v string) (weekdayNum, bool) {
+	if len(v) < 2 {
+		return weekdayNum{}, false
+	}
+	day, ok := weekdays[v[len(v)-2:]]
+	if !ok {
+		return weekdayNum{}, false
+	}
+	n := 0
+	if num := v[:len(v)-2]; num != "" {
+		var err error
+		if n, err = strconv.Atoi(num); err != nil || n == 0 {
+			return weekdayNum{}, false
+		}
+	}
+	return weekdayNum{n, day}, true
+}
+
+// parseUntil reads UNTIL as an instant: a date ends with its last second.
+func parseUntil(v string, z zone) (time.Time, bool) {
+	if t, err := time.Parse("20060102", v); err == nil {
+		return z(t.Add(24*time.Hour - time.Second)), true
+	}
+	if u, ok := strings.CutSuffix(v, "Z"); ok {
+		t, err := time.Parse("20060102T150405", u)
+		return t, err == nil
+	}
+	t, err := time.Parse("20060102T150405", v)
+	return z(t), err == nil
+}
+
+// each calls fn with the wall clock start of every occurrence from first on,
+// in order, until fn returns false or COUNT occurrences were given.
+func (r rule) each(first time.Time, fn func(wall time.Time) bool) {
+	n := 0
+	for k := 0; k < maxPeriods; k++ {
+		for _, wall := range r.period(first, k) {
+			if wall.Before(first) {
+				continue
+			}
+			if r.count > 0 && n >= r.count {
+				return
+			}
+			n++
+			if !fn(wall) {
+				return
+			}
+		}
+	}
+}
+
+// period returns the occurrences in the k-th day, week, month or year of the
+// rule, at first's time of day.
+func (r rule) period(first time.Time, k int) []time.Time {
+	h, m, s := first.Clock()
+	at := func(y int, mon time.Month, d int) time.Time { return time.Date(y, mon, d, h, m, s, 0, time.UTC) }
+	var out []time.Time
+	switch r.freq {
+	case "DAILY":
+		d := first.AddDate(0, 0, k*r.interval)
+		if len(r.byDay) == 0 || r.onDay(d.Weekday()) {
+			out = append(out, d)
+		}
+	case "WEEKLY":
+		start := first.AddDate(0, 0, 7*k*r.interval-(int(first.Weekday())-int(r.wkst)+7)%7)
+		for i := 0; i < 7; i++ {
+			d := start.AddDate(0, 0, i)
+			if (len(r.byDay) == 0 && d.Weekday() == first.Weekday()) || r.onDay(d.Weekday()) {
+				out = append(out, d)
+			}
+		}
+	case "MONTHLY":
+		month := time.Date(first.Year(), first.Month()+time.Month(k*r.interval), 1, 0, 0, 0, 0, time.UTC)
+		y, mon, days := month.Year(), month.Month(), daysIn(month.Year(), month.Month())
+		if len(r.byDay) > 0 {
+			for _, wd := range r.byDay {
+				if wd.n != 0 {
+					if d, ok := nthWeekday(y, mon, wd.n, wd.day); ok {
+						out = append(out, at(y, mon, d))
+					}
+					continue
+				}
+				for d := 1; d <= days; d++ {
+					if at(y, mon, d).Weekday() == wd.day {
+						out = append(out, at(y, mon, d))
+					}
+				}
+			}
+			slices.SortFunc(out, func(a, b time.Time) int { return a.Compare(b) })
+			break
+		}
+		d := first.Day()
+		if r.byMonthDay > 0 {
+			d = r.byMonthDay
+		} else if r.byMonthDay < 0 {
+			d = days + r.byMonthDay + 1
+		}
+		if d >= 1 && d <= days {
+			out = append(out, at(y, mon, d))
+		}
+	case "YEARLY":
+		y := first.Year() + k*r.interval
+		if first.Day() <= daysIn(y, first.Month()) {
+			out = append(out, at(y, first.Month(), first.Day()))
+		}
+	}
+	return out
+}
+
+func (r rule) onDay(wd time.Weekday) bool {
+	return slices.ContainsFunc(r.byDay, func(d weekdayNum) bool { return d.day == wd })
+}
+
+func daysIn(y int, m time.Month) int {
+	return time.Date(y, m+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// nthWeekday returns the day of the month of its n-th weekday day, counting
+// from the end when n is negative.
+func nthWeekday(y int, m time.Month, n int, day time.Weekday) (int, bool) {
+	days := daysIn(y, m)
+	if n > 0 {
+		d := 1 + (int(day)-int(time.Date(y, m, 1, 0, 0, 0, 0, time.UTC).Weekday())+7)%7 + 7*(n-1)
+		return d, d <= days
+	}
+	d := days - (int(time.Date(y, m, days, 0, 0, 0, 0, time.UTC).Weekday())-int(day)+7)%7 + 7*(n+1)
+	return d, d >= 1
+}
@@ -0,0 +1,218 @@
+package calendar
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// zone turns a wall clock time, held in UTC fields, into the instant it
+// names.
+type zone func(wall time.Time) time.Time
+
+func inLocation(loc *time.Location) zone {
+	return func(wall time.Time) time.Time {
+		y, m, d := wall.Date()
+		h, min, s := wall.Clock()
+		return time.Date(y, m, d, h, min, s, 0, loc)
+	}
+}
+
+// resolveZone finds the zone a TZID names: an IANA name, a Windows name as
+// Outlook and Exchange write them, or a VTIMEZONE in the calendar itself.
+func resolveZone(tzid string, zones map[string]zone) (zone, bool) {
+	name := strings.TrimPrefix(tzid, "/")
+	if loc, err := time.LoadLocation(name); err == nil {
+		return inLocation(loc), true
+	}
+	if iana, ok := windowsZones[name]; ok {
+		if loc, err := time.LoadLocation(iana); err == nil {
+			return inLocation(loc), true
+		}
+	}
+	z, ok := zones[tzid]
+	return z, ok
+}
+
+// observance is a STANDARD or DAYLIGHT block of a VTIMEZONE.
+type observance struct {
+	offset time.Duration // TZOFFSETTO
+	start  time.Time     // wall clock of DTSTART
+	yearly bool          // RRULE:FREQ=YEARLY;BYMONTH=…;BYDAY=…
+	month  time.Month
+	week   weekdayNum
+}
+
+// transition is the wall clock time, in the offset before it, at which the
+// observance starts in year y.
+func (o observance) transition(y int) time.Time {
+	d, ok := nthWeekday(y, o.month, o.week.n, o.week.day)
+	if !ok {
+		// Outlook writes the last week of the month as its fifth.
+		d, _ = nthWeekday(y, o.month, -1, o.week.day)
+	}
+	h, m, s := o.start.Clock()
+	return time.Date(y, o.month, d, h, m, s, 0, time.UTC)
+}
+
+// timezoneOf reads a VTIMEZONE block. Its observances must repeat yearly on
+// a weekday of a month, as those written by calendar apps do; otherwise the
+// standard offset holds all year.
+func timezoneOf(c *component) (string, zone, bool) {
+	var id string
+	for _, p := range c.props {
+		if p.name == "TZID" {
+			id = p.value
+		}
+	}
+	var std, dst *observance
+	for _, sub := range c.subs {
+		o, ok := observanceOf(sub)
+		if !ok {
+			continue
+		}
+		switch sub.name {
+		case "STANDARD":
+			if std == nil || o.start.After(std.start) {
+				std = &o
+			}
+		case "DAYLIGHT":
+			if dst == nil || o.start.After(dst.start) {
+				dst = &o
+			}
+		}
+	}
+	if id == "" || std == nil {
+		return "", nil, false
+	}
+	if dst == nil || !std.yearly || !dst.yearly {
+		off := std.offset
+		return id, func(wall time.Time) time.Time { return wall.Add(-off) }, true
+	}
+	return id, func(wall time.Time) time.Time {
+		ds, ss := dst.transition(wall.Year()), std.transition(wall.Year())
+		var summer bool
+		if ds.Before(ss) {
+			summer = !wall.Before(ds) && wall.Before(ss)
+		} else {
+			summer = !wall.Before(ds) || wall.Before(ss)
+		}
+		if summer {
+			return wall.Add(-dst.offset)
+		}
+		return wall.Add(-std.offset)
+	}, true
+}
+
+func observanceOf(c *component) (observance, bool) {
+	var o observance
+	hasOffset := false
+	for _, p := range c.props {
+		switch p.name {
+		case "TZOFFSETTO":
+			off, ok := parseOffset(p.value)
+			if !ok {
+				return o, false
+			}
+			o.offset, hasOffset = off, true
+		case "DTSTART":
+			if t, err := time.Parse("20060102T150405", p.value); err == nil {
+				o.start = t
+			}
+		case "RRULE":
+			o.yearly = true
+			for _, part := range strings.Split(p.value, ";") {
+				key, val, _ := strings.Cut(part, "=")
+				switch strings.ToUpper(key) {
+				case "FREQ":
+					o.yearly = o.yearly && strings.EqualFold(val, "YEARLY")
+				case "BYMONTH":
+					m, err := strconv.Atoi(val)
+					o.yearly = o.yearly && err == nil && m >= 1 && m <= 12
+					o.month = time.Month(m)
+				case "BYDAY":
+					wd, ok := parseWeekdayNum(strings.ToUpper(val))
+					o.yearly = o.yearly && ok && wd.n != 0
+					o.week = wd
+				}
+			}
+			o.yearly = o.yearly && o.month != 0 && o.week.n != 0
+		}
+	}
+	return o, hasOffset
+}
+
+// parseOffset reads a UTC offset such as +0100, -0800 or +053000.
+func parseOffset(v string) (time.Duration, bool) {
+	if len(v) != 5 && len(v) != 7 || (v[0] != '+' && v[0] != '-') {
+		return 0, false
+	}
+	var d time.Duration
+	for i, unit := range []time.Duration{time.Hour, time.Minute, time.Second} {
+		if 1+2*i >= len(v) {
+			break
+		}
+		n, err := strconv.Atoi(v[1+2*i : 3+2*i])
+		if err != nil {
+			return 0, false
+		}
+		d += time.Duration(n) * unit
+	}
+	if v[0] == '-' {
+		d = -d
+	}
+	return d, true
+}
+
+// windowsZones maps the Windows time zone names Outlook and Exchange put in
+// TZID to IANA names.
+var windowsZones = map[string]string{
+	"Dateline Standard Time":         "Etc/GMT+12",
+	"Hawaiian Standard Time":         "Pacific/Honolulu",
+	"Alaskan Standard Time":          "America/Anchorage",
+	"Pacific Standard Time":          "America/Los_Angeles",
+	"US Mountain Standard Time":      "America/Phoenix",
+	"Mountain Standard Time":         "America/Denver",
+	"Central Standard Time":          "America/Chicago",
+	"Canada Central Standard Time":   "America/Regina",
+	"Central Standard Time (Mexico)": "America/Mexico_City",
+	"Eastern Standard Time":          "America/New_York",
+	"US Eastern Standard Time":       "America/Indianapolis",
+	"SA Pacific Standard Time":       "America/Bogota",
+	"Atlantic Standard Time":         "America/Halifax",
+	"Newfoundland Standard Time":     "America/St_Johns",
+	"E. South America Standard Time": "America/Sao_Paulo",
+	"Argentina Standard Time":        "America/Buenos_Aires",
+	"Pacific SA Standard Time":       "America/Santiago",
+	"UTC":                            "UTC",
+	"GMT Standard Time":              "Europe/London",
+	"Greenwich Standard Time":        "Atlantic/Reykjavik",
+	"W. Europe Standard Time":        "Europe/Berlin",
+	"Central Europe Standard Time":   "Europe/Budapest",
+	"Central European Standard Time": "Europe/Warsaw",
+	"Romance Standard Time":          "Europe/Paris",
+	"GTB Standard Time":              "Europe/Bucharest",
+	"FLE Standard Time":              "Europe/Kiev",
+	"E. Europe Standard Time":        "Europe/Chisinau",
+	"Turkey Standard Time":           "Europe/Istanbul",
+	"Israel Standard Time":           "Asia/Jerusalem",
+	"South Africa Standard Time":     "Africa/Johannesburg",
+	"Egypt Standard Time":            "Africa/Cairo",
+	"Russian Standard Time":          "Europe/Moscow",
+	"Arabian Standard Time":          "Asia/Dubai",
+	"Pakistan Standard Time":         "Asia/Karachi",
+	"India Standard Time":            "Asia/Calcutta",
+	"Bangladesh Standard Time":       "Asia/Dhaka",
+	"SE Asia Standard Time":          "Asia/Bangkok",
+	"China Standard Time":            "Asia/Shanghai",
+	"Singapore Standard Time":        "Asia/Singapore",
+	"Taipei Standard Time":           "Asia/Taipei",
+	"Tokyo Standard Time":            "Asia/Tokyo",
+	"Korea Standard Time":            "Asia/Seoul",
+	"W. Australia Standard Time":     "Australia/Perth",
+	"AUS Central Standard Time":      "Australia/Darwin",
+	"Cen. Australia Standard Time":   "Australia/Adelaide",
+	"E. Australia Standard Time":     "Australia/Brisbane",
+	"AUS Eastern Standard Time":      "Australia/Sydney",
+	"New Zealand Standard Time":      "Pacific/Auckland",
+}
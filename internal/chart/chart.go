@@ -0,0 +1,219 @@
+// Package chart renders work history as PNG or SVG images using only the
+// standard library, for embedding in wikis and yearly reviews.
+package chart
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/max-pantom/daily/internal/state"
+)
+
+// Output formats.
+const (
+	PNG = "png"
+	SVG = "svg"
+)
+
+// Colors follow the TUI's base theme.
+var (
+	background = color.RGBA{0x22, 0x22, 0x22, 0xff}
+	textColor  = color.RGBA{0x6f, 0x7a, 0x70, 0xff}
+	barColor   = color.RGBA{0x8a, 0xa7, 0x88, 0xff}
+	levels     = []color.RGBA{
+		{0x2b, 0x31, 0x2a, 0xff}, // nothing logged
+		{0x3d, 0x4a, 0x3c, 0xff},
+		{0x5a, 0x73, 0x58, 0xff},
+		{0x8a, 0xa7, 0x88, 0xff},
+		{0xc2, 0xdc, 0xc0, 0xff}, // goal met
+	}
+)
+
+// FormatForPath picks PNG or SVG from a file extension.
+func FormatForPath(path string) (string, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".png":
+		return PNG, nil
+	case ".svg":
+		return SVG, nil
+	}
+	return "", fmt.Errorf("unsupported chart format %q (want .png or .svg)", filepath.Ext(path))
+}
+
+// Heatmap draws a contribution-style grid of the 53 weeks ending with end: one
+// column per week (Monday at the top), shaded by each day's work relative to
+// its goal in minutes. seconds holds work per YYYY-MM-DD day.
+func Heatmap(w io.Writer, format string, seconds map[string]int, goal func(day string) int, end time.Time) error {
+	const (
+		cell   = 11
+		gap    = 2
+		weeks  = 53
+		left   = 30
+		top    = 20
+		bottom = 10
+	)
+	first := weekStart(end).AddDate(0, 0, -7*(weeks-1))
+	c := &canvas{width: left + weeks*(cell+gap) + 10, height: top + 7*(cell+gap) + bottom}
+	for i, day := range []string{"Mon", "", "Wed", "", "Fri", "", ""} {
+		if day != "" {
+			c.text(0, top+i*(cell+gap)+cell-1, day)
+		}
+	}
+	lastMonth := time.Month(0)
+	for col := 0; col < weeks; col++ {
+		monday := first.AddDate(0, 0, 7*col)
+		if m := monday.AddDate(0, 0, 6).Month(); m != lastMonth {
+			c.text(left+col*(cell+gap), top-6, monday.AddDate(0, 0, 6).Format("Jan"))
+			lastMonth = m
+		}
+		for row := 0; row < 7; row++ {
+			day := monday.AddDate(0, 0, row)
+			if day.After(end) {
+				break
+			}
+			key := day.Format("2006-01-02")
+			secs := seconds[key]
+			c.rect(left+col*(cell+gap), top+row*(cell+gap), cell, cell, shade(secs, goal(key)),
+				fmt.Sprintf("%s: %s", key, state.HumanMinutes(secs/60)))
+		}
+	}
+	return c.write(w, format)
+}
+
+// Weekly draws one bar per week for the n weeks ending with end, labelled with
+// the week's Monday and total hours.
+func Weekly(w io.Writer, format string, seconds map[string]int, n int, end time.Time) error {
+	const (
+		barW   = 24
+		gap    = 8
+		height = 160
+		left   = 10
+		top    = 20
+		bottom = 20
+	)
+	if n < 1 {
+		n = 1
+	}
+	first := weekStart(end).AddDate(0, 0, -7*(n-1))
+	totals := make([]int, n)
+	peak := 0
+	for i := range totals {
+		monday := first.AddDate(0, 0, 7*i)
+		for d := 0; d < 7; d++ {
+			totals[i] += seconds[monday.AddDate(0, 0, d).Format("2006-01-02")]
+		}
+		peak = max(peak, totals[i])
+	}
+	c := &canvas{width: left + n*(barW+gap) + 10, height: top + height + bottom}
+	for i, secs := range totals {
+		monday := first.AddDate(0, 0, 7*i)
+		x := left + i*(barW+gap)
+		h := 0
+		if peak > 0 {
+			h = secs * height / peak
+		}
+		if h == 0 && secs > 0 {
+			h = 1
+		}
+		label := fmt.Sprintf("week of %s: %s", monday.Format("2006-01-02"), state.HumanMinutes(secs/60))
+		c.rect(x, top+height-h, barW, h, barColor, label)
+		if secs > 0 {
+			c.text(x, top+height-h-4, fmt.Sprintf("%dh", secs/3600))
+		}
+		c.text(x, top+height+14, monday.Format("01/02"))
+	}
+	return c.write(w, format)
+}
+
+// weekStart returns the Monday starting t's week.
+func weekStart(t time.Time) time.Time {
+	offset := (int(t.Weekday()) + 6) % 7
+	return state.Midnight(t.AddDate(0, 0, -offset))
+}
+
+// shade picks the cell color for a day's work against the goal.
+func shade(secs, goalMinutes int) color.RGBA {
+	if secs <= 0 {
+		return levels[0]
+	}
+	if goalMinutes <= 0 {
+		return levels[len(levels)-1]
+	}
+	step := secs * (len(levels) - 1) / (goalMinutes * 60)
+	return levels[min(step+1, len(levels)-1)]
+}
+
+type rect struct {
+	x, y, w, h int
+	fill       color.RGBA
+	title      string
+}
+
+type label struct {
+	x, y int
+	text string
+}
+
+// canvas collects shapes so the same chart can be written as PNG or SVG. PNG
+// output has no text, since the standard library cannot draw fonts.
+type canvas struct {
+	width, height int
+	rects         []rect
+	labels        []label
+}
+
+func (c *canvas) rect(x, y, w, h int, fill color.RGBA, title string) {
+	c.rects = append(c.rects, rect{x, y, w, h, fill, title})
+}
+
+func (c *canvas) text(x, y int, s string) {
+	c.labels = append(c.labels, label{x, y, s})
+}
+
+func (c *canvas) write(w io.Writer, format string) error {
+	switch format {
+	case PNG:
+		return c.png(w)
+	case SVG:
+		return c.svg(w)
+	}
+	return fmt.Errorf("unsupported chart format %q", format)
+}
+
+func (c *canvas) png(w io.Writer) error {
+	img := image.NewRGBA(image.Rect(0, 0, c.width, c.height))
+	draw.Draw(img, img.Bounds(), image.NewUniform(background), image.Point{}, draw.Src)
+	for _, r := range c.rects {
+		draw.Draw(img, image.Rect(r.x, r.y, r.x+r.w, r.y+r.h), image.NewUniform(r.fill), image.Point{}, draw.Src)
+	}
+	return png.Encode(w, img)
+}
+
+func (c *canvas) svg(w io.Writer) error {
+	b := bufio.NewWriter(w)
+	fmt.Fprintf(b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+"\n", c.width, c.height, c.width, c.height)
+	fmt.Fprintf(b, `<rect width="100%%" height="100%%" fill="%s"/>`+"\n", hex(background))
+	for _, r := range c.rects {
+		fmt.Fprintf(b, `<rect x="%d" y="%d" width="%d" height="%d" rx="2" fill="%s"><title>%s</title></rect>`+"\n",
+			r.x, r.y, r.w, r.h, hex(r.fill), html.EscapeString(r.title))
+	}
+	for _, l := range c.labels {
+		fmt.Fprintf(b, `<text x="%d" y="%d" fill="%s" font-family="sans-serif" font-size="9">%s</text>`+"\n",
+			l.x, l.y, hex(textColor), html.EscapeString(l.text))
+	}
+	fmt.Fprintln(b, "</svg>")
+	return b.Flush()
+}
+
+func hex(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
@@ -0,0 +1,188 @@
+package daemon
+
+import (
+	"errors"
+	"os"
+	"time"
+
+	"github.com/max-pantom/daily/internal/audit"
+	"github.com/max-pantom/daily/internal/hooks"
+	"github.com/max-pantom/daily/internal/ipc"
+	"github.com/max-pantom/daily/internal/state"
+)
+
+const dialTimeout = 300 * time.Millisecond
+
+// maxAttempts bounds how often Update retries after a conflicting write.
+const maxAttempts = 5
+
+// ErrConflict is returned by Save when the state changed since it was loaded.
+var ErrConflict = ipc.ErrConflict
+
+// Running reports whether a daemon is serving the given state file.
+func Running(statePath string) bool {
+	c, err := dial(statePath)
+	if err != nil {
+		return false
+	}
+	c.Close()
+	return true
+}
+
+// Load returns the daemon's copy of the state, or reads the file directly when
+// no daemon is running.
+func Load(statePath string) (*state.State, error) {
+	c, err := dial(statePath)
+	if err != nil {
+		return state.Load(statePath)
+	}
+	defer c.Close()
+	resp, err := c.Call(ipc.Request{Op: ipc.OpGet})
+	if err != nil {
+		return nil, err
+	}
+	if resp.State == nil {
+		return nil, errors.New("daemon returned no state")
+	}
+	resp.State.Revision = resp.Revision
+	resp.State.Anomalies = resp.State.Check(time.Now())
+	return resp.State, nil
+}
+
+// ErrReadOnly is returned by Save while DAILY_READ_ONLY is set, and by a
+// daemon started with it for every change.
+var ErrReadOnly = errors.New("read-only mode: DAILY_READ_ONLY is set, nothing was saved")
+
+// ReadOnly reports whether DAILY_READ_ONLY is set for this process.
+func ReadOnly() bool {
+	return os.Getenv("DAILY_READ_ONLY") != ""
+}
+
+// Save hands st to the daemon, or writes the file directly when no daemon is
+// running, recording the change in the audit log. It returns ErrConflict if
+// the daemon's state moved on since st was loaded. Without a daemon the hooks
+// the change fires run before Save returns, so a command can wait up to
+// hooks.Timeout for each of them; the daemon runs them in the background.
+func Save(statePath string, st *state.State) error {
+	if ReadOnly() {
+		return ErrReadOnly
+	}
+	c, err := dial(statePath)
+	if err != nil {
+		before := audit.Snapshot(statePath)
+		if err := st.Save(statePath); err != nil {
+			return err
+		}
+		audit.Record(statePath, audit.Source(), before, st)
+		hooks.Run(statePath, hooks.Changes(statePath, audit.Source(), before, st, time.Now()))
+		return nil
+	}
+	defer c.Close()
+	resp, err := c.Call(ipc.Request{Op: ipc.OpPut, Source: audit.Source(), Revision: st.Revision, State: st})
+	if err != nil {
+		return err
+	}
+	st.Revision = resp.Revision
+	return nil
+}
+
+// Update loads the state, normalizes it, applies fn, and saves it, retrying
+// when another process wrote in between.
+func Update(statePath string, fn func(*state.State) error) error {
+	var err error
+	for i := 0; i < maxAttempts; i++ {
+		var st *state.State
+		st, err = Load(statePath)
+		if err != nil {
+			return err
+		}
+		st.Normalize(time.Now())
+		if err = fn(st); err != nil {
+			return err
+		}
+		if err = Save(statePath, st); !errors.Is(err, ErrConflict) {
+			return err
+		}
+	}
+	return err
+}
+
+// Beat refreshes the state's heartbeat and the running session's checkpoint
+// when they are due; with no session running there is nothing to keep alive,
+// so an idle frontend writes nothing. Normalizing first ends a session that
+// outlived its last heartbeat after a crash or suspend.
+func Beat(statePath string, now time.Time) error {
+	st, err := Load(statePath)
+	if err != nil || st.ActiveSession == nil || (!st.HeartbeatDue(now) && !st.CheckpointDue(now)) {
+		return err
+	}
+	return Update(statePath, func(st *state.State) error {
+		st.Normalize(now)
+		st.Heartbeat(now)
+		if st.CheckpointDue(now) {
+			st.SaveCheckpoint(now)
+		}
+		return nil
+	})
+}
+
+// EndHeartbeat clears the heartbeat when a frontend exits cleanly.
+func EndHeartbeat(statePath string) error {
+	if st, err := Load(statePath); err != nil || st.LastSeen == nil {
+		return err
+	}
+	return Update(statePath, func(st *state.State) error {
+		st.ClearHeartbeat()
+		return nil
+	})
+}
+
+// Watcher reports state changes.
+type Watcher interface {
+	Changes() <-chan struct{}
+	Close() error
+}
+
+// Watch subscribes to the daemon's change events, or watches the state file
+// when no daemon is running.
+func Watch(statePath string) (Watcher, error) {
+	c, err := dial(statePath)
+	if err != nil {
+		return state.Watch(statePath)
+	}
+	events, err := c.Subscribe()
+	if err != nil {
+		c.Close()
+		return nil, err
+	}
+	sub := &subscription{c: c, changes: make(chan struct{}, 1)}
+	go sub.loop(events)
+	return sub, nil
+}
+
+type subscription struct {
+	c       *ipc.Client
+	changes chan struct{}
+}
+
+func (s *subscription) Changes() <-chan struct{} {
+	return s.changes
+}
+
+func (s *subscription) Close() error {
+	return s.c.Close()
+}
+
+func (s *subscription) loop(events <-chan ipc.Event) {
+	defer close(s.changes)
+	for range events {
+		select {
+		case s.changes <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func dial(statePath string) (*ipc.Client, error) {
+	return ipc.Dial(SocketPath(statePath), dialTimeout)
+}
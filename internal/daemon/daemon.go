@@ -0,0 +1,366 @@
+// Package daemon keeps a single in-memory copy of the state and serves it to
+// the CLI, TUI, tray, and watchers over a unix socket, so they stop racing on
+// state.json. The wire protocol lives in package ipc.
+package daemon
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/max-pantom/daily/internal/audit"
+	"github.com/max-pantom/daily/internal/hooks"
+	"github.com/max-pantom/daily/internal/i18n"
+	"github.com/max-pantom/daily/internal/ipc"
+	"github.com/max-pantom/daily/internal/logging"
+	"github.com/max-pantom/daily/internal/notify"
+	"github.com/max-pantom/daily/internal/state"
+)
+
+// SocketPath returns the socket the daemon listens on for the given state file.
+func SocketPath(statePath string) string {
+	return filepath.Join(filepath.Dir(statePath), "daemon.sock")
+}
+
+type server struct {
+	path     string
+	log      *slog.Logger
+	readOnly bool // started with DAILY_READ_ONLY: every change is refused
+	hooks    *hooks.Queue
+
+	mu      sync.Mutex
+	st      *state.State
+	rev     uint64
+	savedAt [2]time.Time // mtimes of state and config after our last write, to ignore our own file events
+	subs    map[chan ipc.Response]struct{}
+	changed chan struct{} // wakes remind when the state is replaced
+
+	remindAfter time.Time // no break reminder before this (snoozed or dismissed)
+	prompting   bool      // a break reminder is on screen
+	goalDay     string    // day whose goal has been celebrated
+	started     bool      // the first goal check has run
+	capDay      string    // day the overwork alert counters belong to
+	capAlerts   int       // overwork alerts sent on capDay
+	capNextAt   time.Time // earliest time for the next overwork alert
+	overBreak   time.Time // start of the break the back-to-work alerts belong to
+	overNextAt  time.Time // earliest time for the next back-to-work alert
+	startDay    string    // day the start reminder was sent
+}
+
+// Serve loads the state file and serves it until the listener fails. It
+// returns an error if another daemon is already running for the same file.
+func Serve(statePath string) error {
+	st, err := state.Load(statePath)
+	if err != nil {
+		return err
+	}
+	i18n.Use(st.Language, st.Clock)
+	notify.Use(st.NotifyConfig())
+	sock := SocketPath(statePath)
+	if Running(statePath) {
+		return fmt.Errorf("daemon already running on %s", sock)
+	}
+	// The state file itself is only written by the first change.
+	if err := os.MkdirAll(filepath.Dir(statePath), 0o755); err != nil {
+		return err
+	}
+	_ = os.Remove(sock)
+	ln, err := net.Listen("unix", sock)
+	if err != nil {
+		return err
+	}
+	defer ln.Close()
+	_ = os.Chmod(sock, 0o600)
+
+	s := &server{
+		path:     statePath,
+		log:      logging.New(statePath, "daemon"),
+		readOnly: ReadOnly(),
+		hooks:    hooks.NewQueue(statePath),
+		st:       st,
+		rev:      1,
+		subs:     map[chan ipc.Response]struct{}{},
+		changed:  make(chan struct{}, 1),
+	}
+	s.log.Info("listening", "socket", sock, "read_only", s.readOnly)
+	for _, p := range st.Anomalies {
+		s.log.Warn("state anomaly; run daily doctor", "problem", p)
+	}
+	if w, err := state.Watch(statePath); err == nil {
+		defer w.Close()
+		go s.watchFile(w.Changes())
+	}
+	go s.remind()
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			s.log.Error("accept", "err", err)
+			return err
+		}
+		go s.handle(conn)
+	}
+}
+
+// writeTimeout bounds every write to a client, so one that stops reading
+// cannot hold up the daemon.
+const writeTimeout = 5 * time.Second
+
+func (s *server) handle(conn net.Conn) {
+	defer conn.Close()
+	dec := json.NewDecoder(bufio.NewReader(conn))
+	enc := json.NewEncoder(conn)
+	for {
+		var req ipc.Request
+		if err := dec.Decode(&req); err != nil {
+			return
+		}
+		var resp ipc.Response
+		switch req.Op {
+		case ipc.OpSubscribe:
+			s.subscribe(conn, enc)
+			return
+		case ipc.OpGet:
+			// Encoded under the lock, written without it.
+			s.mu.Lock()
+			data, err := json.Marshal(ipc.Response{Revision: s.rev, State: s.st})
+			s.mu.Unlock()
+			if err != nil {
+				s.log.Error("encode state", "err", err)
+				return
+			}
+			_ = conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+			if _, err := conn.Write(append(data, '\n')); err != nil {
+				return
+			}
+			continue
+		case ipc.OpPut:
+			resp = s.put(req)
+		case ipc.OpStatus, ipc.OpStart, ipc.OpStop, ipc.OpBreak:
+			resp = s.control(req)
+		default:
+			resp = ipc.Response{Error: "unknown op " + req.Op}
+		}
+		_ = conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+		if err := enc.Encode(resp); err != nil {
+			return
+		}
+	}
+}
+
+// put replaces the state when the client saw the latest revision.
+func (s *server) put(req ipc.Request) ipc.Response {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if req.State == nil {
+		return ipc.Response{Revision: s.rev, Error: "missing state"}
+	}
+	if req.Revision != s.rev {
+		return ipc.Response{Revision: s.rev, Error: ipc.ErrConflict.Error(), Conflict: true}
+	}
+	if err := s.commit(req.State, requestSource(req)); err != nil {
+		return ipc.Response{Revision: s.rev, Error: err.Error()}
+	}
+	return ipc.Response{Revision: s.rev}
+}
+
+// control applies one of the public control operations.
+func (s *server) control(req ipc.Request) ipc.Response {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	if req.Op == ipc.OpStatus {
+		status := ipc.StatusOf(s.st, now)
+		return ipc.Response{Revision: s.rev, Status: &status}
+	}
+
+	if err := s.applyLocked(req, now); err != nil {
+		return ipc.Response{Revision: s.rev, Error: err.Error()}
+	}
+	status := ipc.StatusOf(s.st, now)
+	return ipc.Response{Revision: s.rev, Status: &status}
+}
+
+// applyLocked runs a control operation and commits the result. It works on a
+// copy so a failed operation leaves the served state untouched, and must be
+// called with s.mu held.
+func (s *server) applyLocked(req ipc.Request, now time.Time) error {
+	next, err := clone(s.st)
+	if err != nil {
+		return err
+	}
+	next.Normalize(now)
+	if err := apply(next, req, now); err != nil {
+		return err
+	}
+	return s.commit(next, requestSource(req))
+}
+
+// requestSource names the client for the audit log; tools talking to the
+// socket directly usually do not say who they are.
+func requestSource(req ipc.Request) string {
+	if req.Source != "" {
+		return req.Source
+	}
+	return "socket"
+}
+
+func apply(st *state.State, req ipc.Request, now time.Time) error {
+	switch req.Op {
+	case ipc.OpStart:
+		var countdown time.Duration
+		if req.For != "" {
+			d, err := time.ParseDuration(req.For)
+			if err != nil {
+				return fmt.Errorf("invalid for: %w", err)
+			}
+			countdown = d
+		}
+		if !req.Force {
+			if err := st.CheckCap(now); err != nil {
+				return err
+			}
+		}
+		if st.ActiveBreak != nil {
+			if _, err := st.StopBreak(now); err != nil {
+				return err
+			}
+		}
+		var err error
+		if countdown > 0 {
+			err = st.StartCountdown(now, countdown, req.Tags, req.Note)
+		} else {
+			err = st.StartSession(now, req.Tags, req.Note)
+		}
+		if err != nil {
+			return err
+		}
+		st.ActiveSession.Project = req.Project
+		return nil
+	case ipc.OpStop:
+		_, err := st.StopSession(now)
+		return err
+	case ipc.OpBreak:
+		if st.ActiveBreak != nil {
+			_, err := st.StopBreak(now)
+			return err
+		}
+		if st.ActiveSession != nil {
+			if _, err := st.StopSession(now); err != nil {
+				return err
+			}
+		}
+		return st.StartBreak(now)
+	}
+	return errors.New("unknown op " + req.Op)
+}
+
+// commit saves next, makes it the served state, records the change by source
+// in the audit log, and notifies subscribers and hooks. It must be called with s.mu held.
+func (s *server) commit(next *state.State, source string) error {
+	if s.readOnly {
+		return ErrReadOnly
+	}
+	if err := next.Save(s.path); err != nil {
+		s.log.Error("save state", "err", err)
+		return err
+	}
+	audit.Record(s.path, source, s.st, next)
+	// Queued under the lock, so hooks run in commit order; they run after
+	// it is released, as they may well call daily.
+	s.hooks.Add(hooks.Changes(s.path, source, s.st, next, time.Now()))
+	s.savedAt = state.ModTimes(s.path)
+	s.replace(next)
+	return nil
+}
+
+// replace must be called with s.mu held.
+func (s *server) replace(next *state.State) {
+	now := time.Now()
+	ev := ipc.Event{Type: ipc.EventType(s.st, next), Time: now, Status: ipc.StatusOf(next, now)}
+	s.st = next
+	s.rev++
+	i18n.Use(next.Language, next.Clock)
+	notify.Use(next.NotifyConfig())
+	for ch := range s.subs {
+		select {
+		case ch <- ipc.Response{Revision: s.rev, Event: &ev}:
+		default:
+		}
+	}
+	select {
+	case s.changed <- struct{}{}:
+	default:
+	}
+}
+
+// watchFile picks up edits made without the daemon, e.g. by hand or by an
+// older binary.
+func (s *server) watchFile(changes <-chan struct{}) {
+	for range changes {
+		if _, err := os.Stat(s.path); err != nil {
+			continue
+		}
+		s.mu.Lock()
+		if mod := state.ModTimes(s.path); mod != s.savedAt {
+			if st, err := state.Load(s.path); err == nil {
+				s.savedAt = mod
+				audit.Record(s.path, "external edit", s.st, st)
+				s.replace(st)
+				s.log.Info("reloaded state edited outside the daemon")
+			} else {
+				s.log.Warn("reload state", "err", err)
+			}
+		}
+		s.mu.Unlock()
+	}
+}
+
+// subscribe streams change events to conn until the client hangs up.
+func (s *server) subscribe(conn net.Conn, enc *json.Encoder) {
+	ch := make(chan ipc.Response, 8)
+	s.mu.Lock()
+	s.subs[ch] = struct{}{}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.subs, ch)
+		s.mu.Unlock()
+	}()
+	// A subscriber sends nothing more; a read returning means it hung up.
+	gone := make(chan struct{})
+	go func() {
+		_, _ = io.Copy(io.Discard, conn)
+		close(gone)
+	}()
+	for {
+		select {
+		case resp := <-ch:
+			_ = conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+			if err := enc.Encode(resp); err != nil {
+				return
+			}
+		case <-gone:
+			return
+		}
+	}
+}
+
+func clone(st *state.State) (*state.State, error) {
+	data, err := json.Marshal(st)
+	if err != nil {
+		return nil, err
+	}
+	var out state.State
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
@@ -0,0 +1,314 @@
+package daemon
+
+import (
+	"time"
+
+	"github.com/max-pantom/daily/internal/hooks"
+	"github.com/max-pantom/daily/internal/i18n"
+	"github.com/max-pantom/daily/internal/ipc"
+	"github.com/max-pantom/daily/internal/notify"
+	"github.com/max-pantom/daily/internal/sound"
+	"github.com/max-pantom/daily/internal/state"
+)
+
+const (
+	reminderCheck = time.Minute
+	snoozeFor     = 10 * time.Minute
+	overBreakGap  = 10 * time.Minute // between back-to-work alerts
+
+	actionBreak  = "break"
+	actionSnooze = "snooze"
+	actionStart  = "start"
+)
+
+// remind runs the once-a-minute checks: the auto-stop rule, the break
+// reminder, the over-break alert, the start reminder and the goal sound. A
+// countdown is stopped the moment it runs out, whenever that is.
+func (s *server) remind() {
+	s.checkCountdown(time.Now())
+	s.checkAutoStop(time.Now())
+	s.checkGoal(time.Now())
+	s.started = true
+	ticker := time.NewTicker(reminderCheck)
+	defer ticker.Stop()
+	countdown := time.NewTimer(s.countdownLeft(time.Now()))
+	defer countdown.Stop()
+	for {
+		select {
+		case now := <-ticker.C:
+			// Before beat, whose Normalize would stop it without a word.
+			s.checkCountdown(now)
+			s.beat(now)
+			s.checkAutoStop(now)
+			s.checkReminder(now)
+			s.checkGoal(now)
+			s.checkCap(now)
+			s.checkOverBreak(now)
+			s.checkStart(now)
+		case now := <-countdown.C:
+			s.checkCountdown(now)
+		case <-s.changed:
+		}
+		countdown.Reset(s.countdownLeft(time.Now()))
+	}
+}
+
+// countdownLeft is the time until the running countdown runs out, or a
+// reminderCheck when none runs.
+func (s *server) countdownLeft(now time.Time) time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if a := s.st.ActiveSession; a != nil && a.Until != nil {
+		return max(a.Until.Sub(now), 0)
+	}
+	return reminderCheck
+}
+
+// checkCountdown stops a countdown session once its time is up, at its
+// deadline, and says so, so it ends on time with no frontend open.
+func (s *server) checkCountdown(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a := s.st.ActiveSession
+	if s.readOnly || a == nil || a.Until == nil || now.Before(*a.Until) {
+		return
+	}
+	next, err := clone(s.st)
+	if err != nil {
+		return
+	}
+	notice := next.CountdownNotice()
+	if _, ok := next.FinishCountdown(now); !ok {
+		return
+	}
+	if err := s.commit(next, "daemon countdown"); err != nil {
+		s.log.Error("countdown", "err", err)
+		return
+	}
+	s.log.Info("countdown finished")
+	if next.NotificationsOn() {
+		notify.Send("Daily", notice)
+	}
+}
+
+// beat writes the heartbeat and the running session's checkpoint; the daemon
+// stays up as long as the machine is awake, so its heartbeat is the most
+// reliable one.
+func (s *server) beat(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.readOnly || !s.st.HeartbeatDue(now) && !s.st.CheckpointDue(now) {
+		return
+	}
+	next, err := clone(s.st)
+	if err != nil {
+		return
+	}
+	next.Normalize(now)
+	next.Heartbeat(now)
+	if next.CheckpointDue(now) {
+		next.SaveCheckpoint(now)
+	}
+	if err := s.commit(next, "daemon"); err != nil {
+		s.log.Error("heartbeat", "err", err)
+	}
+}
+
+// checkAutoStop ends a session still running at the configured auto-stop time,
+// backdating it to that time, and flags the day for review.
+func (s *server) checkAutoStop(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, due := s.st.AutoStopAt(); !due {
+		return
+	}
+	next, err := clone(s.st)
+	if err != nil {
+		return
+	}
+	at, stopped := next.ApplyAutoStop(now)
+	if !stopped {
+		return
+	}
+	if err := s.commit(next, "daemon auto-stop"); err != nil {
+		s.log.Error("auto-stop", "err", err)
+		return
+	}
+	s.log.Warn("auto-stopped session", "at", at.Format(time.RFC3339))
+	if next.NotificationsOn() {
+		notify.Send("Daily", i18n.T("Session stopped at %s by the auto-stop rule. Check it with daily review.", i18n.Time(at)))
+	}
+}
+
+// capAlertGaps spaces the overwork alerts, getting more insistent; the last gap
+// repeats.
+var capAlertGaps = []time.Duration{30 * time.Minute, 20 * time.Minute, 10 * time.Minute}
+
+// checkCap sends escalating alerts while a session keeps running past the
+// daily cap.
+func (s *server) checkCap(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	day := now.Format("2006-01-02")
+	if s.capDay != day {
+		s.capDay, s.capAlerts, s.capNextAt = day, 0, time.Time{}
+	}
+	over, ok := s.st.OverCap(now)
+	if !ok || s.st.ActiveSession == nil || now.Before(s.capNextAt) || !s.st.NotificationsOn() {
+		return
+	}
+	limit := state.HumanMinutes(s.st.CapMinutes)
+	var msg string
+	switch {
+	case s.capAlerts == 0:
+		msg = i18n.T("You've hit your %s cap. Time to wrap up.", limit)
+	case s.capAlerts == 1:
+		msg = i18n.T("%s past your %s cap. Save your work and stop.", state.HumanMinutes(over), limit)
+	default:
+		msg = i18n.T("%s over your %s cap. Please stop now.", state.HumanMinutes(over), limit)
+	}
+	notify.Send("Daily", msg)
+	s.log.Warn("over daily cap", "over_minutes", over, "alert", s.capAlerts+1)
+	gap := capAlertGaps[len(capAlertGaps)-1]
+	if s.capAlerts < len(capAlertGaps) {
+		gap = capAlertGaps[s.capAlerts]
+	}
+	s.capAlerts++
+	s.capNextAt = now.Add(gap)
+}
+
+// checkOverBreak tells the user to get back to work once the running break
+// passes its length, and again every overBreakGap while it goes on.
+func (s *server) checkOverBreak(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	over, ok := s.st.OverBreak(now)
+	if !ok || !s.st.NotificationsOn() {
+		return
+	}
+	if start := s.st.ActiveBreak.Start; !s.overBreak.Equal(start) {
+		s.overBreak, s.overNextAt = start, time.Time{}
+	}
+	if now.Before(s.overNextAt) {
+		return
+	}
+	msg := i18n.T("Break's over. Time to get back to work.")
+	if over >= time.Minute {
+		msg = i18n.T("Your break ran %s over. Time to get back to work.", state.HumanMinutes(int(over.Minutes())))
+	}
+	notify.Send("Daily", msg)
+	s.log.Info("over break", "over", over.Round(time.Minute))
+	s.overNextAt = now.Add(overBreakGap)
+}
+
+// startReminderLate is how long after the due time the start reminder still
+// makes sense; a daemon started in the evening stays quiet.
+const startReminderLate = 3 * time.Hour
+
+// checkStart asks, once a day, whether the user forgot to start tracking on a
+// workday that has nothing logged well past their usual start.
+func (s *server) checkStart(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	day := now.Format("2006-01-02")
+	if s.startDay == day || !s.st.NotificationsOn() {
+		return
+	}
+	usual, due := s.st.StartReminderDue(now)
+	if !due {
+		return
+	}
+	s.startDay = day
+	if now.Sub(usual) > startReminderLate {
+		return
+	}
+	s.log.Info("start reminder", "usual", usual.Format("15:04"))
+	go s.promptStart(usual)
+}
+
+// promptStart shows the start reminder and starts a session if asked to.
+func (s *server) promptStart(usual time.Time) {
+	msg := i18n.T("Forgot to start tracking? You usually start around %s.", i18n.Time(usual))
+	choice := notify.SendActions("Daily", msg, []notify.Action{{Key: actionStart, Label: i18n.T("Start now")}})
+	if choice != actionStart {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.st.ActiveSession != nil {
+		return
+	}
+	if err := s.applyLocked(ipc.Request{Op: ipc.OpStart, Source: "start reminder"}, time.Now()); err != nil {
+		s.log.Error("start from reminder", "err", err)
+	}
+}
+
+// checkGoal plays the goal sound and runs the on-goal hooks the first time
+// today's work reaches the goal. A goal already reached when the daemon starts
+// stays silent.
+func (s *server) checkGoal(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	day := now.Format("2006-01-02")
+	if s.goalDay == day {
+		return
+	}
+	work, _ := s.st.TodaySummary(now)
+	if goal, _ := s.st.Goal(now); goal <= 0 || work < goal {
+		return
+	}
+	s.goalDay = day
+	if s.started {
+		sound.Play(sound.Goal, s.st.Sounds[sound.Goal])
+		ev := hooks.New(s.path, hooks.OnGoal, s.st, now)
+		ev.Source = "daemon"
+		s.hooks.Add([]hooks.Event{ev})
+	}
+}
+
+// checkReminder nudges the user once they have worked the break interval
+// without a rest, across quick stop/starts, offering to start a break or snooze.
+func (s *server) checkReminder(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st := s.st
+	if s.prompting || st.ActiveSession == nil || st.BreakIntervalMinutes <= 0 || !st.NotificationsOn() {
+		return
+	}
+	worked := st.ContinuousWork(now)
+	interval := time.Duration(st.BreakIntervalMinutes) * time.Minute
+	if worked < interval || now.Before(s.remindAfter) {
+		return
+	}
+	s.prompting = true
+	go s.prompt(worked, interval)
+}
+
+// prompt shows the reminder and acts on the chosen button. Dismissing it
+// postpones the next reminder by a full interval.
+func (s *server) prompt(worked, interval time.Duration) {
+	msg := i18n.T("You've worked %s straight. Time for a break?", state.HumanMinutes(int(worked.Minutes())))
+	choice := notify.SendActions("Daily", msg, []notify.Action{
+		{Key: actionBreak, Label: i18n.T("Start break")},
+		{Key: actionSnooze, Label: i18n.T("Snooze %s", state.HumanMinutes(int(snoozeFor.Minutes())))},
+	})
+
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.prompting = false
+	s.log.Info("break reminder", "worked", worked.Round(time.Minute), "choice", choice)
+	switch choice {
+	case actionBreak:
+		s.remindAfter = time.Time{}
+		if s.st.ActiveBreak == nil {
+			if err := s.applyLocked(ipc.Request{Op: ipc.OpBreak, Source: "break reminder"}, now); err != nil {
+				s.log.Error("start break from reminder", "err", err)
+			}
+		}
+	case actionSnooze:
+		s.remindAfter = now.Add(snoozeFor)
+	default:
+		s.remindAfter = now.Add(interval)
+	}
+}
@@ -0,0 +1,122 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/max-pantom/daily/internal/state"
+)
+
+// Invoicing formats.
+const (
+	Harvest    = "harvest"
+	FreshBooks = "freshbooks"
+)
+
+// InvoiceOptions fills the columns the state does not know about.
+type InvoiceOptions struct {
+	Client  string // client for every row; default is the session's project
+	Task    string // Harvest task / FreshBooks service
+	Person  string // "First Last", required by Harvest for team accounts
+	Project string // project for sessions without one
+}
+
+// InvoiceRow is one finished session in an invoicing export.
+type InvoiceRow struct {
+	Date    string
+	Client  string
+	Project string
+	Task    string
+	Notes   string
+	Hours   float64 // after the configured rounding
+	Rate    float64
+}
+
+// InvoiceRows lists the finished sessions matching f, oldest first.
+func InvoiceRows(st *state.State, f state.Filter, opt InvoiceOptions) []InvoiceRow {
+	var rows []InvoiceRow
+	keys := st.DayKeys(f)
+	sort.Strings(keys)
+	for _, day := range keys {
+		for _, sess := range st.Days[day].Sessions {
+			if sess.End == nil || !f.Match(sess) {
+				continue
+			}
+			mins := st.Rounding.Apply(sess.Seconds(*sess.End))
+			if mins <= 0 {
+				continue
+			}
+			project := sess.Project
+			if project == "" {
+				project = opt.Project
+			}
+			client := opt.Client
+			if client == "" {
+				client = project
+			}
+			rows = append(rows, InvoiceRow{
+				Date:    day,
+				Client:  client,
+				Project: project,
+				Task:    opt.Task,
+				Notes:   invoiceNotes(sess),
+				Hours:   float64(mins) / 60,
+				Rate:    st.Rate(sess.Project),
+			})
+		}
+	}
+	return rows
+}
+
+// invoiceNotes is the session note, or its tags when it has none.
+func invoiceNotes(sess state.Session) string {
+	if sess.Note != "" {
+		return sess.Note
+	}
+	return strings.Join(sess.Tags, ", ")
+}
+
+// WriteInvoiceCSV writes rows in the time import format of Harvest or
+// FreshBooks.
+func WriteInvoiceCSV(w io.Writer, format string, rows []InvoiceRow, opt InvoiceOptions) error {
+	first, last, _ := strings.Cut(strings.TrimSpace(opt.Person), " ")
+	cw := csv.NewWriter(w)
+	var header []string
+	var record func(r InvoiceRow) []string
+	switch format {
+	case Harvest:
+		// Harvest takes rates from the project, so none are exported.
+		header = []string{"Date", "Client", "Project", "Task", "Notes", "Hours", "First name", "Last name"}
+		record = func(r InvoiceRow) []string {
+			return []string{r.Date, r.Client, r.Project, r.Task, r.Notes, decimal(r.Hours), first, strings.TrimSpace(last)}
+		}
+	case FreshBooks:
+		header = []string{"Date", "Client", "Project", "Service", "Note", "Hours", "Rate", "Amount"}
+		// The amount uses the hours as printed so the rows add up on the invoice.
+		record = func(r InvoiceRow) []string {
+			return []string{r.Date, r.Client, r.Project, r.Task, r.Notes, decimal(r.Hours), decimal(r.Rate), decimal(r.Rate * math.Round(r.Hours*100) / 100)}
+		}
+	default:
+		return fmt.Errorf("unknown format %q (want %s or %s)", format, Harvest, FreshBooks)
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, r := range rows {
+		if err := cw.Write(record(r)); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// decimal formats hours and money with two decimals.
+func decimal(v float64) string {
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
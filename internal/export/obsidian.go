@@ -0,0 +1,141 @@
+// Package export writes tracked time into other tools: Markdown daily notes
+// and invoicing CSVs.
+package export
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/max-pantom/daily/internal/state"
+)
+
+// DefaultNotePattern matches Obsidian's default daily note name. YYYY, MM and
+// DD are replaced with the day's date.
+const DefaultNotePattern = "YYYY-MM-DD.md"
+
+// DefaultNoteTemplate renders the time log block; override it with a
+// text/template file receiving a NoteDay.
+const DefaultNoteTemplate = `## Time log
+{{if .Sessions}}| Time | Duration | Project | Tags | Note |
+| --- | --- | --- | --- | --- |
+{{range .Sessions}}| {{.Start}}–{{.End}} | {{.Duration}} | {{cell .Project}} | {{cell .Tags}} | {{cell .Note}} |
+{{end}}{{else}}No sessions logged.
+{{end}}
+**Total:** {{.Total}} of {{.Goal}} goal{{if .Breaks}} · {{.Breaks}} breaks ({{.BreakTotal}}){{end}}
+`
+
+// Markers delimit the block so exporting the same day again replaces it.
+const (
+	blockStart = "<!-- daily:time-log -->"
+	blockEnd   = "<!-- /daily:time-log -->"
+)
+
+// NoteDay is the data handed to the note template.
+type NoteDay struct {
+	Date       string // YYYY-MM-DD
+	Weekday    string
+	Total      string
+	Goal       string
+	Breaks     int
+	BreakTotal string
+	Sessions   []NoteSession
+}
+
+// NoteSession is one session row in the note.
+type NoteSession struct {
+	Start    string
+	End      string
+	Duration string
+	Project  string
+	Tags     string // comma separated
+	Note     string
+}
+
+// NotePath resolves the daily note for day inside vault.
+func NotePath(vault, pattern string, day time.Time) string {
+	if pattern == "" {
+		pattern = DefaultNotePattern
+	}
+	name := strings.NewReplacer("YYYY", day.Format("2006"), "MM", day.Format("01"), "DD", day.Format("02")).Replace(pattern)
+	return filepath.Join(vault, filepath.FromSlash(name))
+}
+
+// Note renders day's time log with tmpl, or DefaultNoteTemplate when empty.
+func Note(st *state.State, day, now time.Time, tmpl string) (string, error) {
+	if tmpl == "" {
+		tmpl = DefaultNoteTemplate
+	}
+	t, err := template.New("note").Funcs(template.FuncMap{
+		"cell": func(s string) string { return strings.ReplaceAll(s, "|", `\|`) },
+	}).Parse(tmpl)
+	if err != nil {
+		return "", err
+	}
+	key := day.Format("2006-01-02")
+	data := NoteDay{Date: key, Weekday: day.Format("Monday"), Goal: state.HumanMinutes(st.DayGoal(key))}
+	var sessions []state.Session
+	total := 0
+	if log, ok := st.Days[key]; ok {
+		sessions = append(sessions, log.Sessions...)
+		total = log.WorkSeconds()
+		data.Breaks = log.BreakCount
+		data.BreakTotal = state.HumanSeconds(log.BreakSeconds())
+	}
+	if st.ActiveSession != nil && key == now.Format("2006-01-02") {
+		sessions = append(sessions, *st.ActiveSession)
+		total += st.ActiveSession.Seconds(now)
+	}
+	data.Total = state.HumanMinutes(st.Rounding.Apply(total))
+	for _, sess := range sessions {
+		end := "now"
+		if sess.End != nil {
+			end = sess.End.Format("15:04")
+		}
+		data.Sessions = append(data.Sessions, NoteSession{
+			Start:    sess.Start.Format("15:04"),
+			End:      end,
+			Duration: state.HumanMinutes(st.Rounding.Apply(sess.Seconds(now))),
+			Project:  sess.Project,
+			Tags:     strings.Join(sess.Tags, ", "),
+			Note:     sess.Note,
+		})
+	}
+	var b bytes.Buffer
+	if err := t.Execute(&b, data); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// WriteNote puts block into the note at path, replacing the block from an
+// earlier export or appending it, and creates the note if needed.
+func WriteNote(path, block string) error {
+	old, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	wrapped := blockStart + "\n" + strings.TrimRight(block, "\n") + "\n" + blockEnd + "\n"
+	text := string(old)
+	if i := strings.Index(text, blockStart); i >= 0 {
+		if j := strings.Index(text[i:], blockEnd); j >= 0 {
+			rest := strings.TrimPrefix(text[i+j+len(blockEnd):], "\n")
+			text = text[:i] + wrapped + rest
+			return os.WriteFile(path, []byte(text), 0o644)
+		}
+	}
+	if text != "" && !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+	if text != "" {
+		text += "\n"
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(text+wrapped), 0o644)
+}
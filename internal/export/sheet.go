@@ -0,0 +1,78 @@
+package export
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/max-pantom/daily/internal/state"
+)
+
+// Spreadsheet layouts: a row per finished session or per day.
+const (
+	SheetSessions = "session"
+	SheetDays     = "day"
+)
+
+// SheetRows lists the header and rows of a spreadsheet export of the days
+// matching f, oldest first. The first column identifies the row: the
+// session's start, or the date. Hours are rounded like invoices.
+func SheetRows(st *state.State, f state.Filter, per string) (header []any, rows [][]any) {
+	keys := st.DayKeys(f)
+	sort.Strings(keys)
+	if per == SheetDays {
+		header = []any{"Date", "Hours", "Sessions", "Break hours", "Projects"}
+		for _, day := range keys {
+			log := st.Days[day]
+			mins, n := 0, 0
+			var projects []string
+			for _, sess := range log.Sessions {
+				if sess.End == nil || !f.Match(sess) {
+					continue
+				}
+				mins += st.Rounding.Apply(sess.Seconds(*sess.End))
+				n++
+				if sess.Project != "" && !contains(projects, sess.Project) {
+					projects = append(projects, sess.Project)
+				}
+			}
+			if n == 0 {
+				continue
+			}
+			rows = append(rows, []any{day, hours(mins * 60), float64(n), hours(log.BreakSeconds()), strings.Join(projects, ", ")})
+		}
+		return header, rows
+	}
+	header = []any{"Start", "End", "Hours", "Project", "Tags", "Note"}
+	for _, day := range keys {
+		for _, sess := range st.Days[day].Sessions {
+			if sess.End == nil || !f.Match(sess) {
+				continue
+			}
+			mins := st.Rounding.Apply(sess.Seconds(*sess.End))
+			rows = append(rows, []any{
+				sess.Start.Format("2006-01-02 15:04:05"),
+				sess.End.Format("2006-01-02 15:04:05"),
+				hours(mins * 60),
+				sess.Project,
+				strings.Join(sess.Tags, ", "),
+				sess.Note,
+			})
+		}
+	}
+	return header, rows
+}
+
+// hours converts seconds to hours with two decimals.
+func hours(seconds int) float64 {
+	return math.Round(float64(seconds)/36) / 100
+}
+
+func contains(list []string, v string) bool {
+	for _, s := range list {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,173 @@
+package focus
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+const (
+	beginMarker = "# daily focus begin"
+	endMarker   = "# daily focus end"
+)
+
+// ErrPermission is returned when the hosts file cannot be modified.
+var ErrPermission = errors.New("focus mode edits the hosts file; re-run with sudo (or as Administrator)")
+
+// HostsPath returns the platform hosts file that focus mode rewrites.
+func HostsPath() string {
+	if runtime.GOOS == "windows" {
+		root := os.Getenv("SystemRoot")
+		if root == "" {
+			root = `C:\Windows`
+		}
+		return root + `\System32\drivers\etc\hosts`
+	}
+	return "/etc/hosts"
+}
+
+// ParseDomains splits a comma-separated list, dropping schemes, paths and blanks.
+func ParseDomains(list string) []string {
+	var out []string
+	seen := make(map[string]bool)
+	for _, d := range strings.Split(list, ",") {
+		d = strings.ToLower(strings.TrimSpace(d))
+		d = strings.TrimPrefix(d, "https://")
+		d = strings.TrimPrefix(d, "http://")
+		d = strings.TrimPrefix(d, "www.")
+		if i := strings.IndexAny(d, "/:"); i >= 0 {
+			d = d[:i]
+		}
+		if d == "" || seen[d] {
+			continue
+		}
+		seen[d] = true
+		out = append(out, d)
+	}
+	return out
+}
+
+// CheckWritable verifies the hosts file can be edited before any loop starts.
+func CheckWritable() error {
+	f, err := os.OpenFile(HostsPath(), os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		if errors.Is(err, os.ErrPermission) {
+			return ErrPermission
+		}
+		return err
+	}
+	return f.Close()
+}
+
+// Block writes a marked section to the hosts file resolving domains to localhost.
+// Any previous daily section is replaced.
+func Block(domains []string) error {
+	if len(domains) == 0 {
+		return errors.New("no domains to block")
+	}
+	base, err := readStripped()
+	if err != nil {
+		return err
+	}
+	var b strings.Builder
+	b.WriteString(base)
+	if base != "" && !strings.HasSuffix(base, "\n") {
+		b.WriteString("\n")
+	}
+	b.WriteString(beginMarker + "\n")
+	for _, d := range domains {
+		for _, host := range []string{d, "www." + d} {
+			fmt.Fprintf(&b, "127.0.0.1 %s\n::1 %s\n", host, host)
+		}
+	}
+	b.WriteString(endMarker + "\n")
+	if err := write(b.String()); err != nil {
+		return err
+	}
+	flushDNS()
+	return nil
+}
+
+// Unblock removes the daily section from the hosts file, if present.
+func Unblock() error {
+	active, err := Active()
+	if err != nil || !active {
+		return err
+	}
+	base, err := readStripped()
+	if err != nil {
+		return err
+	}
+	if err := write(base); err != nil {
+		return err
+	}
+	flushDNS()
+	return nil
+}
+
+// Active reports whether a daily block section is currently in the hosts file.
+func Active() (bool, error) {
+	data, err := os.ReadFile(HostsPath())
+	if err != nil {
+		return false, err
+	}
+	return strings.Contains(string(data), beginMarker), nil
+}
+
+// readStripped returns the hosts file without any daily section.
+func readStripped() (string, error) {
+	data, err := os.ReadFile(HostsPath())
+	if err != nil {
+		return "", err
+	}
+	return strip(string(data))
+}
+
+// strip removes the daily sections from hosts. A begin marker with no end
+// marker after it, left by a hand edit or a cut-off write, is refused rather
+// than taken to run to the end of the file, which would drop every entry
+// after it.
+func strip(hosts string) (string, error) {
+	lines := strings.SplitAfter(hosts, "\n")
+	var b strings.Builder
+	inBlock := false
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == beginMarker:
+			inBlock = true
+		case trimmed == endMarker:
+			inBlock = false
+		case !inBlock:
+			b.WriteString(line)
+		}
+	}
+	if inBlock {
+		return "", fmt.Errorf("%s has %q without %q after it; remove daily's lines from it by hand", HostsPath(), beginMarker, endMarker)
+	}
+	return b.String(), nil
+}
+
+func write(content string) error {
+	// Write in place so the file keeps its owner and mode.
+	if err := os.WriteFile(HostsPath(), []byte(content), 0o644); err != nil {
+		if errors.Is(err, os.ErrPermission) {
+			return ErrPermission
+		}
+		return err
+	}
+	return nil
+}
+
+func flushDNS() {
+	switch runtime.GOOS {
+	case "darwin":
+		_ = exec.Command("dscacheutil", "-flushcache").Run()
+		_ = exec.Command("killall", "-HUP", "mDNSResponder").Run()
+	case "windows":
+		_ = exec.Command("ipconfig", "/flushdns").Run()
+	}
+}
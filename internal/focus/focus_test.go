@@ -0,0 +1,26 @@
+package focus
+
+import "testing"
+
+func TestStrip(t *testing.T) {
+	const section = beginMarker + "\n127.0.0.1 news.example\n::1 news.example\n" + endMarker + "\n"
+	for _, tc := range []struct {
+		name, hosts, want string
+		err               bool
+	}{
+		{name: "no section", hosts: "127.0.0.1 localhost\n", want: "127.0.0.1 localhost\n"},
+		{name: "section at the end", hosts: "127.0.0.1 localhost\n" + section, want: "127.0.0.1 localhost\n"},
+		{name: "section in the middle", hosts: "127.0.0.1 localhost\n" + section + "10.0.0.2 nas\n", want: "127.0.0.1 localhost\n10.0.0.2 nas\n"},
+		{name: "two sections", hosts: section + "10.0.0.2 nas\n" + section, want: "10.0.0.2 nas\n"},
+		{name: "no end marker", hosts: "127.0.0.1 localhost\n" + beginMarker + "\n127.0.0.1 news.example\n10.0.0.2 nas\n", err: true},
+		{name: "cut off in an entry", hosts: beginMarker + "\n127.0.0.1 news.exa", err: true},
+		{name: "stray end marker", hosts: "10.0.0.2 nas\n" + endMarker + "\n", want: "10.0.0.2 nas\n"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := strip(tc.hosts)
+			if (err != nil) != tc.err || got != tc.want {
+				t.Errorf("strip(%q) = %q, %v; want %q, error %v", tc.hosts, got, err, tc.want, tc.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,219 @@
+// Package github fetches a user's recent GitHub activity and summarises the
+// part of it that falls inside a session.
+package github
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultAPI is the GitHub REST endpoint.
+const DefaultAPI = "https://api.github.com"
+
+// Marker starts the summary appended to a session note; notes that already
+// carry it are not enriched again.
+const Marker = "gh:"
+
+// The events API serves at most 300 events from the last 90 days.
+const (
+	perPage  = 100
+	maxPages = 3
+)
+
+// Client reads the events API. With a token, private activity is included and
+// User may be left empty to use the token's owner.
+type Client struct {
+	BaseURL string
+	Token   string
+	User    string
+	HTTP    *http.Client
+}
+
+// Event is one entry of the events feed, reduced to what the summary uses.
+type Event struct {
+	Type    string    `json:"type"`
+	Created time.Time `json:"created_at"`
+	Repo    struct {
+		Name string `json:"name"`
+	} `json:"repo"`
+	Payload struct {
+		Action  string `json:"action"`
+		Size    int    `json:"size"`
+		Commits []struct {
+			Message string `json:"message"`
+		} `json:"commits"`
+		PullRequest *struct {
+			Number int  `json:"number"`
+			Merged bool `json:"merged"`
+		} `json:"pull_request"`
+		Issue *struct {
+			Number int `json:"number"`
+		} `json:"issue"`
+	} `json:"payload"`
+}
+
+// Events returns the user's events created at or after since, oldest first.
+func (c *Client) Events(since time.Time) ([]Event, error) {
+	if c.User == "" {
+		if c.Token == "" {
+			return nil, errors.New("github user unknown: pass --user or set DAILY_GITHUB_TOKEN")
+		}
+		var me struct {
+			Login string `json:"login"`
+		}
+		if err := c.get("/user", &me); err != nil {
+			return nil, err
+		}
+		c.User = me.Login
+	}
+	var out []Event
+	for page := 1; page <= maxPages; page++ {
+		var batch []Event
+		path := fmt.Sprintf("/users/%s/events?per_page=%d&page=%d", c.User, perPage, page)
+		if err := c.get(path, &batch); err != nil {
+			return nil, err
+		}
+		done := len(batch) < perPage
+		for _, e := range batch {
+			if e.Created.Before(since) {
+				done = true
+				continue
+			}
+			out = append(out, e)
+		}
+		if done {
+			break
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Created.Before(out[j].Created) })
+	return out, nil
+}
+
+func (c *Client) get(path string, v any) error {
+	base := c.BaseURL
+	if base == "" {
+		base = DefaultAPI
+	}
+	req, err := http.NewRequest("GET", strings.TrimRight(base, "/")+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	client := c.HTTP
+	if client == nil {
+		client = &http.Client{Timeout: 20 * time.Second}
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+		return fmt.Errorf("github %s: %s", res.Status, strings.TrimSpace(string(msg)))
+	}
+	return json.NewDecoder(res.Body).Decode(v)
+}
+
+// Between returns the events created in [start, end).
+func Between(events []Event, start, end time.Time) []Event {
+	var out []Event
+	for _, e := range events {
+		if !e.Created.Before(start) && e.Created.Before(end) {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// Summary describes events in one line, e.g.
+// "gh: 3 commits to acme/api, opened acme/api#12, reviewed acme/web#8".
+// It returns "" when nothing worth mentioning happened.
+func Summary(events []Event) string {
+	commits := map[string]int{}
+	var repos, items []string
+	seen := map[string]bool{}
+	add := func(s string) {
+		if !seen[s] {
+			seen[s] = true
+			items = append(items, s)
+		}
+	}
+	for _, e := range events {
+		repo := e.Repo.Name
+		p := e.Payload
+		switch e.Type {
+		case "PushEvent":
+			n := len(p.Commits)
+			if p.Size > n {
+				n = p.Size
+			}
+			if n == 0 {
+				n = 1 // the feed no longer lists commits for every push
+			}
+			if _, ok := commits[repo]; !ok {
+				repos = append(repos, repo)
+			}
+			commits[repo] += n
+		case "PullRequestEvent":
+			if p.PullRequest == nil {
+				continue
+			}
+			action := p.Action
+			if action == "closed" && p.PullRequest.Merged {
+				action = "merged"
+			}
+			switch action {
+			case "opened", "merged", "closed", "reopened":
+				add(fmt.Sprintf("%s %s#%d", action, repo, p.PullRequest.Number))
+			}
+		case "PullRequestReviewEvent", "PullRequestReviewCommentEvent":
+			if p.PullRequest != nil {
+				add(fmt.Sprintf("reviewed %s#%d", repo, p.PullRequest.Number))
+			}
+		case "IssuesEvent":
+			if p.Issue != nil && (p.Action == "opened" || p.Action == "closed") {
+				add(fmt.Sprintf("%s issue %s#%d", p.Action, repo, p.Issue.Number))
+			}
+		case "IssueCommentEvent":
+			if p.Issue != nil {
+				add(fmt.Sprintf("commented on %s#%d", repo, p.Issue.Number))
+			}
+		}
+	}
+	var parts []string
+	for _, repo := range repos {
+		word := "commits"
+		if commits[repo] == 1 {
+			word = "commit"
+		}
+		parts = append(parts, fmt.Sprintf("%d %s to %s", commits[repo], word, repo))
+	}
+	parts = append(parts, items...)
+	if len(parts) == 0 {
+		return ""
+	}
+	return Marker + " " + strings.Join(parts, ", ")
+}
+
+// Enriched reports whether note already carries a summary.
+func Enriched(note string) bool {
+	return strings.Contains(note, Marker+" ")
+}
+
+// Attach appends summary to note.
+func Attach(note, summary string) string {
+	if note == "" {
+		return summary
+	}
+	return note + " · " + summary
+}
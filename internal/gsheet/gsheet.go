@@ -0,0 +1,297 @@
+// Package gsheet keeps a tab of a Google Sheets spreadsheet in step with a set
+// of rows, signing in as a service account. Rows are matched by their first
+// cell, so exporting the same days again updates them instead of adding
+// duplicates.
+package gsheet
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// DefaultAPI is the Sheets REST endpoint.
+const DefaultAPI = "https://sheets.googleapis.com/v4/spreadsheets"
+
+const (
+	scope         = "https://www.googleapis.com/auth/spreadsheets"
+	defaultTokens = "https://oauth2.googleapis.com/token"
+)
+
+// Credentials are the parts of a service-account key file that signing in
+// needs. The spreadsheet has to be shared with ClientEmail.
+type Credentials struct {
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+	TokenURI    string `json:"token_uri"`
+}
+
+// LoadCredentials reads a service-account JSON key downloaded from the Google
+// Cloud console.
+func LoadCredentials(path string) (*Credentials, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var c Credentials
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if c.ClientEmail == "" || c.PrivateKey == "" {
+		return nil, fmt.Errorf("%s is not a service-account key (no client_email or private_key)", path)
+	}
+	return &c, nil
+}
+
+// Client talks to the Sheets API for one service account.
+type Client struct {
+	Creds   *Credentials
+	BaseURL string // default DefaultAPI
+	HTTP    *http.Client
+
+	token string
+}
+
+// Sync writes rows to the tab of the spreadsheet, creating the tab, with
+// header as its first row, if needed. A row whose first cell matches an
+// existing row replaces it when anything changed; the others are appended.
+// Cells are strings or float64s and are stored as given.
+func (c *Client) Sync(sheetID, tab string, header []any, rows [][]any) (added, updated int, err error) {
+	existing, err := c.values(sheetID, tab)
+	var apiErr *statusError
+	if errors.As(err, &apiErr) && apiErr.code == http.StatusBadRequest {
+		// A range naming a missing tab is a bad request.
+		if err = c.addTab(sheetID, tab); err == nil {
+			existing, err = nil, nil
+		}
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+
+	index := map[string]int{}
+	for i, row := range existing {
+		if len(row) > 0 {
+			index[fmt.Sprint(row[0])] = i
+		}
+	}
+	var changes []map[string]any
+	var fresh [][]any
+	if len(existing) == 0 {
+		fresh = append(fresh, header)
+	}
+	for _, row := range rows {
+		i, ok := index[fmt.Sprint(row[0])]
+		if !ok {
+			fresh = append(fresh, row)
+			continue
+		}
+		if sameRow(existing[i], row) {
+			continue
+		}
+		changes = append(changes, map[string]any{
+			"range":  fmt.Sprintf("%s!A%d", quoteTab(tab), i+1),
+			"values": [][]any{row},
+		})
+		updated++
+	}
+	if len(changes) > 0 {
+		body := map[string]any{"valueInputOption": "RAW", "data": changes}
+		if err := c.call("POST", "/"+sheetID+"/values:batchUpdate", body, nil); err != nil {
+			return 0, 0, err
+		}
+	}
+	if len(fresh) > 0 {
+		path := fmt.Sprintf("/%s/values/%s:append?valueInputOption=RAW&insertDataOption=INSERT_ROWS", sheetID, url.PathEscape(quoteTab(tab)+"!A1"))
+		if err := c.call("POST", path, map[string]any{"values": fresh}, nil); err != nil {
+			return 0, updated, err
+		}
+		added = len(fresh)
+		if len(existing) == 0 {
+			added-- // the header
+		}
+	}
+	return added, updated, nil
+}
+
+// values reads every row of the tab.
+func (c *Client) values(sheetID, tab string) ([][]any, error) {
+	var out struct {
+		Values [][]any `json:"values"`
+	}
+	path := fmt.Sprintf("/%s/values/%s?valueRenderOption=UNFORMATTED_VALUE", sheetID, url.PathEscape(quoteTab(tab)))
+	if err := c.call("GET", path, nil, &out); err != nil {
+		return nil, err
+	}
+	return out.Values, nil
+}
+
+func (c *Client) addTab(sheetID, tab string) error {
+	body := map[string]any{"requests": []any{
+		map[string]any{"addSheet": map[string]any{"properties": map[string]any{"title": tab}}},
+	}}
+	return c.call("POST", "/"+sheetID+":batchUpdate", body, nil)
+}
+
+// sameRow compares cells as the API returns them: numbers as float64 and
+// trailing empty cells dropped.
+func sameRow(have, want []any) bool {
+	for len(want) > 0 && want[len(want)-1] == "" {
+		want = want[:len(want)-1]
+	}
+	if len(have) != len(want) {
+		return false
+	}
+	for i := range want {
+		if fmt.Sprint(have[i]) != fmt.Sprint(want[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// quoteTab quotes a tab name for use in an A1 range.
+func quoteTab(tab string) string {
+	return "'" + strings.ReplaceAll(tab, "'", "''") + "'"
+}
+
+type statusError struct {
+	code int
+	msg  string
+}
+
+func (e *statusError) Error() string { return e.msg }
+
+// call sends body as JSON and decodes the reply into out when it is not nil.
+func (c *Client) call(method, path string, body, out any) error {
+	if c.token == "" {
+		tok, err := c.signIn()
+		if err != nil {
+			return fmt.Errorf("google sign-in: %w", err)
+		}
+		c.token = tok
+	}
+	var r io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		r = bytes.NewReader(data)
+	}
+	base := c.BaseURL
+	if base == "" {
+		base = DefaultAPI
+	}
+	req, err := http.NewRequest(method, strings.TrimRight(base, "/")+path, r)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Content-Type", "application/json")
+	return c.do(req, out)
+}
+
+// signIn trades a JWT signed with the service account's key for an access
+// token (the OAuth 2.0 JWT bearer flow).
+func (c *Client) signIn() (string, error) {
+	key, err := parseKey(c.Creds.PrivateKey)
+	if err != nil {
+		return "", err
+	}
+	aud := c.Creds.TokenURI
+	if aud == "" {
+		aud = defaultTokens
+	}
+	now := time.Now()
+	enc := base64.RawURLEncoding
+	head, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	claims, _ := json.Marshal(map[string]any{
+		"iss":   c.Creds.ClientEmail,
+		"scope": scope,
+		"aud":   aud,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	unsigned := enc.EncodeToString(head) + "." + enc.EncodeToString(claims)
+	sum := sha256.Sum256([]byte(unsigned))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, sum[:])
+	if err != nil {
+		return "", err
+	}
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {unsigned + "." + enc.EncodeToString(sig)},
+	}
+	req, err := http.NewRequest("POST", aud, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	var out struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := c.do(req, &out); err != nil {
+		return "", err
+	}
+	if out.AccessToken == "" {
+		return "", errors.New("no access token in the reply")
+	}
+	return out.AccessToken, nil
+}
+
+// parseKey reads the PEM private key of a service-account file.
+func parseKey(text string) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(text))
+	if block == nil {
+		return nil, errors.New("private_key is not PEM")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("private_key is not an RSA key")
+	}
+	return key, nil
+}
+
+// do sends req and decodes a 2xx reply into out, turning anything else into
+// an error carrying the reply.
+func (c *Client) do(req *http.Request, out any) error {
+	client := c.HTTP
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+		return &statusError{code: res.StatusCode, msg: fmt.Sprintf("%s: %s", res.Status, strings.TrimSpace(string(msg)))}
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(res.Body).Decode(out)
+}
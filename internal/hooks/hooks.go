@@ -0,0 +1,227 @@
+// Package hooks runs the user's scripts in the hooks directory next to the
+// state file when a session starts or stops, a break starts or the day's goal
+// is reached, passing the event as JSON on stdin.
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/max-pantom/daily/internal/logging"
+	"github.com/max-pantom/daily/internal/state"
+)
+
+// DirName is the hooks directory next to the state file.
+const DirName = "hooks"
+
+// Timeout is how long a hook may run before it is killed.
+const Timeout = 10 * time.Second
+
+// Hook names, which are also the event names.
+const (
+	OnStart = "on-start"
+	OnStop  = "on-stop"
+	OnBreak = "on-break"
+	OnGoal  = "on-goal"
+)
+
+// Event is what a hook gets on stdin.
+type Event struct {
+	Event       string         `json:"event"`
+	Time        time.Time      `json:"time"`
+	Source      string         `json:"source"`            // frontend that made the change, e.g. "tray" or "cli stop"
+	Session     *state.Session `json:"session,omitempty"` // the session started or stopped, or the break started
+	WorkMinutes int            `json:"work_minutes"`      // today so far
+	GoalMinutes int            `json:"goal_minutes"`
+	StatePath   string         `json:"state_path"`
+}
+
+// Dir returns the hooks directory for the given state file.
+func Dir(statePath string) string {
+	return filepath.Join(filepath.Dir(statePath), DirName)
+}
+
+// Changes lists the events in the change from before to after, made by src.
+// A nil before means the previous state is unknown, which fires nothing.
+func Changes(statePath, src string, before, after *state.State, now time.Time) []Event {
+	if before == nil || after == nil {
+		return nil
+	}
+	var out []Event
+	add := func(name string, sess *state.Session) {
+		ev := New(statePath, name, after, now)
+		ev.Source, ev.Session = src, sess
+		out = append(out, ev)
+	}
+	if s := before.ActiveSession; s != nil && (after.ActiveSession == nil || !after.ActiveSession.Start.Equal(s.Start)) {
+		add(OnStop, stopped(after, *s, now))
+	}
+	if b := after.ActiveBreak; b != nil && (before.ActiveBreak == nil || !before.ActiveBreak.Start.Equal(b.Start)) {
+		brk := *b
+		add(OnBreak, &brk)
+	}
+	if t := after.ActiveSession; t != nil && (before.ActiveSession == nil || !before.ActiveSession.Start.Equal(t.Start)) {
+		sess := *t
+		add(OnStart, &sess)
+	}
+	return out
+}
+
+// New returns the event name with today's figures from st, without a source
+// or session.
+func New(statePath, name string, st *state.State, now time.Time) Event {
+	work, _ := st.TodaySummary(now)
+	goal, _ := st.Goal(now)
+	return Event{Event: name, Time: now, WorkMinutes: work, GoalMinutes: goal, StatePath: statePath}
+}
+
+// stopped finds how the session s was logged, or ends it at now when it was
+// dropped, e.g. for being under the minimum length.
+func stopped(st *state.State, s state.Session, now time.Time) *state.Session {
+	for d := s.Start; !d.After(now); d = d.AddDate(0, 0, 1) {
+		log, ok := st.Days[d.Format("2006-01-02")]
+		if !ok {
+			continue
+		}
+		for i := len(log.Sessions) - 1; i >= 0; i-- {
+			if log.Sessions[i].Start.Equal(s.Start) {
+				sess := log.Sessions[i]
+				return &sess
+			}
+		}
+	}
+	s.End = &now
+	return &s
+}
+
+// Run runs the hooks for each event in turn, killing any that takes longer
+// than Timeout. Failures go to the log; they never fail the change that
+// fired them.
+func Run(statePath string, events []Event) {
+	if len(events) == 0 {
+		return
+	}
+	dir := Dir(statePath)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, ev := range events {
+		for _, path := range scripts(dir, entries, ev.Event) {
+			if err := run(path, ev); err != nil {
+				logging.New(statePath, "hooks").Warn("hook failed", "hook", path, "err", err)
+			}
+		}
+	}
+}
+
+// Queue runs batches of events through Run one at a time, in the order they
+// were added, so the hooks for one change finish before those for the next
+// start and a slow hook delays the later ones instead of racing them.
+type Queue struct {
+	statePath string
+	mu        sync.Mutex
+	pending   [][]Event
+	wake      chan struct{}
+}
+
+// NewQueue returns a Queue for the hooks of statePath, with its worker
+// running.
+func NewQueue(statePath string) *Queue {
+	q := &Queue{statePath: statePath, wake: make(chan struct{}, 1)}
+	go q.work()
+	return q
+}
+
+// Add queues events and returns at once; it never waits for a hook.
+func (q *Queue) Add(events []Event) {
+	if len(events) == 0 {
+		return
+	}
+	q.mu.Lock()
+	q.pending = append(q.pending, events)
+	q.mu.Unlock()
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (q *Queue) work() {
+	for range q.wake {
+		for {
+			q.mu.Lock()
+			if len(q.pending) == 0 {
+				q.mu.Unlock()
+				break
+			}
+			events := q.pending[0]
+			q.pending = q.pending[1:]
+			q.mu.Unlock()
+			Run(q.statePath, events)
+		}
+	}
+}
+
+// scripts returns the hooks for name: a file called name, or name with any
+// extension, such as on-stop.sh or on-stop.ps1, in name order.
+func scripts(dir string, entries []os.DirEntry, name string) []string {
+	var out []string
+	for _, e := range entries {
+		n := e.Name()
+		if e.IsDir() || (n != name && !strings.HasPrefix(n, name+".")) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil || (runtime.GOOS != "windows" && info.Mode()&0o111 == 0) {
+			continue // not executable, e.g. a README or a disabled hook
+		}
+		out = append(out, filepath.Join(dir, n))
+	}
+	sort.Strings(out)
+	return out
+}
+
+func run(path string, ev Event) error {
+	input, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), Timeout)
+	defer cancel()
+	var cmd *exec.Cmd
+	if strings.EqualFold(filepath.Ext(path), ".ps1") {
+		cmd = exec.CommandContext(ctx, "powershell", "-NoProfile", "-NonInteractive", "-ExecutionPolicy", "Bypass", "-File", path)
+	} else {
+		cmd = exec.CommandContext(ctx, path)
+	}
+	cmd.Dir = filepath.Dir(path)
+	cmd.Env = append(os.Environ(), "DAILY_EVENT="+ev.Event, "DAILY_STATE="+ev.StatePath)
+	cmd.Stdin = bytes.NewReader(input)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	cmd.WaitDelay = time.Second
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			if len(msg) > 500 {
+				msg = msg[:500] + "…"
+			}
+			return fmt.Errorf("%w: %s", err, msg)
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package hooks
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestQueueOrder queues changes faster than a slow hook runs and checks that
+// they reach it one at a time, in the order they were queued.
+func TestQueueOrder(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the test hook is a shell script")
+	}
+	statePath := filepath.Join(t.TempDir(), "state.json")
+	out := filepath.Join(filepath.Dir(statePath), "ran")
+	if err := os.MkdirAll(Dir(statePath), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	// The lock file catches two copies running at once.
+	script := `#!/bin/sh
+lock="$DAILY_STATE.lock"
+[ -e "$lock" ] && echo overlap >> "` + out + `"
+touch "$lock"
+src=$(sed 's/.*"source":"\([^"]*\)".*/\1/')
+sleep 0.05
+echo "$src" >> "` + out + `"
+rm -f "$lock"
+`
+	if err := os.WriteFile(filepath.Join(Dir(statePath), OnStart), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	q := NewQueue(statePath)
+	var want []string
+	for i := 0; i < 5; i++ {
+		src := fmt.Sprint("change-", i)
+		want = append(want, src)
+		q.Add([]Event{{Event: OnStart, Source: src, StatePath: statePath}})
+	}
+	q.Add(nil)
+
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		b, _ := os.ReadFile(out)
+		got := strings.Fields(string(b))
+		if len(got) >= len(want) || time.Now().After(deadline) {
+			if !slices.Equal(got, want) {
+				t.Fatalf("hooks ran as %q, want %q", got, want)
+			}
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
@@ -0,0 +1,82 @@
+package hotkey
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	skhdBegin = "# >>> daily hotkeys >>>"
+	skhdEnd   = "# <<< daily hotkeys <<<"
+)
+
+func init() {
+	register("darwin", registerSkhd)
+}
+
+// registerSkhd writes the shortcuts into a marked block of ~/.skhdrc and
+// reloads skhd, which grabs the keys without daily needing accessibility
+// access of its own. Without skhd, the user is pointed at Shortcuts.app.
+func registerSkhd(bindings []Binding, exe string, _ func(string)) (func(), error) {
+	noop := func() {}
+	if _, err := exec.LookPath("skhd"); err != nil {
+		return noop, errors.New("global hotkeys on macOS need skhd (brew install koekeishiya/formulae/skhd); or bind `daily toggle` in Shortcuts.app")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return noop, err
+	}
+	path := filepath.Join(home, ".skhdrc")
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return noop, err
+	}
+	var block strings.Builder
+	block.WriteString(skhdBegin + "\n")
+	for _, b := range bindings {
+		fmt.Fprintf(&block, "%s : %q %s\n", skhdKey(b.Combo), exe, b.Action)
+	}
+	block.WriteString(skhdEnd + "\n")
+
+	content := string(data)
+	if i := strings.Index(content, skhdBegin); i >= 0 {
+		if j := strings.Index(content[i:], skhdEnd); j >= 0 {
+			rest := strings.TrimPrefix(content[i+j+len(skhdEnd):], "\n")
+			content = content[:i] + block.String() + rest
+		}
+	} else {
+		if content != "" && !strings.HasSuffix(content, "\n") {
+			content += "\n"
+		}
+		content += block.String()
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		return noop, err
+	}
+	if out, err := exec.Command("skhd", "--reload").CombinedOutput(); err != nil {
+		return noop, fmt.Errorf("skhd --reload: %v: %s", err, strings.TrimSpace(string(out)))
+	}
+	return noop, nil
+}
+
+// skhdKey formats c in skhd syntax, e.g. cmd + shift - d.
+func skhdKey(c Combo) string {
+	var mods []string
+	if c.Cmd {
+		mods = append(mods, "cmd")
+	}
+	if c.Ctrl {
+		mods = append(mods, "ctrl")
+	}
+	if c.Alt {
+		mods = append(mods, "alt")
+	}
+	if c.Shift {
+		mods = append(mods, "shift")
+	}
+	return strings.Join(mods, " + ") + " - " + c.Key
+}
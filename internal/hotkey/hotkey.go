@@ -0,0 +1,161 @@
+// Package hotkey binds global keyboard shortcuts to daily's actions. Windows
+// registers them in-process; GNOME and macOS (through skhd) get a desktop
+// shortcut that runs `daily toggle` or `daily break`, since grabbing keys
+// there needs a window-system library daily does not ship.
+package hotkey
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Actions a hotkey can trigger; each is also a daily command.
+const (
+	Toggle = "toggle" // start or stop tracking
+	Break  = "break"  // start or end a break
+)
+
+// Actions lists the bindable actions.
+var Actions = []string{Toggle, Break}
+
+// Defaults are used until the user sets their own with `daily set-hotkey`.
+var Defaults = map[string]string{Toggle: "cmd+shift+d"}
+
+// Combo is a parsed shortcut such as cmd+shift+d. Cmd is the Command key on
+// macOS and the Super/Windows key elsewhere.
+type Combo struct {
+	Cmd, Ctrl, Alt, Shift bool
+	Key                   string // lower-case letter, digit, f1-f12 or space
+}
+
+// Parse reads a shortcut like "cmd+shift+d" or "ctrl+alt+f8".
+func Parse(s string) (Combo, error) {
+	var c Combo
+	parts := strings.Split(strings.ToLower(strings.ReplaceAll(s, " ", "")), "+")
+	for i, p := range parts {
+		if i == len(parts)-1 {
+			if !validKey(p) {
+				return c, fmt.Errorf("hotkey %q must end in a letter, digit, f1-f12 or space", s)
+			}
+			c.Key = p
+			break
+		}
+		switch p {
+		case "cmd", "super", "win", "meta":
+			c.Cmd = true
+		case "ctrl", "control":
+			c.Ctrl = true
+		case "alt", "opt", "option":
+			c.Alt = true
+		case "shift":
+			c.Shift = true
+		default:
+			return c, fmt.Errorf("unknown modifier %q in hotkey %q", p, s)
+		}
+	}
+	if !c.Cmd && !c.Ctrl && !c.Alt {
+		return c, fmt.Errorf("hotkey %q needs cmd, ctrl or alt so it does not swallow normal typing", s)
+	}
+	return c, nil
+}
+
+func validKey(k string) bool {
+	switch {
+	case len(k) == 1:
+		return k[0] >= 'a' && k[0] <= 'z' || k[0] >= '0' && k[0] <= '9'
+	case k == "space":
+		return true
+	case len(k) >= 2 && k[0] == 'f':
+		var n int
+		_, err := fmt.Sscanf(k[1:], "%d", &n)
+		return err == nil && n >= 1 && n <= 12 && fmt.Sprint(n) == k[1:]
+	}
+	return false
+}
+
+func (c Combo) String() string {
+	var parts []string
+	if c.Cmd {
+		parts = append(parts, "cmd")
+	}
+	if c.Ctrl {
+		parts = append(parts, "ctrl")
+	}
+	if c.Alt {
+		parts = append(parts, "alt")
+	}
+	if c.Shift {
+		parts = append(parts, "shift")
+	}
+	return strings.Join(append(parts, c.Key), "+")
+}
+
+// Binding ties a shortcut to an action.
+type Binding struct {
+	Action string
+	Combo  Combo
+}
+
+// Bindings resolves the configured shortcuts (action -> combo, "off" to
+// disable) over Defaults, skipping ones that do not parse.
+func Bindings(config map[string]string) ([]Binding, []error) {
+	merged := map[string]string{}
+	for a, c := range Defaults {
+		merged[a] = c
+	}
+	for a, c := range config {
+		merged[a] = c
+	}
+	var out []Binding
+	var errs []error
+	for action, s := range merged {
+		if s == "" || s == "off" {
+			continue
+		}
+		c, err := Parse(s)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		out = append(out, Binding{Action: action, Combo: c})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Action < out[j].Action })
+	return out, errs
+}
+
+// ErrUnsupported is returned where no way of binding global keys is known.
+var ErrUnsupported = errors.New("global hotkeys are not supported here; bind `daily toggle` in your desktop's keyboard settings")
+
+// backend installs bindings. Native backends call fire; desktop backends run
+// `exe <action>` instead. The returned function removes what was installed
+// where that matters.
+type backend func(bindings []Binding, exe string, fire func(action string)) (func(), error)
+
+var (
+	mu       sync.Mutex
+	backends = map[string]backend{}
+)
+
+func register(goos string, b backend) {
+	mu.Lock()
+	defer mu.Unlock()
+	backends[goos] = b
+}
+
+// Register installs bindings for this OS. fire is called with the action for
+// shortcuts caught in-process; desktop shortcuts run exe with the action as
+// its command instead. Call the returned function to release in-process
+// shortcuts on exit.
+func Register(bindings []Binding, exe string, fire func(action string)) (func(), error) {
+	mu.Lock()
+	b := backends[runtime.GOOS]
+	mu.Unlock()
+	if b == nil {
+		return func() {}, ErrUnsupported
+	}
+	return b(bindings, exe, fire)
+}
@@ -0,0 +1,97 @@
+package hotkey
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+const (
+	mediaKeys    = "org.gnome.settings-daemon.plugins.media-keys"
+	customSchema = mediaKeys + ".custom-keybinding"
+	customPath   = "/org/gnome/settings-daemon/plugins/media-keys/custom-keybindings/"
+	customPrefix = customPath + "daily-"
+)
+
+func init() {
+	register("linux", registerGNOME)
+}
+
+// registerGNOME adds GNOME custom shortcuts that run `exe <action>`, keeping
+// the user's other custom shortcuts and dropping daily's unbound ones.
+// Nothing needs releasing: the shortcuts belong to the desktop.
+func registerGNOME(bindings []Binding, exe string, _ func(string)) (func(), error) {
+	noop := func() {}
+	if _, err := exec.LookPath("gsettings"); err != nil {
+		return noop, ErrUnsupported
+	}
+	out, err := exec.Command("gsettings", "get", mediaKeys, "custom-keybindings").Output()
+	if err != nil {
+		return noop, fmt.Errorf("gsettings: %v (is this a GNOME session?)", err)
+	}
+	var paths []string
+	for _, p := range quoted.FindAllStringSubmatch(string(out), -1) {
+		if !strings.HasPrefix(p[1], customPrefix) {
+			paths = append(paths, p[1])
+		}
+	}
+	for _, b := range bindings {
+		path := customPrefix + b.Action + "/"
+		schema := customSchema + ":" + path
+		for key, value := range map[string]string{
+			"name":    "Daily " + b.Action,
+			"command": fmt.Sprintf("%q %s", exe, b.Action),
+			"binding": gnomeAccel(b.Combo),
+		} {
+			if err := gsettings(schema, key, gvariant(value)); err != nil {
+				return noop, err
+			}
+		}
+		paths = append(paths, path)
+	}
+	list := make([]string, len(paths))
+	for i, p := range paths {
+		list[i] = gvariant(p)
+	}
+	return noop, gsettings(mediaKeys, "custom-keybindings", "["+strings.Join(list, ", ")+"]")
+}
+
+var quoted = regexp.MustCompile(`'([^']*)'`)
+
+func gsettings(schema, key, value string) error {
+	out, err := exec.Command("gsettings", "set", schema, key, value).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("gsettings set %s %s: %v: %s", schema, key, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// gvariant quotes s as a GVariant string literal.
+func gvariant(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
+
+// gnomeAccel formats c the way GTK accelerators are written, e.g.
+// <Super><Shift>d.
+func gnomeAccel(c Combo) string {
+	var b strings.Builder
+	if c.Cmd {
+		b.WriteString("<Super>")
+	}
+	if c.Ctrl {
+		b.WriteString("<Primary>")
+	}
+	if c.Alt {
+		b.WriteString("<Alt>")
+	}
+	if c.Shift {
+		b.WriteString("<Shift>")
+	}
+	key := c.Key
+	if len(key) > 1 && key[0] == 'f' {
+		key = "F" + key[1:]
+	}
+	b.WriteString(key)
+	return b.String()
+}
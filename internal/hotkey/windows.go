@@ -0,0 +1,126 @@
+//go:build windows
+
+package hotkey
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	user32                = syscall.NewLazyDLL("user32.dll")
+	kernel32              = syscall.NewLazyDLL("kernel32.dll")
+	procRegisterHotKey    = user32.NewProc("RegisterHotKey")
+	procUnregisterHotKey  = user32.NewProc("UnregisterHotKey")
+	procGetMessageW       = user32.NewProc("GetMessageW")
+	procPostThreadMessage = user32.NewProc("PostThreadMessageW")
+	procGetThreadId       = kernel32.NewProc("GetCurrentThreadId")
+)
+
+const (
+	modAlt      = 0x1
+	modControl  = 0x2
+	modShift    = 0x4
+	modWin      = 0x8
+	modNoRepeat = 0x4000
+	wmHotkey    = 0x0312
+	wmQuit      = 0x0012
+)
+
+func init() {
+	register("windows", registerWindows)
+}
+
+// msg mirrors the Win32 MSG struct.
+type msg struct {
+	hwnd    uintptr
+	message uint32
+	wParam  uintptr
+	lParam  uintptr
+	time    uint32
+	pt      struct{ x, y int32 }
+}
+
+// registerWindows registers the shortcuts with RegisterHotKey on a dedicated
+// thread, since WM_HOTKEY is delivered to the thread that registered them.
+func registerWindows(bindings []Binding, _ string, fire func(action string)) (func(), error) {
+	type result struct {
+		tid uintptr
+		err error
+	}
+	ready := make(chan result, 1)
+	go func() {
+		runtime.LockOSThread()
+		defer runtime.UnlockOSThread()
+		tid, _, _ := procGetThreadId.Call()
+
+		var errs []error
+		ids := map[uintptr]string{}
+		for i, b := range bindings {
+			id := uintptr(i + 1)
+			ok, _, err := procRegisterHotKey.Call(0, id, modifiers(b.Combo), vk(b.Combo.Key))
+			if ok == 0 {
+				errs = append(errs, fmt.Errorf("register %s for %s: %v", b.Combo, b.Action, err))
+				continue
+			}
+			ids[id] = b.Action
+		}
+		ready <- result{tid, errors.Join(errs...)}
+		defer func() {
+			for id := range ids {
+				procUnregisterHotKey.Call(0, id)
+			}
+		}()
+
+		var m msg
+		for {
+			r, _, _ := procGetMessageW.Call(uintptr(unsafe.Pointer(&m)), 0, 0, 0)
+			if int32(r) <= 0 { // WM_QUIT or error
+				return
+			}
+			if m.message == wmHotkey {
+				if action, ok := ids[m.wParam]; ok {
+					fire(action)
+				}
+			}
+		}
+	}()
+	r := <-ready
+	stop := func() { procPostThreadMessage.Call(r.tid, wmQuit, 0, 0) }
+	return stop, r.err
+}
+
+func modifiers(c Combo) uintptr {
+	m := uintptr(modNoRepeat)
+	if c.Alt {
+		m |= modAlt
+	}
+	if c.Ctrl {
+		m |= modControl
+	}
+	if c.Shift {
+		m |= modShift
+	}
+	if c.Cmd {
+		m |= modWin
+	}
+	return m
+}
+
+// vk maps a Combo key to its virtual-key code.
+func vk(key string) uintptr {
+	switch {
+	case key == "space":
+		return 0x20
+	case len(key) == 1 && key[0] >= 'a' && key[0] <= 'z':
+		return uintptr(key[0]-'a') + 0x41
+	case len(key) == 1:
+		return uintptr(key[0]-'0') + 0x30
+	}
+	var n int
+	fmt.Sscanf(key[1:], "%d", &n)
+	return uintptr(0x70 + n - 1) // F1 is 0x70
+}
@@ -0,0 +1,171 @@
+package i18n
+
+// catalogs maps each language to translations of the English messages. Keep
+// the verbs in the same order as the English text.
+var catalogs = map[string]map[string]string{
+	"de": {
+		"Forgot to start tracking? You usually start around %s.": "Vergessen, die Zeiterfassung zu starten? Du fängst meist gegen %s an.",
+		"Start now":                   "Jetzt starten",
+		"Started session at %s":       "Sitzung um %s gestartet",
+		" for %s (stops at %s)":       " für %s (endet um %s)",
+		"Started at %s":               "Gestartet um %s",
+		"Stopped session. Logged %s.": "Sitzung beendet. %s erfasst.",
+		"Stopped session. %s is under the minimum, added to the previous session.": "Sitzung beendet. %s liegt unter dem Minimum und wurde der vorigen Sitzung zugeschlagen.",
+		"Stopped session. %s is under the minimum, discarded.":                     "Sitzung beendet. %s liegt unter dem Minimum und wurde verworfen.",
+		"Break started at %s.":                             "Pause um %s begonnen.",
+		"Break started %s":                                 "Pause begonnen %s",
+		"Break over after %s.":                             "Pause nach %s beendet.",
+		"Today: %s logged":                                 "Heute: %s erfasst",
+		" (active %s)":                                     " (aktiv %s)",
+		"Running since %s":                                 "Läuft seit %s",
+		"On break since %s":                                "Pause seit %s",
+		" (%s over)":                                       " (%s drüber)",
+		"Interrupted by %s since %s":                       "Unterbrochen durch %s seit %s",
+		" (daily interrupt to resume)":                     " (daily interrupt zum Fortsetzen)",
+		"Countdown: %s left (stops at %s)":                 "Countdown: noch %s (endet um %s)",
+		"Started focus block %q at %s for %s (ends at %s)": "Fokusblock %q um %s für %s gestartet (endet um %s)",
+		"Focus block %q: %s left (ends at %s)":             "Fokusblock %q: noch %s (endet um %s)",
+		"Time's up: %s is done":                            "Zeit ist um: %s ist erledigt",
+		"Time's up: countdown session stopped":             "Zeit ist um: Countdown-Sitzung beendet",
+		"Goal at ~%s if you keep going":                    "Ziel gegen ~%s, wenn du weitermachst",
+		"Goal: %s | Break interval: %s":                    "Ziel: %s | Pausenintervall: %s",
+		" (%s banked)":                                     " (%s gutgeschrieben)",
+		" (%s to make up)":                                 " (%s nachzuholen)",
+		"Added %s from %s to %s":                           "%s von %s bis %s nachgetragen",
+		" (%s already logged left out)":                    " (%s schon erfasst, ausgelassen)",
+		"Session stopped at %s by the auto-stop rule. Check it with daily review.": "Sitzung um %s durch die Auto-Stopp-Regel beendet. Prüfe sie mit daily review.",
+		"You've hit your %s cap. Time to wrap up.":                                 "Du hast deine Grenze von %s erreicht. Zeit, zum Ende zu kommen.",
+		"%s past your %s cap. Save your work and stop.":                            "%s über deiner Grenze von %s. Speichere und hör auf.",
+		"%s over your %s cap. Please stop now.":                                    "%s über deiner Grenze von %s. Bitte hör jetzt auf.",
+		"Break's over. Time to get back to work.":                                  "Die Pause ist vorbei. Zurück an die Arbeit.",
+		"Your break ran %s over. Time to get back to work.":                        "Deine Pause ist %s zu lang. Zurück an die Arbeit.",
+		"You've worked %s straight. Time for a break?":                             "Du arbeitest seit %s am Stück. Zeit für eine Pause?",
+		"Start break":    "Pause starten",
+		"Snooze %s":      "%s später",
+		"START":          "START",
+		"STOP":           "STOPP",
+		"STATUS":         "STATUS",
+		"BREAK":          "PAUSE",
+		"RELAX":          "ENTSPANNEN",
+		"PAUSED":         "ANGEHALTEN",
+		"RUNNING":        "LÄUFT",
+		"RUN":            "LÄUFT",
+		"%d BREAKS":      "%d PAUSEN",
+		"%s STRAIGHT":    "%s AM STÜCK",
+		"+%s OVER BREAK": "+%s ÜBER DER PAUSE",
+		"GOAL ~%s":       "ZIEL ~%s",
+		"%s BANKED":      "%s GUTGESCHRIEBEN",
+		"%s TO MAKE UP":  "%s NACHZUHOLEN",
+		"+/- goal   [/] break   p sprint   s stats   t day   TAB week   ENTER select   ? help   q quit": "+/- Ziel   [/] Pause   p Sprint   s Statistik   t Tag   TAB Woche   ENTER wählen   ? Hilfe   q Ende",
+	},
+	"es": {
+		"Forgot to start tracking? You usually start around %s.": "¿Olvidaste empezar a registrar? Sueles empezar hacia las %s.",
+		"Start now":                   "Empezar ahora",
+		"Started session at %s":       "Sesión iniciada a las %s",
+		" for %s (stops at %s)":       " durante %s (termina a las %s)",
+		"Started at %s":               "Iniciada a las %s",
+		"Stopped session. Logged %s.": "Sesión detenida. Registrado %s.",
+		"Stopped session. %s is under the minimum, added to the previous session.": "Sesión detenida. %s está por debajo del mínimo; se sumó a la sesión anterior.",
+		"Stopped session. %s is under the minimum, discarded.":                     "Sesión detenida. %s está por debajo del mínimo; se descartó.",
+		"Break started at %s.":                             "Descanso iniciado a las %s.",
+		"Break started %s":                                 "Descanso iniciado %s",
+		"Break over after %s.":                             "Descanso terminado tras %s.",
+		"Today: %s logged":                                 "Hoy: %s registrado",
+		" (active %s)":                                     " (activa %s)",
+		"Running since %s":                                 "En marcha desde las %s",
+		"On break since %s":                                "En descanso desde las %s",
+		" (%s over)":                                       " (%s de más)",
+		"Interrupted by %s since %s":                       "Interrumpido por %s desde las %s",
+		" (daily interrupt to resume)":                     " (daily interrupt para reanudar)",
+		"Countdown: %s left (stops at %s)":                 "Cuenta atrás: quedan %s (termina a las %s)",
+		"Started focus block %q at %s for %s (ends at %s)": "Bloque de concentración %q iniciado a las %s durante %s (termina a las %s)",
+		"Focus block %q: %s left (ends at %s)":             "Bloque de concentración %q: quedan %s (termina a las %s)",
+		"Time's up: %s is done":                            "Se acabó el tiempo: %s ha terminado",
+		"Time's up: countdown session stopped":             "Se acabó el tiempo: sesión de cuenta atrás detenida",
+		"Goal at ~%s if you keep going":                    "Objetivo hacia las ~%s si sigues",
+		"Goal: %s | Break interval: %s":                    "Objetivo: %s | Intervalo de descanso: %s",
+		" (%s banked)":                                     " (%s a favor)",
+		" (%s to make up)":                                 " (%s por recuperar)",
+		"Added %s from %s to %s":                           "%s añadido de %s a %s",
+		" (%s already logged left out)":                    " (%s ya registrado, omitido)",
+		"Session stopped at %s by the auto-stop rule. Check it with daily review.": "Sesión detenida a las %s por la regla de parada automática. Revísala con daily review.",
+		"You've hit your %s cap. Time to wrap up.":                                 "Has llegado a tu límite de %s. Es hora de terminar.",
+		"%s past your %s cap. Save your work and stop.":                            "%s por encima de tu límite de %s. Guarda y para.",
+		"%s over your %s cap. Please stop now.":                                    "%s por encima de tu límite de %s. Para ya, por favor.",
+		"Break's over. Time to get back to work.":                                  "Se acabó el descanso. Hora de volver al trabajo.",
+		"Your break ran %s over. Time to get back to work.":                        "Tu descanso se pasó %s. Hora de volver al trabajo.",
+		"You've worked %s straight. Time for a break?":                             "Llevas %s trabajando sin parar. ¿Un descanso?",
+		"Start break":    "Empezar descanso",
+		"Snooze %s":      "Posponer %s",
+		"START":          "EMPEZAR",
+		"STOP":           "PARAR",
+		"STATUS":         "ESTADO",
+		"BREAK":          "DESCANSO",
+		"RELAX":          "RELAJARSE",
+		"PAUSED":         "EN PAUSA",
+		"RUNNING":        "EN MARCHA",
+		"RUN":            "EN MARCHA",
+		"%d BREAKS":      "%d DESCANSOS",
+		"%s STRAIGHT":    "%s SEGUIDAS",
+		"+%s OVER BREAK": "+%s DE DESCANSO DE MÁS",
+		"GOAL ~%s":       "OBJETIVO ~%s",
+		"%s BANKED":      "%s A FAVOR",
+		"%s TO MAKE UP":  "%s POR RECUPERAR",
+		"+/- goal   [/] break   p sprint   s stats   t day   TAB week   ENTER select   ? help   q quit": "+/- objetivo   [/] descanso   p sprint   s estadísticas   t día   TAB semana   ENTER elegir   ? ayuda   q salir",
+	},
+	"fr": {
+		"Forgot to start tracking? You usually start around %s.": "Vous avez oublié de démarrer le suivi ? Vous commencez d'habitude vers %s.",
+		"Start now":                   "Démarrer",
+		"Started session at %s":       "Session démarrée à %s",
+		" for %s (stops at %s)":       " pour %s (s'arrête à %s)",
+		"Started at %s":               "Démarrée à %s",
+		"Stopped session. Logged %s.": "Session arrêtée. %s enregistrées.",
+		"Stopped session. %s is under the minimum, added to the previous session.": "Session arrêtée. %s est sous le minimum, ajouté à la session précédente.",
+		"Stopped session. %s is under the minimum, discarded.":                     "Session arrêtée. %s est sous le minimum, ignoré.",
+		"Break started at %s.":                             "Pause commencée à %s.",
+		"Break started %s":                                 "Pause commencée %s",
+		"Break over after %s.":                             "Pause terminée après %s.",
+		"Today: %s logged":                                 "Aujourd'hui : %s enregistrées",
+		" (active %s)":                                     " (en cours %s)",
+		"Running since %s":                                 "En cours depuis %s",
+		"On break since %s":                                "En pause depuis %s",
+		" (%s over)":                                       " (%s de trop)",
+		"Interrupted by %s since %s":                       "Interrompu par %s depuis %s",
+		" (daily interrupt to resume)":                     " (daily interrupt pour reprendre)",
+		"Countdown: %s left (stops at %s)":                 "Compte à rebours : encore %s (s'arrête à %s)",
+		"Started focus block %q at %s for %s (ends at %s)": "Bloc de concentration %q démarré à %s pour %s (se termine à %s)",
+		"Focus block %q: %s left (ends at %s)":             "Bloc de concentration %q : encore %s (se termine à %s)",
+		"Time's up: %s is done":                            "Temps écoulé : %s est terminé",
+		"Time's up: countdown session stopped":             "Temps écoulé : session à rebours arrêtée",
+		"Goal at ~%s if you keep going":                    "Objectif vers ~%s si vous continuez",
+		"Goal: %s | Break interval: %s":                    "Objectif : %s | Intervalle de pause : %s",
+		" (%s banked)":                                     " (%s d'avance)",
+		" (%s to make up)":                                 " (%s à rattraper)",
+		"Added %s from %s to %s":                           "%s ajouté de %s à %s",
+		" (%s already logged left out)":                    " (%s déjà enregistré, ignoré)",
+		"Session stopped at %s by the auto-stop rule. Check it with daily review.": "Session arrêtée à %s par la règle d'arrêt automatique. Vérifiez-la avec daily review.",
+		"You've hit your %s cap. Time to wrap up.":                                 "Vous avez atteint votre limite de %s. Il est temps de conclure.",
+		"%s past your %s cap. Save your work and stop.":                            "%s au-delà de votre limite de %s. Enregistrez et arrêtez.",
+		"%s over your %s cap. Please stop now.":                                    "%s au-delà de votre limite de %s. Arrêtez maintenant.",
+		"Break's over. Time to get back to work.":                                  "La pause est finie. Au travail.",
+		"Your break ran %s over. Time to get back to work.":                        "Votre pause a dépassé de %s. Au travail.",
+		"You've worked %s straight. Time for a break?":                             "Vous travaillez depuis %s sans arrêt. Une pause ?",
+		"Start break":    "Commencer la pause",
+		"Snooze %s":      "Rappeler dans %s",
+		"START":          "DÉMARRER",
+		"STOP":           "ARRÊTER",
+		"STATUS":         "ÉTAT",
+		"BREAK":          "PAUSE",
+		"RELAX":          "DÉTENTE",
+		"PAUSED":         "EN PAUSE",
+		"RUNNING":        "EN COURS",
+		"RUN":            "EN COURS",
+		"%d BREAKS":      "%d PAUSES",
+		"%s STRAIGHT":    "%s D'AFFILÉE",
+		"+%s OVER BREAK": "+%s DE PAUSE EN TROP",
+		"GOAL ~%s":       "OBJECTIF ~%s",
+		"%s BANKED":      "%s D'AVANCE",
+		"%s TO MAKE UP":  "%s À RATTRAPER",
+		"+/- goal   [/] break   p sprint   s stats   t day   TAB week   ENTER select   ? help   q quit": "+/- objectif   [/] pause   p sprint   s stats   t jour   TAB semaine   ENTER choisir   ? aide   q quitter",
+	},
+}
@@ -0,0 +1,124 @@
+// Package i18n translates daily's messages and formats clock times for the
+// user's language. Messages are looked up by their English text, so anything
+// without a translation stays in English.
+package i18n
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Languages lists the languages with a catalog, English first.
+var Languages = []string{"en", "de", "es", "fr"}
+
+var (
+	mu      sync.RWMutex
+	lang    = "en"
+	clock24 bool
+)
+
+// The clock settings Use takes besides "", which follows the language.
+const (
+	Clock12 = "12h"
+	Clock24 = "24h"
+)
+
+func init() {
+	Use("", "")
+}
+
+// Use selects language, one of Languages, or the one the environment names
+// (LC_ALL, LC_MESSAGES, then LANG) when it is "". Unknown languages fall back
+// to English. clock, Clock12 or Clock24, overrides the language's clock.
+func Use(language, clock string) {
+	envLang, region := fromEnv()
+	if language == "" {
+		language = envLang
+	}
+	if !Known(language) {
+		language = "en"
+	}
+	if language != envLang {
+		region = ""
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	lang = language
+	switch clock {
+	case Clock12:
+		clock24 = false
+	case Clock24:
+		clock24 = true
+	default:
+		clock24 = !(language == "en" && twelveHour[region])
+	}
+}
+
+// Known reports whether there is a catalog for language.
+func Known(language string) bool {
+	for _, l := range Languages {
+		if l == language {
+			return true
+		}
+	}
+	return false
+}
+
+// Language is the language in use.
+func Language() string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return lang
+}
+
+// twelveHour are the English-speaking regions that read clocks as 3:04PM;
+// the rest, like the other languages, use 15:04.
+var twelveHour = map[string]bool{"": true, "US": true, "CA": true, "AU": true, "NZ": true, "PH": true, "IN": true}
+
+// fromEnv parses a locale such as de_DE.UTF-8 into its language and region.
+func fromEnv() (language, region string) {
+	var locale string
+	for _, key := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if locale = os.Getenv(key); locale != "" {
+			break
+		}
+	}
+	locale, _, _ = strings.Cut(locale, ".")
+	locale, _, _ = strings.Cut(locale, "@")
+	language, region, _ = strings.Cut(locale, "_")
+	if language == "C" || language == "POSIX" {
+		return "en", ""
+	}
+	return strings.ToLower(language), strings.ToUpper(region)
+}
+
+// T translates msg and, given args, formats it with fmt.Sprintf.
+func T(msg string, args ...any) string {
+	mu.RLock()
+	if s, ok := catalogs[lang][msg]; ok {
+		msg = s
+	}
+	mu.RUnlock()
+	if len(args) == 0 {
+		return msg
+	}
+	return fmt.Sprintf(msg, args...)
+}
+
+// Time formats the clock time of t as 3:04PM or 15:04, as the language reads it.
+func Time(t time.Time) string {
+	return t.Format(TimeLayout())
+}
+
+// TimeLayout is the time layout Time uses.
+func TimeLayout() string {
+	mu.RLock()
+	defer mu.RUnlock()
+	if clock24 {
+		return "15:04"
+	}
+	return time.Kitchen
+}
@@ -0,0 +1,49 @@
+package idle
+
+import (
+	"errors"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func init() {
+	Register("darwin", ProviderFunc{ID: "ioreg", Fn: idleDarwin})
+	RegisterLock("darwin", lockedDarwin)
+}
+
+// lockedDarwin looks for the console session's screen lock flag.
+func lockedDarwin() (bool, error) {
+	out, err := exec.Command("ioreg", "-n", "Root", "-d1").Output()
+	if err != nil {
+		return false, err
+	}
+	return strings.Contains(string(out), `"CGSSessionScreenIsLocked"=Yes`), nil
+}
+
+func idleDarwin() (time.Duration, error) {
+	// ioreg returns nanoseconds in HIDIdleTime
+	out, err := exec.Command("ioreg", "-c", "IOHIDSystem").Output()
+	if err != nil {
+		return 0, err
+	}
+	lines := strings.Split(string(out), "\n")
+	for _, line := range lines {
+		if strings.Contains(line, "HIDIdleTime") {
+			fields := strings.Fields(line)
+			if len(fields) == 0 {
+				continue
+			}
+			valStr := fields[len(fields)-1]
+			// trim commas
+			valStr = strings.Trim(valStr, ",")
+			ns, err := strconv.ParseInt(valStr, 10, 64)
+			if err != nil {
+				continue
+			}
+			return time.Duration(ns), nil
+		}
+	}
+	return 0, errors.New("HIDIdleTime not found")
+}
@@ -1,65 +1,104 @@
+// Package idle reports how long the user has been away from keyboard and mouse.
+// Platform backends register themselves as providers; Duration picks the first
+// one that works on the current OS.
 package idle
 
 import (
-	"bytes"
 	"errors"
-	"os/exec"
+	"fmt"
+	"os"
 	"runtime"
-	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
-// Duration returns approximate system idle time.
-// Supports macOS (ioreg) and Linux (xprintidle). Returns error if unavailable.
-func Duration() (time.Duration, error) {
-	switch runtime.GOOS {
-	case "darwin":
-		return idleDarwin()
-	case "linux":
-		return idleLinux()
-	default:
-		return 0, errors.New("idle detection not supported")
-	}
+// Provider is one way of measuring idle time.
+type Provider interface {
+	Name() string
+	Idle() (time.Duration, error)
 }
 
-func idleDarwin() (time.Duration, error) {
-	// ioreg returns nanoseconds in HIDIdleTime
-	out, err := exec.Command("ioreg", "-c", "IOHIDSystem").Output()
-	if err != nil {
-		return 0, err
-	}
-	lines := strings.Split(string(out), "\n")
-	for _, line := range lines {
-		if strings.Contains(line, "HIDIdleTime") {
-			fields := strings.Fields(line)
-			if len(fields) == 0 {
-				continue
-			}
-			valStr := fields[len(fields)-1]
-			// trim commas
-			valStr = strings.Trim(valStr, ",")
-			ns, err := strconv.ParseInt(valStr, 10, 64)
-			if err != nil {
-				continue
-			}
-			return time.Duration(ns), nil
+// ProviderFunc adapts a function to Provider.
+type ProviderFunc struct {
+	ID string
+	Fn func() (time.Duration, error)
+}
+
+func (p ProviderFunc) Name() string                 { return p.ID }
+func (p ProviderFunc) Idle() (time.Duration, error) { return p.Fn() }
+
+// MockEnv names the environment variable that replaces every provider with the
+// mock one, so watch can be exercised without a GUI session. See Mock.
+const MockEnv = "DAILY_IDLE_MOCK"
+
+type registration struct {
+	goos string
+	p    Provider
+}
+
+var (
+	mu       sync.Mutex
+	registry []registration
+	selected Provider
+)
+
+// Register adds a provider for goos ("" for any OS). Providers are tried in
+// registration order.
+func Register(goos string, p Provider) {
+	mu.Lock()
+	defer mu.Unlock()
+	registry = append(registry, registration{goos: goos, p: p})
+	selected = nil
+}
+
+// Providers returns the names of the providers registered for this OS.
+func Providers() []string {
+	mu.Lock()
+	defer mu.Unlock()
+	var names []string
+	for _, r := range registry {
+		if r.goos == "" || r.goos == runtime.GOOS {
+			names = append(names, r.p.Name())
 		}
 	}
-	return 0, errors.New("HIDIdleTime not found")
+	return names
 }
 
-func idleLinux() (time.Duration, error) {
-	cmd := exec.Command("xprintidle")
-	var buf bytes.Buffer
-	cmd.Stdout = &buf
-	if err := cmd.Run(); err != nil {
-		return 0, err
+// Duration returns approximate system idle time from the first provider that
+// works, remembering it for later calls; when it stops working the others are
+// tried again. Returns error if none is available.
+func Duration() (time.Duration, error) {
+	if v := os.Getenv(MockEnv); v != "" {
+		return Mock(v).Idle()
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	var errs []string
+	failed := ""
+	if selected != nil {
+		d, err := selected.Idle()
+		if err == nil {
+			return d, nil
+		}
+		// The backend that worked so far failed, e.g. its tool vanished or the
+		// display went away; look for another one.
+		errs = append(errs, fmt.Sprintf("%s: %v", selected.Name(), err))
+		failed, selected = selected.Name(), nil
 	}
-	msStr := strings.TrimSpace(buf.String())
-	ms, err := strconv.ParseInt(msStr, 10, 64)
-	if err != nil {
-		return 0, err
+	for _, r := range registry {
+		if r.goos != "" && r.goos != runtime.GOOS || r.p.Name() == failed {
+			continue
+		}
+		d, err := r.p.Idle()
+		if err == nil {
+			selected = r.p
+			return d, nil
+		}
+		errs = append(errs, fmt.Sprintf("%s: %v", r.p.Name(), err))
+	}
+	if len(errs) == 0 {
+		return 0, errors.New("idle detection not supported")
 	}
-	return time.Duration(ms) * time.Millisecond, nil
+	return 0, errors.New("no idle backend available (" + strings.Join(errs, "; ") + ")")
 }
@@ -0,0 +1,116 @@
+package idle
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestMock(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "idle")
+	for _, tc := range []struct {
+		name    string
+		mock    string
+		file    string // written to file first when set
+		idle    time.Duration
+		idleErr bool
+		locked  bool
+		lockErr bool
+	}{
+		{name: "duration", mock: "15m", idle: 15 * time.Minute, lockErr: true},
+		{name: "zero", mock: "0s", lockErr: true},
+		{name: "locked", mock: "locked", idleErr: true, locked: true},
+		{name: "unlocked", mock: "unlocked", idleErr: true},
+		{name: "file duration", mock: file, file: "5m\n", idle: 5 * time.Minute, lockErr: true},
+		{name: "file locked", mock: file, file: "locked", idleErr: true, locked: true},
+		{name: "file garbage", mock: file, file: "soon", idleErr: true, lockErr: true},
+		{name: "missing file", mock: filepath.Join(t.TempDir(), "none"), idleErr: true, lockErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.file != "" {
+				if err := os.WriteFile(file, []byte(tc.file), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			t.Setenv(MockEnv, tc.mock)
+			d, err := Duration()
+			if (err != nil) != tc.idleErr || d != tc.idle {
+				t.Errorf("Duration() = %v, %v; want %v, error %v", d, err, tc.idle, tc.idleErr)
+			}
+			locked, err := Locked()
+			if (err != nil) != tc.lockErr || locked != tc.locked {
+				t.Errorf("Locked() = %v, %v; want %v, error %v", locked, err, tc.locked, tc.lockErr)
+			}
+		})
+	}
+}
+
+// TestMockFollowsFile checks that a file mock is read again on every call,
+// which is what lets a test drive watch while it runs.
+func TestMockFollowsFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "idle")
+	t.Setenv(MockEnv, file)
+	for _, want := range []time.Duration{0, 90 * time.Second, 0} {
+		if err := os.WriteFile(file, []byte(want.String()), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if d, err := Duration(); err != nil || d != want {
+			t.Fatalf("Duration() = %v, %v; want %v", d, err, want)
+		}
+	}
+}
+
+// TestDurationFallsBack checks that a provider that stops working is dropped
+// for the next one, and tried again once that fails too.
+func TestDurationFallsBack(t *testing.T) {
+	t.Setenv(MockEnv, "")
+	mu.Lock()
+	saved, savedSel := registry, selected
+	registry, selected = nil, nil
+	mu.Unlock()
+	t.Cleanup(func() {
+		mu.Lock()
+		registry, selected = saved, savedSel
+		mu.Unlock()
+	})
+
+	firstOK, secondOK := true, true
+	Register(runtime.GOOS, ProviderFunc{"first", func() (time.Duration, error) {
+		if !firstOK {
+			return 0, errors.New("gone")
+		}
+		return time.Minute, nil
+	}})
+	Register("", ProviderFunc{"second", func() (time.Duration, error) {
+		if !secondOK {
+			return 0, errors.New("gone")
+		}
+		return 2 * time.Minute, nil
+	}})
+	Register("plan9-not-this-os", ProviderFunc{"other", func() (time.Duration, error) { return time.Hour, nil }})
+
+	if got := Providers(); len(got) != 2 {
+		t.Fatalf("Providers() = %v, want first and second", got)
+	}
+	steps := []struct {
+		firstOK, secondOK bool
+		want              time.Duration
+		err               bool
+	}{
+		{true, true, time.Minute, false},
+		{false, true, 2 * time.Minute, false},
+		{true, true, 2 * time.Minute, false}, // sticks with the one that works
+		{true, false, time.Minute, false},
+		{false, false, 0, true},
+	}
+	for i, s := range steps {
+		firstOK, secondOK = s.firstOK, s.secondOK
+		d, err := Duration()
+		if (err != nil) != s.err || d != s.want {
+			t.Errorf("step %d: Duration() = %v, %v; want %v, error %v", i, d, err, s.want, s.err)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package idle
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+func init() {
+	Register("linux", ProviderFunc{ID: "xprintidle", Fn: idleXprintidle})
+	Register("linux", ProviderFunc{ID: "dbus", Fn: idleDBus})
+	Register("linux", ProviderFunc{ID: "input", Fn: idleInput})
+	RegisterLock("linux", lockedLoginctl)
+	RegisterLock("linux", lockedScreenSaver)
+}
+
+// lockedLoginctl reads logind's LockedHint, which GNOME, KDE and most lock
+// screens set.
+func lockedLoginctl() (bool, error) {
+	session := os.Getenv("XDG_SESSION_ID")
+	if session == "" {
+		session = "self"
+	}
+	out, err := exec.Command("loginctl", "show-session", session, "-p", "LockedHint", "--value").Output()
+	if err != nil {
+		return false, err
+	}
+	return strings.TrimSpace(string(out)) == "yes", nil
+}
+
+// lockedScreenSaver asks the freedesktop screensaver whether it is active.
+func lockedScreenSaver() (bool, error) {
+	out, err := exec.Command("gdbus", "call", "--session",
+		"--dest", "org.freedesktop.ScreenSaver",
+		"--object-path", "/org/freedesktop/ScreenSaver",
+		"--method", "org.freedesktop.ScreenSaver.GetActive").Output()
+	if err != nil {
+		return false, err
+	}
+	// Output looks like "(true,)".
+	return strings.Contains(string(out), "true"), nil
+}
+
+func idleXprintidle() (time.Duration, error) {
+	cmd := exec.Command("xprintidle")
+	var buf bytes.Buffer
+	cmd.Stdout = &buf
+	if err := cmd.Run(); err != nil {
+		return 0, err
+	}
+	msStr := strings.TrimSpace(buf.String())
+	ms, err := strconv.ParseInt(msStr, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(ms) * time.Millisecond, nil
+}
+
+// idleDBus asks GNOME's Mutter IdleMonitor, which works on Wayland where
+// xprintidle cannot.
+func idleDBus() (time.Duration, error) {
+	out, err := exec.Command("gdbus", "call", "--session",
+		"--dest", "org.gnome.Mutter.IdleMonitor",
+		"--object-path", "/org/gnome/Mutter/IdleMonitor/Core",
+		"--method", "org.gnome.Mutter.IdleMonitor.GetIdletime").Output()
+	if err != nil {
+		return 0, err
+	}
+	// Output looks like "(uint64 12345,)".
+	s := strings.Trim(strings.TrimSpace(string(out)), "(),")
+	s = strings.TrimSpace(strings.TrimPrefix(s, "uint64"))
+	ms, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(ms) * time.Millisecond, nil
+}
+
+// inputMonitor tracks the last keyboard or mouse event seen on /dev/input.
+var inputMonitor struct {
+	once sync.Once
+	err  error
+	last atomic.Int64 // unix nanoseconds
+}
+
+// idleInput measures idle time from /dev/input event devices, for headless
+// and non-X11 sessions. Reading them usually requires membership in the
+// "input" group.
+func idleInput() (time.Duration, error) {
+	inputMonitor.once.Do(func() {
+		inputMonitor.err = startInputMonitor()
+	})
+	if inputMonitor.err != nil {
+		return 0, inputMonitor.err
+	}
+	return time.Since(time.Unix(0, inputMonitor.last.Load())), nil
+}
+
+func startInputMonitor() error {
+	devices, err := inputDevices()
+	if err != nil {
+		return err
+	}
+	if len(devices) == 0 {
+		return errors.New("no keyboard or mouse found in /proc/bus/input/devices")
+	}
+	inputMonitor.last.Store(time.Now().UnixNano())
+	opened := 0
+	for _, dev := range devices {
+		f, err := os.Open(dev)
+		if err != nil {
+			continue
+		}
+		opened++
+		go func() {
+			defer f.Close()
+			buf := make([]byte, 64)
+			for {
+				if _, err := f.Read(buf); err != nil {
+					return
+				}
+				inputMonitor.last.Store(time.Now().UnixNano())
+			}
+		}()
+	}
+	if opened == 0 {
+		return fmt.Errorf("cannot read %s (add your user to the input group)", strings.Join(devices, ", "))
+	}
+	return nil
+}
+
+// inputDevices lists the event devices of keyboards and mice.
+func inputDevices() ([]string, error) {
+	f, err := os.Open("/proc/bus/input/devices")
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var devices []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := sc.Text()
+		if !strings.HasPrefix(line, "H: Handlers=") {
+			continue
+		}
+		handlers := strings.Fields(strings.TrimPrefix(line, "H: Handlers="))
+		wanted := false
+		for _, h := range handlers {
+			if h == "kbd" || strings.HasPrefix(h, "mouse") {
+				wanted = true
+			}
+		}
+		if !wanted {
+			continue
+		}
+		for _, h := range handlers {
+			if strings.HasPrefix(h, "event") {
+				devices = append(devices, filepath.Join("/dev/input", h))
+			}
+		}
+	}
+	return devices, sc.Err()
+}
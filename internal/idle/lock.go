@@ -0,0 +1,35 @@
+package idle
+
+import (
+	"errors"
+	"os"
+	"runtime"
+)
+
+var lockChecks = map[string][]func() (bool, error){}
+
+// RegisterLock adds a screen lock check for goos, tried in registration order.
+func RegisterLock(goos string, fn func() (bool, error)) {
+	mu.Lock()
+	defer mu.Unlock()
+	lockChecks[goos] = append(lockChecks[goos], fn)
+}
+
+// Locked reports whether the screen is locked. It is the fallback for when
+// idle time cannot be measured, so it shares none of the idle backends' tools.
+func Locked() (bool, error) {
+	if v := os.Getenv(MockEnv); v != "" {
+		return Mock(v).Locked()
+	}
+	mu.Lock()
+	checks := lockChecks[runtime.GOOS]
+	mu.Unlock()
+	err := errors.New("screen lock detection not supported")
+	for _, check := range checks {
+		var locked bool
+		if locked, err = check(); err == nil {
+			return locked, nil
+		}
+	}
+	return false, err
+}
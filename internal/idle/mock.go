@@ -0,0 +1,63 @@
+package idle
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Mock is a provider driven by a string, normally the DAILY_IDLE_MOCK
+// environment variable. A duration such as "15m" is reported as-is; anything
+// else is read as a file holding a duration, so a test can change the idle
+// time while watch runs:
+//
+//	echo 0s > /tmp/idle && DAILY_IDLE_MOCK=/tmp/idle daily watch --idle 1 --interval 1s &
+//	echo 5m > /tmp/idle   # watch auto-pauses on its next poll
+//
+// "locked" and "unlocked" fail Idle but answer Locked, to exercise the lock
+// fallback.
+type Mock string
+
+func (m Mock) Name() string { return "mock" }
+
+func (m Mock) Idle() (time.Duration, error) {
+	v, err := m.value()
+	if err != nil {
+		return 0, err
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, fmt.Errorf("mock idle: %w", err)
+	}
+	return d, nil
+}
+
+// Locked reports a mocked screen lock.
+func (m Mock) Locked() (bool, error) {
+	v, err := m.value()
+	if err != nil {
+		return false, err
+	}
+	switch v {
+	case "locked":
+		return true, nil
+	case "unlocked":
+		return false, nil
+	}
+	return false, fmt.Errorf("mock lock: %q is not locked or unlocked", v)
+}
+
+// value is the mock string itself when it parses as a duration or lock state,
+// otherwise the contents of the file it names.
+func (m Mock) value() (string, error) {
+	v := string(m)
+	if _, err := time.ParseDuration(v); err == nil || v == "locked" || v == "unlocked" {
+		return v, nil
+	}
+	data, err := os.ReadFile(v)
+	if err != nil {
+		return "", fmt.Errorf("mock idle: %w", err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
@@ -0,0 +1,37 @@
+//go:build windows
+
+package idle
+
+import (
+	"errors"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+var (
+	user32               = syscall.NewLazyDLL("user32.dll")
+	kernel32             = syscall.NewLazyDLL("kernel32.dll")
+	procGetLastInputInfo = user32.NewProc("GetLastInputInfo")
+	procGetTickCount     = kernel32.NewProc("GetTickCount")
+)
+
+func init() {
+	Register("windows", ProviderFunc{ID: "windows", Fn: idleWindows})
+}
+
+// lastInputInfo mirrors the Win32 LASTINPUTINFO struct.
+type lastInputInfo struct {
+	size uint32
+	time uint32
+}
+
+func idleWindows() (time.Duration, error) {
+	info := lastInputInfo{size: uint32(unsafe.Sizeof(lastInputInfo{}))}
+	if ok, _, err := procGetLastInputInfo.Call(uintptr(unsafe.Pointer(&info))); ok == 0 {
+		return 0, errors.New("GetLastInputInfo: " + err.Error())
+	}
+	now, _, _ := procGetTickCount.Call()
+	// Both are millisecond tick counts that wrap together after ~49 days.
+	return time.Duration(uint32(now)-info.time) * time.Millisecond, nil
+}
@@ -0,0 +1,190 @@
+// Package ipc defines the control protocol spoken on the daemon's unix socket.
+//
+// Each message is one JSON object per line. A client writes a Request and reads
+// one Response, and may send further requests on the same connection. After a
+// subscribe request the daemon instead writes a Response carrying an Event for
+// every change until the client disconnects. For example:
+//
+//	$ echo '{"op":"start","tags":["review"],"for":"25m"}' | nc -U ~/.config/daily/daemon.sock
+//	{"revision":4,"status":{"working":true,...}}
+package ipc
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net"
+	"time"
+
+	"github.com/max-pantom/daily/internal/state"
+)
+
+// Operations understood by the daemon.
+const (
+	OpStatus    = "status"    // report the current Status
+	OpStart     = "start"     // start a session, ending a break first
+	OpStop      = "stop"      // stop the running session
+	OpBreak     = "break"     // start a break, or end the current one
+	OpSubscribe = "subscribe" // stream an Event after every change
+	OpGet       = "get"       // full state, used by daily's own frontends
+	OpPut       = "put"       // replace the state if Revision is current
+)
+
+// Event types delivered to subscribers.
+const (
+	EventStarted      = "started"
+	EventStopped      = "stopped"
+	EventBreakStarted = "break_started"
+	EventBreakEnded   = "break_ended"
+	EventChanged      = "changed" // anything else, e.g. goal or tags
+)
+
+// Request is one line sent by a client.
+type Request struct {
+	Op     string `json:"op"`
+	Source string `json:"source,omitempty"` // who is asking, for the audit log; default "socket"
+
+	// start
+	Tags    []string `json:"tags,omitempty"`
+	Note    string   `json:"note,omitempty"`
+	Project string   `json:"project,omitempty"`
+	For     string   `json:"for,omitempty"`   // countdown, e.g. "25m"
+	Force   bool     `json:"force,omitempty"` // start past a strict daily cap
+
+	// put
+	Revision uint64       `json:"revision,omitempty"`
+	State    *state.State `json:"state,omitempty"`
+}
+
+// Response is one line sent back by the daemon.
+type Response struct {
+	Revision uint64       `json:"revision"`
+	Status   *Status      `json:"status,omitempty"`
+	Event    *Event       `json:"event,omitempty"`
+	State    *state.State `json:"state,omitempty"`
+	Error    string       `json:"error,omitempty"`
+	Conflict bool         `json:"conflict,omitempty"`
+}
+
+// Status is a summary of today suitable for status bars and plugins.
+type Status struct {
+	Working          bool       `json:"working"`
+	OnBreak          bool       `json:"on_break"`
+	Since            *time.Time `json:"since,omitempty"`
+	Tags             []string   `json:"tags,omitempty"`
+	Note             string     `json:"note,omitempty"`
+	Project          string     `json:"project,omitempty"`
+	TodayMinutes     int        `json:"today_minutes"`
+	GoalMinutes      int        `json:"goal_minutes"`
+	RemainingSeconds int        `json:"remaining_seconds,omitempty"` // countdown sessions only
+}
+
+// Event describes a change pushed to subscribers.
+type Event struct {
+	Type   string    `json:"type"`
+	Time   time.Time `json:"time"`
+	Status Status    `json:"status"`
+}
+
+// ErrConflict is returned for a put whose revision is stale.
+var ErrConflict = errors.New("state changed by another process; try again")
+
+// StatusOf summarizes st at now.
+func StatusOf(st *state.State, now time.Time) Status {
+	work, _ := st.TodaySummary(now)
+	goal, _ := st.Goal(now)
+	s := Status{TodayMinutes: work, GoalMinutes: goal}
+	if a := st.ActiveSession; a != nil {
+		since := a.Start
+		s.Working = true
+		s.Since = &since
+		s.Tags = a.Tags
+		s.Note = a.Note
+		s.Project = a.Project
+		if left, ok := st.Remaining(now); ok {
+			s.RemainingSeconds = int(left.Seconds())
+		}
+	}
+	if b := st.ActiveBreak; b != nil {
+		since := b.Start
+		s.OnBreak = true
+		s.Since = &since
+	}
+	return s
+}
+
+// EventType classifies the change from before to after.
+func EventType(before, after *state.State) string {
+	switch {
+	case before.ActiveSession == nil && after.ActiveSession != nil:
+		return EventStarted
+	case before.ActiveBreak == nil && after.ActiveBreak != nil:
+		return EventBreakStarted
+	case before.ActiveSession != nil && after.ActiveSession == nil:
+		return EventStopped
+	case before.ActiveBreak != nil && after.ActiveBreak == nil:
+		return EventBreakEnded
+	}
+	return EventChanged
+}
+
+// Client is a connection to the daemon.
+type Client struct {
+	conn net.Conn
+	enc  *json.Encoder
+	dec  *json.Decoder
+}
+
+// Dial connects to the daemon socket.
+func Dial(socket string, timeout time.Duration) (*Client, error) {
+	conn, err := net.DialTimeout("unix", socket, timeout)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{conn: conn, enc: json.NewEncoder(conn), dec: json.NewDecoder(bufio.NewReader(conn))}, nil
+}
+
+// Close closes the connection.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// Call sends req and waits for the response.
+func (c *Client) Call(req Request) (Response, error) {
+	if err := c.enc.Encode(req); err != nil {
+		return Response{}, err
+	}
+	var resp Response
+	if err := c.dec.Decode(&resp); err != nil {
+		return Response{}, err
+	}
+	if resp.Conflict {
+		return resp, ErrConflict
+	}
+	if resp.Error != "" {
+		return resp, errors.New(resp.Error)
+	}
+	return resp, nil
+}
+
+// Subscribe turns the connection into an event stream. The channel is closed
+// when the connection ends.
+func (c *Client) Subscribe() (<-chan Event, error) {
+	if err := c.enc.Encode(Request{Op: OpSubscribe}); err != nil {
+		return nil, err
+	}
+	events := make(chan Event)
+	go func() {
+		defer close(events)
+		for {
+			var resp Response
+			if err := c.dec.Decode(&resp); err != nil {
+				return
+			}
+			if resp.Event != nil {
+				events <- *resp.Event
+			}
+		}
+	}()
+	return events, nil
+}
@@ -0,0 +1,96 @@
+// Package logging writes leveled logs for the long-running commands (daemon,
+// watch, tray, sprint) to a rotated file next to the state, since their output
+// is lost once they run detached.
+package logging
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+)
+
+const (
+	// FileName is the active log file inside Dir.
+	FileName = "daily.log"
+
+	maxSize = 1 << 20 // rotate after 1 MiB
+	keep    = 3       // daily.log.1 .. daily.log.3
+)
+
+// Dir returns the log directory for the given state file.
+func Dir(statePath string) string {
+	return filepath.Join(filepath.Dir(statePath), "logs")
+}
+
+// Path returns the active log file for the given state file.
+func Path(statePath string) string {
+	return filepath.Join(Dir(statePath), FileName)
+}
+
+// New returns a logger that tags records with component and appends them to
+// the log file. Logging never fails the caller: if the file cannot be opened
+// the logger discards records.
+func New(statePath, component string) *slog.Logger {
+	w := &rotatingFile{path: Path(statePath)}
+	if err := os.MkdirAll(Dir(statePath), 0o755); err != nil {
+		return slog.New(slog.NewTextHandler(io.Discard, nil))
+	}
+	h := slog.NewTextHandler(w, &slog.HandlerOptions{Level: slog.LevelInfo})
+	return slog.New(h).With("component", component)
+}
+
+// rotatingFile appends to path, rotating it by size. Several processes share
+// the file, so it reopens whenever another process has rotated it.
+type rotatingFile struct {
+	path string
+
+	mu sync.Mutex
+	f  *os.File
+}
+
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.ensureOpen(); err != nil {
+		return 0, err
+	}
+	if info, err := r.f.Stat(); err == nil && info.Size()+int64(len(p)) > maxSize {
+		r.rotate()
+		if err := r.ensureOpen(); err != nil {
+			return 0, err
+		}
+	}
+	return r.f.Write(p)
+}
+
+// ensureOpen opens the file, or reopens it when the path no longer points at
+// the file we hold.
+func (r *rotatingFile) ensureOpen() error {
+	if r.f != nil {
+		held, err1 := r.f.Stat()
+		onDisk, err2 := os.Stat(r.path)
+		if err1 == nil && err2 == nil && os.SameFile(held, onDisk) {
+			return nil
+		}
+		r.f.Close()
+		r.f = nil
+	}
+	f, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	r.f = f
+	return nil
+}
+
+func (r *rotatingFile) rotate() {
+	r.f.Close()
+	r.f = nil
+	for i := keep - 1; i >= 1; i-- {
+		_ = os.Rename(r.path+"."+strconv.Itoa(i), r.path+"."+strconv.Itoa(i+1))
+	}
+	_ = os.Rename(r.path, r.path+".1")
+}
@@ -0,0 +1,152 @@
+// Package media detects whether a camera or microphone is in use, which
+// usually means a call is running even though the keyboard is idle.
+package media
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CacheFor is how long an answer from InUse is reused. The probes run tools
+// and walk every process's open files, too much for each poll of watch.
+const CacheFor = 30 * time.Second
+
+var (
+	mu      sync.Mutex
+	checked time.Time
+	last    bool
+	lastErr error
+)
+
+// InUse reports whether the camera or microphone is currently in use.
+// Supports macOS (input audio engines and camera stream log events) and Linux
+// (PulseAudio/PipeWire recording streams and open /dev/video devices).
+func InUse() (bool, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	now := time.Now()
+	if !checked.IsZero() && now.Sub(checked) < CacheFor {
+		return last, lastErr
+	}
+	switch runtime.GOOS {
+	case "darwin":
+		last, lastErr = inUseDarwin(now)
+	case "linux":
+		last, lastErr = inUseLinux()
+	default:
+		last, lastErr = false, errors.New("camera/microphone detection not supported")
+	}
+	checked = now
+	return last, lastErr
+}
+
+func inUseDarwin(now time.Time) (bool, error) {
+	out, err := exec.Command("ioreg", "-r", "-c", "IOAudioEngine", "-l", "-w0").Output()
+	if err != nil {
+		return false, err
+	}
+	if inputRunning(out) {
+		return true, nil
+	}
+	return cameraStreamingDarwin(now)
+}
+
+// inputRunning reports whether ioreg's listing of audio engines has a running
+// one that records, rather than plays: music playing is no call. An engine
+// records when its class says Input or one of its streams has direction 1.
+func inputRunning(out []byte) bool {
+	type engine struct{ input, running bool }
+	var engines []engine
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	sc.Buffer(nil, 1<<20)
+	for sc.Scan() {
+		line := sc.Text()
+		switch {
+		case strings.Contains(line, "+-o ") && strings.Contains(line, "Engine"):
+			class := line[strings.Index(line, "+-o "):]
+			engines = append(engines, engine{input: strings.Contains(class, "Input")})
+		case len(engines) == 0:
+		case strings.Contains(line, `"IOAudioEngineState" = 1`):
+			engines[len(engines)-1].running = true
+		case strings.Contains(line, `"IOAudioStreamDirection" = 1`):
+			engines[len(engines)-1].input = true
+		}
+	}
+	for _, e := range engines {
+		if e.input && e.running {
+			return true
+		}
+	}
+	return false
+}
+
+// The camera's state as of cameraAt, from the log events seen so far.
+var (
+	camera   bool
+	cameraAt time.Time
+)
+
+// cameraStreamingDarwin looks for the camera assistant's stream start/stop
+// events and reports whether the latest one is a start. Only the log written
+// since the previous call is read; the first call looks back an hour.
+func cameraStreamingDarwin(now time.Time) (bool, error) {
+	args := []string{"show", "--style", "compact", "--predicate", `eventMessage CONTAINS "kCameraStream"`}
+	if cameraAt.IsZero() {
+		args = append(args, "--last", "1h")
+	} else {
+		args = append(args, "--start", cameraAt.Format("2006-01-02 15:04:05"))
+	}
+	out, err := exec.Command("log", args...).Output()
+	if err != nil {
+		return false, err
+	}
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	for sc.Scan() {
+		line := sc.Text()
+		switch {
+		case strings.Contains(line, "kCameraStreamStart"):
+			camera = true
+		case strings.Contains(line, "kCameraStreamStop"):
+			camera = false
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return false, err
+	}
+	cameraAt = now
+	return camera, nil
+}
+
+func inUseLinux() (bool, error) {
+	// Source outputs are streams recording from a microphone; asking
+	// PulseAudio is much cheaper than walking every process's files.
+	out, err := exec.Command("pactl", "list", "short", "source-outputs").Output()
+	if err == nil && len(bytes.TrimSpace(out)) > 0 {
+		return true, nil
+	}
+	if cameraOpenLinux() {
+		return true, nil
+	}
+	return false, err
+}
+
+// cameraOpenLinux reports whether any process we can inspect holds a
+// /dev/video device open.
+func cameraOpenLinux() bool {
+	fds, _ := filepath.Glob("/proc/[0-9]*/fd/*")
+	for _, fd := range fds {
+		target, err := os.Readlink(fd)
+		if err == nil && strings.HasPrefix(target, "/dev/video") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,52 @@
+package media
+
+import "testing"
+
+func TestInputRunning(t *testing.T) {
+	const (
+		output = `+-o AppleHDAEngineOutput  <class AppleHDAEngineOutput, id 0x1, registered, matched, active, busy 0, retain 9>
+    {
+      "IOAudioEngineState" = 1
+    }
+  +-o AppleHDAStream  <class AppleHDAStream, id 0x2>
+      {
+        "IOAudioStreamDirection" = 0
+      }
+`
+		inputIdle = `+-o AppleHDAEngineInput  <class AppleHDAEngineInput, id 0x3, registered, matched, active, busy 0, retain 9>
+    {
+      "IOAudioEngineState" = 0
+    }
+`
+		inputBusy = `+-o AppleHDAEngineInput  <class AppleHDAEngineInput, id 0x3, registered, matched, active, busy 0, retain 9>
+    {
+      "IOAudioEngineState" = 1
+    }
+`
+		usbMic = `+-o AppleUSBAudioEngine  <class AppleUSBAudioEngine, id 0x4, registered, matched, active, busy 0, retain 9>
+    {
+      "IOAudioEngineState" = 1
+    }
+  +-o AppleUSBAudioStream  <class AppleUSBAudioStream, id 0x5>
+      {
+        "IOAudioStreamDirection" = 1
+      }
+`
+	)
+	for _, tc := range []struct {
+		name string
+		out  string
+		want bool
+	}{
+		{"nothing", "", false},
+		{"music playing", output, false},
+		{"microphone idle while music plays", output + inputIdle, false},
+		{"microphone running", output + inputBusy, true},
+		{"usb microphone by stream direction", usbMic, true},
+		{"microphone running before output", inputBusy + output, true},
+	} {
+		if got := inputRunning([]byte(tc.out)); got != tc.want {
+			t.Errorf("%s: inputRunning = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package notify
+
+import (
+	"context"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// Action is a button offered on a notification.
+type Action struct {
+	Key   string // returned by SendActions when clicked
+	Label string // button text
+}
+
+// SendActions shows a notification with buttons and blocks until the user
+// picks one or dismisses it, returning the chosen key ("" when dismissed or
+// when buttons are unsupported). Linux needs notify-send with --action support
+// (libnotify 0.7.9+); macOS needs terminal-notifier. Without them it falls back
+// to a plain notification, as it does with a custom command set by Use. Push
+// services get a plain copy; the buttons stay on the desktop.
+func SendActions(title, message string, actions []Action) string {
+	if len(localProvider().command) > 0 {
+		Send(title, message)
+		return ""
+	}
+	wait := sendAll(title, message, false)
+	defer wait()
+	plain := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+		defer cancel()
+		_ = desktop(ctx, title, message)
+	}
+	switch runtime.GOOS {
+	case "linux":
+		out, err := exec.Command("notify-send", notifySendArgs(title, message, actions)...).Output()
+		if err != nil {
+			// Older notify-send rejects --action; show the reminder anyway.
+			plain()
+			return ""
+		}
+		return matchAction(strings.TrimSpace(string(out)), actions)
+	case "darwin":
+		if _, err := exec.LookPath("terminal-notifier"); err != nil {
+			plain()
+			return ""
+		}
+		out, err := exec.Command("terminal-notifier", terminalNotifierArgs(title, message, actions)...).Output()
+		if err != nil {
+			return ""
+		}
+		return matchAction(strings.TrimSpace(string(out)), actions)
+	default:
+		plain()
+		return ""
+	}
+}
+
+// notifySendArgs are notify-send's arguments for a notification, waiting for
+// a click on one of actions when there are any. The -- keeps a title or
+// message starting with a dash from being read as an option.
+func notifySendArgs(title, message string, actions []Action) []string {
+	var args []string
+	if len(actions) > 0 {
+		args = append(args, "--wait", "--app-name=Daily")
+		for _, a := range actions {
+			args = append(args, "--action="+a.Key+"="+a.Label)
+		}
+	}
+	return append(args, "--", title, message)
+}
+
+// terminalNotifierArgs are terminal-notifier's arguments for a notification
+// offering actions as buttons.
+func terminalNotifierArgs(title, message string, actions []Action) []string {
+	labels := make([]string, len(actions))
+	for i, a := range actions {
+		labels[i] = a.Label
+	}
+	return []string{
+		"-title", notifierText(title),
+		"-message", notifierText(message),
+		"-actions", strings.Join(labels, ","),
+		"-timeout", "300",
+	}
+}
+
+// notifierText escapes a leading dash, which terminal-notifier would
+// otherwise take for an option.
+func notifierText(s string) string {
+	if strings.HasPrefix(s, "-") {
+		return "\\" + s
+	}
+	return s
+}
+
+// matchAction maps the helper's output, a key on Linux or a label on macOS,
+// back to an action key.
+func matchAction(out string, actions []Action) string {
+	for _, a := range actions {
+		if out == a.Key || out == a.Label {
+			return a.Key
+		}
+	}
+	return ""
+}
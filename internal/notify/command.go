@@ -0,0 +1,65 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+)
+
+// runCommand shows the notification with a custom command, split into words
+// by splitWords and run without a shell; {title} and {message} in any word
+// are replaced.
+func runCommand(ctx context.Context, command []string, title, message string) error {
+	if len(command) == 0 {
+		return errors.New("empty command")
+	}
+	r := strings.NewReplacer("{title}", title, "{message}", message)
+	args := make([]string, len(command))
+	for i, a := range command {
+		args[i] = r.Replace(a)
+	}
+	return exec.CommandContext(ctx, args[0], args[1:]...).Run()
+}
+
+// splitWords splits s on blanks, keeping quoted text together: 'single'
+// quotes are literal, inside "double" ones \" and \\ are escapes and any
+// other backslash is kept, so Windows paths need no doubling.
+func splitWords(s string) []string {
+	var words []string
+	var cur strings.Builder
+	inWord := false
+	var quote rune
+	escaped := false
+	for _, r := range s {
+		switch {
+		case escaped:
+			if r != '"' && r != '\\' {
+				cur.WriteRune('\\')
+			}
+			cur.WriteRune(r)
+			escaped = false
+		case quote == '"' && r == '\\':
+			escaped = true
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			cur.WriteRune(r)
+		case r == '\'' || r == '"':
+			quote, inWord = r, true
+		case r == ' ' || r == '\t':
+			if inWord {
+				words = append(words, cur.String())
+				cur.Reset()
+				inWord = false
+			}
+		default:
+			cur.WriteRune(r)
+			inWord = true
+		}
+	}
+	if inWord {
+		words = append(words, cur.String())
+	}
+	return words
+}
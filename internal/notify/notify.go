@@ -1,36 +1,188 @@
 package notify
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
 	"os/exec"
 	"runtime"
+	"sync"
+	"time"
 )
 
-// Send best-effort desktop notification. Falls back silently if unavailable.
+// Provider delivers a notification somewhere: this desktop, a custom
+// command, or a push service that reaches a phone.
+type Provider interface {
+	Name() string
+	Notify(ctx context.Context, title, message string) error
+}
+
+// Config selects the providers. Secrets come from the environment.
+type Config struct {
+	Commands map[string]string // custom desktop command by GOOS or "default"
+	Push     string            // Ntfy, Pushover, Telegram or "" for none
+	PushTo   string            // ntfy topic URL, Pushover user key or Telegram chat ID
+}
+
+// sendTimeout bounds one delivery, so a hung command or push service cannot
+// stall the reminder loop that sent it.
+const sendTimeout = 30 * time.Second
+
+var (
+	mu     sync.Mutex
+	active = []Provider{local{}}
+)
+
+// Use selects the providers Send delivers to: the custom command for this
+// platform, or the desktop notifier, plus the push service when one is set
+// up. A push service missing its token is left out; Providers reports why.
+func Use(cfg Config) {
+	providers, _ := Providers(cfg, os.Getenv)
+	mu.Lock()
+	defer mu.Unlock()
+	active = providers
+}
+
+// Providers returns the providers cfg describes, with the error that kept
+// the push service out, if any.
+func Providers(cfg Config, env func(string) string) ([]Provider, error) {
+	c, ok := cfg.Commands[runtime.GOOS]
+	if !ok {
+		c = cfg.Commands["default"]
+	}
+	providers := []Provider{local{command: splitWords(c)}}
+	if cfg.Push == "" {
+		return providers, nil
+	}
+	push, err := NewPush(cfg, env)
+	if err != nil {
+		return providers, err
+	}
+	return append(providers, push), nil
+}
+
+// Send best-effort notification through every provider set by Use, at once.
+// Failures are dropped; the desktop one falls back silently if unavailable.
 func Send(title, message string) {
+	wait := sendAll(title, message, true)
+	wait()
+}
+
+// Local shows the notification on this machine only, for feedback on
+// something just done at it, such as a shortcut, that a phone need not get.
+func Local(title, message string) {
+	ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+	defer cancel()
+	_ = localProvider().Notify(ctx, title, message)
+}
+
+// localProvider is the provider for this machine set by Use.
+func localProvider() local {
+	mu.Lock()
+	defer mu.Unlock()
+	for _, p := range active {
+		if p, ok := p.(local); ok {
+			return p
+		}
+	}
+	return local{}
+}
+
+// sendAll starts delivering to the providers, or only the push ones, and
+// returns a func that waits for them.
+func sendAll(title, message string, desktop bool) func() {
+	mu.Lock()
+	providers := active
+	mu.Unlock()
+	var wg sync.WaitGroup
+	for _, p := range providers {
+		if _, ok := p.(local); ok && !desktop {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+			defer cancel()
+			_ = p.Notify(ctx, title, message)
+		}()
+	}
+	return wg.Wait
+}
+
+// local shows notifications on this machine, with the custom command when
+// there is one and it works.
+type local struct {
+	command []string
+}
+
+func (l local) Name() string {
+	if len(l.command) > 0 {
+		return "command"
+	}
+	return "desktop"
+}
+
+func (l local) Notify(ctx context.Context, title, message string) error {
+	if len(l.command) > 0 {
+		err := runCommand(ctx, l.command, title, message)
+		if err == nil {
+			return nil
+		}
+		if desktop(ctx, title, message) == nil {
+			return fmt.Errorf("notify command: %v (shown by the desktop notifier instead)", err)
+		}
+		return fmt.Errorf("notify command: %w", err)
+	}
+	return desktop(ctx, title, message)
+}
+
+// desktop shows a native notification.
+func desktop(ctx context.Context, title, message string) error {
 	switch runtime.GOOS {
 	case "darwin":
-		// osascript native notification
-		_ = exec.Command("osascript", "-e", `display notification "`+escape(message)+`" with title "`+escape(title)+`"`).Run()
+		return osascript(ctx, title, message).Run()
 	case "linux":
-		_ = exec.Command("notify-send", title, message).Run()
-	default:
-		// no-op for other platforms
+		return exec.CommandContext(ctx, "notify-send", notifySendArgs(title, message, nil)...).Run()
+	case "windows":
+		return toast(ctx, title, message).Run()
 	}
+	return errors.New("no desktop notifications on " + runtime.GOOS)
 }
 
-func escape(s string) string {
-	// Minimal escaping for osascript quotes.
-	return escapeQuotes(s)
+// toastApp is the app a Windows toast is shown as. Toasts need a registered
+// AppUserModelID; PowerShell's is present on every install.
+const toastApp = `{1AC14E77-02E7-4E5D-B744-2EB1AE5198B7}\WindowsPowerShell\v1.0\powershell.exe`
+
+// toastScript shows a toast through the WinRT notification API. The text
+// comes in through the environment, so it needs no quoting.
+const toastScript = `$m = [Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime]
+$x = $m::GetTemplateContent([Windows.UI.Notifications.ToastTemplateType]::ToastText02)
+$t = $x.GetElementsByTagName('text')
+$null = $t.Item(0).AppendChild($x.CreateTextNode($env:DAILY_TITLE))
+$null = $t.Item(1).AppendChild($x.CreateTextNode($env:DAILY_MESSAGE))
+$m::CreateToastNotifier('` + toastApp + `').Show([Windows.UI.Notifications.ToastNotification]::new($x))`
+
+func toast(ctx context.Context, title, message string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, "powershell", "-NoProfile", "-NonInteractive", "-Command", toastScript)
+	cmd.Env = append(os.Environ(), "DAILY_TITLE="+title, "DAILY_MESSAGE="+message)
+	return cmd
 }
 
-func escapeQuotes(s string) string {
-	out := make([]rune, 0, len(s))
-	for _, r := range s {
-		if r == '"' {
-			out = append(out, '\\', '"')
-		} else {
-			out = append(out, r)
-		}
+// notificationScript shows a native macOS notification. The title and
+// message are handed to it as arguments rather than spliced into the
+// source, so quotes, backslashes and the like cannot break out of it.
+var notificationScript = []string{
+	"on run argv",
+	"display notification (item 2 of argv) with title (item 1 of argv)",
+	"end run",
+}
+
+func osascript(ctx context.Context, title, message string) *exec.Cmd {
+	var args []string
+	for _, line := range notificationScript {
+		args = append(args, "-e", line)
 	}
-	return string(out)
+	return exec.CommandContext(ctx, "osascript", append(args, "--", title, message)...)
 }
@@ -0,0 +1,143 @@
+package notify
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// hostile are titles and messages that would break out of a shell line, an
+// AppleScript string or an argument list if they were spliced in.
+var hostile = []string{
+	`plain`,
+	`say "hi"`,
+	`it's`,
+	`C:\Users\me\`,
+	`\" & do shell script "touch /tmp/pwned" & "`,
+	`" & (do shell script "id") & "`,
+	"end run\ndo shell script \"id\"",
+	"two\nlines",
+	`-title`,
+	`--help`,
+	`-`,
+	`$(id) ; rm -rf ~ | cat`,
+}
+
+func TestOsascript(t *testing.T) {
+	for _, title := range hostile {
+		for _, message := range hostile {
+			args := osascript(context.Background(), title, message).Args
+			n := len(args)
+			if n < 4 || args[n-3] != "--" || args[n-2] != title || args[n-1] != message {
+				t.Fatalf("osascript(%q, %q) = %q; want the text as the last arguments, after --", title, message, args)
+			}
+			// The script is fixed; the text never becomes part of it.
+			var script []string
+			for i := 1; i < n-3; i += 2 {
+				if args[i] != "-e" {
+					t.Fatalf("osascript(%q, %q) = %q; want only -e lines before --", title, message, args)
+				}
+				script = append(script, args[i+1])
+			}
+			if !slices.Equal(script, notificationScript) {
+				t.Fatalf("osascript(%q, %q) script = %q, want %q", title, message, script, notificationScript)
+			}
+		}
+	}
+}
+
+func TestNotifySendArgs(t *testing.T) {
+	actions := []Action{{"snooze", "Snooze 10m"}, {"stop", "Stop"}}
+	for _, title := range hostile {
+		for _, message := range hostile {
+			want := []string{"--", title, message}
+			if got := notifySendArgs(title, message, nil); !slices.Equal(got, want) {
+				t.Errorf("notifySendArgs(%q, %q) = %q, want %q", title, message, got, want)
+			}
+			want = append([]string{"--wait", "--app-name=Daily", "--action=snooze=Snooze 10m", "--action=stop=Stop"}, want...)
+			if got := notifySendArgs(title, message, actions); !slices.Equal(got, want) {
+				t.Errorf("notifySendArgs(%q, %q, actions) = %q, want %q", title, message, got, want)
+			}
+		}
+	}
+}
+
+func TestTerminalNotifierArgs(t *testing.T) {
+	actions := []Action{{"snooze", "Snooze 10m"}, {"stop", "Stop"}}
+	for _, title := range hostile {
+		for _, message := range hostile {
+			got := terminalNotifierArgs(title, message, actions)
+			want := []string{
+				"-title", notifierText(title),
+				"-message", notifierText(message),
+				"-actions", "Snooze 10m,Stop",
+				"-timeout", "300",
+			}
+			if !slices.Equal(got, want) {
+				t.Fatalf("terminalNotifierArgs(%q, %q) = %q, want %q", title, message, got, want)
+			}
+			// Values sit in option slots and never look like options.
+			for i := 1; i < len(got); i += 2 {
+				if strings.HasPrefix(got[i], "-") {
+					t.Errorf("terminalNotifierArgs(%q, %q): value %q would be read as an option", title, message, got[i])
+				}
+			}
+		}
+	}
+}
+
+func TestNotifierText(t *testing.T) {
+	for in, want := range map[string]string{
+		"":                    "",
+		"plain":               "plain",
+		"-title":              `\-title`,
+		"--help":              `\--help`,
+		"a -b":                "a -b",
+		`\-x`:                 `\-x`,
+		`say "hi"`:            `say "hi"`,
+		"-two\nlines":         "\\-two\nlines",
+		`" & do shell script`: `" & do shell script`,
+	} {
+		if got := notifierText(in); got != want {
+			t.Errorf("notifierText(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+// TestDesktopLinux runs desktop against a stand-in notify-send that records
+// the arguments it gets, so the text reaches it untouched and unsplit.
+func TestDesktopLinux(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("notify-send is used on Linux only")
+	}
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no sh")
+	}
+	dir := t.TempDir()
+	out := filepath.Join(dir, "args")
+	script := "#!/bin/sh\nfor a in \"$@\"; do printf '%s\\0' \"$a\"; done > \"$ARGS_OUT\"\n"
+	if err := os.WriteFile(filepath.Join(dir, "notify-send"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir)
+	t.Setenv("ARGS_OUT", out)
+	for _, text := range hostile {
+		if err := desktop(context.Background(), text, text+"\n"+text); err != nil {
+			t.Fatal(err)
+		}
+		b, err := os.ReadFile(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := strings.Split(strings.TrimSuffix(string(b), "\x00"), "\x00")
+		want := []string{"--", text, text + "\n" + text}
+		if !slices.Equal(got, want) {
+			t.Errorf("notify-send got %q, want %q", got, want)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Push services.
+const (
+	Ntfy     = "ntfy"
+	Pushover = "pushover"
+	Telegram = "telegram"
+)
+
+// NewPush returns the push service cfg describes. Pushover takes the app
+// token and Telegram the bot token from DAILY_PUSH_TOKEN; ntfy needs one only
+// for protected topics.
+func NewPush(cfg Config, env func(string) string) (Provider, error) {
+	token := env("DAILY_PUSH_TOKEN")
+	if cfg.PushTo == "" {
+		return nil, fmt.Errorf("no push target for %s (daily config set push.to ...)", cfg.Push)
+	}
+	switch cfg.Push {
+	case Ntfy:
+		topic := cfg.PushTo
+		if !strings.HasPrefix(topic, "https://") && !strings.HasPrefix(topic, "http://") {
+			topic = "https://ntfy.sh/" + topic
+		}
+		return &ntfy{url: topic, token: token}, nil
+	case Pushover, Telegram:
+		if token == "" {
+			return nil, fmt.Errorf("%s needs DAILY_PUSH_TOKEN", cfg.Push)
+		}
+		if cfg.Push == Pushover {
+			return &pushover{user: cfg.PushTo, token: token}, nil
+		}
+		return &telegram{chat: cfg.PushTo, token: token}, nil
+	}
+	return nil, fmt.Errorf("unknown push service %q (want %s, %s or %s)", cfg.Push, Ntfy, Pushover, Telegram)
+}
+
+// ntfy publishes to a topic on ntfy.sh or a self-hosted server.
+type ntfy struct {
+	url, token string
+}
+
+func (n *ntfy) Name() string { return Ntfy }
+
+func (n *ntfy) Notify(ctx context.Context, title, message string) error {
+	req, err := http.NewRequestWithContext(ctx, "POST", n.url, strings.NewReader(message))
+	if err != nil {
+		return err
+	}
+	// Headers are ASCII; RFC 2047 carries anything else, which ntfy decodes.
+	req.Header.Set("Title", mime.BEncoding.Encode("UTF-8", title))
+	req.Header.Set("Tags", "stopwatch")
+	if n.token != "" {
+		req.Header.Set("Authorization", "Bearer "+n.token)
+	}
+	return do(req, Ntfy)
+}
+
+// pushover sends through the Pushover API to a user or group key.
+type pushover struct {
+	user, token string
+}
+
+func (p *pushover) Name() string { return Pushover }
+
+func (p *pushover) Notify(ctx context.Context, title, message string) error {
+	form := url.Values{"token": {p.token}, "user": {p.user}, "title": {title}, "message": {message}}
+	return postForm(ctx, "https://api.pushover.net/1/messages.json", form, Pushover)
+}
+
+// telegram sends as a bot to a chat the bot was started in.
+type telegram struct {
+	chat, token string
+}
+
+func (t *telegram) Name() string { return Telegram }
+
+func (t *telegram) Notify(ctx context.Context, title, message string) error {
+	form := url.Values{"chat_id": {t.chat}, "text": {title + "\n" + message}}
+	return postForm(ctx, "https://api.telegram.org/bot"+t.token+"/sendMessage", form, Telegram)
+}
+
+func postForm(ctx context.Context, endpoint string, form url.Values, service string) error {
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return do(req, service)
+}
+
+// do sends req, turning a non-2xx answer into an error with the start of its
+// body, which is where these services explain what was wrong.
+func do(req *http.Request, service string) error {
+	req.Header.Set("User-Agent", "daily")
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		// Don't leak a token in the URL (Telegram) into logs.
+		var uerr *url.Error
+		if errors.As(err, &uerr) {
+			err = uerr.Err
+		}
+		return fmt.Errorf("%s: %w", service, err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		return nil
+	}
+	body, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+	return fmt.Errorf("%s: %s: %s", service, res.Status, strings.TrimSpace(string(body)))
+}
@@ -0,0 +1,119 @@
+// Package power notices when the machine was asleep. Sleep is detected by the
+// wall clock running ahead of the monotonic clock, which stops during suspend;
+// the exact moment the machine went to sleep comes from logind on Linux and
+// the pmset log on macOS when available.
+package power
+
+import (
+	"bufio"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// minSleep is the smallest clock gap treated as a suspend.
+const minSleep = time.Minute
+
+// Monitor tracks suspends between calls to Check.
+type Monitor struct {
+	prev time.Time
+
+	mu         sync.Mutex
+	preparedAt time.Time // last PrepareForSleep seen from logind
+}
+
+// Sleep is a detected suspend.
+type Sleep struct {
+	Start time.Time // when the machine went to sleep
+	End   time.Time // when it woke up
+}
+
+// NewMonitor starts tracking from now. On Linux it also listens for logind's
+// PrepareForSleep signal to learn the exact sleep time.
+func NewMonitor() *Monitor {
+	m := &Monitor{prev: time.Now()}
+	if runtime.GOOS == "linux" {
+		go m.listenLogind()
+	}
+	return m
+}
+
+// Check reports a suspend since the previous call.
+func (m *Monitor) Check(now time.Time) (Sleep, bool) {
+	prev := m.prev
+	m.prev = now
+	asleep := now.Round(0).Sub(prev.Round(0)) - now.Sub(prev)
+	if asleep < minSleep {
+		return Sleep{}, false
+	}
+	// Without a better source, the machine was last seen awake at prev.
+	start := prev.Round(0)
+	if exact, ok := m.sleepTime(prev.Round(0), now.Round(0)); ok {
+		start = exact
+	}
+	return Sleep{Start: start, End: start.Add(asleep)}, true
+}
+
+// sleepTime returns when the machine went to sleep between from and to.
+func (m *Monitor) sleepTime(from, to time.Time) (time.Time, bool) {
+	m.mu.Lock()
+	prepared := m.preparedAt
+	m.mu.Unlock()
+	if prepared.After(from) && prepared.Before(to) {
+		return prepared, true
+	}
+	if runtime.GOOS == "darwin" {
+		return pmsetSleep(from, to)
+	}
+	return time.Time{}, false
+}
+
+// listenLogind records PrepareForSleep(true) signals from systemd-logind.
+func (m *Monitor) listenLogind() {
+	cmd := exec.Command("gdbus", "monitor", "--system",
+		"--dest", "org.freedesktop.login1",
+		"--object-path", "/org/freedesktop/login1")
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return
+	}
+	if err := cmd.Start(); err != nil {
+		return
+	}
+	sc := bufio.NewScanner(out)
+	for sc.Scan() {
+		// e.g. "/org/freedesktop/login1: org.freedesktop.login1.Manager.PrepareForSleep (true,)"
+		line := sc.Text()
+		if strings.Contains(line, "PrepareForSleep") && strings.Contains(line, "(true") {
+			m.mu.Lock()
+			m.preparedAt = time.Now().Round(0)
+			m.mu.Unlock()
+		}
+	}
+	cmd.Wait()
+}
+
+// pmsetSleep finds the last "Sleep" entry in the macOS power log between from
+// and to.
+func pmsetSleep(from, to time.Time) (time.Time, bool) {
+	out, err := exec.Command("pmset", "-g", "log").Output()
+	if err != nil {
+		return time.Time{}, false
+	}
+	var found time.Time
+	sc := bufio.NewScanner(strings.NewReader(string(out)))
+	for sc.Scan() {
+		// e.g. "2024-05-01 22:41:05 +0200 Sleep  \tEntering Sleep state due to 'Idle Sleep'..."
+		line := sc.Text()
+		if len(line) < 31 || strings.TrimSpace(line[25:31]) != "Sleep" {
+			continue
+		}
+		t, err := time.Parse("2006-01-02 15:04:05 -0700", line[:25])
+		if err == nil && t.After(from) && t.Before(to) {
+			found = t
+		}
+	}
+	return found, !found.IsZero()
+}
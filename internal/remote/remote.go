@@ -0,0 +1,264 @@
+// Package remote stores one encrypted blob, the synced history, on a remote
+// backend: an S3 bucket, a WebDAV share or any server that takes HTTP PUT and
+// GET. Writes are conditional on the version last read, so two machines
+// syncing at once cannot overwrite each other unnoticed.
+package remote
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Backends.
+const (
+	S3     = "s3"
+	WebDAV = "webdav"
+	HTTP   = "http"
+)
+
+var (
+	// ErrNotFound means nothing has been uploaded yet.
+	ErrNotFound = errors.New("nothing stored yet")
+	// ErrConflict means the blob changed since it was read.
+	ErrConflict = errors.New("changed on the remote since it was read")
+)
+
+// Store reads and writes the blob. Get returns its version (an ETag), which
+// Put takes to write only over that version; "" writes only if there is none.
+type Store interface {
+	Get() (data []byte, version string, err error)
+	Put(data []byte, version string) (newVersion string, err error)
+}
+
+// Config selects and addresses a backend. Secrets come from the environment.
+type Config struct {
+	Backend string // S3, WebDAV or HTTP
+	URL     string // the object, e.g. https://bucket.s3.eu-west-1.amazonaws.com/daily.bin
+	User    string // WebDAV/HTTP basic auth
+	Region  string // S3
+}
+
+// New returns the store cfg describes. S3 takes AWS_ACCESS_KEY_ID,
+// AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN; WebDAV and HTTP take the
+// password for User from DAILY_SYNC_PASSWORD.
+func New(cfg Config, env func(string) string) (Store, error) {
+	if cfg.URL == "" {
+		return nil, errors.New("no sync URL (daily sync setup --url)")
+	}
+	if !strings.HasPrefix(cfg.URL, "https://") && !strings.HasPrefix(cfg.URL, "http://") {
+		return nil, fmt.Errorf("sync URL %q is not http(s)", cfg.URL)
+	}
+	client := &http.Client{Timeout: 30 * time.Second}
+	switch cfg.Backend {
+	case S3:
+		s := &s3Store{url: cfg.URL, region: cfg.Region, key: env("AWS_ACCESS_KEY_ID"), secret: env("AWS_SECRET_ACCESS_KEY"), token: env("AWS_SESSION_TOKEN"), http: client}
+		if s.region == "" {
+			s.region = "us-east-1"
+		}
+		if s.key == "" || s.secret == "" {
+			return nil, errors.New("S3 needs AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY")
+		}
+		return s, nil
+	case WebDAV, HTTP:
+		return &httpStore{url: cfg.URL, user: cfg.User, password: env("DAILY_SYNC_PASSWORD"), dav: cfg.Backend == WebDAV, http: client}, nil
+	}
+	return nil, fmt.Errorf("unknown sync backend %q (want %s, %s or %s)", cfg.Backend, S3, WebDAV, HTTP)
+}
+
+// Sealed blobs start with magic, then the salt and nonce.
+const (
+	magic      = "DLYSYNC1"
+	saltSize   = 16
+	iterations = 210_000 // PBKDF2-HMAC-SHA256
+)
+
+// Seal encrypts data with a key derived from passphrase (AES-256-GCM), so the
+// backend only ever sees ciphertext.
+func Seal(data []byte, passphrase string) ([]byte, error) {
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	out := append([]byte(magic), salt...)
+	out = append(out, nonce...)
+	return aead.Seal(out, nonce, data, []byte(magic)), nil
+}
+
+// Open decrypts a blob made by Seal.
+func Open(blob []byte, passphrase string) ([]byte, error) {
+	if !strings.HasPrefix(string(blob), magic) || len(blob) < len(magic)+saltSize {
+		return nil, errors.New("the remote file is not a daily sync file")
+	}
+	rest := blob[len(magic):]
+	aead, err := newAEAD(passphrase, rest[:saltSize])
+	if err != nil {
+		return nil, err
+	}
+	rest = rest[saltSize:]
+	if len(rest) < aead.NonceSize() {
+		return nil, errors.New("the remote file is truncated")
+	}
+	data, err := aead.Open(nil, rest[:aead.NonceSize()], rest[aead.NonceSize():], []byte(magic))
+	if err != nil {
+		return nil, errors.New("cannot decrypt the remote file: wrong passphrase or damaged file")
+	}
+	return data, nil
+}
+
+func newAEAD(passphrase string, salt []byte) (cipher.AEAD, error) {
+	if passphrase == "" {
+		return nil, errors.New("no passphrase; set DAILY_SYNC_PASSPHRASE")
+	}
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, iterations, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// httpStore keeps the blob at a URL with GET and conditional PUT. WebDAV
+// servers also get the missing parent folder created on the first upload.
+type httpStore struct {
+	url, user, password string
+	dav                 bool
+	http                *http.Client
+}
+
+func (s *httpStore) Get() ([]byte, string, error) {
+	req, err := s.request("GET", nil)
+	if err != nil {
+		return nil, "", err
+	}
+	return get(s.http, req)
+}
+
+func (s *httpStore) Put(data []byte, version string) (string, error) {
+	res, err := s.put(data, version)
+	if err != nil {
+		return "", err
+	}
+	if res.StatusCode == http.StatusConflict && s.dav {
+		// WebDAV answers 409 when the parent collection is missing.
+		res.Body.Close()
+		if err := s.mkcol(); err != nil {
+			return "", err
+		}
+		if res, err = s.put(data, version); err != nil {
+			return "", err
+		}
+	}
+	return putResult(res)
+}
+
+func (s *httpStore) put(data []byte, version string) (*http.Response, error) {
+	req, err := s.request("PUT", data)
+	if err != nil {
+		return nil, err
+	}
+	conditional(req, version)
+	return s.http.Do(req)
+}
+
+func (s *httpStore) mkcol() error {
+	parent := s.url[:strings.LastIndex(s.url, "/")+1]
+	req, err := http.NewRequest("MKCOL", parent, nil)
+	if err != nil {
+		return err
+	}
+	if s.user != "" {
+		req.SetBasicAuth(s.user, s.password)
+	}
+	res, err := s.http.Do(req)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	if res.StatusCode/100 != 2 && res.StatusCode != http.StatusMethodNotAllowed {
+		return fmt.Errorf("create %s: %s", parent, res.Status)
+	}
+	return nil
+}
+
+func (s *httpStore) request(method string, data []byte) (*http.Request, error) {
+	var body io.Reader
+	if data != nil {
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, s.url, body)
+	if err != nil {
+		return nil, err
+	}
+	if s.user != "" {
+		req.SetBasicAuth(s.user, s.password)
+	}
+	if data != nil {
+		req.Header.Set("Content-Type", "application/octet-stream")
+	}
+	return req, nil
+}
+
+// conditional makes a PUT apply only over version, or only where there is
+// nothing yet.
+func conditional(req *http.Request, version string) {
+	if version == "" {
+		req.Header.Set("If-None-Match", "*")
+	} else {
+		req.Header.Set("If-Match", version)
+	}
+}
+
+// get reads a blob and its ETag.
+func get(client *http.Client, req *http.Request) ([]byte, string, error) {
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer res.Body.Close()
+	if res.StatusCode == http.StatusNotFound {
+		return nil, "", ErrNotFound
+	}
+	if res.StatusCode/100 != 2 {
+		return nil, "", statusError(res)
+	}
+	data, err := io.ReadAll(res.Body)
+	return data, res.Header.Get("ETag"), err
+}
+
+// putResult turns the reply to a conditional PUT into the new version.
+func putResult(res *http.Response) (string, error) {
+	defer res.Body.Close()
+	switch {
+	case res.StatusCode == http.StatusPreconditionFailed:
+		return "", ErrConflict
+	case res.StatusCode/100 != 2:
+		return "", statusError(res)
+	}
+	return res.Header.Get("ETag"), nil
+}
+
+func statusError(res *http.Response) error {
+	msg, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+	return fmt.Errorf("%s %s: %s %s", res.Request.Method, res.Request.URL.Redacted(), res.Status, strings.TrimSpace(string(msg)))
+}
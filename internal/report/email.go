@@ -0,0 +1,61 @@
+package report
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// SMTPConfig holds outgoing mail settings. The password is supplied separately
+// so it never has to live in the state file.
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	User     string
+	Password string
+	From     string
+}
+
+// Email sends the HTML report to the given address.
+func (r Report) Email(cfg SMTPConfig, to string) error {
+	if cfg.Host == "" {
+		return errors.New("smtp host not configured (daily set-smtp --host ...)")
+	}
+	if to == "" {
+		return errors.New("no recipient")
+	}
+	from := cfg.From
+	if from == "" {
+		from = cfg.User
+	}
+	if from == "" {
+		return errors.New("smtp sender not configured (daily set-smtp --from ...)")
+	}
+	port := cfg.Port
+	if port == 0 {
+		port = 587
+	}
+
+	var body bytes.Buffer
+	if err := r.WriteHTML(&body); err != nil {
+		return err
+	}
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", from)
+	fmt.Fprintf(&msg, "To: %s\r\n", to)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", strings.ReplaceAll(r.Title, "\n", " "))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/html; charset=utf-8\r\n\r\n")
+	msg.Write(body.Bytes())
+
+	var auth smtp.Auth
+	if cfg.User != "" {
+		auth = smtp.PlainAuth("", cfg.User, cfg.Password, cfg.Host)
+	}
+	addr := fmt.Sprintf("%s:%d", cfg.Host, port)
+	return smtp.SendMail(addr, auth, from, []string{to}, msg.Bytes())
+}
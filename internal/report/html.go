@@ -0,0 +1,68 @@
+package report
+
+import (
+	"html/template"
+	"io"
+
+	"github.com/max-pantom/daily/internal/state"
+)
+
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"human": state.HumanMinutes,
+	"pct": func(v, max int) int {
+		if max <= 0 {
+			return 0
+		}
+		return v * 100 / max
+	},
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", sans-serif; background: #222; color: #e4e4e4; margin: 2em; }
+h1 { color: #8aa788; font-weight: 600; }
+.muted { color: #6f7a70; }
+table { border-collapse: collapse; width: 100%; max-width: 760px; }
+td, th { padding: 4px 8px; text-align: left; }
+th { color: #6f7a70; font-weight: normal; border-bottom: 1px solid #444; }
+.bar { background: #8aa788; height: 12px; border-radius: 2px; }
+.met .bar { background: #FFA132; }
+.track { background: #2b312a; width: 320px; border-radius: 2px; }
+.totals td { border-top: 1px solid #444; font-weight: 600; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p class="muted">{{.From}} &ndash; {{.To}}</p>
+<p>Worked <b>{{human .WorkMinutes}}</b> over {{.DaysWorked}} days (avg {{human .AverageMinutes}}/day), goal met {{.GoalDaysMet}} times, {{.Breaks}} breaks ({{human .BreakMinutes}}).</p>
+<p class="muted">{{.HabitsLine}}</p>
+{{$max := .MaxMinutes}}
+<table>
+<tr><th>Date</th><th></th><th>Work</th><th></th><th>Breaks</th></tr>
+{{range .Days}}<tr{{if and (gt .GoalMinutes 0) (ge .WorkMinutes .GoalMinutes)}} class="met"{{end}}>
+<td>{{.Date}}</td><td class="muted">{{.Weekday}}</td><td>{{human .WorkMinutes}}</td>
+<td><div class="track"><div class="bar" style="width: {{pct .WorkMinutes $max}}%"></div></div></td>
+<td class="muted">{{human .BreakMinutes}} ({{.Breaks}})</td>
+</tr>
+{{end}}<tr class="totals"><td>Total</td><td></td><td>{{human .WorkMinutes}}</td><td></td><td>{{human .BreakMinutes}}</td></tr>
+</table>
+{{if .Tags}}<h2>Tags</h2>
+<table>
+{{$work := .WorkMinutes}}{{range .Tags}}<tr><td>{{.Tag}}</td><td>{{human .Minutes}}</td>
+<td><div class="track"><div class="bar" style="width: {{pct .Minutes $work}}%"></div></div></td></tr>
+{{end}}</table>{{end}}
+{{if .Interruptions}}<h2>Interruptions</h2>
+<p class="muted">{{human .InterruptionMinutes}} lost</p>
+<table>
+{{range .Interruptions}}<tr><td>{{.Reason}}</td><td class="muted">{{.Count}}×</td><td>{{human .Minutes}}</td></tr>
+{{end}}</table>{{end}}
+</body>
+</html>
+`))
+
+// WriteHTML renders the report as a standalone HTML page with bar charts.
+func (r Report) WriteHTML(w io.Writer) error {
+	return htmlTemplate.Execute(w, r)
+}
@@ -0,0 +1,200 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/max-pantom/daily/internal/state"
+	"github.com/max-pantom/daily/internal/stats"
+)
+
+// Day is one row of a report.
+type Day struct {
+	Date         string
+	Weekday      string
+	WorkMinutes  int
+	BreakMinutes int
+	Breaks       int
+	Sessions     int
+	GoalMinutes  int
+
+	InterruptionMinutes int
+}
+
+// TagTotal is the time logged under a single tag.
+type TagTotal struct {
+	Tag     string
+	Minutes int
+}
+
+// ReasonTotal is the time lost to one interruption reason.
+type ReasonTotal struct {
+	Reason  string
+	Count   int
+	Minutes int
+}
+
+// Report summarizes a date range of tracked work.
+type Report struct {
+	Title        string
+	From         string
+	To           string
+	Days         []Day
+	WorkMinutes  int
+	BreakMinutes int
+	Breaks       int
+	DaysWorked   int
+	GoalDaysMet  int
+	Tags         []TagTotal
+	Stats        stats.Summary
+
+	InterruptionMinutes int
+	Interruptions       []ReasonTotal // by reason, longest first
+}
+
+// Week covers the seven days ending with now.
+func Week(st *state.State, now time.Time, filter state.Filter) Report {
+	from := now.AddDate(0, 0, -6)
+	return Build(st, "Weekly summary", from, now, filter)
+}
+
+// Month covers the calendar month containing now, up to now.
+func Month(st *state.State, now time.Time, filter state.Filter) Report {
+	from := state.Midnight(now.AddDate(0, 0, 1-now.Day()))
+	return Build(st, fmt.Sprintf("Monthly summary %s", now.Format("January 2006")), from, now, filter)
+}
+
+// Build collects every day between from and to (inclusive), including empty ones.
+// Tag/project filters restrict work totals to matching sessions.
+func Build(st *state.State, title string, from, to time.Time, filter state.Filter) Report {
+	r := Report{Title: title, From: from.Format("2006-01-02"), To: to.Format("2006-01-02"), Stats: stats.Range(st, from, to)}
+	tags := make(map[string]int) // seconds per tag
+	for d := state.Midnight(from); !d.After(to); d = d.AddDate(0, 0, 1) {
+		key := d.Format("2006-01-02")
+		day := Day{Date: key, Weekday: d.Format("Mon"), GoalMinutes: st.DayGoal(key)}
+		if log, ok := st.Days[key]; ok {
+			day.WorkMinutes = st.Rounding.Apply(st.FilteredWorkSeconds(key, filter))
+			if !filter.HasSessionFilter() {
+				day.BreakMinutes = log.TotalBreakMinutes
+				day.Breaks = log.BreakCount
+				secs := 0
+				for _, in := range log.Interruptions {
+					secs += in.Elapsed
+				}
+				day.InterruptionMinutes = secs / 60
+			}
+			for _, sess := range log.Sessions {
+				if !filter.Match(sess) {
+					continue
+				}
+				day.Sessions++
+				for _, t := range sess.Tags {
+					tags[t] += sess.Seconds(to)
+				}
+			}
+		}
+		r.Days = append(r.Days, day)
+		r.WorkMinutes += day.WorkMinutes
+		r.BreakMinutes += day.BreakMinutes
+		r.Breaks += day.Breaks
+		r.InterruptionMinutes += day.InterruptionMinutes
+		if day.WorkMinutes > 0 {
+			r.DaysWorked++
+		}
+		if day.GoalMinutes > 0 && day.WorkMinutes >= day.GoalMinutes {
+			r.GoalDaysMet++
+		}
+	}
+	for t, secs := range tags {
+		r.Tags = append(r.Tags, TagTotal{Tag: t, Minutes: st.Rounding.Apply(secs)})
+	}
+	sort.Slice(r.Tags, func(i, j int) bool {
+		if r.Tags[i].Minutes == r.Tags[j].Minutes {
+			return r.Tags[i].Tag < r.Tags[j].Tag
+		}
+		return r.Tags[i].Minutes > r.Tags[j].Minutes
+	})
+	// Interruptions belong to no session, so session filters leave them out
+	// like breaks.
+	if !filter.HasSessionFilter() {
+		for _, t := range st.InterruptionTotals(r.From, r.To) {
+			r.Interruptions = append(r.Interruptions, ReasonTotal{Reason: t.Reason, Count: t.Count, Minutes: t.Seconds / 60})
+		}
+	}
+	return r
+}
+
+// InterruptionsLine summarizes interruption time and its top reasons.
+func (r Report) InterruptionsLine() string {
+	const top = 5
+	parts := make([]string, 0, top)
+	for i, t := range r.Interruptions {
+		if i == top {
+			break
+		}
+		parts = append(parts, fmt.Sprintf("%s %s (%d×)", t.Reason, state.HumanMinutes(t.Minutes), t.Count))
+	}
+	return fmt.Sprintf("%s lost, %s", state.HumanMinutes(r.InterruptionMinutes), strings.Join(parts, ", "))
+}
+
+// AverageMinutes returns the mean work per day worked.
+func (r Report) AverageMinutes() int {
+	if r.DaysWorked == 0 {
+		return 0
+	}
+	return r.WorkMinutes / r.DaysWorked
+}
+
+// HabitsLine summarizes start time, session length and break ratio.
+func (r Report) HabitsLine() string {
+	return fmt.Sprintf("avg start %s, avg session %s (%d sessions), break ratio %d%%",
+		r.Stats.AvgStartLabel(),
+		state.HumanMinutes(r.Stats.AvgSessionMinutes),
+		r.Stats.Sessions,
+		int(r.Stats.BreakRatio*100+0.5),
+	)
+}
+
+// MaxMinutes returns the longest day, for scaling charts.
+func (r Report) MaxMinutes() int {
+	max := 0
+	for _, d := range r.Days {
+		if d.WorkMinutes > max {
+			max = d.WorkMinutes
+		}
+	}
+	return max
+}
+
+// WriteText renders the report as a plain terminal table.
+func (r Report) WriteText(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s (%s to %s)\n", r.Title, r.From, r.To)
+	fmt.Fprintf(&b, "%-10s  %-3s  %8s  %8s  %s\n", "date", "day", "work", "breaks", "goal")
+	for _, d := range r.Days {
+		goal := ""
+		if d.GoalMinutes > 0 && d.WorkMinutes >= d.GoalMinutes {
+			goal = "met"
+		}
+		fmt.Fprintf(&b, "%-10s  %-3s  %8s  %8s  %s\n", d.Date, d.Weekday,
+			state.HumanMinutes(d.WorkMinutes), state.HumanMinutes(d.BreakMinutes), goal)
+	}
+	fmt.Fprintf(&b, "total: %s over %d days (avg %s/day worked), goal met %d times, streak %d days\n",
+		state.HumanMinutes(r.WorkMinutes), r.DaysWorked, state.HumanMinutes(r.AverageMinutes()), r.GoalDaysMet, r.Stats.Streak)
+	fmt.Fprintf(&b, "habits: %s\n", r.HabitsLine())
+	if len(r.Tags) > 0 {
+		parts := make([]string, 0, len(r.Tags))
+		for _, t := range r.Tags {
+			parts = append(parts, fmt.Sprintf("%s %s", t.Tag, state.HumanMinutes(t.Minutes)))
+		}
+		fmt.Fprintf(&b, "tags: %s\n", strings.Join(parts, ", "))
+	}
+	if len(r.Interruptions) > 0 {
+		fmt.Fprintf(&b, "interruptions: %s\n", r.InterruptionsLine())
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,105 @@
+package report
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/max-pantom/daily/internal/state"
+)
+
+// standupItem is one line of a standup: sessions sharing a project and note.
+type standupItem struct {
+	project string
+	note    string
+	tags    []string
+	seconds int
+}
+
+// Standup renders "Yesterday / Today so far" from session notes and tags, in
+// Slack markdown. Yesterday is the last day before today with work logged, so
+// Monday's standup covers Friday.
+func Standup(st *state.State, now time.Time) string {
+	today := now.Format("2006-01-02")
+	var b strings.Builder
+	for _, key := range st.DayKeys(state.Filter{To: now.AddDate(0, 0, -1).Format("2006-01-02")}) {
+		if log := st.Days[key]; log.WorkSeconds() > 0 {
+			day, _ := time.ParseInLocation("2006-01-02", key, time.Local)
+			label := "Yesterday"
+			if key != now.AddDate(0, 0, -1).Format("2006-01-02") {
+				label = day.Format("Monday")
+			}
+			writeStandupDay(&b, label, log.Sessions, log.WorkSeconds(), now)
+			break
+		}
+	}
+	var sessions []state.Session
+	total := 0
+	if log, ok := st.Days[today]; ok {
+		sessions = append(sessions, log.Sessions...)
+		total = log.WorkSeconds()
+	}
+	if st.ActiveSession != nil {
+		sessions = append(sessions, *st.ActiveSession)
+		total += st.ActiveSession.Seconds(now)
+	}
+	writeStandupDay(&b, "Today so far", sessions, total, now)
+	return b.String()
+}
+
+func writeStandupDay(b *strings.Builder, label string, sessions []state.Session, total int, now time.Time) {
+	fmt.Fprintf(b, "*%s* (%s)\n", label, state.HumanMinutes(total/60))
+	var items []*standupItem
+	byKey := make(map[string]*standupItem)
+	for _, sess := range sessions {
+		key := strings.ToLower(sess.Project + "\x00" + sess.Note)
+		if sess.Note == "" {
+			key += "\x00" + strings.ToLower(strings.Join(sess.Tags, ","))
+		}
+		item, ok := byKey[key]
+		if !ok {
+			item = &standupItem{project: sess.Project, note: sess.Note}
+			byKey[key] = item
+			items = append(items, item)
+		}
+		for _, t := range sess.Tags {
+			if !containsFold(item.tags, t) {
+				item.tags = append(item.tags, t)
+			}
+		}
+		item.seconds += sess.Seconds(now)
+	}
+	switch {
+	case len(items) == 0 && total > 0:
+		b.WriteString("• no session details recorded\n")
+	case len(items) == 0:
+		b.WriteString("• nothing logged yet\n")
+	}
+	for _, item := range items {
+		text := item.note
+		if text == "" && len(item.tags) > 0 {
+			text = strings.Join(item.tags, ", ")
+			item.tags = nil
+		}
+		if text == "" {
+			text = "untracked work"
+		}
+		if item.project != "" {
+			text = item.project + ": " + text
+		}
+		fmt.Fprintf(b, "• %s (%s)", text, state.HumanSeconds(item.seconds))
+		for _, t := range item.tags {
+			fmt.Fprintf(b, " #%s", t)
+		}
+		b.WriteString("\n")
+	}
+}
+
+func containsFold(list []string, v string) bool {
+	for _, s := range list {
+		if strings.EqualFold(s, v) {
+			return true
+		}
+	}
+	return false
+}
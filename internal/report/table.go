@@ -0,0 +1,89 @@
+package report
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/max-pantom/daily/internal/state"
+)
+
+// WriteMarkdown renders the report as a Markdown table for notes and wikis.
+func (r Report) WriteMarkdown(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "### %s (%s to %s)\n\n", r.Title, r.From, r.To)
+	b.WriteString("| Date | Day | Work | Breaks | Goal |\n")
+	b.WriteString("|------|-----|-----:|-------:|:----:|\n")
+	for _, d := range r.Days {
+		goal := ""
+		if d.GoalMinutes > 0 && d.WorkMinutes >= d.GoalMinutes {
+			goal = "✓"
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s | %s | %s |\n", d.Date, d.Weekday,
+			state.HumanMinutes(d.WorkMinutes), state.HumanMinutes(d.BreakMinutes), goal)
+	}
+	fmt.Fprintf(&b, "| **Total** | | **%s** | %s | %d |\n",
+		state.HumanMinutes(r.WorkMinutes), state.HumanMinutes(r.BreakMinutes), r.GoalDaysMet)
+	fmt.Fprintf(&b, "\n_%s_\n", r.HabitsLine())
+	if len(r.Tags) > 0 {
+		b.WriteString("\n| Tag | Time |\n|-----|-----:|\n")
+		for _, t := range r.Tags {
+			fmt.Fprintf(&b, "| %s | %s |\n", escapeCell(t.Tag), state.HumanMinutes(t.Minutes))
+		}
+	}
+	if len(r.Interruptions) > 0 {
+		fmt.Fprintf(&b, "\n| Interruption | Times | Time |\n|--------------|------:|-----:|\n")
+		for _, t := range r.Interruptions {
+			fmt.Fprintf(&b, "| %s | %d | %s |\n", escapeCell(t.Reason), t.Count, state.HumanMinutes(t.Minutes))
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteCSV renders one row per day with minute values for spreadsheets.
+func (r Report) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"date", "weekday", "work_minutes", "break_minutes", "breaks", "sessions", "goal_minutes", "interruption_minutes"}); err != nil {
+		return err
+	}
+	for _, d := range r.Days {
+		row := []string{
+			d.Date,
+			d.Weekday,
+			strconv.Itoa(d.WorkMinutes),
+			strconv.Itoa(d.BreakMinutes),
+			strconv.Itoa(d.Breaks),
+			strconv.Itoa(d.Sessions),
+			strconv.Itoa(d.GoalMinutes),
+			strconv.Itoa(d.InterruptionMinutes),
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// Write renders the report in the named format: text, md, csv or html.
+func (r Report) Write(w io.Writer, format string) error {
+	switch format {
+	case "", "text":
+		return r.WriteText(w)
+	case "md", "markdown":
+		return r.WriteMarkdown(w)
+	case "csv":
+		return r.WriteCSV(w)
+	case "html":
+		return r.WriteHTML(w)
+	default:
+		return fmt.Errorf("unknown format %q (want text, md, csv or html)", format)
+	}
+}
+
+func escapeCell(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
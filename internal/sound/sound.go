@@ -0,0 +1,97 @@
+// Package sound plays short alerts for sprint phase changes and goals, since
+// notifications are easy to miss in fullscreen apps.
+package sound
+
+import (
+	"embed"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Events that can play a sound.
+const (
+	WorkEnd  = "work_end"  // a sprint work phase ended
+	BreakEnd = "break_end" // a sprint break ended
+	Goal     = "goal"      // the daily goal was reached
+)
+
+// Events lists every event name, for validation and help text.
+var Events = []string{WorkEnd, BreakEnd, Goal}
+
+// Default selects the bundled sound for an event.
+const Default = "default"
+
+//go:embed sounds/*.wav
+var bundled embed.FS
+
+// Valid reports whether event is a known event name.
+func Valid(event string) bool {
+	for _, e := range Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// Play starts the sound configured for event without waiting for it to finish.
+// setting is "" (silent), Default, or a path to an audio file. Failures are
+// ignored, like notifications.
+func Play(event, setting string) {
+	if setting == "" {
+		return
+	}
+	path := setting
+	if setting == Default {
+		var err error
+		if path, err = bundledFile(event); err != nil {
+			return
+		}
+	}
+	cmd := player(path)
+	if cmd == nil {
+		return
+	}
+	if err := cmd.Start(); err != nil {
+		return
+	}
+	go cmd.Wait()
+}
+
+// bundledFile writes the embedded sound to the temp dir, since the players
+// need a file path.
+func bundledFile(event string) (string, error) {
+	data, err := bundled.ReadFile("sounds/" + event + ".wav")
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(os.TempDir(), "daily-sounds")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, event+".wav")
+	if info, err := os.Stat(path); err == nil && info.Size() == int64(len(data)) {
+		return path, nil
+	}
+	return path, os.WriteFile(path, data, 0o644)
+}
+
+func player(path string) *exec.Cmd {
+	switch runtime.GOOS {
+	case "darwin":
+		return exec.Command("afplay", path)
+	case "linux":
+		if _, err := exec.LookPath("paplay"); err == nil {
+			return exec.Command("paplay", path)
+		}
+		return exec.Command("aplay", "-q", path)
+	case "windows":
+		quoted := "'" + strings.ReplaceAll(path, "'", "''") + "'"
+		return exec.Command("powershell", "-NoProfile", "-Command",
+			"(New-Object Media.SoundPlayer "+quoted+").PlaySync()")
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package state
+
+import (
+	"fmt"
+	"time"
+)
+
+// ParseAutoStop validates an HH:MM time of day for the auto-stop rule.
+func ParseAutoStop(v string) (string, error) {
+	t, err := time.Parse("15:04", v)
+	if err != nil {
+		return "", fmt.Errorf("invalid time %q (want HH:MM, e.g. 23:30)", v)
+	}
+	return t.Format("15:04"), nil
+}
+
+// AutoStopAt returns the first auto-stop time after the running session
+// started, so a session begun after it today runs until tomorrow's.
+func (s *State) AutoStopAt() (time.Time, bool) {
+	if s.ActiveSession == nil || s.AutoStop == "" {
+		return time.Time{}, false
+	}
+	clock, err := time.Parse("15:04", s.AutoStop)
+	if err != nil {
+		return time.Time{}, false
+	}
+	start := s.ActiveSession.Start.In(time.Local)
+	y, m, d := start.Date()
+	at := time.Date(y, m, d, clock.Hour(), clock.Minute(), 0, 0, time.Local)
+	if !at.After(start) {
+		at = time.Date(y, m, d+1, clock.Hour(), clock.Minute(), 0, 0, time.Local)
+	}
+	return at, true
+}
+
+// ApplyAutoStop ends the running session at the auto-stop time once it has
+// passed, even if that was hours ago, and flags the day for review. It
+// returns when the session was stopped.
+func (s *State) ApplyAutoStop(now time.Time) (time.Time, bool) {
+	at, ok := s.AutoStopAt()
+	if !ok || now.Before(at) {
+		return time.Time{}, false
+	}
+	if _, err := s.StopSessionAt(at); err != nil {
+		return time.Time{}, false
+	}
+	s.editDay(dateKey(at), func(log *DayLog) { log.AutoStopped = &at })
+	return at, true
+}
@@ -0,0 +1,101 @@
+package state
+
+import (
+	"time"
+
+	"github.com/max-pantom/daily/internal/i18n"
+)
+
+// GoalCarry policies: which differences between a workday's work and its goal
+// move into the next workday's goal. Time over the goal is banked and lowers
+// it, time short of it raises it. The balance runs from Monday, so a week
+// starts even.
+const (
+	CarryOver  = "over"
+	CarryUnder = "under"
+	CarryBoth  = "both"
+)
+
+// Goal returns the goal of day's workday after carry-over, and the minutes
+// carried into it: positive when earlier days banked time, negative when they
+// fell short. Without a GoalCarry policy, and on days off, it is the day's own
+// goal. Days before the first one logged carry nothing, and a carried goal
+// stays between MinGoalMinutes and twice the day's own goal.
+func (s *State) Goal(day time.Time) (goal, carried int) {
+	goal = s.baseGoal(dateKey(day))
+	if s.GoalCarry == "" || !s.Workday(day) {
+		return goal, 0
+	}
+	first := s.firstDay()
+	for d := Monday(day); !sameDate(d, day) && d.Before(day); d = d.AddDate(0, 0, 1) {
+		key := dateKey(d)
+		if first == "" || key < first {
+			continue
+		}
+		work, due := 0, 0
+		if log, ok := s.Days[key]; ok {
+			work = log.TotalWorkMinutes
+		}
+		if s.Workday(d) {
+			due = s.baseGoal(key)
+		}
+		carried += work - due
+		switch s.GoalCarry {
+		case CarryOver:
+			carried = max(carried, 0)
+		case CarryUnder:
+			carried = min(carried, 0)
+		}
+	}
+	return min(max(goal-carried, MinGoalMinutes), 2*goal), carried
+}
+
+// baseGoal is the goal a day was logged with, or the current one.
+func (s *State) baseGoal(key string) int {
+	if log, ok := s.Days[key]; ok && log.GoalMinutes > 0 {
+		return log.GoalMinutes
+	}
+	return s.GoalMinutes
+}
+
+// DayGoal is Goal for the day key, without the carried minutes.
+func (s *State) DayGoal(key string) int {
+	day, err := time.ParseInLocation("2006-01-02", key, time.Local)
+	if err != nil {
+		return s.baseGoal(key)
+	}
+	goal, _ := s.Goal(day)
+	return goal
+}
+
+// CarriedLabel describes carried minutes after a goal, e.g. " (1h30m banked)",
+// or is empty when nothing was carried.
+func CarriedLabel(carried int) string {
+	switch {
+	case carried > 0:
+		return i18n.T(" (%s banked)", HumanMinutes(carried))
+	case carried < 0:
+		return i18n.T(" (%s to make up)", HumanMinutes(-carried))
+	}
+	return ""
+}
+
+// WeekGoal is the work goal of the week starting monday: WeekGoalMinutes, or
+// the goals of its workdays added up.
+func (s *State) WeekGoal(monday time.Time) int {
+	if s.WeekGoalMinutes > 0 {
+		return s.WeekGoalMinutes
+	}
+	total := 0
+	for i := 0; i < 7; i++ {
+		if d := monday.AddDate(0, 0, i); s.Workday(d) {
+			total += s.baseGoal(dateKey(d))
+		}
+	}
+	return total
+}
+
+// Monday returns the first instant of the Monday starting t's week.
+func Monday(t time.Time) time.Time {
+	return Midnight(t).AddDate(0, 0, -(int(t.Weekday())+6)%7)
+}
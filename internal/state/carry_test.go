@@ -0,0 +1,47 @@
+package state
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGoalCarry(t *testing.T) {
+	// Monday 2 March 2026; the first day logged is Wednesday.
+	day := func(d int) time.Time { return time.Date(2026, 3, d, 12, 0, 0, 0, time.Local) }
+	s := defaults()
+	s.GoalMinutes = 480
+	s.GoalCarry = CarryBoth
+	s.Days = map[string]*DayLog{}
+	if got := s.firstDay(); got != "" {
+		t.Fatalf("firstDay() = %q with nothing logged", got)
+	}
+	for d, work := range map[int]int{4: 540, 5: 420} {
+		s.setDay(dateKey(day(d)), &DayLog{TotalWorkMinutes: work, GoalMinutes: 480})
+	}
+	if got, want := s.firstDay(), "2026-03-04"; got != want {
+		t.Fatalf("firstDay() = %q, want %q", got, want)
+	}
+	for _, tc := range []struct {
+		day           int
+		goal, carried int
+	}{
+		{3, 480, 0},  // before the first logged day
+		{4, 480, 0},  // the first logged day
+		{5, 420, 60}, // Wednesday banked an hour
+		{6, 480, 0},  // Thursday spent it
+	} {
+		goal, carried := s.Goal(day(tc.day))
+		if goal != tc.goal || carried != tc.carried {
+			t.Errorf("Goal(%s) = %d, %d; want %d, %d", dateKey(day(tc.day)), goal, carried, tc.goal, tc.carried)
+		}
+	}
+
+	// An older day logged later moves the start back.
+	s.setDay("2026-02-27", &DayLog{})
+	if got, want := s.firstDay(), "2026-02-27"; got != want {
+		t.Fatalf("firstDay() = %q, want %q", got, want)
+	}
+	if goal, carried := s.Goal(day(4)); goal != 960 || carried != -960 {
+		t.Errorf("Goal(2026-03-04) = %d, %d; want 960, -960", goal, carried)
+	}
+}
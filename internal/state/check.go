@@ -0,0 +1,131 @@
+package state
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// clockSkewSlack is how far in the future a timestamp may be before Check
+// blames the clock.
+const clockSkewSlack = 5 * time.Minute
+
+// Check looks for inconsistencies in the state: mislabeled days, sessions that
+// end before they start or overlap, totals that disagree with the sessions,
+// and days or timestamps in the future (a sign of clock skew). It returns one
+// message per problem.
+func (s *State) Check(now time.Time) []string {
+	var problems []string
+	keys := make([]string, 0, len(s.Days))
+	for key := range s.Days {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		log := s.Days[key]
+		if log == nil {
+			problems = append(problems, fmt.Sprintf("day %s is empty", key))
+			continue
+		}
+		if _, err := time.ParseInLocation("2006-01-02", key, time.Local); err != nil {
+			problems = append(problems, fmt.Sprintf("day key %q is not a YYYY-MM-DD date", key))
+		}
+		if log.Date != "" && log.Date != key {
+			problems = append(problems, fmt.Sprintf("day %s is labeled %s", key, log.Date))
+		}
+		if key > dateKey(now.Add(clockSkewSlack)) {
+			problems = append(problems, fmt.Sprintf("day %s is in the future", key))
+		}
+		for i, sess := range log.Sessions {
+			switch {
+			case sess.End == nil:
+				problems = append(problems, fmt.Sprintf("%s session %d has no end", key, i+1))
+			case sess.End.Before(sess.Start):
+				problems = append(problems, fmt.Sprintf("%s session %d ends before it starts", key, i+1))
+			}
+			if sess.Start.After(now.Add(clockSkewSlack)) {
+				problems = append(problems, fmt.Sprintf("%s session %d starts in the future", key, i+1))
+			}
+		}
+		for _, pair := range overlaps(log.Sessions) {
+			problems = append(problems, fmt.Sprintf("%s sessions %d and %d overlap", key, pair[0]+1, pair[1]+1))
+		}
+		if sum, ok := totalMismatch(log); ok {
+			problems = append(problems, fmt.Sprintf("%s total %s disagrees with its sessions (%s)",
+				key, HumanMinutes(log.TotalWorkSeconds/60), HumanMinutes(sum/60)))
+		}
+	}
+	if s.ActiveSession != nil && s.ActiveSession.Start.After(now.Add(clockSkewSlack)) {
+		problems = append(problems, "the running session starts in the future")
+	}
+	if s.ActiveBreak != nil && s.ActiveBreak.Start.After(now.Add(clockSkewSlack)) {
+		problems = append(problems, "the running break starts in the future")
+	}
+	if s.ActiveSession != nil && s.ActiveBreak != nil {
+		problems = append(problems, "a session and a break are both running")
+	}
+	return problems
+}
+
+// RepairTotals recomputes the work totals of days that disagree with their
+// sessions, the one problem Check finds that has an unambiguous fix. It
+// returns the days it changed.
+func (s *State) RepairTotals() []string {
+	var fixed []string
+	for key, log := range s.Days {
+		if sum, ok := totalMismatch(log); ok {
+			s.editDay(key, func(log *DayLog) {
+				log.TotalWorkSeconds = sum
+				log.TotalWorkMinutes = sum / 60
+			})
+			fixed = append(fixed, key)
+		}
+	}
+	sort.Strings(fixed)
+	return fixed
+}
+
+// totalMismatch sums the day's finished sessions and reports whether the
+// recorded total is more than a minute off. Old files only kept minutes, and
+// sessions were not always recorded, so those days are left alone.
+func totalMismatch(log *DayLog) (int, bool) {
+	if log == nil || log.TotalWorkSeconds <= 0 || len(log.Sessions) == 0 {
+		return 0, false
+	}
+	sum := listSeconds(log.Sessions)
+	return sum, abs(sum-log.TotalWorkSeconds) > 60
+}
+
+// overlaps returns the index pairs of finished sessions whose times overlap.
+func overlaps(sessions []Session) [][2]int {
+	order := make([]int, 0, len(sessions))
+	for i, sess := range sessions {
+		if sess.End != nil && !sess.End.Before(sess.Start) {
+			order = append(order, i)
+		}
+	}
+	sort.Slice(order, func(a, b int) bool { return sessions[order[a]].Start.Before(sessions[order[b]].Start) })
+	var pairs [][2]int
+	for n := 1; n < len(order); n++ {
+		// Compare with the earlier session that runs longest, so one long
+		// session covering several short ones is reported against each.
+		latest := order[0]
+		for _, i := range order[1:n] {
+			if sessions[i].End.After(*sessions[latest].End) {
+				latest = i
+			}
+		}
+		cur := order[n]
+		if sessions[cur].Start.Before(*sessions[latest].End) {
+			pairs = append(pairs, [2]int{min(latest, cur), max(latest, cur)})
+		}
+	}
+	return pairs
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
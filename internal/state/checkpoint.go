@@ -0,0 +1,59 @@
+package state
+
+import "time"
+
+// DefaultCheckpointMinutes is the autosave interval new state files start with.
+const DefaultCheckpointMinutes = 5
+
+// CheckpointDue reports whether the running session's progress should be
+// saved again.
+func (s *State) CheckpointDue(now time.Time) bool {
+	if s.ActiveSession == nil || s.CheckpointMinutes <= 0 {
+		return false
+	}
+	last := s.ActiveSession.Start
+	if c := s.Checkpoint; c != nil && c.End != nil && c.Start.Equal(last) {
+		last = *c.End
+	}
+	return now.Sub(last) >= time.Duration(s.CheckpointMinutes)*time.Minute
+}
+
+// SaveCheckpoint records the running session as finished at now. Stopping the
+// session discards the record; if the session disappears any other way, such
+// as a crash that loses it or a restored state file, Normalize logs the
+// checkpoint instead, so at most one interval of work is lost.
+func (s *State) SaveCheckpoint(now time.Time) {
+	if s.ActiveSession == nil || now.Before(s.ActiveSession.Start) {
+		return
+	}
+	sess := *s.ActiveSession
+	end := now
+	sess.End = &end
+	sess.Until = nil
+	sess.Apps = nil
+	s.Checkpoint = &sess
+}
+
+// recoverCheckpoint logs a checkpoint whose session is no longer running
+// without having been stopped. A session started since then wins over the
+// part of the checkpoint it overlaps.
+func (s *State) recoverCheckpoint() bool {
+	c := s.Checkpoint
+	if c == nil || c.End == nil {
+		s.Checkpoint = nil
+		return false
+	}
+	if s.ActiveSession != nil && s.ActiveSession.Start.Equal(c.Start) {
+		return false
+	}
+	s.Checkpoint = nil
+	end := *c.End
+	if s.ActiveSession != nil && s.ActiveSession.Start.Before(end) {
+		end = s.ActiveSession.Start
+	}
+	if !end.After(c.Start) {
+		return false
+	}
+	s.AddWork(*c, c.Start, end)
+	return true
+}
@@ -0,0 +1,164 @@
+package state
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// configFields are the JSON keys of the settings, which Save keeps out of the
+// data file. State files written before the split carry them, and Load reads
+// them from there until the first save moves them to config.toml.
+var configFields = settingFields()
+
+// settingFields finds the JSON keys behind Settings by setting each to its
+// example on an empty state and seeing what changes, so a new setting cannot
+// end up in the data file by being left off a list.
+func settingFields() []string {
+	encode := func(s *State) map[string]string {
+		data, err := json.Marshal(s)
+		if err != nil {
+			panic(err)
+		}
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(data, &fields); err != nil {
+			panic(err)
+		}
+		out := make(map[string]string, len(fields))
+		for k, v := range fields {
+			out[k] = string(v)
+		}
+		return out
+	}
+	empty := encode(&State{})
+	seen := map[string]bool{}
+	var keys []string
+	for _, set := range Settings {
+		var s State
+		if set.Family {
+			_ = set.put(&s, "example", set.Example)
+		} else {
+			_ = set.set(&s, set.Example)
+		}
+		for k, v := range encode(&s) {
+			if empty[k] != v && !seen[k] {
+				seen[k] = true
+				keys = append(keys, k)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ConfigPath is the settings file that goes with the state file at path:
+// config.toml next to state.json, or work.toml next to work.json. Keeping it
+// apart lets the history be synced between machines without their settings.
+func ConfigPath(path string) string {
+	dir, name := filepath.Split(path)
+	if name == "state.json" {
+		return filepath.Join(dir, "config.toml")
+	}
+	return filepath.Join(dir, strings.TrimSuffix(name, filepath.Ext(name))+".toml")
+}
+
+// ModTimes returns the mtimes of the state file at path and its config file,
+// which tell cheaply whether either was rewritten.
+func ModTimes(path string) [2]time.Time {
+	var mod [2]time.Time
+	for i, p := range []string{path, ConfigPath(path)} {
+		if info, err := os.Stat(p); err == nil {
+			mod[i] = info.ModTime()
+		}
+	}
+	return mod
+}
+
+// loadConfig replaces the settings with those in the config file next to path.
+// Without one, settings still held by the data file (a combined state file,
+// split by the next save) are kept, and otherwise the defaults.
+func (s *State) loadConfig(path string, data []byte) error {
+	text, err := os.ReadFile(ConfigPath(path))
+	if errors.Is(err, os.ErrNotExist) {
+		var fields map[string]json.RawMessage
+		if len(data) > 0 && json.Unmarshal(data, &fields) == nil {
+			for _, key := range configFields {
+				if _, ok := fields[key]; ok {
+					return nil
+				}
+			}
+		}
+		return s.DecodeSettings(bytes.NewReader(nil))
+	}
+	if err != nil {
+		return err
+	}
+	if err := s.DecodeSettings(bytes.NewReader(text)); err != nil {
+		return fmt.Errorf("%s: %w", ConfigPath(path), err)
+	}
+	return nil
+}
+
+// saveConfig writes the settings next to path, leaving the file alone when it
+// already says the same so hand edits keep their layout.
+func (s *State) saveConfig(path string) error {
+	var buf bytes.Buffer
+	buf.WriteString("# daily settings; change them here, with daily config or the set- commands.\n")
+	if err := s.EncodeSettings(&buf); err != nil {
+		return err
+	}
+	config := ConfigPath(path)
+	if old, err := os.ReadFile(config); err == nil {
+		var cur State
+		if cur.DecodeSettings(bytes.NewReader(old)) == nil && sameSettings(&cur, s) {
+			return nil
+		}
+	}
+	return writeAtomic(config, buf.Bytes())
+}
+
+func sameSettings(a, b *State) bool {
+	av, bv := a.SettingValues(), b.SettingValues()
+	if len(av) != len(bv) {
+		return false
+	}
+	for i := range av {
+		if av[i].Key != bv[i].Key || av[i].Value != bv[i].Value {
+			return false
+		}
+	}
+	return true
+}
+
+// dataJSON encodes the state without its settings.
+func (s *State) dataJSON() ([]byte, error) {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	for _, key := range configFields {
+		delete(fields, key)
+	}
+	return json.MarshalIndent(fields, "", "  ")
+}
+
+func writeAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
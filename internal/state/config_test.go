@@ -0,0 +1,77 @@
+package state
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// TestSettingFields checks that every setting is behind some JSON key of the
+// state, which its example has to change for settingFields to find it.
+func TestSettingFields(t *testing.T) {
+	empty, _ := json.Marshal(&State{})
+	for _, set := range Settings {
+		var s State
+		var err error
+		if set.Family {
+			err = set.put(&s, "example", set.Example)
+		} else {
+			err = set.set(&s, set.Example)
+		}
+		if err != nil {
+			t.Errorf("%s: example %q: %v", set.Key, set.Example, err)
+			continue
+		}
+		if data, _ := json.Marshal(&s); string(data) == string(empty) {
+			t.Errorf("%s: example %q is the default, so its JSON key is not known", set.Key, set.Example)
+		}
+	}
+	for _, key := range []string{"goal_minutes", "notifications_enabled", "tray_title", "rates", "tag_rules"} {
+		if !slices.Contains(configFields, key) {
+			t.Errorf("configFields lacks %s: %v", key, configFields)
+		}
+	}
+	for _, key := range []string{"days", "active_session", "active_break"} {
+		if slices.Contains(configFields, key) {
+			t.Errorf("configFields has data key %s", key)
+		}
+	}
+}
+
+// TestSaveSplitsSettings checks that no setting is written to the data file.
+func TestSaveSplitsSettings(t *testing.T) {
+	s := defaults()
+	for _, set := range Settings {
+		if set.Family {
+			_ = set.put(s, "example", set.Example)
+		} else {
+			_ = set.set(s, set.Example)
+		}
+	}
+	path := filepath.Join(t.TempDir(), "state.json")
+	if err := s.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatal(err)
+	}
+	for key := range fields {
+		if slices.Contains(configFields, key) {
+			t.Errorf("state.json holds setting %s", key)
+		}
+	}
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sameSettings(s, loaded) {
+		t.Errorf("settings changed on the round trip through %s", ConfigPath(path))
+	}
+}
@@ -0,0 +1,137 @@
+package state
+
+import (
+	"testing"
+	"time"
+)
+
+func zone(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Skipf("no time zone data for %s: %v", name, err)
+	}
+	return loc
+}
+
+// at parses a wall clock time with its offset, e.g. "2026-03-08 03:00 -0400",
+// in loc.
+func at(t *testing.T, loc *time.Location, v string) time.Time {
+	t.Helper()
+	tm, err := time.Parse("2006-01-02 15:04 -0700", v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tm.In(loc)
+}
+
+func TestMidnight(t *testing.T) {
+	for _, tc := range []struct {
+		name, zone string
+		day        string // any time that day, with its offset
+		midnight   string // the first instant of the day
+		hours      float64
+	}{
+		{"plain day", "America/New_York", "2026-03-07 12:00 -0500", "2026-03-07 00:00 -0500", 24},
+		{"spring forward at 02:00", "America/New_York", "2026-03-08 12:00 -0400", "2026-03-08 00:00 -0500", 23},
+		{"fall back at 02:00", "America/New_York", "2026-11-01 12:00 -0500", "2026-11-01 00:00 -0400", 25},
+		{"spring forward, Europe", "Europe/Berlin", "2026-03-29 12:00 +0200", "2026-03-29 00:00 +0100", 23},
+		{"fall back, Europe", "Europe/Berlin", "2026-10-25 12:00 +0100", "2026-10-25 00:00 +0200", 25},
+		// DST starts at midnight: 00:00 never happens and the day starts at 01:00.
+		{"spring forward at midnight", "America/Santiago", "2026-09-06 12:00 -0300", "2026-09-06 01:00 -0300", 23},
+		{"day before spring forward at midnight", "America/Santiago", "2026-09-05 12:00 -0400", "2026-09-05 00:00 -0400", 24},
+		// DST ends at midnight: the day before gets 23:00 to 24:00 twice.
+		{"fall back at midnight", "America/Santiago", "2026-04-05 12:00 -0400", "2026-04-05 00:00 -0400", 24},
+		{"day before fall back at midnight", "America/Santiago", "2026-04-04 12:00 -0300", "2026-04-04 00:00 -0300", 25},
+		{"spring forward at midnight, Havana", "America/Havana", "2026-03-08 12:00 -0400", "2026-03-08 01:00 -0400", 23},
+		{"fall back at 01:00, Havana", "America/Havana", "2026-11-01 12:00 -0500", "2026-11-01 00:00 -0400", 25},
+		{"early morning on a short day", "America/Santiago", "2026-09-06 01:30 -0300", "2026-09-06 01:00 -0300", 23},
+		{"late evening on a long day", "America/Santiago", "2026-04-04 23:30 -0400", "2026-04-04 00:00 -0300", 25},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			loc := zone(t, tc.zone)
+			day := at(t, loc, tc.day)
+			want := at(t, loc, tc.midnight)
+			got := Midnight(day)
+			if !got.Equal(want) {
+				t.Fatalf("Midnight(%v) = %v, want %v", day, got, want)
+			}
+			if dateKey(got) != dateKey(day) {
+				t.Errorf("Midnight(%v) falls on %s", day, dateKey(got))
+			}
+			if h := nextMidnight(day).Sub(got).Hours(); h != tc.hours {
+				t.Errorf("day of %v is %vh long, want %vh", day, h, tc.hours)
+			}
+			if again := Midnight(got); !again.Equal(got) {
+				t.Errorf("Midnight(Midnight(%v)) = %v, want it unchanged", day, again)
+			}
+		})
+	}
+}
+
+// TestSplitAcrossMidnight runs a session over midnight on DST days and checks
+// that each day gets the real time worked before and after its midnight.
+func TestSplitAcrossMidnight(t *testing.T) {
+	for _, tc := range []struct {
+		name, zone  string
+		start, stop string
+		before      string // day the session starts on
+		after       string // day it stops on
+		first, last time.Duration
+	}{
+		{"plain night", "America/New_York", "2026-03-06 23:00 -0500", "2026-03-07 02:00 -0500", "2026-03-06", "2026-03-07", time.Hour, 2 * time.Hour},
+		{"into spring forward", "America/New_York", "2026-03-07 23:00 -0500", "2026-03-08 04:00 -0400", "2026-03-07", "2026-03-08", time.Hour, 3 * time.Hour},
+		{"into fall back", "America/New_York", "2026-10-31 23:00 -0400", "2026-11-01 03:00 -0500", "2026-10-31", "2026-11-01", time.Hour, 4 * time.Hour},
+		{"into spring forward at midnight", "America/Santiago", "2026-09-05 22:00 -0400", "2026-09-06 03:00 -0300", "2026-09-05", "2026-09-06", 2 * time.Hour, 2 * time.Hour},
+		{"over fall back at midnight", "America/Santiago", "2026-04-04 22:00 -0300", "2026-04-05 02:00 -0400", "2026-04-04", "2026-04-05", 3 * time.Hour, 2 * time.Hour},
+		{"into spring forward at midnight, Havana", "America/Havana", "2026-03-07 23:00 -0500", "2026-03-08 02:00 -0400", "2026-03-07", "2026-03-08", time.Hour, time.Hour},
+	} {
+		for _, how := range []string{"stop", "normalize"} {
+			t.Run(tc.name+"/"+how, func(t *testing.T) {
+				loc := zone(t, tc.zone)
+				start, stop := at(t, loc, tc.start), at(t, loc, tc.stop)
+				s := defaults()
+				if err := s.StartSession(start, nil, ""); err != nil {
+					t.Fatal(err)
+				}
+				switch how {
+				case "stop":
+					if _, err := s.StopSessionAt(stop); err != nil {
+						t.Fatal(err)
+					}
+				case "normalize":
+					s.Normalize(stop)
+					if s.ActiveSession == nil || !s.ActiveSession.Start.Equal(Midnight(stop)) {
+						t.Fatalf("running session starts at %v, want %v", s.ActiveSession, Midnight(stop))
+					}
+					if _, err := s.StopSession(stop); err != nil {
+						t.Fatal(err)
+					}
+				}
+				for _, d := range []struct {
+					key  string
+					want time.Duration
+				}{{tc.before, tc.first}, {tc.after, tc.last}} {
+					log, ok := s.Days[d.key]
+					if !ok {
+						t.Fatalf("no log for %s; have %v", d.key, keys(s.Days))
+					}
+					if got := time.Duration(log.TotalWorkSeconds) * time.Second; got != d.want {
+						t.Errorf("%s: %v worked, want %v", d.key, got, d.want)
+					}
+				}
+				if len(s.Days) != 2 {
+					t.Errorf("logged days %v, want %s and %s", keys(s.Days), tc.before, tc.after)
+				}
+			})
+		}
+	}
+}
+
+func keys(days map[string]*DayLog) []string {
+	var out []string
+	for k := range days {
+		out = append(out, k)
+	}
+	return out
+}
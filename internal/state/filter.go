@@ -0,0 +1,140 @@
+package state
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Filter narrows sessions and days for history/today views.
+// Zero-valued fields match everything; From/To are inclusive local dates.
+type Filter struct {
+	Tag     string
+	Project string
+	From    string // YYYY-MM-DD
+	To      string // YYYY-MM-DD
+}
+
+// ParseDay validates a YYYY-MM-DD date.
+func ParseDay(v string) (string, error) {
+	t, err := time.ParseInLocation("2006-01-02", v, time.Local)
+	if err != nil {
+		return "", fmt.Errorf("invalid date %q (want YYYY-MM-DD)", v)
+	}
+	return dateKey(t), nil
+}
+
+// HasSessionFilter reports whether the filter looks inside sessions (tag/project).
+func (f Filter) HasSessionFilter() bool {
+	return f.Tag != "" || f.Project != ""
+}
+
+// HasRange reports whether a date range was given.
+func (f Filter) HasRange() bool {
+	return f.From != "" || f.To != ""
+}
+
+// MatchDay reports whether the day key falls inside the date range.
+func (f Filter) MatchDay(key string) bool {
+	if f.From != "" && key < f.From {
+		return false
+	}
+	if f.To != "" && key > f.To {
+		return false
+	}
+	return true
+}
+
+// Match reports whether the session carries the filter's tag and project.
+func (f Filter) Match(sess Session) bool {
+	if f.Tag != "" && !sess.HasTag(f.Tag) {
+		return false
+	}
+	if f.Project != "" && !strings.EqualFold(sess.Project, f.Project) {
+		return false
+	}
+	return true
+}
+
+// Seconds returns the session length, measuring an open session up to now.
+func (s Session) Seconds(now time.Time) int {
+	if s.End != nil && s.Elapsed > 0 {
+		return s.Elapsed
+	}
+	end := now
+	if s.End != nil {
+		end = *s.End
+	}
+	secs := int(end.Sub(s.Start).Seconds())
+	if secs < 0 {
+		return 0
+	}
+	return secs
+}
+
+// DayKeys returns the logged days matching the filter's date range, newest first.
+func (s *State) DayKeys(f Filter) []string {
+	return s.RecentDayKeys(f, 0)
+}
+
+// FilteredWorkSeconds sums the day's work matching f. Without a tag/project
+// filter it returns the recorded day total.
+func (s *State) FilteredWorkSeconds(key string, f Filter) int {
+	log, ok := s.Days[key]
+	if !ok {
+		return 0
+	}
+	if !f.HasSessionFilter() {
+		return log.WorkSeconds()
+	}
+	total := 0
+	for _, sess := range log.Sessions {
+		if f.Match(sess) {
+			total += sess.Seconds(time.Now())
+		}
+	}
+	return total
+}
+
+// SearchHit is a session whose note, tags or project matched a search.
+type SearchHit struct {
+	Day     string  `json:"day"`
+	Session Session `json:"session"`
+}
+
+// Search scans sessions newest day first for a case-insensitive substring in
+// notes, tags and projects. limit <= 0 returns every hit.
+func (s *State) Search(query string, limit int) []SearchHit {
+	q := strings.ToLower(strings.TrimSpace(query))
+	if q == "" {
+		return nil
+	}
+	var hits []SearchHit
+	if s.ActiveSession != nil && sessionContains(*s.ActiveSession, q) {
+		hits = append(hits, SearchHit{Day: dateKey(s.ActiveSession.Start), Session: *s.ActiveSession})
+	}
+	for _, key := range s.DayKeys(Filter{}) {
+		sessions := s.Days[key].Sessions
+		for i := len(sessions) - 1; i >= 0; i-- {
+			if limit > 0 && len(hits) >= limit {
+				return hits
+			}
+			if sessionContains(sessions[i], q) {
+				hits = append(hits, SearchHit{Day: key, Session: sessions[i]})
+			}
+		}
+	}
+	return hits
+}
+
+func sessionContains(sess Session, q string) bool {
+	if strings.Contains(strings.ToLower(sess.Note), q) || strings.Contains(strings.ToLower(sess.Project), q) {
+		return true
+	}
+	for _, t := range sess.Tags {
+		if strings.Contains(strings.ToLower(t), q) {
+			return true
+		}
+	}
+	return false
+}
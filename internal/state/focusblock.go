@@ -0,0 +1,96 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/max-pantom/daily/internal/i18n"
+)
+
+// FocusTag marks the sessions of focus blocks; their note is the block's
+// label.
+const FocusTag = "focus"
+
+// FocusBlock is a labelled countdown offered in the tray's Focus block menu.
+type FocusBlock struct {
+	Label   string `json:"label"`
+	Minutes int    `json:"minutes"`
+}
+
+func (b FocusBlock) String() string {
+	return b.Label + "=" + HumanMinutes(b.Minutes)
+}
+
+// ParseFocusBlocks reads focus blocks written as "Write report=45m, Review
+// PRs=25m".
+func ParseFocusBlocks(v string) ([]FocusBlock, error) {
+	var out []FocusBlock
+	for _, item := range strings.Split(v, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		i := strings.LastIndex(item, "=")
+		if i < 0 {
+			return nil, fmt.Errorf("want label=duration, e.g. \"Write report=45m\", not %q", item)
+		}
+		label := strings.TrimSpace(item[:i])
+		if label == "" {
+			return nil, fmt.Errorf("%q has no label", item)
+		}
+		minutes, err := parseMinutes(strings.TrimSpace(item[i+1:]), false)
+		if err != nil || minutes == 0 {
+			return nil, fmt.Errorf("%s: want a duration such as 45m, not %q", label, item[i+1:])
+		}
+		out = append(out, FocusBlock{Label: label, Minutes: minutes})
+	}
+	return out, nil
+}
+
+// StartFocus starts a focus block: a countdown session of d, tagged FocusTag
+// as well as with tags, whose note is label.
+func (s *State) StartFocus(now time.Time, label string, d time.Duration, tags []string) error {
+	label = strings.TrimSpace(label)
+	if label == "" {
+		return errors.New("a focus block needs a label")
+	}
+	if err := s.StartCountdown(now, d, tags, label); err != nil {
+		return err
+	}
+	// Added after the tag check, which strict_tags would fail it.
+	if !s.ActiveSession.HasTag(FocusTag) {
+		s.ActiveSession.Tags = append(slices.Clone(s.ActiveSession.Tags), FocusTag)
+	}
+	return nil
+}
+
+// FocusLabel returns the label of the running focus block.
+func (s *State) FocusLabel() (string, bool) {
+	a := s.ActiveSession
+	if a == nil || a.Until == nil || a.Note == "" || !a.HasTag(FocusTag) {
+		return "", false
+	}
+	return a.Note, true
+}
+
+// ShortLabel cuts a focus block's label to fit a tray title or status bar.
+func ShortLabel(label string) string {
+	const width = 24
+	if r := []rune(label); len(r) > width {
+		return string(r[:width-1]) + "…"
+	}
+	return label
+}
+
+// CountdownNotice is the message for the running countdown session running
+// out, naming the focus block when it is one. Ask before FinishCountdown ends
+// the session.
+func (s *State) CountdownNotice() string {
+	if label, ok := s.FocusLabel(); ok {
+		return i18n.T("Time's up: %s is done", label)
+	}
+	return i18n.T("Time's up: countdown session stopped")
+}
@@ -0,0 +1,160 @@
+package state
+
+import (
+	"errors"
+	"time"
+)
+
+// A session looks forgotten when it runs for LongSession or more, or when the
+// last one of a day ends at LateStopHour or later.
+const (
+	LongSession  = 10 * time.Hour
+	LateStopHour = 23
+)
+
+// forgottenLookback bounds how many days back the last session is checked.
+const forgottenLookback = 7
+
+// ForgottenStop is a session that looks like it was left running: it may
+// still be running, possibly carried over midnight, or have ended very late.
+// Sessions split at midnight are reported as one, from Start to End.
+type ForgottenStop struct {
+	Day     string // day of the first piece
+	Index   int    // of the first piece in that day's sessions; -1 when it is the running session
+	Start   time.Time
+	End     time.Time // the recorded end, or now while running
+	Running bool
+}
+
+// FindForgottenStop looks for a session to fix before a new one starts: the
+// running session once it is long or has run on from an earlier day, or,
+// before today's first session, the last session of the last day worked when
+// it was long, ran late or was auto-stopped.
+func (s *State) FindForgottenStop(now time.Time) (ForgottenStop, bool) {
+	today := dateKey(now)
+	if s.ActiveSession != nil {
+		f := ForgottenStop{Index: -1, Start: s.ActiveSession.Start, End: now, Running: true, Day: dateKey(s.ActiveSession.Start)}
+		// Normalize splits a session running past midnight; walk back
+		// through the pieces to where it really began.
+		for {
+			key, i, ok := s.pieceEndingAt(f.Start)
+			if !ok {
+				break
+			}
+			f.Day, f.Index, f.Start = key, i, s.Days[key].Sessions[i].Start
+		}
+		if f.Day != today || now.Sub(f.Start) >= LongSession {
+			return f, true
+		}
+		return ForgottenStop{}, false
+	}
+	if log := s.Days[today]; log != nil && len(log.Sessions) > 0 {
+		return ForgottenStop{}, false
+	}
+	keys := s.DayKeys(Filter{From: dateKey(now.AddDate(0, 0, -forgottenLookback)), To: dateKey(now.AddDate(0, 0, -1))})
+	for _, key := range keys {
+		log := s.Days[key]
+		if log == nil || len(log.Sessions) == 0 {
+			continue
+		}
+		i := lastEnded(log.Sessions)
+		if i < 0 {
+			return ForgottenStop{}, false
+		}
+		f := ForgottenStop{Day: key, Index: i, Start: log.Sessions[i].Start, End: *log.Sessions[i].End}
+		for {
+			k, j, ok := s.pieceEndingAt(f.Start)
+			if !ok {
+				break
+			}
+			f.Day, f.Index, f.Start = k, j, s.Days[k].Sessions[j].Start
+		}
+		late := f.End.Hour() >= LateStopHour || !sameDate(f.End, f.End.Add(-time.Nanosecond)) // ran up to midnight
+		if late || f.End.Sub(f.Start) >= LongSession || log.AutoStopped != nil {
+			return f, true
+		}
+		return ForgottenStop{}, false
+	}
+	return ForgottenStop{}, false
+}
+
+// pieceEndingAt finds the finished session ending exactly at t, a midnight,
+// which the session starting at t continues.
+func (s *State) pieceEndingAt(t time.Time) (string, int, bool) {
+	if !Midnight(t).Equal(t) {
+		return "", 0, false
+	}
+	key := dateKey(t.Add(-time.Nanosecond))
+	log := s.Days[key]
+	if log == nil {
+		return "", 0, false
+	}
+	for i, sess := range log.Sessions {
+		if sess.End != nil && sess.End.Equal(t) {
+			return key, i, true
+		}
+	}
+	return "", 0, false
+}
+
+// lastEnded is the index of the session that ended last, or -1.
+func lastEnded(list []Session) int {
+	last := -1
+	for i, sess := range list {
+		if sess.End != nil && (last < 0 || sess.End.After(*list[last].End)) {
+			last = i
+		}
+	}
+	return last
+}
+
+// FixForgottenStop ends the session f describes at end instead, dropping the
+// pieces after it and ending the running session if it was one of them. The
+// days it touches get their totals rebuilt and their auto-stop flag cleared.
+func (s *State) FixForgottenStop(f ForgottenStop, end time.Time) error {
+	if !end.After(f.Start) {
+		return errors.New("the end must be after the session started")
+	}
+	if end.After(f.End) {
+		return errors.New("the end must be before the session's current end")
+	}
+	key, i := f.Day, f.Index
+	for i >= 0 {
+		piece := s.Days[key].Sessions[i]
+		was := *piece.End
+		s.editDay(key, func(log *DayLog) {
+			switch {
+			case !piece.Start.Before(end):
+				log.Sessions = append(log.Sessions[:i], log.Sessions[i+1:]...)
+			case was.After(end):
+				e := end
+				log.Sessions[i].End = &e
+				log.Sessions[i].Elapsed = int(end.Sub(piece.Start).Seconds())
+			}
+			log.TotalWorkSeconds = listSeconds(log.Sessions)
+			log.TotalWorkMinutes = log.TotalWorkSeconds / 60
+			log.AutoStopped = nil
+		})
+		if !Midnight(was).Equal(was) {
+			break
+		}
+		// The session went on past midnight: next piece.
+		key, i = dateKey(was), -1
+		if next := s.Days[key]; next != nil {
+			for j, sess := range next.Sessions {
+				if sess.Start.Equal(was) && sess.End != nil {
+					i = j
+				}
+			}
+		}
+	}
+	if f.Running && s.ActiveSession != nil {
+		if !s.ActiveSession.Start.Before(end) {
+			s.ActiveSession, s.Checkpoint = nil, nil
+			return nil
+		}
+		_, err := s.StopSessionAt(end)
+		return err
+	}
+	return nil
+}
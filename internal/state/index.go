@@ -0,0 +1,232 @@
+package state
+
+import (
+	"slices"
+	"sort"
+)
+
+// dayIndex groups the logged day keys by month, both newest first, and keeps
+// each month's unfiltered totals, so views over recent days, a date range or
+// whole months visit only what they need instead of every day of a
+// multi-year history. It is built on first use; from then on every change to
+// Days goes through editDay or setDay, which keep it in step.
+type dayIndex struct {
+	months  []string
+	byMonth map[string][]string
+	totals  map[string]*MonthTotal
+}
+
+// monthOf is the YYYY-MM of a day key.
+func monthOf(key string) string {
+	if len(key) < 7 {
+		return key
+	}
+	return key[:7]
+}
+
+func (s *State) index() *dayIndex {
+	if s.idx != nil {
+		return s.idx
+	}
+	idx := &dayIndex{byMonth: make(map[string][]string), totals: make(map[string]*MonthTotal)}
+	for key, log := range s.Days {
+		m := monthOf(key)
+		if _, ok := idx.byMonth[m]; !ok {
+			idx.months = append(idx.months, m)
+			idx.totals[m] = &MonthTotal{Month: m}
+		}
+		idx.byMonth[m] = append(idx.byMonth[m], key)
+		idx.count(key, log, 1)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(idx.months)))
+	for _, keys := range idx.byMonth {
+		sort.Sort(sort.Reverse(sort.StringSlice(keys)))
+	}
+	s.idx = idx
+	return idx
+}
+
+// editDay applies fn to the day logged under key, adding the day first if it
+// is missing. Days are only ever changed through it or setDay.
+func (s *State) editDay(key string, fn func(log *DayLog)) {
+	log := s.Days[key]
+	if log == nil {
+		log = &DayLog{Date: key, GoalMinutes: s.GoalMinutes}
+		s.setDay(key, log)
+	}
+	if s.idx != nil {
+		s.idx.count(key, log, -1)
+	}
+	fn(log)
+	if s.idx != nil {
+		s.idx.count(key, log, 1)
+	}
+}
+
+// setDay logs log under key in place of whatever was there, or removes the
+// day when log is nil.
+func (s *State) setDay(key string, log *DayLog) {
+	old, had := s.Days[key]
+	if idx := s.idx; idx != nil {
+		idx.count(key, old, -1)
+		switch {
+		case log == nil && had:
+			idx.remove(key)
+		case log != nil && !had:
+			idx.insert(key)
+		}
+		idx.count(key, log, 1)
+	}
+	if log == nil {
+		delete(s.Days, key)
+		return
+	}
+	if s.Days == nil {
+		s.Days = make(map[string]*DayLog)
+	}
+	s.Days[key] = log
+}
+
+// SetDays replaces every logged day, such as with the history from another
+// machine.
+func (s *State) SetDays(days map[string]*DayLog) {
+	s.Days = days
+	s.idx = nil
+}
+
+// count adds the day's share to its month's totals, or takes it off again
+// when sign is -1.
+func (idx *dayIndex) count(key string, log *DayLog, sign int) {
+	t := idx.totals[monthOf(key)]
+	if log == nil || t == nil {
+		return
+	}
+	work := log.WorkSeconds()
+	t.WorkSeconds += sign * work
+	t.BreakSeconds += sign * log.BreakSeconds()
+	if work > 0 {
+		t.DaysWorked += sign
+	}
+}
+
+// insert indexes a day key just added to Days.
+func (idx *dayIndex) insert(key string) {
+	m := monthOf(key)
+	keys, ok := idx.byMonth[m]
+	if !ok {
+		i := sort.Search(len(idx.months), func(i int) bool { return idx.months[i] < m })
+		idx.months = slices.Insert(idx.months, i, m)
+		idx.totals[m] = &MonthTotal{Month: m}
+	}
+	i := sort.Search(len(keys), func(i int) bool { return keys[i] < key })
+	idx.byMonth[m] = slices.Insert(keys, i, key)
+}
+
+// remove drops a day key just removed from Days, and its month with the last
+// of its days.
+func (idx *dayIndex) remove(key string) {
+	m := monthOf(key)
+	keys := idx.byMonth[m]
+	if i := slices.Index(keys, key); i >= 0 {
+		keys = slices.Delete(keys, i, i+1)
+	}
+	if len(keys) > 0 {
+		idx.byMonth[m] = keys
+		return
+	}
+	delete(idx.byMonth, m)
+	delete(idx.totals, m)
+	if i := slices.Index(idx.months, m); i >= 0 {
+		idx.months = slices.Delete(idx.months, i, i+1)
+	}
+}
+
+// firstDay is the oldest logged day's key, or "" when none is logged.
+func (s *State) firstDay() string {
+	idx := s.index()
+	if len(idx.months) == 0 {
+		return ""
+	}
+	keys := idx.byMonth[idx.months[len(idx.months)-1]]
+	return keys[len(keys)-1]
+}
+
+// eachDay calls fn with the logged days in f's date range, newest first,
+// until it returns false.
+func (s *State) eachDay(f Filter, fn func(key string) bool) {
+	idx := s.index()
+	for _, m := range idx.months {
+		if f.To != "" && m+"-00" > f.To {
+			continue
+		}
+		if f.From != "" && m+"-99" < f.From {
+			return
+		}
+		for _, key := range idx.byMonth[m] {
+			if f.MatchDay(key) && !fn(key) {
+				return
+			}
+		}
+	}
+}
+
+// RecentDayKeys returns up to n of the newest logged days matching the
+// filter's date range, newest first; n <= 0 returns them all. Only the
+// months holding them are visited.
+func (s *State) RecentDayKeys(f Filter, n int) []string {
+	var keys []string
+	s.eachDay(f, func(key string) bool {
+		keys = append(keys, key)
+		return n <= 0 || len(keys) < n
+	})
+	return keys
+}
+
+// MonthTotal sums the logged days of one month.
+type MonthTotal struct {
+	Month        string `json:"month"` // YYYY-MM
+	DaysWorked   int    `json:"days_worked"`
+	WorkSeconds  int    `json:"work_seconds"`
+	BreakSeconds int    `json:"break_seconds"`
+}
+
+// MonthTotals sums the logged days in f's date range by month, newest first.
+// Months wholly inside the range come from the kept totals; only the days of
+// the months at its ends are added up, and every day when tag/project
+// filters count only matching sessions.
+func (s *State) MonthTotals(f Filter) []MonthTotal {
+	idx := s.index()
+	var out []MonthTotal
+	for _, m := range idx.months {
+		if f.To != "" && m+"-00" > f.To {
+			continue
+		}
+		if f.From != "" && m+"-99" < f.From {
+			break
+		}
+		if !f.HasSessionFilter() && (f.From == "" || f.From <= m+"-01") && (f.To == "" || f.To >= m+"-31") {
+			out = append(out, *idx.totals[m])
+			continue
+		}
+		t := MonthTotal{Month: m}
+		matched := false
+		for _, key := range idx.byMonth[m] {
+			if !f.MatchDay(key) {
+				continue
+			}
+			matched = true
+			work := s.FilteredWorkSeconds(key, f)
+			t.WorkSeconds += work
+			if work > 0 {
+				t.DaysWorked++
+			}
+			if log := s.Days[key]; log != nil && !f.HasSessionFilter() {
+				t.BreakSeconds += log.BreakSeconds()
+			}
+		}
+		if matched {
+			out = append(out, t)
+		}
+	}
+	return out
+}
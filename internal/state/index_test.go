@@ -0,0 +1,112 @@
+package state
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+// scanDays is RecentDayKeys and MonthTotals the slow way, over every day.
+func scanDays(s *State, f Filter) ([]string, []MonthTotal) {
+	var keys []string
+	for key := range s.Days {
+		if f.MatchDay(key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(keys)))
+	var months []MonthTotal
+	for _, key := range keys {
+		m := monthOf(key)
+		if len(months) == 0 || months[len(months)-1].Month != m {
+			months = append(months, MonthTotal{Month: m})
+		}
+		t := &months[len(months)-1]
+		work := s.FilteredWorkSeconds(key, f)
+		t.WorkSeconds += work
+		if work > 0 {
+			t.DaysWorked++
+		}
+		if log := s.Days[key]; log != nil && !f.HasSessionFilter() {
+			t.BreakSeconds += log.BreakSeconds()
+		}
+	}
+	return keys, months
+}
+
+func TestDayIndex(t *testing.T) {
+	local := func(v string) time.Time {
+		at, err := time.ParseInLocation("2006-01-02 15:04", v, time.Local)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return at
+	}
+	s := defaults()
+	s.SetDays(days(map[string][]int{
+		"2026-01-30": {60, 30},
+		"2026-02-02": {90},
+		"2026-02-16": {0, 45},
+		"2026-03-31": {120},
+	}))
+	base := clone(t, s.Days)
+	filters := []Filter{
+		{},
+		{From: "2026-02-10"},
+		{To: "2026-02-28"},
+		{From: "2026-02-01", To: "2026-03-01"},
+		{From: "2026-02-03", To: "2026-02-20"},
+		{Tag: "deep"},
+		{Tag: "deep", From: "2026-03-01"},
+	}
+	check := func(step string) {
+		t.Helper()
+		for _, f := range filters {
+			wantKeys, wantMonths := scanDays(s, f)
+			if got := s.RecentDayKeys(f, 0); !reflect.DeepEqual(got, wantKeys) {
+				t.Errorf("%s: RecentDayKeys(%+v) = %v, want %v", step, f, got, wantKeys)
+			}
+			if n := 2; len(wantKeys) > n {
+				if got := s.RecentDayKeys(f, n); !reflect.DeepEqual(got, wantKeys[:n]) {
+					t.Errorf("%s: RecentDayKeys(%+v, %d) = %v, want %v", step, f, n, got, wantKeys[:n])
+				}
+			}
+			if got := s.MonthTotals(f); !reflect.DeepEqual(got, wantMonths) {
+				t.Errorf("%s: MonthTotals(%+v) = %+v, want %+v", step, f, got, wantMonths)
+			}
+		}
+	}
+	check("loaded")
+
+	// Across the end of February into a month with no days yet.
+	s.AddWork(Session{Tags: []string{"deep"}}, local("2026-02-28 23:00"), local("2026-03-01 01:30"))
+	s.AddBreak(local("2026-02-16 12:00"), local("2026-02-16 12:20"))
+	s.AddBreak(local("2026-04-02 10:00"), local("2026-04-02 10:15"))
+	check("added")
+
+	// Merging takes the old session's time off its day and adds the whole span.
+	if _, err := s.AddSession(Session{}, local("2026-03-31 08:00"), local("2026-03-31 10:00"), local("2026-04-10 00:00"), OverlapMerge); err != nil {
+		t.Fatal(err)
+	}
+	check("merged a session")
+
+	s.RecalcTotals()
+	s.RecordRelax(local("2026-05-01 18:00"), 600, 3)
+	check("recalculated")
+
+	// The other machine deleted January and logged a day in June.
+	theirs := clone(t, base)
+	delete(theirs, "2026-01-30")
+	for key, log := range days(map[string][]int{"2026-06-01": {30}}) {
+		theirs[key] = log
+	}
+	s.MergeDays(base, theirs)
+	if _, ok := s.Days["2026-01-30"]; ok {
+		t.Fatal("merge kept the day the other machine deleted")
+	}
+	check("merged days")
+
+	s.SetDays(days(map[string][]int{"2025-12-24": {15}}))
+	check("replaced")
+}
@@ -0,0 +1,129 @@
+package state
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Interruption is time a session was paused for something else, such as a
+// phone call, with the reason given.
+type Interruption struct {
+	Start   time.Time  `json:"start"`
+	End     *time.Time `json:"end,omitempty"`
+	Reason  string     `json:"reason"`
+	Elapsed int        `json:"seconds,omitempty"`
+	Resume  *Session   `json:"resume,omitempty"` // tags, note and project of the paused session
+}
+
+// Seconds is the interruption's length, up to now while it lasts.
+func (i Interruption) Seconds(now time.Time) int {
+	if i.End != nil {
+		return i.Elapsed
+	}
+	if secs := int(now.Sub(i.Start).Seconds()); secs > 0 {
+		return secs
+	}
+	return 0
+}
+
+// Interrupt stops the running session and starts an interruption for reason.
+// Resume starts the session again with the same tags, note and project.
+func (s *State) Interrupt(now time.Time, reason string) error {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return errors.New("give a reason, e.g. daily interrupt \"phone call\"")
+	}
+	if s.ActiveInterruption != nil {
+		return errors.New("already interrupted by " + s.ActiveInterruption.Reason)
+	}
+	if s.ActiveSession == nil {
+		return errors.New("no active session to interrupt")
+	}
+	paused := Session{Tags: s.ActiveSession.Tags, Note: s.ActiveSession.Note, Project: s.ActiveSession.Project}
+	if _, err := s.StopSession(now); err != nil {
+		return err
+	}
+	s.ActiveInterruption = &Interruption{Start: now, Reason: reason, Resume: &paused}
+	return nil
+}
+
+// Resume ends the interruption, records it and restarts the paused session.
+func (s *State) Resume(now time.Time) (Interruption, error) {
+	in, err := s.EndInterruption(now)
+	if err != nil {
+		return in, err
+	}
+	var sess Session
+	if in.Resume != nil {
+		sess = *in.Resume
+	}
+	if err := s.StartSession(now, sess.Tags, sess.Note); err != nil {
+		return in, err
+	}
+	s.ActiveSession.Project = sess.Project
+	return in, nil
+}
+
+// EndInterruption records the running interruption in the log of the day it
+// began, without restarting the session.
+func (s *State) EndInterruption(now time.Time) (Interruption, error) {
+	if s.ActiveInterruption == nil {
+		return Interruption{}, errors.New("no interruption running")
+	}
+	in := *s.ActiveInterruption
+	if now.Before(in.Start) {
+		return in, errors.New("interruption end before start")
+	}
+	end := now
+	in.End = &end
+	in.Elapsed = int(now.Sub(in.Start).Seconds())
+	s.ActiveInterruption = nil
+	recorded := in
+	recorded.Resume = nil
+	s.editDay(dateKey(in.Start), func(log *DayLog) {
+		log.Interruptions = append(log.Interruptions, recorded)
+	})
+	return in, nil
+}
+
+// ReasonTotal is the time lost to one interruption reason.
+type ReasonTotal struct {
+	Reason  string
+	Count   int
+	Seconds int
+}
+
+// InterruptionTotals sums the interruptions logged from the day from to the
+// day to (YYYY-MM-DD, inclusive) by reason, longest first. Reasons differing
+// only in case count as one.
+func (s *State) InterruptionTotals(from, to string) []ReasonTotal {
+	byReason := map[string]*ReasonTotal{}
+	for key, log := range s.Days {
+		if key < from || key > to {
+			continue
+		}
+		for _, in := range log.Interruptions {
+			id := strings.ToLower(in.Reason)
+			t := byReason[id]
+			if t == nil {
+				t = &ReasonTotal{Reason: in.Reason}
+				byReason[id] = t
+			}
+			t.Count++
+			t.Seconds += in.Elapsed
+		}
+	}
+	out := make([]ReasonTotal, 0, len(byReason))
+	for _, t := range byReason {
+		out = append(out, *t)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Seconds == out[j].Seconds {
+			return out[i].Reason < out[j].Reason
+		}
+		return out[i].Seconds > out[j].Seconds
+	})
+	return out
+}
@@ -0,0 +1,239 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Interval is the time from Start up to, not including, End.
+type Interval struct {
+	Start, End time.Time
+}
+
+// Empty reports whether the interval holds no time.
+func (i Interval) Empty() bool {
+	return !i.End.After(i.Start)
+}
+
+// Overlaps reports whether the two intervals share any time; touching ends
+// do not count.
+func (i Interval) Overlaps(j Interval) bool {
+	return i.Start.Before(j.End) && j.Start.Before(i.End)
+}
+
+// Subtract returns the parts of i that none of cut covers, in order.
+func Subtract(i Interval, cut []Interval) []Interval {
+	cut = Union(cut)
+	var out []Interval
+	for _, c := range cut {
+		if !c.Overlaps(i) {
+			continue
+		}
+		if c.Start.After(i.Start) {
+			out = append(out, Interval{i.Start, c.Start})
+		}
+		if c.End.After(i.Start) {
+			i.Start = c.End
+		}
+	}
+	if !i.Empty() {
+		out = append(out, i)
+	}
+	return out
+}
+
+// Union merges overlapping or touching intervals, returning them sorted and
+// without the empty ones.
+func Union(list []Interval) []Interval {
+	sorted := make([]Interval, 0, len(list))
+	for _, i := range list {
+		if !i.Empty() {
+			sorted = append(sorted, i)
+		}
+	}
+	sort.Slice(sorted, func(a, b int) bool { return sorted[a].Start.Before(sorted[b].Start) })
+	var out []Interval
+	for _, i := range sorted {
+		if n := len(out); n > 0 && !i.Start.After(out[n-1].End) {
+			if i.End.After(out[n-1].End) {
+				out[n-1].End = i.End
+			}
+			continue
+		}
+		out = append(out, i)
+	}
+	return out
+}
+
+// Overlap policies for AddSession.
+const (
+	OverlapRefuse = "refuse" // leave everything as it is and say what is in the way
+	OverlapTrim   = "trim"   // add only the time no session covers yet
+	OverlapMerge  = "merge"  // join the session with those it overlaps into one
+)
+
+// OverlapError is returned by AddSession under OverlapRefuse.
+type OverlapError struct {
+	Day     string
+	Session Session // the first session in the way
+}
+
+func (e *OverlapError) Error() string {
+	end := "now (running)"
+	if e.Session.End != nil {
+		end = e.Session.End.Format("15:04")
+	}
+	return fmt.Sprintf("overlaps the session on %s from %s to %s", e.Day, e.Session.Start.Format("15:04"), end)
+}
+
+// sessionRef locates a finished session in Days.
+type sessionRef struct {
+	day   string
+	index int
+}
+
+// overlapping returns the finished sessions sharing time with span.
+func (s *State) overlapping(span Interval) []sessionRef {
+	var refs []sessionRef
+	for d := Midnight(span.Start).AddDate(0, 0, -1); d.Before(span.End); d = d.AddDate(0, 0, 1) {
+		key := dateKey(d)
+		log, ok := s.Days[key]
+		if !ok {
+			continue
+		}
+		for i, sess := range log.Sessions {
+			if sess.End != nil && (Interval{sess.Start, *sess.End}).Overlaps(span) {
+				refs = append(refs, sessionRef{key, i})
+			}
+		}
+	}
+	return refs
+}
+
+// AddSession records sess as work from start to end after the fact, splitting
+// it at midnight and keeping the day totals in step. Time other sessions
+// already cover is refused, left out or merged with them according to policy;
+// time the running session covers is never added. It returns the work added.
+func (s *State) AddSession(sess Session, start, end, now time.Time, policy string) (time.Duration, error) {
+	span := Interval{start.Round(0), end.Round(0)}
+	switch {
+	case span.Empty():
+		return 0, errors.New("the session must end after it starts")
+	case span.End.After(now):
+		return 0, errors.New("the session must not end in the future")
+	case policy != OverlapRefuse && policy != OverlapTrim && policy != OverlapMerge:
+		return 0, fmt.Errorf("unknown overlap policy %q (want %s, %s or %s)", policy, OverlapRefuse, OverlapTrim, OverlapMerge)
+	}
+	if a := s.ActiveSession; a != nil && a.Start.Before(span.End) {
+		if policy == OverlapRefuse {
+			return 0, &OverlapError{Day: dateKey(a.Start), Session: *a}
+		}
+		span.End = a.Start
+		if span.Empty() {
+			return 0, errors.New("the running session covers all of it")
+		}
+	}
+	sess.Start, sess.Tags = span.Start, slices.Clone(sess.Tags)
+	s.applyTagRules(&sess, &span.End)
+	refs := s.overlapping(span)
+	if len(refs) > 0 && policy == OverlapRefuse {
+		return 0, &OverlapError{Day: refs[0].day, Session: s.Days[refs[0].day].Sessions[refs[0].index]}
+	}
+
+	if policy == OverlapMerge && len(refs) > 0 {
+		before := s.workIn(refs)
+		merged := span
+		for _, r := range refs {
+			old := s.Days[r.day].Sessions[r.index]
+			merged.Start = minTime(merged.Start, old.Start)
+			merged.End = maxTime(merged.End, *old.End)
+			sess = mergeSessions(sess, old)
+		}
+		s.removeSessions(refs)
+		s.AddWork(sess, merged.Start, merged.End)
+		s.sortSessions(merged)
+		return merged.End.Sub(merged.Start) - before, nil
+	}
+	var taken []Interval
+	for _, r := range refs {
+		old := s.Days[r.day].Sessions[r.index]
+		taken = append(taken, Interval{old.Start, *old.End})
+	}
+	pieces := Subtract(span, taken)
+	if len(pieces) == 0 {
+		return 0, errors.New("other sessions already cover all of it")
+	}
+	var added time.Duration
+	for _, p := range pieces {
+		s.AddWork(sess, p.Start, p.End)
+		added += p.End.Sub(p.Start)
+	}
+	s.sortSessions(span)
+	return added, nil
+}
+
+// sortSessions puts the sessions of the days span touches back in order of
+// start, which sessions added after the fact break.
+func (s *State) sortSessions(span Interval) {
+	for d := Midnight(span.Start); d.Before(span.End); d = d.AddDate(0, 0, 1) {
+		if key := dateKey(d); s.Days[key] != nil {
+			s.editDay(key, func(log *DayLog) {
+				sort.SliceStable(log.Sessions, func(i, j int) bool { return log.Sessions[i].Start.Before(log.Sessions[j].Start) })
+			})
+		}
+	}
+}
+
+// workIn is the time the sessions refs logged, which merging replaces.
+func (s *State) workIn(refs []sessionRef) time.Duration {
+	var total time.Duration
+	for _, r := range refs {
+		old := s.Days[r.day].Sessions[r.index]
+		total += time.Duration(old.Seconds(*old.End)) * time.Second
+	}
+	return total
+}
+
+// removeSessions drops the sessions refs points to, taking their time off
+// the day totals.
+func (s *State) removeSessions(refs []sessionRef) {
+	byDay := map[string][]int{}
+	for _, r := range refs {
+		byDay[r.day] = append(byDay[r.day], r.index)
+	}
+	for key, idx := range byDay {
+		slices.Sort(idx)
+		s.editDay(key, func(log *DayLog) {
+			for i := len(idx) - 1; i >= 0; i-- {
+				secs := log.Sessions[idx[i]].Seconds(*log.Sessions[idx[i]].End)
+				log.Sessions = slices.Delete(log.Sessions, idx[i], idx[i]+1)
+				log.TotalWorkSeconds = max(log.WorkSeconds()-secs, 0)
+				log.TotalWorkMinutes = log.TotalWorkSeconds / 60
+			}
+		})
+	}
+}
+
+// mergeSessions keeps a's project, or takes b's, and joins their tags and
+// notes.
+func mergeSessions(a, b Session) Session {
+	for _, t := range b.Tags {
+		if !a.HasTag(t) {
+			a.Tags = append(a.Tags, t)
+		}
+	}
+	switch {
+	case a.Note == "":
+		a.Note = b.Note
+	case b.Note != "" && !strings.Contains(a.Note, b.Note):
+		a.Note += "; " + b.Note
+	}
+	if a.Project == "" {
+		a.Project = b.Project
+	}
+	return a
+}
@@ -0,0 +1,210 @@
+package state
+
+import (
+	"errors"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// clock parses "DD HH:MM", a time in March 2026.
+func clock(t *testing.T, v string) time.Time {
+	t.Helper()
+	tm, err := time.Parse("2006-01-02 15:04", "2026-03-"+v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tm
+}
+
+// spans parses intervals written "DD HH:MM-DD HH:MM".
+func spans(t *testing.T, list ...string) []Interval {
+	t.Helper()
+	var out []Interval
+	for _, v := range list {
+		from, to, _ := strings.Cut(v, "-")
+		out = append(out, Interval{clock(t, from), clock(t, to)})
+	}
+	return out
+}
+
+// logged lists the finished sessions, day by day, as spans would read them.
+func logged(s *State) []string {
+	var out []string
+	days := keys(s.Days)
+	slices.Sort(days)
+	for _, key := range days {
+		for _, sess := range s.Days[key].Sessions {
+			out = append(out, sess.Start.Format("02 15:04")+"-"+sess.End.Format("02 15:04"))
+		}
+	}
+	return out
+}
+
+// workMinutes adds up the days' TotalWorkMinutes.
+func workMinutes(s *State) int {
+	total := 0
+	for _, log := range s.Days {
+		total += log.TotalWorkMinutes
+	}
+	return total
+}
+
+func TestUnion(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		in, want []string
+	}{
+		{"nothing", nil, nil},
+		{"empty ones dropped", []string{"02 09:00-02 09:00", "02 11:00-02 10:00"}, nil},
+		{"apart stay apart", []string{"02 11:00-02 12:00", "02 09:00-02 10:00"}, []string{"02 09:00-02 10:00", "02 11:00-02 12:00"}},
+		{"touching joined", []string{"02 09:00-02 10:00", "02 10:00-02 11:00"}, []string{"02 09:00-02 11:00"}},
+		{"overlapping joined", []string{"02 10:30-02 12:00", "02 09:00-02 11:00"}, []string{"02 09:00-02 12:00"}},
+		{"contained", []string{"02 09:00-02 12:00", "02 10:00-02 11:00"}, []string{"02 09:00-02 12:00"}},
+		{"across midnight", []string{"02 23:00-03 01:00", "03 00:30-03 02:00"}, []string{"02 23:00-03 02:00"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got, want := Union(spans(t, tc.in...)), spans(t, tc.want...); !reflect.DeepEqual(got, want) {
+				t.Errorf("Union = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestSubtract(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		from      string
+		cut, want []string
+	}{
+		{"nothing cut", "02 09:00-02 12:00", nil, []string{"02 09:00-02 12:00"}},
+		{"cut outside", "02 09:00-02 12:00", []string{"02 12:00-02 13:00", "02 07:00-02 09:00"}, []string{"02 09:00-02 12:00"}},
+		{"middle", "02 09:00-02 12:00", []string{"02 10:00-02 11:00"}, []string{"02 09:00-02 10:00", "02 11:00-02 12:00"}},
+		{"start", "02 09:00-02 12:00", []string{"02 08:00-02 10:00"}, []string{"02 10:00-02 12:00"}},
+		{"end", "02 09:00-02 12:00", []string{"02 11:00-02 13:00"}, []string{"02 09:00-02 11:00"}},
+		{"all of it", "02 09:00-02 12:00", []string{"02 09:00-02 10:30", "02 10:00-02 12:00"}, nil},
+		{"overlapping cuts", "02 09:00-02 13:00", []string{"02 11:00-02 12:00", "02 10:00-02 11:30"}, []string{"02 09:00-02 10:00", "02 12:00-02 13:00"}},
+		{"across midnight", "02 22:00-03 02:00", []string{"02 23:00-03 01:00"}, []string{"02 22:00-02 23:00", "03 01:00-03 02:00"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := Subtract(spans(t, tc.from)[0], spans(t, tc.cut...))
+			if want := spans(t, tc.want...); !reflect.DeepEqual(got, want) {
+				t.Errorf("Subtract = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestAddSession(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		logged  []string // sessions already there, tagged "old"
+		running string   // start of the running session, "" for none
+		add     string
+		policy  string
+		err     string // part of the error, "overlap" for an *OverlapError
+		want    []string
+		added   time.Duration
+		joined  bool // merged with the logged sessions, taking their tags
+	}{
+		{name: "refuse: nothing in the way", logged: []string{"02 09:00-02 10:00"}, add: "02 10:00-02 11:00", policy: OverlapRefuse,
+			want: []string{"02 09:00-02 10:00", "02 10:00-02 11:00"}, added: time.Hour},
+		{name: "refuse: overlaps a session", logged: []string{"02 09:00-02 10:00"}, add: "02 09:30-02 11:00", policy: OverlapRefuse, err: "overlap"},
+		{name: "refuse: overlaps the running session", running: "02 10:30", add: "02 10:00-02 11:00", policy: OverlapRefuse, err: "overlap"},
+		{name: "refuse: across midnight", add: "02 23:00-03 01:00", policy: OverlapRefuse,
+			want: []string{"02 23:00-03 00:00", "03 00:00-03 01:00"}, added: 2 * time.Hour},
+		{name: "trim: both ends", logged: []string{"02 09:00-02 10:00"}, add: "02 08:30-02 10:30", policy: OverlapTrim,
+			want: []string{"02 08:30-02 09:00", "02 09:00-02 10:00", "02 10:00-02 10:30"}, added: time.Hour},
+		{name: "trim: between two", logged: []string{"02 09:00-02 10:00", "02 11:00-02 12:00"}, add: "02 09:30-02 11:30", policy: OverlapTrim,
+			want: []string{"02 09:00-02 10:00", "02 10:00-02 11:00", "02 11:00-02 12:00"}, added: time.Hour},
+		{name: "trim: fully covered", logged: []string{"02 09:00-02 10:00", "02 10:00-02 12:00"}, add: "02 09:30-02 11:00", policy: OverlapTrim, err: "already cover"},
+		{name: "trim: up to the running session", running: "02 11:00", add: "02 10:00-02 12:00", policy: OverlapTrim,
+			want: []string{"02 10:00-02 11:00"}, added: time.Hour},
+		{name: "trim: all running", running: "02 09:00", add: "02 10:00-02 11:00", policy: OverlapTrim, err: "running session covers"},
+		{name: "trim: across midnight", logged: []string{"03 00:00-03 00:30"}, add: "02 23:00-03 01:00", policy: OverlapTrim,
+			want: []string{"02 23:00-03 00:00", "03 00:00-03 00:30", "03 00:30-03 01:00"}, added: 90 * time.Minute},
+		{name: "merge: joins both", logged: []string{"02 09:00-02 10:00", "02 10:30-02 11:00"}, add: "02 09:30-02 10:45", policy: OverlapMerge,
+			want: []string{"02 09:00-02 11:00"}, added: 30 * time.Minute, joined: true},
+		{name: "merge: covered adds nothing", logged: []string{"02 09:00-02 12:00"}, add: "02 10:00-02 11:00", policy: OverlapMerge,
+			want: []string{"02 09:00-02 12:00"}, joined: true},
+		{name: "merge: nothing in the way", logged: []string{"02 09:00-02 10:00"}, add: "02 11:00-02 12:00", policy: OverlapMerge,
+			want: []string{"02 09:00-02 10:00", "02 11:00-02 12:00"}, added: time.Hour},
+		{name: "merge: across midnight", logged: []string{"02 23:00-03 00:30"}, add: "02 23:30-03 01:00", policy: OverlapMerge,
+			want: []string{"02 23:00-03 00:00", "03 00:00-03 01:00"}, added: 30 * time.Minute, joined: true},
+		{name: "empty", add: "02 10:00-02 10:00", policy: OverlapTrim, err: "end after it starts"},
+		{name: "in the future", add: "09 23:00-10 01:00", policy: OverlapTrim, err: "future"},
+		{name: "unknown policy", add: "02 10:00-02 11:00", policy: "squash", err: "unknown overlap policy"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s := defaults()
+			for _, span := range spans(t, tc.logged...) {
+				s.AddWork(Session{Tags: []string{"old"}}, span.Start, span.End)
+			}
+			if tc.running != "" {
+				if err := s.StartSession(clock(t, tc.running), nil, ""); err != nil {
+					t.Fatal(err)
+				}
+			}
+			before, minutes := logged(s), workMinutes(s)
+			span := spans(t, tc.add)[0]
+			added, err := s.AddSession(Session{Tags: []string{"new"}}, span.Start, span.End, clock(t, "10 00:00"), tc.policy)
+			if tc.err != "" {
+				var overlap *OverlapError
+				switch {
+				case err == nil:
+					t.Fatalf("added %s, want an error", added)
+				case tc.err == "overlap" && !errors.As(err, &overlap):
+					t.Fatalf("err = %v, want an *OverlapError", err)
+				case tc.err != "overlap" && !strings.Contains(err.Error(), tc.err):
+					t.Fatalf("err = %v, want %q", err, tc.err)
+				}
+				if got := logged(s); !slices.Equal(got, before) || workMinutes(s) != minutes {
+					t.Errorf("refused, yet the sessions went from %v to %v", before, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := logged(s); !slices.Equal(got, tc.want) {
+				t.Errorf("sessions = %v, want %v", got, tc.want)
+			}
+			if added != tc.added {
+				t.Errorf("added %s, want %s", added, tc.added)
+			}
+			if got := time.Duration(workMinutes(s)-minutes) * time.Minute; got != added {
+				t.Errorf("TotalWorkMinutes grew by %s, but AddSession says %s", got, added)
+			}
+			for _, key := range keys(s.Days) {
+				if log := s.Days[key]; log.TotalWorkSeconds != listSeconds(log.Sessions) {
+					t.Errorf("%s: total %ds, sessions %ds", key, log.TotalWorkSeconds, listSeconds(log.Sessions))
+				}
+			}
+			if tc.joined {
+				for _, sess := range s.Days[dateKey(span.Start)].Sessions {
+					if sess.Start.Before(span.End) && span.Start.Before(*sess.End) && !(sess.HasTag("old") && sess.HasTag("new")) {
+						t.Errorf("merged session tagged %v, want old and new", sess.Tags)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestRemoveSessions(t *testing.T) {
+	s := defaults()
+	for _, span := range spans(t, "02 09:00-02 10:00", "02 11:00-02 11:30", "02 14:00-02 16:00", "03 09:00-03 10:00") {
+		s.AddWork(Session{}, span.Start, span.End)
+	}
+	s.removeSessions([]sessionRef{{"2026-03-02", 2}, {"2026-03-03", 0}, {"2026-03-02", 0}})
+	if got, want := logged(s), []string{"02 11:00-02 11:30"}; !slices.Equal(got, want) {
+		t.Errorf("sessions = %v, want %v", got, want)
+	}
+	for key, want := range map[string]int{"2026-03-02": 30, "2026-03-03": 0} {
+		if log := s.Days[key]; log.TotalWorkMinutes != want || log.TotalWorkSeconds != want*60 {
+			t.Errorf("%s: total %d min (%ds), want %d min", key, log.TotalWorkMinutes, log.TotalWorkSeconds, want)
+		}
+	}
+}
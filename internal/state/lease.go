@@ -0,0 +1,83 @@
+package state
+
+import "time"
+
+// LeaseTTL is how long a lease lasts unless renewed. Holders renew it well
+// within that, so one that crashed frees the session within LeaseTTL.
+const LeaseTTL = 3 * time.Minute
+
+// Lease records which component controls the active session and break, such
+// as a sprint switching between them on a timer. While it is live the others
+// leave them alone and ask the holder instead, so two long-running commands
+// never stop or start sessions over each other.
+type Lease struct {
+	Owner string     `json:"owner"` // the holder and its pid, e.g. "sprint 4242"
+	Until time.Time  `json:"until"`
+	Pause *time.Time `json:"pause,omitempty"` // asked of the holder: stop the session here, e.g. where watch saw idleness begin
+}
+
+// LeaseError is returned when another component holds the lease.
+type LeaseError struct {
+	Owner string
+}
+
+func (e *LeaseError) Error() string {
+	return "the session is controlled by " + e.Owner
+}
+
+// Holder is the owner of the live lease, or "".
+func (s *State) Holder(now time.Time) string {
+	if s.Lease == nil || !now.Before(s.Lease.Until) {
+		return ""
+	}
+	return s.Lease.Owner
+}
+
+// Acquire takes the lease for owner, or renews it, until now+LeaseTTL.
+func (s *State) Acquire(owner string, now time.Time) error {
+	if h := s.Holder(now); h != "" && h != owner {
+		return &LeaseError{Owner: h}
+	}
+	if s.Lease == nil || s.Lease.Owner != owner {
+		s.Lease = &Lease{Owner: owner}
+	}
+	s.Lease.Until = now.Round(0).Add(LeaseTTL)
+	return nil
+}
+
+// Release drops owner's lease, if it still holds it.
+func (s *State) Release(owner string) {
+	if s.Lease != nil && s.Lease.Owner == owner {
+		s.Lease = nil
+	}
+}
+
+// RequestPause asks the lease holder to stop the active session at t,
+// reporting whether there is a live holder to ask. The earliest request wins.
+func (s *State) RequestPause(now, t time.Time) bool {
+	if s.Holder(now) == "" {
+		return false
+	}
+	if s.Lease.Pause == nil || t.Before(*s.Lease.Pause) {
+		t = t.Round(0)
+		s.Lease.Pause = &t
+	}
+	return true
+}
+
+// TakePause returns and clears a pause asked of owner.
+func (s *State) TakePause(owner string) (time.Time, bool) {
+	if s.Lease == nil || s.Lease.Owner != owner || s.Lease.Pause == nil {
+		return time.Time{}, false
+	}
+	t := *s.Lease.Pause
+	s.Lease.Pause = nil
+	return t, true
+}
+
+// expireLease drops a lease its holder stopped renewing.
+func (s *State) expireLease(now time.Time) {
+	if s.Lease != nil && s.Holder(now) == "" {
+		s.Lease = nil
+	}
+}
@@ -0,0 +1,209 @@
+package state
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"time"
+)
+
+// history is the part of the state that sync shares between machines: the
+// logged days, without settings or anything still running.
+type history struct {
+	Days map[string]*DayLog `json:"days"`
+}
+
+// HistoryJSON encodes the logged days for sync. The encoding is stable, so
+// equal histories give equal bytes.
+func (s *State) HistoryJSON() ([]byte, error) {
+	return json.Marshal(history{Days: s.Days})
+}
+
+// ParseHistory decodes days encoded by HistoryJSON.
+func ParseHistory(data []byte) (map[string]*DayLog, error) {
+	var h history
+	if err := json.Unmarshal(data, &h); err != nil {
+		return nil, err
+	}
+	if h.Days == nil {
+		h.Days = map[string]*DayLog{}
+	}
+	return h.Days, nil
+}
+
+// MergeDays merges the days of another machine, theirs, into s, given base,
+// the history both started from at the last sync, and returns the days it
+// changed. What only one side changed since base wins, deletions included:
+// a session or day that one side removed and the other left alone is gone.
+// Sessions and breaks are matched by start time; where both sides changed the
+// same one, the finished or longer version is kept, and one side's edit
+// beats the other's deletion, so nothing is lost in a real conflict. Without
+// a base, every entry is taken to be new on its side, which only adds.
+func (s *State) MergeDays(base, theirs map[string]*DayLog) (changed []string) {
+	if s.Days == nil {
+		s.Days = map[string]*DayLog{}
+	}
+	keys := map[string]bool{}
+	for _, days := range []map[string]*DayLog{s.Days, theirs, base} {
+		for key, log := range days {
+			if log != nil {
+				keys[key] = true
+			}
+		}
+	}
+	for key := range keys {
+		ours, t, b := s.Days[key], theirs[key], base[key]
+		var next *DayLog
+		switch {
+		case sameJSON(ours, t), sameJSON(t, b):
+			continue
+		case sameJSON(ours, b):
+			next = t
+		default:
+			next = mergeDay(key, b, ours, t)
+			if sameJSON(next, ours) {
+				continue
+			}
+		}
+		s.setDay(key, next)
+		changed = append(changed, key)
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// mergeDay merges a day both sides changed since base; any of the three may
+// be nil. It returns nil when the merge leaves nothing.
+func mergeDay(key string, b, ours, t *DayLog) *DayLog {
+	// A side that removed the day has no say in its other fields.
+	out := *orDay(ours, t)
+	both := ours != nil && t != nil
+	empty := &DayLog{Date: key}
+	b, ours, t = orDay(b, empty), orDay(ours, empty), orDay(t, empty)
+	out.Sessions = merge3(b.Sessions, ours.Sessions, t.Sessions, sessionStart, longer)
+	out.Breaks = merge3(b.Breaks, ours.Breaks, t.Breaks, sessionStart, longer)
+	out.Interruptions = merge3(b.Interruptions, ours.Interruptions, t.Interruptions,
+		func(i Interruption) time.Time { return i.Start },
+		func(a, c Interruption) bool {
+			return c.End == nil && a.End != nil || a.End != nil && c.End != nil && a.End.After(*c.End)
+		})
+	if both {
+		out.GoalMinutes = pick3(b.GoalMinutes, ours.GoalMinutes, t.GoalMinutes)
+		out.RelaxSeconds = pick3(b.RelaxSeconds, ours.RelaxSeconds, t.RelaxSeconds)
+		out.GameHighScore = max(ours.GameHighScore, t.GameHighScore)
+		if sameJSON(ours.AutoStopped, b.AutoStopped) {
+			out.AutoStopped = t.AutoStopped
+		}
+	}
+
+	// Totals move with the listed entries, keeping any time counted before
+	// entries were listed. Days with no lists at all only have totals.
+	if len(b.Sessions)+len(ours.Sessions)+len(t.Sessions) == 0 {
+		out.TotalWorkSeconds = pick3(b.WorkSeconds(), ours.WorkSeconds(), t.WorkSeconds())
+		if ours.WorkSeconds() != b.WorkSeconds() && t.WorkSeconds() != b.WorkSeconds() {
+			out.TotalWorkSeconds = max(ours.WorkSeconds(), t.WorkSeconds())
+		}
+	} else {
+		out.TotalWorkSeconds = max(ours.WorkSeconds()+listSeconds(out.Sessions)-listSeconds(ours.Sessions), 0)
+	}
+	if len(b.Breaks)+len(ours.Breaks)+len(t.Breaks) == 0 {
+		if ours.BreakSeconds() == b.BreakSeconds() && ours.BreakCount == b.BreakCount {
+			out.TotalBreakSeconds, out.BreakCount = t.BreakSeconds(), t.BreakCount
+		} else {
+			out.TotalBreakSeconds = ours.BreakSeconds()
+		}
+	} else {
+		out.TotalBreakSeconds = max(ours.BreakSeconds()+listSeconds(out.Breaks)-listSeconds(ours.Breaks), 0)
+		out.BreakCount = max(ours.BreakCount+len(out.Breaks)-len(ours.Breaks), 0)
+	}
+	out.TotalWorkMinutes, out.TotalBreakMinutes = out.TotalWorkSeconds/60, out.TotalBreakSeconds/60
+	if len(out.Sessions)+len(out.Breaks)+len(out.Interruptions) == 0 && out.TotalWorkSeconds == 0 && out.TotalBreakSeconds == 0 {
+		return nil
+	}
+	return &out
+}
+
+func orDay(d, empty *DayLog) *DayLog {
+	if d == nil {
+		return empty
+	}
+	return d
+}
+
+// merge3 merges two edits, ours and theirs, of the list base, matching
+// entries by start. An entry only one side changed, added or removed takes
+// that side's version; where both changed it differently, the one better
+// says is kept, and an edit beats a removal. The result is in start order.
+func merge3[T any](base, ours, theirs []T, start func(T) time.Time, better func(a, b T) bool) []T {
+	find := func(list []T, at time.Time) (T, bool) {
+		for _, e := range list {
+			if start(e).Equal(at) {
+				return e, true
+			}
+		}
+		var zero T
+		return zero, false
+	}
+	var out []T
+	seen := map[int64]bool{}
+	for _, list := range [][]T{ours, theirs, base} {
+		for _, e := range list {
+			at := start(e)
+			if seen[at.UnixNano()] {
+				continue
+			}
+			seen[at.UnixNano()] = true
+			b, inB := find(base, at)
+			o, inO := find(ours, at)
+			t, inT := find(theirs, at)
+			switch {
+			case inO == inT && (!inO || sameJSON(o, t)):
+				if inO {
+					out = append(out, o)
+				}
+			case inB && inO == inB && sameJSON(o, b):
+				if inT {
+					out = append(out, t)
+				}
+			case inB && inT == inB && sameJSON(t, b):
+				if inO {
+					out = append(out, o)
+				}
+			case !inT || inO && !better(t, o):
+				out = append(out, o)
+			default:
+				out = append(out, t)
+			}
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return start(out[i]).Before(start(out[j])) })
+	return out
+}
+
+// pick3 takes theirs where ours still has the base value, and ours otherwise.
+func pick3[T comparable](base, ours, theirs T) T {
+	if ours == base {
+		return theirs
+	}
+	return ours
+}
+
+// sameJSON reports whether a and b encode alike, which compares times by
+// instant and treats nil and empty lists the same as the state file does.
+func sameJSON(a, b any) bool {
+	x, err1 := json.Marshal(a)
+	y, err2 := json.Marshal(b)
+	return err1 == nil && err2 == nil && bytes.Equal(x, y)
+}
+
+func sessionStart(s Session) time.Time {
+	return s.Start
+}
+
+// longer reports whether a is finished where b is not, or ends later.
+func longer(a, b Session) bool {
+	if a.End == nil || b.End == nil {
+		return a.End != nil
+	}
+	return a.End.After(*b.End)
+}
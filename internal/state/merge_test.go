@@ -0,0 +1,168 @@
+package state
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// days builds a history from day key to session lengths in minutes, each
+// session starting on the hour from 09:00; 0 leaves that hour out.
+func days(spec map[string][]int) map[string]*DayLog {
+	out := map[string]*DayLog{}
+	for key, lengths := range spec {
+		start, _ := time.Parse("2006-01-02", key)
+		log := &DayLog{Date: key, GoalMinutes: 480}
+		for i, m := range lengths {
+			if m == 0 {
+				continue
+			}
+			s := start.Add(time.Duration(9+i) * time.Hour)
+			e := s.Add(time.Duration(m) * time.Minute)
+			log.Sessions = append(log.Sessions, Session{Start: s, End: &e})
+			log.TotalWorkSeconds += m * 60
+		}
+		log.TotalWorkMinutes = log.TotalWorkSeconds / 60
+		out[key] = log
+	}
+	return out
+}
+
+// clone copies a history the way it travels, through JSON.
+func clone(t *testing.T, d map[string]*DayLog) map[string]*DayLog {
+	t.Helper()
+	data, err := json.Marshal(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out map[string]*DayLog
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func TestMergeDays(t *testing.T) {
+	const d1, d2 = "2026-03-02", "2026-03-03"
+	for _, tc := range []struct {
+		name               string
+		base, ours, theirs map[string][]int
+		noBase             bool
+		want               map[string][]int
+	}{
+		{
+			name:   "both added",
+			base:   map[string][]int{d1: {60}},
+			ours:   map[string][]int{d1: {60, 30}},
+			theirs: map[string][]int{d1: {60, 0, 45}},
+			want:   map[string][]int{d1: {60, 30, 45}},
+		},
+		{
+			name:   "deleted on their side",
+			base:   map[string][]int{d1: {60, 30}},
+			ours:   map[string][]int{d1: {60, 30, 20}},
+			theirs: map[string][]int{d1: {60}},
+			want:   map[string][]int{d1: {60, 0, 20}},
+		},
+		{
+			name:   "deleted on our side",
+			base:   map[string][]int{d1: {60, 30}},
+			ours:   map[string][]int{d1: {0, 30}},
+			theirs: map[string][]int{d1: {60, 30, 20}},
+			want:   map[string][]int{d1: {0, 30, 20}},
+		},
+		{
+			name:   "shortened on their side",
+			base:   map[string][]int{d1: {60, 30}},
+			ours:   map[string][]int{d1: {60, 30, 20}},
+			theirs: map[string][]int{d1: {40, 30}},
+			want:   map[string][]int{d1: {40, 30, 20}},
+		},
+		{
+			name:   "both edited the same session",
+			base:   map[string][]int{d1: {60}},
+			ours:   map[string][]int{d1: {50, 10}},
+			theirs: map[string][]int{d1: {70}},
+			want:   map[string][]int{d1: {70, 10}},
+		},
+		{
+			name:   "edit beats deletion",
+			base:   map[string][]int{d1: {60, 30}},
+			ours:   map[string][]int{d1: {60, 0, 10}},
+			theirs: map[string][]int{d1: {60, 45}},
+			want:   map[string][]int{d1: {60, 45, 10}},
+		},
+		{
+			name:   "day deleted on their side",
+			base:   map[string][]int{d1: {60}, d2: {30}},
+			ours:   map[string][]int{d1: {60}, d2: {30, 10}},
+			theirs: map[string][]int{d2: {30}},
+			want:   map[string][]int{d2: {30, 10}},
+		},
+		{
+			name:   "no base only adds",
+			noBase: true,
+			ours:   map[string][]int{d1: {60, 30}},
+			theirs: map[string][]int{d1: {40}, d2: {20}},
+			want:   map[string][]int{d1: {60, 30}, d2: {20}},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var base map[string]*DayLog
+			if !tc.noBase {
+				base = days(tc.base)
+			}
+			s := &State{Days: clone(t, days(tc.ours))}
+			s.MergeDays(clone(t, base), clone(t, days(tc.theirs)))
+			want := days(tc.want)
+			if len(s.Days) != len(want) {
+				t.Fatalf("merged days %v, want %v", s.Days, want)
+			}
+			for key, w := range want {
+				got := s.Days[key]
+				if got == nil {
+					t.Fatalf("day %s missing", key)
+				}
+				if !sameJSON(got.Sessions, w.Sessions) {
+					t.Errorf("%s sessions:\n got %s\nwant %s", key, mustJSON(got.Sessions), mustJSON(w.Sessions))
+				}
+				if got.WorkSeconds() != w.WorkSeconds() {
+					t.Errorf("%s total %ds, want %ds", key, got.WorkSeconds(), w.WorkSeconds())
+				}
+			}
+			if keys := s.DayKeys(Filter{}); len(keys) != len(want) {
+				t.Errorf("index lists %v", keys)
+			}
+		})
+	}
+}
+
+// TestMergeDaysKeepsUnlisted checks that break time counted before breaks
+// were listed survives merging the listed ones.
+func TestMergeDaysKeepsUnlisted(t *testing.T) {
+	const key = "2026-03-02"
+	nine := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	brk := func(start time.Time, m int) Session {
+		e := start.Add(time.Duration(m) * time.Minute)
+		return Session{Start: start, End: &e}
+	}
+	base := &DayLog{Date: key, TotalBreakSeconds: 900, BreakCount: 2, Breaks: []Session{brk(nine, 5)}}
+	ours := *base
+	ours.Breaks = append([]Session{}, base.Breaks...)
+	theirs := *base
+	theirs.Breaks = append([]Session{brk(nine, 5)}, brk(nine.Add(time.Hour), 10))
+	theirs.TotalBreakSeconds, theirs.BreakCount = 1500, 3
+	ours.RelaxSeconds = 60 // both sides changed the day
+
+	s := &State{Days: map[string]*DayLog{key: &ours}}
+	s.MergeDays(map[string]*DayLog{key: base}, map[string]*DayLog{key: &theirs})
+	got := s.Days[key]
+	if got.BreakSeconds() != 1500 || got.BreakCount != 3 || got.RelaxSeconds != 60 {
+		t.Errorf("breaks %ds in %d, relax %ds; want 1500s in 3, relax 60s", got.BreakSeconds(), got.BreakCount, got.RelaxSeconds)
+	}
+}
+
+func mustJSON(v any) string {
+	data, _ := json.Marshal(v)
+	return string(data)
+}
@@ -0,0 +1,73 @@
+package state
+
+import (
+	"fmt"
+	"time"
+)
+
+// Policies for sessions shorter than the minimum, e.g. from an accidental
+// start/stop or watch flapping.
+const (
+	ShortDiscard = "discard"
+	ShortMerge   = "merge"
+)
+
+// MinSession is the shortest session StopSession records on its own. A nil
+// *MinSession keeps every session.
+type MinSession struct {
+	Seconds int    `json:"seconds"`
+	Policy  string `json:"policy"` // ShortDiscard or ShortMerge
+}
+
+// ParseMinSession validates a threshold and policy as given to
+// `daily set-min-session`.
+func ParseMinSession(threshold, policy string) (*MinSession, error) {
+	d, err := time.ParseDuration(threshold)
+	if err != nil || d < time.Second || d > time.Hour {
+		return nil, fmt.Errorf("minimum session must be a duration between 1s and 1h, e.g. 60s")
+	}
+	switch policy {
+	case ShortDiscard, ShortMerge:
+	default:
+		return nil, fmt.Errorf("short session policy must be %s or %s", ShortDiscard, ShortMerge)
+	}
+	return &MinSession{Seconds: int(d.Seconds()), Policy: policy}, nil
+}
+
+// Short reports whether a session of seconds falls under the minimum.
+func (m *MinSession) Short(seconds int) bool {
+	return m != nil && seconds < m.Seconds
+}
+
+func (m *MinSession) String() string {
+	if m == nil {
+		return "off (every session is kept)"
+	}
+	return fmt.Sprintf("%s sessions under %s", m.Policy, HumanSeconds(m.Seconds))
+}
+
+// absorbShort handles a session under the minimum. A session that continues
+// the previous one without a gap, like the remainder of one split at
+// midnight, is always merged into it; otherwise the policy decides, and merge
+// adds the time to the day's previous session. It reports false when the
+// session should be recorded normally, i.e. merge with nothing to merge into.
+func (d *DayLog) absorbShort(sess Session, seconds int, policy string) bool {
+	var prev *Session
+	if n := len(d.Sessions); n > 0 && d.Sessions[n-1].End != nil && !d.Sessions[n-1].End.After(sess.Start) {
+		prev = &d.Sessions[n-1]
+	}
+	contiguous := prev != nil && prev.End.Equal(sess.Start)
+	if policy == ShortDiscard && !contiguous {
+		return true
+	}
+	if prev == nil {
+		return false
+	}
+	prev.Elapsed = prev.Seconds(*prev.End) + seconds
+	if contiguous {
+		prev.End = sess.End
+	}
+	d.TotalWorkSeconds = d.WorkSeconds() + seconds
+	d.TotalWorkMinutes = d.TotalWorkSeconds / 60
+	return true
+}
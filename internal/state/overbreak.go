@@ -0,0 +1,32 @@
+package state
+
+import "time"
+
+// DefaultBreakMinutes is the break length new state files start with.
+const DefaultBreakMinutes = 15
+
+// BreakDue returns when the running break should be over: the end of the
+// sprint's break phase during a sprint, otherwise BreakMinutes after it began.
+// It reports false without a break or with the break length off.
+func (s *State) BreakDue() (time.Time, bool) {
+	b := s.ActiveBreak
+	if b == nil {
+		return time.Time{}, false
+	}
+	if s.SprintPhaseEnd != nil && s.SprintPhaseEnd.After(b.Start) {
+		return *s.SprintPhaseEnd, true
+	}
+	if s.BreakMinutes <= 0 {
+		return time.Time{}, false
+	}
+	return b.Start.Add(time.Duration(s.BreakMinutes) * time.Minute), true
+}
+
+// OverBreak returns how long the running break has gone past BreakDue.
+func (s *State) OverBreak(now time.Time) (time.Duration, bool) {
+	due, ok := s.BreakDue()
+	if !ok || now.Before(due) {
+		return 0, false
+	}
+	return now.Sub(due), true
+}
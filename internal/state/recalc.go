@@ -0,0 +1,69 @@
+package state
+
+import "sort"
+
+// Recalc describes the totals RecalcTotals changed for one day.
+type Recalc struct {
+	Day                     string
+	WorkBefore, WorkAfter   int // seconds
+	BreakBefore, BreakAfter int // seconds
+	CountBefore, CountAfter int // breaks
+}
+
+// RecalcTotals rebuilds every day's work totals from its sessions and break
+// totals and count from its breaks, undoing accounting bugs and hand edits.
+// Days logged before sessions or breaks were listed carry totals with nothing
+// to rebuild them from, so those totals are kept, as is a break total above
+// what the listed breaks add up to; kept counts such days.
+func (s *State) RecalcTotals() (changed []Recalc, kept int) {
+	for key, log := range s.Days {
+		if log == nil {
+			continue
+		}
+		r := Recalc{
+			Day:        key,
+			WorkBefore: log.WorkSeconds(), BreakBefore: log.BreakSeconds(), CountBefore: log.BreakCount,
+		}
+		r.WorkAfter, r.BreakAfter, r.CountAfter = r.WorkBefore, r.BreakBefore, r.CountBefore
+		legacy := false
+		if len(log.Sessions) > 0 || r.WorkBefore == 0 {
+			r.WorkAfter = listSeconds(log.Sessions)
+		} else {
+			legacy = true
+		}
+		// Breaks taken before they were listed still count in the total on
+		// the day of the upgrade, so a list short of it is only added to.
+		if listed := listSeconds(log.Breaks); listed >= r.BreakBefore {
+			r.BreakAfter, r.CountAfter = listed, len(log.Breaks)
+		} else {
+			r.CountAfter = max(r.CountBefore, len(log.Breaks))
+			legacy = true
+		}
+		if legacy {
+			kept++
+		}
+		if r.WorkAfter == r.WorkBefore && r.BreakAfter == r.BreakBefore && r.CountAfter == r.CountBefore &&
+			log.TotalWorkMinutes == r.WorkAfter/60 && log.TotalBreakMinutes == r.BreakAfter/60 {
+			continue
+		}
+		s.editDay(key, func(log *DayLog) {
+			log.TotalWorkSeconds, log.TotalWorkMinutes = r.WorkAfter, r.WorkAfter/60
+			log.TotalBreakSeconds, log.TotalBreakMinutes = r.BreakAfter, r.BreakAfter/60
+			log.BreakCount = r.CountAfter
+		})
+		changed = append(changed, r)
+	}
+	sort.Slice(changed, func(i, j int) bool { return changed[i].Day < changed[j].Day })
+	return changed, kept
+}
+
+// listSeconds sums finished entries, skipping ones that end before they start.
+func listSeconds(list []Session) int {
+	sum := 0
+	for _, sess := range list {
+		if sess.End != nil && !sess.End.Before(sess.Start) {
+			sum += sess.Seconds(*sess.End)
+		}
+	}
+	return sum
+}
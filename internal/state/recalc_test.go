@@ -0,0 +1,58 @@
+package state
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRecalcTotalsKeepsUnlistedBreaks(t *testing.T) {
+	nine := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	span := func(start time.Time, d time.Duration) Session {
+		end := start.Add(d)
+		return Session{Start: start, End: &end}
+	}
+	for _, tc := range []struct {
+		name             string
+		log              DayLog
+		work, brk, count int
+		changed, kept    bool
+	}{
+		{
+			name: "upgrade day: a break counted before breaks were listed",
+			log:  DayLog{TotalBreakSeconds: 1500, TotalBreakMinutes: 25, BreakCount: 2, Breaks: []Session{span(nine, 10*time.Minute)}},
+			brk:  1500, count: 2, kept: true,
+		},
+		{
+			name: "every break listed",
+			log:  DayLog{TotalBreakSeconds: 600, TotalBreakMinutes: 10, BreakCount: 1, Breaks: []Session{span(nine, 10*time.Minute)}},
+			brk:  600, count: 1,
+		},
+		{
+			name: "break missing from the total",
+			log:  DayLog{TotalBreakSeconds: 300, TotalBreakMinutes: 5, BreakCount: 1, Breaks: []Session{span(nine, 5*time.Minute), span(nine.Add(time.Hour), 5*time.Minute)}},
+			brk:  600, count: 2, changed: true,
+		},
+		{
+			name: "legacy day without lists",
+			log:  DayLog{TotalWorkSeconds: 7200, TotalWorkMinutes: 120, TotalBreakSeconds: 900, TotalBreakMinutes: 15, BreakCount: 1},
+			work: 7200, brk: 900, count: 1, kept: true,
+		},
+		{
+			name: "work rebuilt from sessions",
+			log:  DayLog{TotalWorkSeconds: 9000, TotalWorkMinutes: 150, Sessions: []Session{span(nine, time.Hour)}},
+			work: 3600, changed: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			log := tc.log
+			s := &State{Days: map[string]*DayLog{"2026-03-02": &log}}
+			changed, kept := s.RecalcTotals()
+			if log.WorkSeconds() != tc.work || log.BreakSeconds() != tc.brk || log.BreakCount != tc.count {
+				t.Errorf("work %ds, breaks %ds in %d; want %ds, %ds in %d", log.WorkSeconds(), log.BreakSeconds(), log.BreakCount, tc.work, tc.brk, tc.count)
+			}
+			if (len(changed) > 0) != tc.changed || (kept > 0) != tc.kept {
+				t.Errorf("changed %v, kept %d; want changed %v, kept %v", changed, kept, tc.changed, tc.kept)
+			}
+		})
+	}
+}
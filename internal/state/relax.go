@@ -0,0 +1,23 @@
+package state
+
+import "time"
+
+// RecordRelax adds seconds spent in relax mode to the day of start and
+// keeps score if it beats the day's best.
+func (s *State) RecordRelax(start time.Time, seconds, score int) {
+	if seconds <= 0 && score <= 0 {
+		return
+	}
+	s.editDay(dateKey(start), func(log *DayLog) {
+		log.RelaxSeconds += max(seconds, 0)
+		log.GameHighScore = max(log.GameHighScore, score)
+	})
+}
+
+// Relax returns the relax-mode time and best game score of now's day.
+func (s *State) Relax(now time.Time) (seconds, highScore int) {
+	if log, ok := s.Days[dateKey(now)]; ok {
+		return log.RelaxSeconds, log.GameHighScore
+	}
+	return 0, 0
+}
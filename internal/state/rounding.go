@@ -0,0 +1,62 @@
+package state
+
+import (
+	"fmt"
+	"math"
+)
+
+// Rounding modes for durations shown in listings, reports and exports. Stored
+// times are never rounded.
+const (
+	RoundNearest = "nearest"
+	RoundUp      = "up"
+	RoundDown    = "down"
+)
+
+// Rounding is the billing granularity applied when durations are displayed.
+// A nil *Rounding truncates to whole minutes.
+type Rounding struct {
+	Mode    string `json:"mode"`    // RoundNearest, RoundUp or RoundDown
+	Minutes int    `json:"minutes"` // step, e.g. 1, 5 or 15
+}
+
+// ParseRounding validates a mode and step as given to `daily set-rounding`.
+func ParseRounding(mode string, minutes int) (*Rounding, error) {
+	switch mode {
+	case RoundNearest, RoundUp, RoundDown:
+	default:
+		return nil, fmt.Errorf("rounding mode must be %s, %s or %s", RoundNearest, RoundUp, RoundDown)
+	}
+	if minutes < 1 || minutes > 60 {
+		return nil, fmt.Errorf("rounding step must be 1-60 minutes")
+	}
+	return &Rounding{Mode: mode, Minutes: minutes}, nil
+}
+
+// Apply converts seconds to minutes, rounded to the policy's step.
+func (r *Rounding) Apply(seconds int) int {
+	if seconds <= 0 {
+		return 0
+	}
+	if r == nil || r.Minutes <= 0 {
+		return seconds / 60
+	}
+	step := float64(r.Minutes * 60)
+	units := float64(seconds) / step
+	switch r.Mode {
+	case RoundUp:
+		units = math.Ceil(units)
+	case RoundDown:
+		units = math.Floor(units)
+	default:
+		units = math.Round(units)
+	}
+	return int(units) * r.Minutes
+}
+
+func (r *Rounding) String() string {
+	if r == nil {
+		return "off (whole minutes, truncated)"
+	}
+	return fmt.Sprintf("%s %dm", r.Mode, r.Minutes)
+}
@@ -0,0 +1,709 @@
+package state
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/max-pantom/daily/internal/i18n"
+	"github.com/max-pantom/daily/internal/notify"
+)
+
+// Setting is one user preference, addressed by a key such as goal or
+// smtp.host. Families like rate.<project> hold one value per name. Values are
+// strings in the form the matching set- command takes; setting "" restores
+// the default.
+type Setting struct {
+	Key     string
+	Help    string
+	Example string // a value other than the default, which configFields relies on
+	Family  bool   // Key names a family: rate.acme, rate.default, ...
+
+	get     func(s *State) string
+	set     func(s *State, v string) error
+	entries func(s *State) map[string]string
+	put     func(s *State, name, v string) error
+}
+
+// Settings lists every setting in the order they are shown.
+var Settings = []*Setting{
+	{Key: "goal", Help: "daily work goal", Example: "8h",
+		get: func(s *State) string { return HumanMinutes(s.GoalMinutes) },
+		set: func(s *State, v string) error {
+			m, err := parseMinutes(v, true)
+			if m <= 0 {
+				m = defaultGoalMinutes
+			}
+			s.GoalMinutes = m
+			return err
+		}},
+	{Key: "goal_carry", Help: "carry time over or short of the goal into the next workday's goal, within the week: off, over, under or both", Example: "both",
+		get: func(s *State) string { return orDefault(s.GoalCarry, "off") },
+		set: func(s *State, v string) error {
+			if v == "off" {
+				v = ""
+			}
+			if v != "" && v != CarryOver && v != CarryUnder && v != CarryBoth {
+				return fmt.Errorf("want off, %s, %s or %s, not %q", CarryOver, CarryUnder, CarryBoth, v)
+			}
+			s.GoalCarry = v
+			return nil
+		}},
+	{Key: "week_goal", Help: "weekly work goal for status --forecast, in hours or a duration; auto adds up the workdays' goals", Example: "40h",
+		get: func(s *State) string {
+			if s.WeekGoalMinutes <= 0 {
+				return "auto"
+			}
+			return HumanMinutes(s.WeekGoalMinutes)
+		},
+		set: func(s *State, v string) error {
+			if v == "" || v == "auto" {
+				s.WeekGoalMinutes = 0
+				return nil
+			}
+			if n, err := strconv.Atoi(v); err == nil && n > 0 && n <= 7*24 {
+				s.WeekGoalMinutes = n * 60
+				return nil
+			}
+			m, err := parseMinutes(v, false)
+			s.WeekGoalMinutes = m
+			return err
+		}},
+	{Key: "break_interval", Help: "remind to take a break after this much work", Example: "2h",
+		get: func(s *State) string { return HumanMinutes(s.BreakIntervalMinutes) },
+		set: func(s *State, v string) error {
+			m, err := parseMinutes(v, false)
+			if m <= 0 {
+				m = defaultBreakIntervalMinutes
+			}
+			s.BreakIntervalMinutes = m
+			return err
+		}},
+	{Key: "break_length", Help: "say when a break runs longer than this, or off", Example: "15m",
+		get: func(s *State) string { return offOr(s.BreakMinutes > 0, HumanMinutes(s.BreakMinutes)) },
+		set: func(s *State, v string) error {
+			if v == "" {
+				s.BreakMinutes = DefaultBreakMinutes
+				return nil
+			}
+			m, err := parseMinutes(v, false)
+			s.BreakMinutes = m
+			return err
+		}},
+	{Key: "workdays", Help: "days you work, for the start reminder", Example: "mon-fri",
+		get: func(s *State) string { return orDefault(s.Workdays, DefaultWorkdays) },
+		set: func(s *State, v string) error {
+			if v == "" {
+				s.Workdays = ""
+				return nil
+			}
+			days, err := ParseWorkdays(v)
+			if err == nil {
+				s.Workdays = days
+			}
+			return err
+		}},
+	{Key: "start_reminder", Help: "ask on a workday with nothing tracked: auto (30m after your usual start), HH:MM, or off", Example: "09:30",
+		get: func(s *State) string { return orDefault(s.StartReminder, "auto") },
+		set: func(s *State, v string) error {
+			switch {
+			case v == "" || v == "auto":
+				s.StartReminder = ""
+			case isOff(v):
+				s.StartReminder = "off"
+			default:
+				at, err := ParseAutoStop(v)
+				if err != nil {
+					return err
+				}
+				s.StartReminder = at
+			}
+			return nil
+		}},
+	{Key: "notifications", Help: "desktop notifications", Example: "true",
+		get: func(s *State) string { return strconv.FormatBool(s.NotificationsOn()) },
+		set: func(s *State, v string) error {
+			on, err := parseBool(v, true)
+			s.NotificationsEnabled = boolPtr(on)
+			return err
+		}},
+	{Key: "cap", Help: "hard daily limit, or off", Example: "10h",
+		get: func(s *State) string { return offOr(s.CapMinutes > 0, HumanMinutes(s.CapMinutes)) },
+		set: func(s *State, v string) error {
+			m, err := parseMinutes(v, true)
+			s.CapMinutes = m
+			return err
+		}},
+	{Key: "cap_strict", Help: "refuse new sessions past the cap", Example: "true",
+		get: func(s *State) string { return strconv.FormatBool(s.CapStrict) },
+		set: func(s *State, v string) (err error) {
+			s.CapStrict, err = parseBool(v, false)
+			return err
+		}},
+	{Key: "rounding", Help: "round durations: nearest, up or down and a step in minutes, or off", Example: "nearest 15",
+		get: func(s *State) string {
+			if s.Rounding == nil {
+				return "off"
+			}
+			return fmt.Sprintf("%s %d", s.Rounding.Mode, s.Rounding.Minutes)
+		},
+		set: func(s *State, v string) error {
+			if isOff(v) {
+				s.Rounding = nil
+				return nil
+			}
+			mode, step, _ := strings.Cut(v, " ")
+			minutes := 1
+			if step = strings.TrimSuffix(strings.TrimSpace(step), "m"); step != "" {
+				var err error
+				if minutes, err = strconv.Atoi(step); err != nil {
+					return fmt.Errorf("rounding step must be a number of minutes")
+				}
+			}
+			r, err := ParseRounding(mode, minutes)
+			if err == nil {
+				s.Rounding = r
+			}
+			return err
+		}},
+	{Key: "min_session", Help: "shortest session kept, then discard or merge, or off", Example: "60s discard",
+		get: func(s *State) string {
+			if s.MinSession == nil {
+				return "off"
+			}
+			return fmt.Sprintf("%s %s", time.Duration(s.MinSession.Seconds)*time.Second, s.MinSession.Policy)
+		},
+		set: func(s *State, v string) error {
+			if isOff(v) {
+				s.MinSession = nil
+				return nil
+			}
+			threshold, policy, _ := strings.Cut(v, " ")
+			if policy = strings.TrimSpace(policy); policy == "" {
+				policy = ShortDiscard
+			}
+			m, err := ParseMinSession(threshold, policy)
+			if err == nil {
+				s.MinSession = m
+			}
+			return err
+		}},
+	{Key: "auto_stop", Help: "stop a session still running at HH:MM, or off", Example: "23:30",
+		get: func(s *State) string { return offOr(s.AutoStop != "", s.AutoStop) },
+		set: func(s *State, v string) error {
+			if isOff(v) {
+				s.AutoStop = ""
+				return nil
+			}
+			at, err := ParseAutoStop(v)
+			if err == nil {
+				s.AutoStop = at
+			}
+			return err
+		}},
+	{Key: "checkpoint", Help: "autosave the running session this often, or off", Example: "5m",
+		get: func(s *State) string { return offOr(s.CheckpointMinutes > 0, HumanMinutes(s.CheckpointMinutes)) },
+		set: func(s *State, v string) error {
+			if v == "" {
+				s.CheckpointMinutes = DefaultCheckpointMinutes
+				return nil
+			}
+			m, err := parseMinutes(v, false)
+			s.CheckpointMinutes = m
+			return err
+		}},
+	{Key: "tray_title", Help: "tray title: auto (countdown when timed) or total", Example: "total",
+		get: func(s *State) string { return orDefault(s.TrayTitle, "auto") },
+		set: func(s *State, v string) error {
+			if v == "auto" {
+				v = ""
+			}
+			if v != "" && v != "total" {
+				return fmt.Errorf("want auto or total, not %q", v)
+			}
+			s.TrayTitle = v
+			return nil
+		}},
+	{Key: "focus_blocks", Help: "focus blocks the tray offers, as label=duration, comma separated", Example: "Write report=45m, Review PRs=25m",
+		get: func(s *State) string {
+			items := make([]string, len(s.FocusBlocks))
+			for i, b := range s.FocusBlocks {
+				items[i] = b.String()
+			}
+			return strings.Join(items, ", ")
+		},
+		set: func(s *State, v string) (err error) {
+			s.FocusBlocks, err = ParseFocusBlocks(v)
+			return err
+		}},
+	{Key: "theme", Help: "colours: default or colorblind (blue and orange instead of green and red)", Example: "colorblind",
+		get: func(s *State) string { return orDefault(s.Theme, "default") },
+		set: func(s *State, v string) error {
+			if v == "default" {
+				v = ""
+			}
+			if v != "" && v != ThemeColorblind {
+				return fmt.Errorf("want default or %s, not %q", ThemeColorblind, v)
+			}
+			s.Theme = v
+			return nil
+		}},
+	{Key: "break_guide", Help: "what daily ui opens when a break begins: breathe (box breathing), stretch (a checklist) or off", Example: "stretch",
+		get: func(s *State) string { return orDefault(s.BreakGuide, "breathe") },
+		set: func(s *State, v string) error {
+			if v == "breathe" {
+				v = ""
+			}
+			if v != "" && v != GuideStretch && v != GuideOff {
+				return fmt.Errorf("want breathe, %s or %s, not %q", GuideStretch, GuideOff, v)
+			}
+			s.BreakGuide = v
+			return nil
+		}},
+	{Key: "language", Help: "language of messages and clock times: auto (from LANG) or " + strings.Join(i18n.Languages, ", "), Example: "de",
+		get: func(s *State) string { return orDefault(s.Language, "auto") },
+		set: func(s *State, v string) error {
+			if v == "auto" {
+				v = ""
+			}
+			if v != "" && !i18n.Known(v) {
+				return fmt.Errorf("want auto or one of %s, not %q", strings.Join(i18n.Languages, ", "), v)
+			}
+			s.Language = v
+			return nil
+		}},
+	{Key: "clock", Help: "clock times: auto (as the language reads them), 12h (3:04PM) or 24h (15:04)", Example: "24h",
+		get: func(s *State) string { return orDefault(s.Clock, "auto") },
+		set: func(s *State, v string) error {
+			if v == "auto" {
+				v = ""
+			}
+			if v != "" && v != i18n.Clock12 && v != i18n.Clock24 {
+				return fmt.Errorf("want auto, %s or %s, not %q", i18n.Clock12, i18n.Clock24, v)
+			}
+			s.Clock = v
+			return nil
+		}},
+	{Key: "tags", Help: "predefined tags, comma separated; typos of them get a suggestion", Example: "review, meeting",
+		get: func(s *State) string { return strings.Join(s.AllowedTags, ", ") },
+		set: func(s *State, v string) error {
+			s.AllowedTags = strings.FieldsFunc(v, func(r rune) bool { return r == ',' || r == ' ' })
+			return nil
+		}},
+	{Key: "strict_tags", Help: "refuse tags that are not in tags", Example: "true",
+		get: func(s *State) string { return strconv.FormatBool(s.StrictTags) },
+		set: func(s *State, v string) (err error) {
+			s.StrictTags, err = parseBool(v, false)
+			return err
+		}},
+	{Key: "tag_rule", Family: true, Help: "tag sessions automatically at start and stop: weekdays, workdays, mon-fri, HH:MM-HH:MM, dir:path, calendar[:text]", Example: "weekdays 09:00-09:30",
+		entries: func(s *State) map[string]string {
+			out := map[string]string{}
+			for k, v := range s.TagRules {
+				out[k] = v
+			}
+			return out
+		},
+		put: func(s *State, name, v string) error {
+			if v == "" || isOff(v) {
+				delete(s.TagRules, name)
+				return nil
+			}
+			r, err := ParseTagRule(v)
+			if err != nil {
+				return err
+			}
+			if s.TagRules == nil {
+				s.TagRules = map[string]string{}
+			}
+			s.TagRules[name] = r.String()
+			return nil
+		}},
+	stringSetting("calendar", "calendar .ics file or URL that tags meetings", "~/cal.ics", func(s *State) *string { return &s.CalendarSource }),
+	stringSetting("smtp.host", "SMTP server for report --email", "smtp.example.com", func(s *State) *string { return &s.SMTPHost }),
+	{Key: "smtp.port", Help: "SMTP port", Example: "587",
+		get: func(s *State) string {
+			if s.SMTPPort == 0 {
+				return ""
+			}
+			return strconv.Itoa(s.SMTPPort)
+		},
+		set: func(s *State, v string) error {
+			if isOff(v) {
+				s.SMTPPort = 0
+				return nil
+			}
+			p, err := strconv.Atoi(v)
+			if err != nil || p <= 0 || p > 65535 {
+				return fmt.Errorf("want a port number, not %q", v)
+			}
+			s.SMTPPort = p
+			return nil
+		}},
+	stringSetting("smtp.user", "SMTP user; the password comes from DAILY_SMTP_PASSWORD", "me@example.com", func(s *State) *string { return &s.SMTPUser }),
+	stringSetting("smtp.from", "sender address", "me@example.com", func(s *State) *string { return &s.SMTPFrom }),
+	stringSetting("jira.url", "Jira site for push", "https://acme.atlassian.net", func(s *State) *string { return &s.JiraURL }),
+	stringSetting("jira.email", "Jira account; the token comes from DAILY_JIRA_TOKEN", "me@acme.com", func(s *State) *string { return &s.JiraEmail }),
+	stringSetting("obsidian.vault", "notes folder for export --obsidian", "~/notes", func(s *State) *string { return &s.ObsidianVault }),
+	stringSetting("obsidian.pattern", "daily note path inside the vault", "Daily/YYYY-MM-DD.md", func(s *State) *string { return &s.ObsidianPattern }),
+	stringSetting("obsidian.template", "text/template file for the time log", "~/notes/log.tmpl", func(s *State) *string { return &s.ObsidianTemplate }),
+	stringSetting("gsheet.credentials", "service-account JSON key for export --gsheet; share the sheet with its client_email", "~/daily-sa.json", func(s *State) *string { return &s.GSheetCredentials }),
+	stringSetting("gsheet.tab", "spreadsheet tab for export --gsheet (default Daily)", "Timesheet", func(s *State) *string { return &s.GSheetTab }),
+	stringSetting("team.url", "team server for team report; the token comes from DAILY_TEAM_TOKEN", "https://team.example.com:8787", func(s *State) *string { return &s.TeamURL }),
+	stringSetting("team.member", "secret your totals are pushed under (made up on the first push); keep it private", "5f0c9a7e2b41d8c3a6e07f19b2d54c8e3a91f6d07b2c4e58a1d3f9b6c0e27a45", func(s *State) *string { return &s.TeamMember }),
+	{Key: "sync.backend", Help: "where daily sync keeps the encrypted history: s3, webdav or http", Example: "webdav",
+		get: func(s *State) string { return s.SyncBackend },
+		set: func(s *State, v string) error {
+			if v != "" && v != "s3" && v != "webdav" && v != "http" {
+				return fmt.Errorf("want s3, webdav or http, not %q", v)
+			}
+			s.SyncBackend = v
+			return nil
+		}},
+	stringSetting("sync.url", "URL of the synced file", "https://dav.example.com/daily/history.bin", func(s *State) *string { return &s.SyncURL }),
+	stringSetting("sync.user", "WebDAV/HTTP user; the password comes from DAILY_SYNC_PASSWORD", "me", func(s *State) *string { return &s.SyncUser }),
+	stringSetting("sync.region", "S3 region (default us-east-1)", "eu-west-1", func(s *State) *string { return &s.SyncRegion }),
+	{Key: "push.service", Help: "also send notifications to your phone: ntfy, pushover or telegram; the token comes from DAILY_PUSH_TOKEN", Example: "ntfy",
+		get: func(s *State) string { return s.PushService },
+		set: func(s *State, v string) error {
+			if v != "" && v != notify.Ntfy && v != notify.Pushover && v != notify.Telegram {
+				return fmt.Errorf("want %s, %s or %s, not %q", notify.Ntfy, notify.Pushover, notify.Telegram, v)
+			}
+			s.PushService = v
+			return nil
+		}},
+	stringSetting("push.to", "ntfy topic or URL, Pushover user key, or Telegram chat ID", "daily-3f9a21c4", func(s *State) *string { return &s.PushTo }),
+	{Key: "rate", Family: true, Help: "hourly rate by project; rate.default for the rest", Example: "95",
+		entries: func(s *State) map[string]string {
+			out := map[string]string{}
+			for k, v := range s.Rates {
+				if k == "" {
+					k = "default"
+				}
+				out[k] = strconv.FormatFloat(v, 'f', -1, 64)
+			}
+			return out
+		},
+		put: func(s *State, name, v string) error {
+			if name == "default" {
+				name = ""
+			}
+			if v == "" || isOff(v) {
+				delete(s.Rates, name)
+				return nil
+			}
+			rate, err := strconv.ParseFloat(v, 64)
+			if err != nil || rate < 0 {
+				return fmt.Errorf("invalid rate %q", v)
+			}
+			if s.Rates == nil {
+				s.Rates = map[string]float64{}
+			}
+			s.Rates[name] = rate
+			return nil
+		}},
+	mapSetting("sound", "sound for work_end, break_end or goal: default or an audio file", "default", func(s *State) *map[string]string { return &s.Sounds }),
+	mapSetting("hotkey", "global shortcut for toggle or break", "cmd+shift+d", func(s *State) *map[string]string { return &s.Hotkeys }),
+	mapSetting("notify_command", "command showing notifications on windows, linux, darwin or default, with {title} and {message}", "notify-send {title} {message}", func(s *State) *map[string]string { return &s.NotifyCommands }),
+}
+
+func stringSetting(key, help, example string, field func(s *State) *string) *Setting {
+	return &Setting{Key: key, Help: help, Example: example,
+		get: func(s *State) string { return *field(s) },
+		set: func(s *State, v string) error {
+			*field(s) = v
+			return nil
+		}}
+}
+
+func mapSetting(key, help, example string, field func(s *State) *map[string]string) *Setting {
+	return &Setting{Key: key, Family: true, Help: help, Example: example,
+		entries: func(s *State) map[string]string {
+			out := map[string]string{}
+			for k, v := range *field(s) {
+				out[k] = v
+			}
+			return out
+		},
+		put: func(s *State, name, v string) error {
+			m := field(s)
+			if v == "" {
+				delete(*m, name)
+				return nil
+			}
+			if *m == nil {
+				*m = map[string]string{}
+			}
+			(*m)[name] = v
+			return nil
+		}}
+}
+
+// LookupSetting finds the setting for key, returning the name within a family
+// (acme for rate.acme).
+func LookupSetting(key string) (*Setting, string, error) {
+	for _, set := range Settings {
+		if set.Key == key && !set.Family {
+			return set, "", nil
+		}
+		if name, ok := strings.CutPrefix(key, set.Key+"."); ok && set.Family && name != "" {
+			return set, unquoteKey(name), nil
+		}
+	}
+	return nil, "", fmt.Errorf("unknown setting %q (see daily config list)", key)
+}
+
+// GetSetting returns the value of key; unset family entries are "".
+func (s *State) GetSetting(key string) (string, error) {
+	set, name, err := LookupSetting(key)
+	if err != nil {
+		return "", err
+	}
+	if set.Family {
+		return set.entries(s)[name], nil
+	}
+	return set.get(s), nil
+}
+
+// SetSetting changes key to value; "" restores the default.
+func (s *State) SetSetting(key, value string) error {
+	set, name, err := LookupSetting(key)
+	if err != nil {
+		return err
+	}
+	value = strings.TrimSpace(value)
+	if set.Family {
+		err = set.put(s, name, value)
+	} else {
+		err = set.set(s, value)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", key, err)
+	}
+	return nil
+}
+
+// SettingValue is one key and its current value.
+type SettingValue struct {
+	Key, Value string
+	Setting    *Setting
+}
+
+// SettingValues lists every scalar setting and every family entry, in the
+// order of Settings.
+func (s *State) SettingValues() []SettingValue {
+	var out []SettingValue
+	for _, set := range Settings {
+		if !set.Family {
+			out = append(out, SettingValue{Key: set.Key, Value: set.get(s), Setting: set})
+			continue
+		}
+		entries := set.entries(s)
+		names := make([]string, 0, len(entries))
+		for name := range entries {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			out = append(out, SettingValue{Key: set.Key + "." + quoteKey(name), Value: entries[name], Setting: set})
+		}
+	}
+	return out
+}
+
+// EncodeSettings writes the settings as TOML, one commented key per line.
+// Families without entries get a commented-out example.
+func (s *State) EncodeSettings(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	values := s.SettingValues()
+	for _, set := range Settings {
+		fmt.Fprintf(bw, "# %s\n", set.Help)
+		n := 0
+		for _, v := range values {
+			if v.Setting == set {
+				fmt.Fprintf(bw, "%s = %s\n", v.Key, tomlValue(v.Value))
+				n++
+			}
+		}
+		if set.Family && n == 0 {
+			fmt.Fprintf(bw, "# %s.name = %s\n", set.Key, tomlValue(set.Example))
+		}
+	}
+	return bw.Flush()
+}
+
+// DecodeSettings replaces every setting with the TOML read from r; keys that
+// are left out take their defaults.
+func (s *State) DecodeSettings(r io.Reader) error {
+	values := map[string]string{}
+	var order []string
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			return fmt.Errorf("line %d: tables are not supported; use dotted keys like smtp.host", n)
+		}
+		key, raw, ok := strings.Cut(line, "=")
+		if !ok {
+			return fmt.Errorf("line %d: want key = value", n)
+		}
+		key = strings.TrimSpace(key)
+		value, err := parseTOMLValue(strings.TrimSpace(raw))
+		if err != nil {
+			return fmt.Errorf("line %d: %s: %v", n, key, err)
+		}
+		if _, _, err := LookupSetting(key); err != nil {
+			return fmt.Errorf("line %d: %v", n, err)
+		}
+		if _, dup := values[key]; !dup {
+			order = append(order, key)
+		}
+		values[key] = value
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+
+	for _, set := range Settings {
+		if !set.Family {
+			if err := set.set(s, ""); err != nil {
+				return err
+			}
+			continue
+		}
+		for name := range set.entries(s) {
+			if err := set.put(s, name, ""); err != nil {
+				return err
+			}
+		}
+	}
+	for _, key := range order {
+		if err := s.SetSetting(key, values[key]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func tomlValue(v string) string {
+	if v == "true" || v == "false" {
+		return v
+	}
+	if _, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return v
+	}
+	return strconv.Quote(v)
+}
+
+// parseTOMLValue reads a basic or literal string, integer, float or boolean,
+// ignoring a trailing comment.
+func parseTOMLValue(raw string) (string, error) {
+	switch {
+	case strings.HasPrefix(raw, `"`):
+		end := 1
+		for ; end < len(raw); end++ {
+			if raw[end] == '\\' {
+				end++
+			} else if raw[end] == '"' {
+				break
+			}
+		}
+		if end >= len(raw) {
+			return "", fmt.Errorf("unterminated string")
+		}
+		if rest := strings.TrimSpace(raw[end+1:]); rest != "" && !strings.HasPrefix(rest, "#") {
+			return "", fmt.Errorf("unexpected %q after value", rest)
+		}
+		return strconv.Unquote(raw[:end+1])
+	case strings.HasPrefix(raw, "'"):
+		v, rest, ok := strings.Cut(raw[1:], "'")
+		if !ok {
+			return "", fmt.Errorf("unterminated string")
+		}
+		if rest = strings.TrimSpace(rest); rest != "" && !strings.HasPrefix(rest, "#") {
+			return "", fmt.Errorf("unexpected %q after value", rest)
+		}
+		return v, nil
+	}
+	v, _, _ := strings.Cut(raw, "#")
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return "", fmt.Errorf("missing value")
+	}
+	return v, nil
+}
+
+// quoteKey quotes a family entry name that is not a bare TOML key.
+func quoteKey(name string) string {
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-') {
+			return strconv.Quote(name)
+		}
+	}
+	return name
+}
+
+func unquoteKey(name string) string {
+	if v, err := strconv.Unquote(name); err == nil {
+		return v
+	}
+	return name
+}
+
+func isOff(v string) bool {
+	return v == "" || v == "off"
+}
+
+func offOr(set bool, v string) string {
+	if !set {
+		return "off"
+	}
+	return v
+}
+
+func orDefault(v, def string) string {
+	if v == "" {
+		return def
+	}
+	return v
+}
+
+func parseBool(v string, def bool) (bool, error) {
+	if v == "" {
+		return def, nil
+	}
+	switch strings.ToLower(v) {
+	case "true", "on", "yes", "1":
+		return true, nil
+	case "false", "off", "no", "0":
+		return false, nil
+	}
+	return def, fmt.Errorf("want true or false, not %q", v)
+}
+
+// parseMinutes reads a duration such as 7h30m or 45m, or a bare number of
+// minutes; with hours, numbers up to 24 are hours as in set-goal. "" and off
+// are 0.
+func parseMinutes(v string, hours bool) (int, error) {
+	if isOff(v) {
+		return 0, nil
+	}
+	if n, err := strconv.Atoi(v); err == nil && n > 0 {
+		if hours {
+			return ParseGoalMinutes(n), nil
+		}
+		return n, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d < time.Minute {
+		return 0, fmt.Errorf("want a duration such as 8h or 45m, not %q", v)
+	}
+	return int(d / time.Minute), nil
+}
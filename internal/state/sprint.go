@@ -0,0 +1,33 @@
+package state
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrNoSprint is returned when a sprint control finds no sprint running.
+var ErrNoSprint = errors.New("no sprint running")
+
+// SkipPhase ends the running sprint phase at now; the sprint moves on to its
+// break or next work phase.
+func (s *State) SkipPhase(now time.Time) error {
+	if s.SprintPhaseEnd == nil {
+		return ErrNoSprint
+	}
+	end := now.Round(0)
+	s.SprintPhaseEnd = &end
+	return nil
+}
+
+// ExtendPhase moves the end of the running sprint phase by d.
+func (s *State) ExtendPhase(now time.Time, d time.Duration) error {
+	if s.SprintPhaseEnd == nil {
+		return ErrNoSprint
+	}
+	end := s.SprintPhaseEnd.Add(d)
+	if end.Before(now) {
+		end = now.Round(0)
+	}
+	s.SprintPhaseEnd = &end
+	return nil
+}
@@ -0,0 +1,134 @@
+package state
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultWorkdays are the days the start reminder watches unless configured.
+const DefaultWorkdays = "mon,tue,wed,thu,fri"
+
+var weekdayNames = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// ParseWorkdays normalises a list of weekdays, such as "mon-fri" or
+// "mon,wed,sat", to comma-separated three-letter names from Monday on.
+func ParseWorkdays(v string) (string, error) {
+	var days [7]bool
+	for _, part := range strings.FieldsFunc(strings.ToLower(v), func(r rune) bool { return r == ',' || r == ' ' }) {
+		from, to, isRange := strings.Cut(part, "-")
+		a, ok := weekdayIndex(from)
+		if !ok {
+			return "", fmt.Errorf("unknown weekday %q (want mon, tue, … or a range like mon-fri)", from)
+		}
+		b := a
+		if isRange {
+			if b, ok = weekdayIndex(to); !ok {
+				return "", fmt.Errorf("unknown weekday %q (want mon, tue, … or a range like mon-fri)", to)
+			}
+		}
+		for i := a; ; i = (i + 1) % 7 {
+			days[i] = true
+			if i == b {
+				break
+			}
+		}
+	}
+	var out []string
+	for i := 1; i <= 7; i++ {
+		if days[i%7] {
+			out = append(out, weekdayNames[i%7])
+		}
+	}
+	if len(out) == 0 {
+		return "", fmt.Errorf("no weekdays in %q", v)
+	}
+	return strings.Join(out, ","), nil
+}
+
+func weekdayIndex(name string) (int, bool) {
+	if len(name) < 3 {
+		return 0, false
+	}
+	for i, n := range weekdayNames {
+		if strings.HasPrefix(name, n) {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// Workday reports whether t falls on one of the configured workdays.
+func (s *State) Workday(t time.Time) bool {
+	days := s.Workdays
+	if days == "" {
+		days = DefaultWorkdays
+	}
+	return strings.Contains(days, weekdayNames[t.Weekday()])
+}
+
+// Learning the usual start: the first session of each workday in the last
+// startLearnDays days, once there are at least startLearnMin of them. The
+// reminder comes startReminderGrace after it.
+const (
+	startLearnDays     = 28
+	startLearnMin      = 5
+	startReminderGrace = 30 * time.Minute
+)
+
+// UsualStart is the median time of day the first session of a workday began
+// over the last few weeks, before today.
+func (s *State) UsualStart(now time.Time) (time.Duration, bool) {
+	var starts []time.Duration
+	today := Midnight(now)
+	for i := 1; i <= startLearnDays; i++ {
+		day := today.AddDate(0, 0, -i)
+		log := s.Days[dateKey(day)]
+		if log == nil || len(log.Sessions) == 0 || !s.Workday(day) {
+			continue
+		}
+		first := log.Sessions[0].Start
+		for _, sess := range log.Sessions[1:] {
+			if sess.Start.Before(first) {
+				first = sess.Start
+			}
+		}
+		starts = append(starts, ClockOffset(first.In(time.Local)))
+	}
+	if len(starts) < startLearnMin {
+		return 0, false
+	}
+	sort.Slice(starts, func(i, j int) bool { return starts[i] < starts[j] })
+	return starts[len(starts)/2], true
+}
+
+// StartReminderDue reports whether to remind that no session has started
+// today, with the start time the reminder refers to: the fixed
+// StartReminder time, or the learned usual start plus a grace period.
+func (s *State) StartReminderDue(now time.Time) (usual time.Time, due bool) {
+	if s.StartReminder == "off" || s.ActiveSession != nil || s.ActiveBreak != nil || !s.Workday(now) {
+		return time.Time{}, false
+	}
+	if log := s.Days[dateKey(now)]; log != nil && (len(log.Sessions) > 0 || log.WorkSeconds() > 0) {
+		return time.Time{}, false
+	}
+	var clock, grace time.Duration
+	if s.StartReminder != "" {
+		t, err := time.Parse("15:04", s.StartReminder)
+		if err != nil {
+			return time.Time{}, false
+		}
+		clock = ClockOffset(t)
+	} else {
+		var ok bool
+		if clock, ok = s.UsualStart(now); !ok {
+			return time.Time{}, false
+		}
+		grace = startReminderGrace
+	}
+	y, m, d := now.Date()
+	usual = time.Date(y, m, d, 0, int(clock/time.Minute), 0, 0, now.Location())
+	at := usual.Add(grace)
+	return usual, !now.Before(at)
+}
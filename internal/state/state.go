@@ -6,8 +6,12 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"path/filepath"
+	"sort"
+	"strings"
 	"time"
+
+	"github.com/max-pantom/daily/internal/i18n"
+	"github.com/max-pantom/daily/internal/notify"
 )
 
 // State is the persisted application state.
@@ -18,38 +22,181 @@ type State struct {
 	BreakIntervalMinutes int                `json:"break_interval_minutes"`
 	ActiveSession        *Session           `json:"active_session,omitempty"`
 	ActiveBreak          *Session           `json:"active_break,omitempty"`
+	ActiveInterruption   *Interruption      `json:"active_interruption,omitempty"`
 	NotificationsEnabled *bool              `json:"notifications_enabled,omitempty"`
+	CalendarSource       string             `json:"calendar_source,omitempty"`
+	SMTPHost             string             `json:"smtp_host,omitempty"`
+	SMTPPort             int                `json:"smtp_port,omitempty"`
+	SMTPUser             string             `json:"smtp_user,omitempty"`
+	SMTPFrom             string             `json:"smtp_from,omitempty"`
+	JiraURL              string             `json:"jira_url,omitempty"`
+	JiraEmail            string             `json:"jira_email,omitempty"`
+	ObsidianVault        string             `json:"obsidian_vault,omitempty"`
+	ObsidianPattern      string             `json:"obsidian_pattern,omitempty"`   // daily note path inside the vault
+	ObsidianTemplate     string             `json:"obsidian_template,omitempty"`  // text/template file for the time log
+	GSheetCredentials    string             `json:"gsheet_credentials,omitempty"` // service-account key for export --gsheet
+	GSheetTab            string             `json:"gsheet_tab,omitempty"`
+	TeamURL              string             `json:"team_url,omitempty"`     // team server for team report
+	TeamMember           string             `json:"team_member,omitempty"`  // random secret pushed to the team server
+	SyncBackend          string             `json:"sync_backend,omitempty"` // s3, webdav or http; "" means no sync
+	SyncURL              string             `json:"sync_url,omitempty"`
+	SyncUser             string             `json:"sync_user,omitempty"`
+	SyncRegion           string             `json:"sync_region,omitempty"`
+	SprintPhaseEnd       *time.Time         `json:"sprint_phase_end,omitempty"`   // end of the running sprint's work or break phase
+	Lease                *Lease             `json:"lease,omitempty"`              // component controlling the session, e.g. a running sprint
+	TrayTitle            string             `json:"tray_title,omitempty"`         // "auto" (default) or "total"
+	Sounds               map[string]string  `json:"sounds,omitempty"`             // event -> "default" or an audio file
+	CapMinutes           int                `json:"cap_minutes,omitempty"`        // hard daily limit; 0 means none
+	CapStrict            bool               `json:"cap_strict,omitempty"`         // refuse new sessions past the cap
+	LastSeen             *time.Time         `json:"last_seen,omitempty"`          // heartbeat from a running frontend
+	Rounding             *Rounding          `json:"rounding,omitempty"`           // display/report granularity
+	LastBreakEnd         *time.Time         `json:"last_break_end,omitempty"`     // resets continuous work
+	Rates                map[string]float64 `json:"rates,omitempty"`              // hourly rate by project; "" is the default
+	AutoStop             string             `json:"auto_stop,omitempty"`          // HH:MM; the daemon stops sessions still running then
+	MinSession           *MinSession        `json:"min_session,omitempty"`        // shorter sessions are discarded or merged
+	Hotkeys              map[string]string  `json:"hotkeys,omitempty"`            // action -> shortcut, e.g. toggle: cmd+shift+d; "off" disables
+	NotifyCommands       map[string]string  `json:"notify_commands,omitempty"`    // platform (windows, linux, darwin or default) -> notification command with {title} and {message}
+	PushService          string             `json:"push_service,omitempty"`       // ntfy, pushover or telegram; "" means desktop only
+	PushTo               string             `json:"push_to,omitempty"`            // ntfy topic, Pushover user key or Telegram chat ID
+	CheckpointMinutes    int                `json:"checkpoint_minutes,omitempty"` // how often the running session is autosaved; 0 means never
+	Checkpoint           *Session           `json:"checkpoint,omitempty"`         // running session as of its last autosave
+	AllowedTags          []string           `json:"allowed_tags,omitempty"`       // predefined tags, suggested for typos
+	StrictTags           bool               `json:"strict_tags,omitempty"`        // refuse tags outside AllowedTags
+	BreakMinutes         int                `json:"break_minutes,omitempty"`      // break length before the back-to-work alert; 0 means none
+	Workdays             string             `json:"workdays,omitempty"`           // e.g. "mon,tue,wed"; "" means DefaultWorkdays
+	StartReminder        string             `json:"start_reminder,omitempty"`     // "" learns the usual start, "off", or HH:MM
+	Theme                string             `json:"theme,omitempty"`              // "" (default) or ThemeColorblind
+	Language             string             `json:"language,omitempty"`           // "" follows LANG
+	Clock                string             `json:"clock,omitempty"`              // "" follows Language, or i18n.Clock12/Clock24
+	BreakGuide           string             `json:"break_guide,omitempty"`        // "" (box breathing), GuideStretch or GuideOff
+	GoalCarry            string             `json:"goal_carry,omitempty"`         // "" (off), CarryOver, CarryUnder or CarryBoth
+	WeekGoalMinutes      int                `json:"week_goal_minutes,omitempty"`  // 0 adds up the workdays' goals
+	FocusBlocks          []FocusBlock       `json:"focus_blocks,omitempty"`       // offered in the tray
+	TagRules             map[string]string  `json:"tag_rules,omitempty"`          // tag -> TagRule, applied at start and stop
 	Days                 map[string]*DayLog `json:"days"`
+
+	// Anomalies are the problems Check found when the state was loaded; they
+	// are not persisted.
+	Anomalies []string `json:"-"`
+
+	// Revision is the daemon's version of this state when it was loaded; it is
+	// not persisted.
+	Revision uint64 `json:"-"`
+
+	// RuleEnv is what the frontend knows for tag rules; it is not persisted.
+	RuleEnv RuleEnv `json:"-"`
+
+	idx *dayIndex // see index.go
 }
 
 type Session struct {
-	Start time.Time  `json:"start"`
-	End   *time.Time `json:"end,omitempty"`
-	Tags  []string   `json:"tags,omitempty"`
-	Note  string     `json:"note,omitempty"`
+	Start   time.Time      `json:"start"`
+	End     *time.Time     `json:"end,omitempty"`
+	Tags    []string       `json:"tags,omitempty"`
+	Note    string         `json:"note,omitempty"`
+	Project string         `json:"project,omitempty"`
+	Apps    map[string]int `json:"apps,omitempty"`    // seconds per foreground app
+	Until   *time.Time     `json:"until,omitempty"`   // countdown deadline for the active session
+	Elapsed int            `json:"seconds,omitempty"` // recorded length in seconds, set when it ends
+	Pushed  []string       `json:"pushed,omitempty"`  // worklogs sent, as tracker:issue (e.g. jira:PROJ-1)
+	// RuleTags are the tags tag rules added when the active session started,
+	// kept only if the finished session still meets their rules.
+	RuleTags []string `json:"rule_tags,omitempty"`
+}
+
+// HasTag reports whether the session carries tag (case-insensitive).
+func (s Session) HasTag(tag string) bool {
+	for _, t := range s.Tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
 }
 
 type DayLog struct {
-	Date              string    `json:"date"`
-	Sessions          []Session `json:"sessions"`
-	TotalWorkMinutes  int       `json:"total_work_minutes"`
-	TotalWorkSeconds  int       `json:"total_work_seconds,omitempty"`
-	TotalBreakMinutes int       `json:"total_break_minutes"`
-	BreakCount        int       `json:"break_count"`
-	GoalMinutes       int       `json:"goal_minutes"`
+	Date              string         `json:"date"`
+	Sessions          []Session      `json:"sessions"`
+	Breaks            []Session      `json:"breaks,omitempty"` // days logged before breaks were listed only have the totals
+	Interruptions     []Interruption `json:"interruptions,omitempty"`
+	TotalWorkMinutes  int            `json:"total_work_minutes"`
+	TotalWorkSeconds  int            `json:"total_work_seconds,omitempty"`
+	TotalBreakMinutes int            `json:"total_break_minutes"`
+	TotalBreakSeconds int            `json:"total_break_seconds,omitempty"`
+	BreakCount        int            `json:"break_count"`
+	GoalMinutes       int            `json:"goal_minutes"`
+	AutoStopped       *time.Time     `json:"auto_stopped,omitempty"`    // set when the auto-stop rule ended a session; cleared by review
+	RelaxSeconds      int            `json:"relax_seconds,omitempty"`   // time spent in the TUI's relax mode
+	GameHighScore     int            `json:"game_high_score,omitempty"` // best Block Breaker score of the day
+}
+
+// WorkSeconds returns the day's exact work total, falling back to minutes for
+// days logged before seconds were kept. The seconds are authoritative; the
+// minute totals are derived from them.
+func (d *DayLog) WorkSeconds() int {
+	if d.TotalWorkSeconds > 0 {
+		return d.TotalWorkSeconds
+	}
+	return d.TotalWorkMinutes * 60
+}
+
+// BreakSeconds is WorkSeconds for breaks.
+func (d *DayLog) BreakSeconds() int {
+	if d.TotalBreakSeconds > 0 {
+		return d.TotalBreakSeconds
+	}
+	return d.TotalBreakMinutes * 60
+}
+
+func (d *DayLog) addWork(sess Session, seconds int) {
+	sess.Elapsed = seconds
+	d.Sessions = append(d.Sessions, sess)
+	d.TotalWorkSeconds = d.WorkSeconds() + seconds
+	d.TotalWorkMinutes = d.TotalWorkSeconds / 60
 }
 
+func (d *DayLog) addBreak(start, end time.Time) {
+	seconds := int(end.Sub(start).Seconds())
+	d.Breaks = append(d.Breaks, Session{Start: start, End: &end, Elapsed: seconds})
+	d.TotalBreakSeconds = d.BreakSeconds() + seconds
+	d.TotalBreakMinutes = d.TotalBreakSeconds / 60
+	d.BreakCount++
+}
+
+// ThemeColorblind is the Theme that swaps the green-to-red colours for the
+// Okabe–Ito palette, which stays distinct under common colour blindness.
+const ThemeColorblind = "colorblind"
+
+// BreakGuide values: the TUI opens a stretch checklist instead of box
+// breathing when a break begins, or nothing.
+const (
+	GuideStretch = "stretch"
+	GuideOff     = "off"
+)
+
 const (
 	defaultGoalMinutes          = 12 * 60
 	defaultBreakIntervalMinutes = 120
 )
 
-// Load loads state from disk or returns defaults when missing.
+// Steps and floors for the quick goal and break-interval adjustments offered by
+// the TUI and tray.
+const (
+	GoalStepMinutes  = 30
+	BreakStepMinutes = 5
+	MinGoalMinutes   = 30
+	MinBreakMinutes  = 5
+)
+
+// Load loads state from disk or returns defaults when missing. Settings come
+// from the config file next to it (see ConfigPath); a state file from before
+// they were split is read as it is and migrated by the next Save. Load never
+// writes, so commands that only read leave the files alone.
 func Load(path string) (*State, error) {
 	data, err := os.ReadFile(path)
 	if errors.Is(err, os.ErrNotExist) {
 		st := defaults()
-		if err := st.Save(path); err != nil {
+		if err := st.loadConfig(path, nil); err != nil {
 			return nil, err
 		}
 		return st, nil
@@ -62,43 +209,140 @@ func Load(path string) (*State, error) {
 	if err := json.Unmarshal(data, &st); err != nil {
 		return nil, err
 	}
+	if err := st.loadConfig(path, data); err != nil {
+		return nil, err
+	}
 	st.ensureDefaults()
+	st.Anomalies = st.Check(time.Now())
 	return &st, nil
 }
 
-// Save writes state to disk atomically.
+// Save writes the settings to the config file and the rest of the state to
+// path, each atomically.
 func (s *State) Save(path string) error {
-	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+	if err := s.saveConfig(path); err != nil {
 		return err
 	}
-
-	tmp := path + ".tmp"
-	f, err := os.Create(tmp)
+	data, err := s.dataJSON()
 	if err != nil {
 		return err
 	}
-	enc := json.NewEncoder(f)
-	enc.SetIndent("", "  ")
-	if err := enc.Encode(s); err != nil {
-		f.Close()
-		return err
-	}
-	if err := f.Close(); err != nil {
-		return err
-	}
-	return os.Rename(tmp, path)
+	return writeAtomic(path, append(data, '\n'))
 }
 
-// StartSession sets an active session if none is running.
+// StartSession sets an active session if none is running, ending any
+// interruption. Tags must pass CheckTags.
 func (s *State) StartSession(now time.Time, tags []string, note string) error {
 	if s.ActiveSession != nil {
-		return fmt.Errorf("session already running since %s", s.ActiveSession.Start.Format(time.Kitchen))
+		return fmt.Errorf("session already running since %s", i18n.Time(s.ActiveSession.Start))
+	}
+	if err := s.CheckTags(tags); err != nil {
+		return err
+	}
+	if s.ActiveInterruption != nil {
+		if _, err := s.EndInterruption(now); err != nil {
+			return err
+		}
 	}
 	s.ActiveSession = &Session{Start: now, Tags: tags, Note: note}
+	s.ActiveSession.RuleTags = s.applyTagRules(s.ActiveSession, nil)
 	return nil
 }
 
-// StopSession closes the active session and records it to today's log.
+// StartCountdown starts a session that stops itself once d has elapsed.
+func (s *State) StartCountdown(now time.Time, d time.Duration, tags []string, note string) error {
+	if d <= 0 {
+		return errors.New("countdown must be > 0")
+	}
+	if err := s.StartSession(now, tags, note); err != nil {
+		return err
+	}
+	until := now.Add(d)
+	s.ActiveSession.Until = &until
+	return nil
+}
+
+// Remaining returns the time left on a countdown session.
+func (s *State) Remaining(now time.Time) (time.Duration, bool) {
+	if s.ActiveSession == nil || s.ActiveSession.Until == nil {
+		return 0, false
+	}
+	left := s.ActiveSession.Until.Sub(now)
+	if left < 0 {
+		left = 0
+	}
+	return left, true
+}
+
+// PhaseRemaining returns the time left on a countdown session or, failing that,
+// on the current sprint phase.
+func (s *State) PhaseRemaining(now time.Time) (time.Duration, bool) {
+	if left, ok := s.Remaining(now); ok {
+		return left, true
+	}
+	if s.SprintPhaseEnd == nil || !now.Before(*s.SprintPhaseEnd) {
+		return 0, false
+	}
+	return s.SprintPhaseEnd.Sub(now), true
+}
+
+// FinishCountdown stops a countdown session whose deadline has passed, ending it
+// at the deadline rather than now. It reports whether a session was stopped.
+func (s *State) FinishCountdown(now time.Time) (int, bool) {
+	if s.ActiveSession == nil || s.ActiveSession.Until == nil || now.Before(*s.ActiveSession.Until) {
+		return 0, false
+	}
+	minutes, err := s.StopSessionAt(*s.ActiveSession.Until)
+	if err != nil {
+		return 0, false
+	}
+	return minutes, true
+}
+
+// TagActive adds tag to the running session, filling in note when it is empty.
+// It reports whether the session changed.
+func (s *State) TagActive(tag, note string) bool {
+	if s.ActiveSession == nil || s.ActiveSession.HasTag(tag) {
+		return false
+	}
+	s.ActiveSession.Tags = append(s.ActiveSession.Tags, tag)
+	if s.ActiveSession.Note == "" {
+		s.ActiveSession.Note = note
+	}
+	return true
+}
+
+// RecordApp attributes seconds of the running session to the named app.
+func (s *State) RecordApp(name string, seconds int) {
+	if s.ActiveSession == nil || name == "" || seconds <= 0 {
+		return
+	}
+	if s.ActiveSession.Apps == nil {
+		s.ActiveSession.Apps = make(map[string]int)
+	}
+	s.ActiveSession.Apps[name] += seconds
+}
+
+// AppTotals sums per-app seconds across a day's sessions plus the running session.
+func (s *State) AppTotals(dayKey string) map[string]int {
+	totals := make(map[string]int)
+	if log, ok := s.Days[dayKey]; ok {
+		for _, sess := range log.Sessions {
+			for app, secs := range sess.Apps {
+				totals[app] += secs
+			}
+		}
+	}
+	if s.ActiveSession != nil && dateKey(s.ActiveSession.Start) == dayKey {
+		for app, secs := range s.ActiveSession.Apps {
+			totals[app] += secs
+		}
+	}
+	return totals
+}
+
+// StopSession closes the active session and records it to today's log. A
+// session under MinSession is discarded or merged instead.
 func (s *State) StopSession(now time.Time) (int, error) {
 	if s.ActiveSession == nil {
 		return 0, errors.New("no active session")
@@ -109,23 +353,102 @@ func (s *State) StopSession(now time.Time) (int, error) {
 
 	seconds := int(now.Sub(s.ActiveSession.Start).Seconds())
 	end := now
-	sess := Session{Start: s.ActiveSession.Start, End: &end, Tags: s.ActiveSession.Tags, Note: s.ActiveSession.Note}
-
-	dayKey := dateKey(now)
-	log := s.dayLog(dayKey)
-	if log.TotalWorkSeconds == 0 && log.TotalWorkMinutes > 0 {
-		log.TotalWorkSeconds = log.TotalWorkMinutes * 60
-	}
-	log.Sessions = append(log.Sessions, sess)
-	log.TotalWorkSeconds += seconds
-	log.TotalWorkMinutes = log.TotalWorkSeconds / 60
-	log.GoalMinutes = s.GoalMinutes
-	s.Days[dayKey] = log
+	sess := *s.ActiveSession
+	sess.End = &end
+	sess.Until = nil
+	s.settleTagRules(&sess, &end)
+
+	s.editDay(dateKey(now), func(log *DayLog) {
+		if !s.MinSession.Short(seconds) || !log.absorbShort(sess, seconds, s.MinSession.Policy) {
+			log.addWork(sess, seconds)
+		}
+		log.GoalMinutes = s.GoalMinutes
+	})
 
 	s.ActiveSession = nil
+	s.Checkpoint = nil
 	return seconds / 60, nil
 }
 
+// StopSessionAt ends the running session at t, which may lie in the past,
+// first splitting it at any midnight before t.
+func (s *State) StopSessionAt(t time.Time) (int, error) {
+	s.splitActive(t)
+	return s.StopSession(t)
+}
+
+// AddWork records sess's tags, note and project as work between start and end
+// after the fact, such as idle time the user chose to keep, splitting it at
+// midnight.
+func (s *State) AddWork(sess Session, start, end time.Time) {
+	sess.Apps = nil
+	for start.Before(end) {
+		stop := nextMidnight(start)
+		if stop.After(end) {
+			stop = end
+		}
+		sess.Start = start
+		s.addWorkSpan(sess, stop)
+		start = stop
+	}
+}
+
+// AddBreak records a break between start and end after the fact, such as time
+// the machine spent asleep, splitting it at midnight.
+func (s *State) AddBreak(start, end time.Time) {
+	for start.Before(end) {
+		stop := nextMidnight(start)
+		if stop.After(end) {
+			stop = end
+		}
+		s.addBreakSpan(start, stop)
+		start = stop
+	}
+	s.noteBreakEnd(end)
+}
+
+func (s *State) noteBreakEnd(t time.Time) {
+	if s.LastBreakEnd == nil || t.After(*s.LastBreakEnd) {
+		s.LastBreakEnd = &t
+	}
+}
+
+// ContinuousGap is the shortest pause between sessions that counts as rest
+// for ContinuousWork; a quick stop and restart does not.
+const ContinuousGap = 5 * time.Minute
+
+// ContinuousWork returns how long the user has been working without a rest:
+// the running session plus the sessions before it that were separated by less
+// than ContinuousGap and not by a logged break. It is zero when nothing runs.
+func (s *State) ContinuousWork(now time.Time) time.Duration {
+	if s.ActiveSession == nil {
+		return 0
+	}
+	start := s.ActiveSession.Start
+	total := now.Sub(start)
+	var earlier []Session
+	for _, t := range []time.Time{start, start.AddDate(0, 0, -1)} {
+		if log, ok := s.Days[dateKey(t)]; ok {
+			earlier = append(earlier, log.Sessions...)
+		}
+	}
+	sort.Slice(earlier, func(i, j int) bool { return earlier[i].Start.After(earlier[j].Start) })
+	for _, sess := range earlier {
+		if sess.End == nil || sess.End.After(start) {
+			continue
+		}
+		if start.Sub(*sess.End) >= ContinuousGap {
+			break
+		}
+		if s.LastBreakEnd != nil && !s.LastBreakEnd.Before(*sess.End) && !s.LastBreakEnd.After(start) {
+			break
+		}
+		total += time.Duration(sess.Seconds(now)) * time.Second
+		start = sess.Start
+	}
+	return total
+}
+
 // StartBreak starts a break; if a work session is running, it is ended first.
 func (s *State) StartBreak(now time.Time) error {
 	if s.ActiveBreak != nil {
@@ -136,6 +459,11 @@ func (s *State) StartBreak(now time.Time) error {
 			return err
 		}
 	}
+	if s.ActiveInterruption != nil {
+		if _, err := s.EndInterruption(now); err != nil {
+			return err
+		}
+	}
 	s.ActiveBreak = &Session{Start: now}
 	return nil
 }
@@ -148,42 +476,46 @@ func (s *State) StopBreak(now time.Time) (int, error) {
 	if now.Before(s.ActiveBreak.Start) {
 		return 0, errors.New("break end before start")
 	}
-	minutes := int(now.Sub(s.ActiveBreak.Start).Minutes())
-	dayKey := dateKey(now)
-	log := s.dayLog(dayKey)
-	log.BreakCount++
-	log.TotalBreakMinutes += minutes
-	// We keep break totals only; per-break list can be added later if needed.
-	s.Days[dayKey] = log
+	seconds := int(now.Sub(s.ActiveBreak.Start).Seconds())
+	start := s.ActiveBreak.Start
+	s.editDay(dateKey(now), func(log *DayLog) { log.addBreak(start, now) })
+	s.noteBreakEnd(now)
 
 	s.ActiveBreak = nil
-	return minutes, nil
+	return seconds / 60, nil
 }
 
 // TodaySummary returns the accumulated minutes for today including the running session.
 func (s *State) TodaySummary(now time.Time) (workMinutes int, activeMinutes int) {
-	dayKey := dateKey(now)
-	if log, ok := s.Days[dayKey]; ok {
-		workMinutes += log.TotalWorkMinutes
+	work, active := s.TodaySeconds(now)
+	return work / 60, active / 60
+}
+
+// TodaySeconds is TodaySummary in seconds.
+func (s *State) TodaySeconds(now time.Time) (workSeconds int, activeSeconds int) {
+	if log, ok := s.Days[dateKey(now)]; ok {
+		workSeconds += log.WorkSeconds()
 	}
 	if s.ActiveSession != nil {
-		activeMinutes = int(now.Sub(s.ActiveSession.Start).Minutes())
-		workMinutes += activeMinutes
+		activeSeconds = s.ActiveSession.Seconds(now)
+		workSeconds += activeSeconds
 	}
-	// Breaks are tracked separately; workMinutes excludes break minutes.
-	return workMinutes, activeMinutes
+	// Breaks are tracked separately; workSeconds excludes breaks.
+	return workSeconds, activeSeconds
 }
 
-func (s *State) dayLog(key string) *DayLog {
-	if s.Days == nil {
-		s.Days = make(map[string]*DayLog)
+// GoalETA returns the wall-clock time today's goal will be met if the running
+// session continues uninterrupted. It is false when nothing is running or the goal is met.
+func (s *State) GoalETA(now time.Time) (time.Time, bool) {
+	goal, _ := s.Goal(now)
+	if s.ActiveSession == nil || goal <= 0 {
+		return time.Time{}, false
 	}
-	if log, ok := s.Days[key]; ok {
-		return log
+	work, _ := s.TodaySummary(now)
+	if work >= goal {
+		return time.Time{}, false
 	}
-	log := &DayLog{Date: key, GoalMinutes: s.GoalMinutes}
-	s.Days[key] = log
-	return log
+	return now.Add(time.Duration(goal-work) * time.Minute), true
 }
 
 func (s *State) ensureDefaults() {
@@ -205,12 +537,66 @@ func defaults() *State {
 	return &State{
 		GoalMinutes:          defaultGoalMinutes,
 		BreakIntervalMinutes: defaultBreakIntervalMinutes,
+		CheckpointMinutes:    DefaultCheckpointMinutes,
+		BreakMinutes:         DefaultBreakMinutes,
 		Days:                 make(map[string]*DayLog),
 	}
 }
 
 // Normalize ensures active session/break don’t span days; it splits at midnight.
+// Countdown sessions past their deadline are stopped at the deadline, and the
+// checkpoint of a session that vanished without being stopped is logged.
 func (s *State) Normalize(now time.Time) {
+	s.recoverCheckpoint()
+	s.capAtLastSeen(now)
+	s.FinishCountdown(now)
+	s.splitActive(now)
+	s.expireLease(now)
+}
+
+// Heartbeats let a session survive only as long as something was awake to see
+// it: frontends write LastSeen every HeartbeatInterval, and a session whose
+// heartbeat is older than HeartbeatStale is ended at LastSeen.
+const (
+	HeartbeatInterval = time.Minute
+	HeartbeatStale    = 10 * time.Minute
+)
+
+// HeartbeatDue reports whether LastSeen should be refreshed.
+func (s *State) HeartbeatDue(now time.Time) bool {
+	return s.LastSeen == nil || now.Sub(*s.LastSeen) >= HeartbeatInterval
+}
+
+// Heartbeat records that a frontend is running at now.
+func (s *State) Heartbeat(now time.Time) {
+	s.LastSeen = &now
+}
+
+// ClearHeartbeat forgets LastSeen, for frontends exiting cleanly; without it a
+// session kept running from the CLI alone would later be cut short.
+func (s *State) ClearHeartbeat() {
+	s.LastSeen = nil
+}
+
+// capAtLastSeen ends a session that was being watched by a frontend that then
+// went silent (crash or suspend), counting time only up to the last heartbeat.
+// It reports whether a session was stopped.
+func (s *State) capAtLastSeen(now time.Time) bool {
+	if s.ActiveSession == nil || s.LastSeen == nil {
+		return false
+	}
+	seen := *s.LastSeen
+	if !seen.After(s.ActiveSession.Start) || now.Sub(seen) < HeartbeatStale {
+		return false
+	}
+	if _, err := s.StopSessionAt(seen); err != nil {
+		return false
+	}
+	s.LastSeen = nil
+	return true
+}
+
+func (s *State) splitActive(now time.Time) {
 	// Normalize active work session across day boundary.
 	for s.ActiveSession != nil && !sameDate(s.ActiveSession.Start, now) {
 		if !now.After(s.ActiveSession.Start) {
@@ -225,7 +611,12 @@ func (s *State) Normalize(now time.Time) {
 			end = now
 		}
 		if end.After(s.ActiveSession.Start) {
-			s.addWorkSpan(s.ActiveSession.Start, end, s.ActiveSession.Tags, s.ActiveSession.Note)
+			sess := *s.ActiveSession
+			s.settleTagRules(&sess, &end)
+			s.addWorkSpan(sess, end)
+			// App time sampled so far belongs to the day that just closed.
+			s.ActiveSession.Apps = nil
+			s.Checkpoint = nil
 		}
 		s.ActiveSession.Start = end
 		if !end.Before(now) {
@@ -256,33 +647,72 @@ func (s *State) Normalize(now time.Time) {
 	}
 }
 
-func (s *State) addWorkSpan(start, end time.Time, tags []string, note string) {
+// addWorkSpan records sess from its start to end, keeping its tags, note and project.
+func (s *State) addWorkSpan(sess Session, end time.Time) {
+	start := sess.Start
 	seconds := int(end.Sub(start).Seconds())
 	if seconds <= 0 {
 		return
 	}
-	dayKey := dateKey(start)
-	log := s.dayLog(dayKey)
-	if log.TotalWorkSeconds == 0 && log.TotalWorkMinutes > 0 {
-		log.TotalWorkSeconds = log.TotalWorkMinutes * 60
-	}
-	log.Sessions = append(log.Sessions, Session{Start: start, End: &end, Tags: tags, Note: note})
-	log.TotalWorkSeconds += seconds
-	log.TotalWorkMinutes = log.TotalWorkSeconds / 60
-	log.GoalMinutes = s.GoalMinutes
-	s.Days[dayKey] = log
+	sess.End = &end
+	sess.Until, sess.RuleTags = nil, nil
+	s.editDay(dateKey(start), func(log *DayLog) {
+		log.addWork(sess, seconds)
+		log.GoalMinutes = s.GoalMinutes
+	})
 }
 
 func (s *State) addBreakSpan(start, end time.Time) {
-	minutes := int(end.Sub(start).Minutes())
-	if minutes <= 0 {
+	seconds := int(end.Sub(start).Seconds())
+	if seconds <= 0 {
 		return
 	}
-	dayKey := dateKey(start)
-	log := s.dayLog(dayKey)
-	log.TotalBreakMinutes += minutes
-	log.BreakCount++
-	s.Days[dayKey] = log
+	s.editDay(dateKey(start), func(log *DayLog) { log.addBreak(start, end) })
+}
+
+// AdjustGoal changes the daily goal by delta minutes, keeping it at or above
+// MinGoalMinutes, and returns the new goal.
+func (s *State) AdjustGoal(delta int) int {
+	s.GoalMinutes += delta
+	if s.GoalMinutes < MinGoalMinutes {
+		s.GoalMinutes = MinGoalMinutes
+	}
+	return s.GoalMinutes
+}
+
+// AdjustBreakInterval changes the break reminder interval by delta minutes,
+// keeping it at or above MinBreakMinutes, and returns the new interval.
+func (s *State) AdjustBreakInterval(delta int) int {
+	s.BreakIntervalMinutes += delta
+	if s.BreakIntervalMinutes < MinBreakMinutes {
+		s.BreakIntervalMinutes = MinBreakMinutes
+	}
+	return s.BreakIntervalMinutes
+}
+
+// OverCap returns how many minutes today's work exceeds the daily cap. It is
+// false when no cap is set or the cap has not been reached.
+func (s *State) OverCap(now time.Time) (int, bool) {
+	if s.CapMinutes <= 0 {
+		return 0, false
+	}
+	work, _ := s.TodaySummary(now)
+	if work < s.CapMinutes {
+		return 0, false
+	}
+	return work - s.CapMinutes, true
+}
+
+// CheckCap returns an error when a strict cap has been reached, so callers
+// refuse to start another session unless forced.
+func (s *State) CheckCap(now time.Time) error {
+	if !s.CapStrict {
+		return nil
+	}
+	if _, over := s.OverCap(now); over {
+		return fmt.Errorf("daily cap of %s reached; use `daily start --force` to keep going", HumanMinutes(s.CapMinutes))
+	}
+	return nil
 }
 
 func (s *State) NotificationsOn() bool {
@@ -292,6 +722,21 @@ func (s *State) NotificationsOn() bool {
 	return *s.NotificationsEnabled
 }
 
+// NotifyConfig is how notifications are delivered, for notify.Use.
+func (s *State) NotifyConfig() notify.Config {
+	return notify.Config{Commands: s.NotifyCommands, Push: s.PushService, PushTo: s.PushTo}
+}
+
+// Rate returns the hourly rate for project, falling back to the default rate.
+func (s *State) Rate(project string) float64 {
+	for k, v := range s.Rates {
+		if k != "" && strings.EqualFold(k, project) {
+			return v
+		}
+	}
+	return s.Rates[""]
+}
+
 func boolPtr(v bool) *bool {
 	return &v
 }
@@ -308,14 +753,36 @@ func sameDate(a, b time.Time) bool {
 	return y1 == y2 && m1 == m2 && d1 == d2
 }
 
-func midnight(t time.Time) time.Time {
+// Midnight returns the first instant of t's local day. Durations are always
+// taken between instants (time.Sub), so a day containing a DST change is 23 or
+// 25 hours long rather than 24.
+func Midnight(t time.Time) time.Time {
 	y, m, d := t.Date()
-	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+	return dayStart(y, m, d, t.Location())
 }
 
 func nextMidnight(t time.Time) time.Time {
 	y, m, d := t.Date()
-	return time.Date(y, m, d+1, 0, 0, 0, 0, t.Location())
+	return dayStart(y, m, d+1, t.Location())
+}
+
+// dayStart returns the first instant of the given day. Where DST begins at
+// midnight, 00:00 does not exist and time.Date may land on the evening before;
+// the day then starts when the new offset takes effect.
+func dayStart(y int, m time.Month, d int, loc *time.Location) time.Time {
+	t := time.Date(y, m, d, 0, 0, 0, 0, loc)
+	if noon := time.Date(y, m, d, 12, 0, 0, 0, loc); !sameDate(t, noon) {
+		_, end := t.ZoneBounds()
+		t = end
+	}
+	return t
+}
+
+// ClockOffset returns how far into its day t falls by the clock on the wall,
+// e.g. 9h for 09:00 even on a day when DST made the morning an hour shorter.
+func ClockOffset(t time.Time) time.Duration {
+	h, m, sec := t.Clock()
+	return time.Duration(h)*time.Hour + time.Duration(m)*time.Minute + time.Duration(sec)*time.Second
 }
 
 // ParseGoalMinutes interprets an input value as either hours (< 24) or minutes.
@@ -342,6 +809,38 @@ func HumanMinutes(total int) string {
 	return fmt.Sprintf("%dh%02dm", hours, mins)
 }
 
+// HumanSeconds renders a duration in seconds like HumanMinutes, showing
+// anything under a minute as seconds ("45s") instead of "0m".
+func HumanSeconds(total int) string {
+	if total < 60 {
+		if total < 0 {
+			total = 0
+		}
+		return fmt.Sprintf("%ds", total)
+	}
+	return HumanMinutes(total / 60)
+}
+
+// HumanRemaining renders a countdown rounded up, so "1m" shows until it ends.
+func HumanRemaining(d time.Duration) string {
+	if d <= 0 {
+		return "0m"
+	}
+	return HumanMinutes(int((d + time.Minute - 1) / time.Minute))
+}
+
+// Clock renders a countdown as mm:ss, or h:mm:ss from an hour up.
+func Clock(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	secs := int(d.Round(time.Second).Seconds())
+	if secs >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", secs/3600, secs/60%60, secs%60)
+	}
+	return fmt.Sprintf("%02d:%02d", secs/60, secs%60)
+}
+
 // Copy writes the state JSON to an io.Writer, mainly for debugging.
 func (s *State) Copy(w io.Writer) error {
 	enc := json.NewEncoder(w)
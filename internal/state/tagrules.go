@@ -0,0 +1,246 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/max-pantom/daily/internal/calendar"
+)
+
+// TagRule is a condition under which sessions get a tag automatically. A
+// session matches when it meets every part that is set.
+type TagRule struct {
+	Days     string        // ParseWorkdays form, or "workdays" for the configured ones
+	From, To time.Duration // time of day; To before From runs past midnight
+	Window   bool          // From and To are set
+	Dir      string        // started from this directory or one below it
+	Calendar bool          // during a calendar event
+	Event    string        // whose summary contains this, case-insensitively
+}
+
+// RuleEnv is what a frontend knows beyond the session for tag rules to look
+// at. Rules needing what is missing do not match.
+type RuleEnv struct {
+	Dir    string                  // working directory the session is started from
+	Events func() []calendar.Event // the calendar, fetched once a rule asks
+}
+
+// ParseTagRule reads a rule such as "weekdays 09:00-09:30", "dir:~/code/acme"
+// or "calendar:standup": blank-separated parts, all of which must hold.
+func ParseTagRule(v string) (TagRule, error) {
+	var r TagRule
+	parts := strings.Fields(v)
+	if len(parts) == 0 {
+		return r, errors.New("empty rule")
+	}
+	for _, p := range parts {
+		lower := strings.ToLower(p)
+		switch {
+		case strings.HasPrefix(lower, "dir:"):
+			r.Dir = p[len("dir:"):]
+			if r.Dir == "" {
+				return r, errors.New("dir: needs a directory")
+			}
+		case lower == "calendar" || strings.HasPrefix(lower, "calendar:"):
+			r.Calendar = true
+			r.Event = strings.TrimPrefix(lower, "calendar")
+			r.Event = strings.TrimPrefix(r.Event, ":")
+		case strings.Contains(p, ":") && strings.Contains(p, "-"):
+			from, to, _ := strings.Cut(p, "-")
+			a, errA := time.Parse("15:04", from)
+			b, errB := time.Parse("15:04", to)
+			if errA != nil || errB != nil || a.Equal(b) {
+				return r, fmt.Errorf("invalid time window %q (want HH:MM-HH:MM, e.g. 09:00-09:30)", p)
+			}
+			r.From = time.Duration(a.Hour())*time.Hour + time.Duration(a.Minute())*time.Minute
+			r.To = time.Duration(b.Hour())*time.Hour + time.Duration(b.Minute())*time.Minute
+			r.Window = true
+		case lower == "workdays":
+			r.Days = lower
+		case lower == "weekdays":
+			r.Days = DefaultWorkdays
+		case lower == "weekends":
+			r.Days = "sat,sun"
+		default:
+			days, err := ParseWorkdays(lower)
+			if err != nil {
+				return r, fmt.Errorf("%q is not a weekday list, HH:MM-HH:MM, dir:path or calendar[:text]", p)
+			}
+			r.Days = days
+		}
+	}
+	return r, nil
+}
+
+func (r TagRule) String() string {
+	var parts []string
+	if r.Days != "" {
+		parts = append(parts, r.Days)
+	}
+	if r.Window {
+		parts = append(parts, clockOf(r.From)+"-"+clockOf(r.To))
+	}
+	if r.Dir != "" {
+		parts = append(parts, "dir:"+r.Dir)
+	}
+	if r.Calendar {
+		parts = append(parts, strings.TrimSuffix("calendar:"+r.Event, ":"))
+	}
+	return strings.Join(parts, " ")
+}
+
+func clockOf(d time.Duration) string {
+	return fmt.Sprintf("%02d:%02d", int(d.Hours()), int(d.Minutes())%60)
+}
+
+// matches reports whether the rule holds for a session starting at start
+// and, when end is set, ending there. A finished session has to have spent at
+// least half its time in the window or event, and a directory is only known
+// at the start.
+func (r TagRule) matches(s *State, start time.Time, end *time.Time) bool {
+	switch {
+	case r.Days == "workdays" && !s.Workday(start):
+		return false
+	case r.Days != "" && r.Days != "workdays" && !strings.Contains(r.Days, weekdayNames[start.Weekday()]):
+		return false
+	case r.Dir != "" && (end != nil || !within(s.RuleEnv.Dir, r.Dir)):
+		return false
+	}
+	if r.Window && !r.covers(start, end, r.windows(start, end)) {
+		return false
+	}
+	if r.Calendar {
+		if s.RuleEnv.Events == nil {
+			return false
+		}
+		var busy []Interval
+		for _, ev := range s.RuleEnv.Events() {
+			if strings.Contains(strings.ToLower(ev.Summary), r.Event) {
+				busy = append(busy, Interval{ev.Start, ev.End})
+			}
+		}
+		if !r.covers(start, end, busy) {
+			return false
+		}
+	}
+	return true
+}
+
+// windows returns the rule's time window on each day from the one before
+// start to the one end falls on. Its ends are wall clock times, so a window
+// keeps to them on days when the clocks change.
+func (r TagRule) windows(start time.Time, end *time.Time) []Interval {
+	last := start
+	if end != nil {
+		last = *end
+	}
+	var out []Interval
+	for d := Midnight(start).AddDate(0, 0, -1); !d.After(last); d = d.AddDate(0, 0, 1) {
+		y, m, day := d.Date()
+		from := time.Date(y, m, day, int(r.From/time.Hour), int(r.From%time.Hour/time.Minute), 0, 0, d.Location())
+		if r.To <= r.From {
+			day++
+		}
+		to := time.Date(y, m, day, int(r.To/time.Hour), int(r.To%time.Hour/time.Minute), 0, 0, d.Location())
+		out = append(out, Interval{from, to})
+	}
+	return out
+}
+
+// covers reports whether start falls in one of spans or, for a finished
+// session, whether they cover at least half of it.
+func (r TagRule) covers(start time.Time, end *time.Time, spans []Interval) bool {
+	if end == nil {
+		for _, sp := range spans {
+			if !start.Before(sp.Start) && start.Before(sp.End) {
+				return true
+			}
+		}
+		return false
+	}
+	sess := Interval{start, *end}
+	if sess.Empty() {
+		return false
+	}
+	var free time.Duration
+	for _, gap := range Subtract(sess, spans) {
+		free += gap.End.Sub(gap.Start)
+	}
+	return 2*free <= sess.End.Sub(sess.Start)
+}
+
+// within reports whether dir is root or below it.
+func within(dir, root string) bool {
+	if dir == "" {
+		return false
+	}
+	if rest, ok := strings.CutPrefix(root, "~"); ok {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return false
+		}
+		root = home + rest
+	}
+	rel, err := filepath.Rel(filepath.Clean(root), filepath.Clean(dir))
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// settleTagRules applies the rules to sess as it ends at end. The tags they
+// added when it started were only provisional: one whose rule the finished
+// session fails is taken off again. Its directory was checked at the start,
+// and without a calendar to look at an event is given the benefit of the
+// doubt.
+func (s *State) settleTagRules(sess *Session, end *time.Time) {
+	sess.Tags = slices.Clone(sess.Tags)
+	for _, tag := range sess.RuleTags {
+		v, ok := s.TagRules[tag]
+		if !ok {
+			continue
+		}
+		r, err := ParseTagRule(v)
+		if err != nil {
+			continue
+		}
+		r.Dir = ""
+		if s.RuleEnv.Events == nil {
+			r.Calendar = false
+		}
+		if !r.matches(s, sess.Start, end) {
+			sess.Tags = slices.DeleteFunc(sess.Tags, func(t string) bool { return t == tag })
+		}
+	}
+	sess.RuleTags = nil
+	s.applyTagRules(sess, end)
+}
+
+// applyTagRules adds to sess the tags of the rules it matches, in name
+// order, returning the tags it added.
+func (s *State) applyTagRules(sess *Session, end *time.Time) []string {
+	if len(s.TagRules) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(s.TagRules))
+	for tag := range s.TagRules {
+		names = append(names, tag)
+	}
+	sort.Strings(names)
+	var added []string
+	for _, tag := range names {
+		if sess.HasTag(tag) {
+			continue
+		}
+		r, err := ParseTagRule(s.TagRules[tag])
+		if err != nil || !r.matches(s, sess.Start, end) {
+			continue
+		}
+		sess.Tags = append(sess.Tags, tag)
+		added = append(added, tag)
+	}
+	return added
+}
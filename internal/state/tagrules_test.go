@@ -0,0 +1,121 @@
+package state
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/max-pantom/daily/internal/calendar"
+)
+
+// monday is a time on Monday 2 March 2026.
+func monday(hour, min int) time.Time {
+	return time.Date(2026, 3, 2, hour, min, 0, 0, time.UTC)
+}
+
+func TestTagRulesAtStop(t *testing.T) {
+	standup := []calendar.Event{{Summary: "Standup", Start: monday(9, 0), End: monday(9, 30)}}
+	for _, tc := range []struct {
+		name        string
+		rule        string
+		tags        []string // given at start
+		start, stop time.Time
+		env         RuleEnv // at the start; the stop has none
+		stopEnv     *RuleEnv
+		atStart     bool // the rule tagged the running session
+		want        bool // the logged session is tagged
+	}{
+		{name: "started in the window, mostly outside it", rule: "weekdays 09:00-09:30", start: monday(9, 10), stop: monday(13, 10), atStart: true},
+		{name: "inside the window", rule: "weekdays 09:00-09:30", start: monday(9, 5), stop: monday(9, 25), atStart: true, want: true},
+		{name: "half in the window", rule: "09:00-09:30", start: monday(9, 10), stop: monday(9, 50), atStart: true, want: true},
+		{name: "started before, mostly inside", rule: "09:00-09:30", start: monday(8, 55), stop: monday(9, 30), want: true},
+		{name: "tagged by hand", rule: "09:00-09:30", tags: []string{"standup"}, start: monday(9, 10), stop: monday(13, 10), want: true},
+		{name: "dir only known at start", rule: "dir:/work/acme", env: RuleEnv{Dir: "/work/acme/api"}, start: monday(9, 10), stop: monday(13, 10), atStart: true, want: true},
+		{name: "dir and window", rule: "dir:/work/acme 09:00-09:30", env: RuleEnv{Dir: "/work/acme"}, start: monday(9, 10), stop: monday(13, 10), atStart: true},
+		{name: "calendar unknown at stop", rule: "calendar:standup", env: RuleEnv{Events: func() []calendar.Event { return standup }}, start: monday(9, 10), stop: monday(13, 10), atStart: true, want: true},
+		{name: "calendar known at stop", rule: "calendar:standup", env: RuleEnv{Events: func() []calendar.Event { return standup }}, stopEnv: &RuleEnv{Events: func() []calendar.Event { return standup }}, start: monday(9, 10), stop: monday(13, 10), atStart: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s := defaults()
+			s.TagRules = map[string]string{"standup": tc.rule}
+			s.RuleEnv = tc.env
+			if err := s.StartSession(tc.start, slices.Clone(tc.tags), ""); err != nil {
+				t.Fatal(err)
+			}
+			if got := s.ActiveSession.HasTag("standup"); got != tc.atStart && tc.tags == nil {
+				t.Fatalf("running session tagged %v, want %v", got, tc.atStart)
+			}
+			s.RuleEnv = RuleEnv{}
+			if tc.stopEnv != nil {
+				s.RuleEnv = *tc.stopEnv
+			}
+			if _, err := s.StopSession(tc.stop); err != nil {
+				t.Fatal(err)
+			}
+			sessions := s.Days[dateKey(tc.start)].Sessions
+			if len(sessions) != 1 {
+				t.Fatalf("logged %d sessions, want 1", len(sessions))
+			}
+			if got := sessions[0].HasTag("standup"); got != tc.want {
+				t.Errorf("logged session %v tagged %v, want %v", sessions[0].Tags, got, tc.want)
+			}
+			if sessions[0].RuleTags != nil {
+				t.Errorf("logged session keeps provisional tags %v", sessions[0].RuleTags)
+			}
+		})
+	}
+}
+
+// TestTagRulesOverMidnight checks that a running session split at midnight
+// settles its provisional tags on the day that closed, and again on the rest.
+func TestTagRulesOverMidnight(t *testing.T) {
+	s := defaults()
+	s.TagRules = map[string]string{"late": "22:30-23:59"}
+	if err := s.StartSession(monday(23, 0), nil, ""); err != nil {
+		t.Fatal(err)
+	}
+	s.Normalize(monday(23, 0).Add(2 * time.Hour))
+	first := s.Days["2026-03-02"].Sessions[0]
+	if !first.HasTag("late") || first.RuleTags != nil {
+		t.Errorf("before midnight: tags %v, provisional %v; want late, none", first.Tags, first.RuleTags)
+	}
+	if _, err := s.StopSession(monday(23, 0).Add(3 * time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if rest := s.Days["2026-03-03"].Sessions[0]; rest.HasTag("late") {
+		t.Errorf("after midnight: tags %v, want no late", rest.Tags)
+	}
+}
+
+func TestTagRuleWindowsDST(t *testing.T) {
+	for _, tc := range []struct {
+		name, zone, rule string
+		day              string // any time that day, with its offset
+		from, to         string
+	}{
+		{"spring forward", "Europe/Berlin", "09:00-09:30", "2026-03-29 12:00 +0200", "2026-03-29 09:00 +0200", "2026-03-29 09:30 +0200"},
+		{"fall back", "Europe/Berlin", "09:00-09:30", "2026-10-25 12:00 +0100", "2026-10-25 09:00 +0100", "2026-10-25 09:30 +0100"},
+		{"over spring forward", "America/New_York", "23:00-03:00", "2026-03-07 12:00 -0500", "2026-03-07 23:00 -0500", "2026-03-08 03:00 -0400"},
+		{"over fall back", "America/New_York", "23:00-03:00", "2026-10-31 12:00 -0400", "2026-10-31 23:00 -0400", "2026-11-01 03:00 -0500"},
+		{"midnight start", "America/Santiago", "09:00-17:00", "2026-09-06 12:00 -0300", "2026-09-06 09:00 -0300", "2026-09-06 17:00 -0300"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			loc := zone(t, tc.zone)
+			r, err := ParseTagRule(tc.rule)
+			if err != nil {
+				t.Fatal(err)
+			}
+			day := at(t, loc, tc.day)
+			want := Interval{at(t, loc, tc.from), at(t, loc, tc.to)}
+			for _, w := range r.windows(day, nil) {
+				if dateKey(w.Start) == dateKey(day) {
+					if !w.Start.Equal(want.Start) || !w.End.Equal(want.End) {
+						t.Errorf("window %v-%v, want %v-%v", w.Start, w.End, want.Start, want.End)
+					}
+					return
+				}
+			}
+			t.Errorf("no window on %s", dateKey(day))
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package state
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// KnownTags lists the allowed tags and every tag used in the log, once each
+// ignoring case, sorted.
+func (s *State) KnownTags() []string {
+	seen := map[string]bool{}
+	var out []string
+	add := func(tag string) {
+		if key := strings.ToLower(tag); tag != "" && !seen[key] {
+			seen[key] = true
+			out = append(out, tag)
+		}
+	}
+	for _, t := range s.AllowedTags {
+		add(t)
+	}
+	for _, log := range s.Days {
+		for _, sess := range log.Sessions {
+			for _, t := range sess.Tags {
+				add(t)
+			}
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// SuggestTag returns the known tag that tag most likely misspells, such as
+// review for reveiw, or "" when tag is already known or nothing is close.
+func (s *State) SuggestTag(tag string) string {
+	return closest(tag, s.KnownTags())
+}
+
+// CheckTags refuses tags outside AllowedTags while StrictTags is on. Strict
+// mode without allowed tags checks nothing.
+func (s *State) CheckTags(tags []string) error {
+	if !s.StrictTags || len(s.AllowedTags) == 0 {
+		return nil
+	}
+	for _, t := range tags {
+		allowed := false
+		for _, a := range s.AllowedTags {
+			allowed = allowed || strings.EqualFold(a, t)
+		}
+		if allowed {
+			continue
+		}
+		if hint := closest(t, s.AllowedTags); hint != "" {
+			return fmt.Errorf("tag %q is not allowed; did you mean %q?", t, hint)
+		}
+		return fmt.Errorf("tag %q is not allowed (strict_tags; allowed: %s)", t, strings.Join(s.AllowedTags, ", "))
+	}
+	return nil
+}
+
+// closest finds the candidate within typo distance of tag: one edit for short
+// tags, two for longer ones. An exact match means there is nothing to suggest;
+// a match differing only in case is suggested.
+func closest(tag string, candidates []string) string {
+	lower := strings.ToLower(tag)
+	limit := 1
+	if len([]rune(lower)) > 4 {
+		limit = 2
+	}
+	best, bestDist := "", limit+1
+	for _, c := range candidates {
+		if c == tag {
+			return ""
+		}
+		if d := levenshtein(lower, strings.ToLower(c)); d < bestDist {
+			best, bestDist = c, d
+		}
+	}
+	return best
+}
+
+// levenshtein is the number of single-rune insertions, deletions,
+// substitutions and adjacent swaps turning a into b.
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev2 := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				cur[j] = min(cur[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return prev[len(rb)]
+}
@@ -0,0 +1,125 @@
+package state
+
+import "time"
+
+// Slot is what filled one stretch of a day's timeline.
+type Slot byte
+
+const (
+	SlotFree Slot = iota
+	SlotWork
+	SlotBreak
+	SlotInterrupted
+)
+
+// Default hours of a day's timeline; a day's sessions widen it.
+const (
+	TimelineFrom = 7
+	TimelineTo   = 22
+)
+
+// TimelineHours returns the whole hours the timeline of day spans: 07:00 to
+// 22:00, stretched to include anything logged earlier or later.
+func (s *State) TimelineHours(day, now time.Time) (from, to int) {
+	from, to = TimelineFrom, TimelineTo
+	for _, sp := range s.timelineSpans(day, now) {
+		start, end := ClockOffset(sp.start), ClockOffset(sp.end)
+		if !sameDate(sp.end, day) {
+			end = 24 * time.Hour
+		}
+		from = min(from, int(start/time.Hour))
+		to = max(to, int((end+time.Hour-1)/time.Hour))
+	}
+	return from, min(to, 24)
+}
+
+// Timeline divides day from hour from to hour to into width slots and marks
+// each with whatever took up most of it, or SlotFree when less than half of it
+// was logged. The running session, break or interruption count up to now.
+func (s *State) Timeline(day time.Time, from, to, width int, now time.Time) []Slot {
+	slots := make([]Slot, width)
+	if width <= 0 || to <= from {
+		return slots
+	}
+	y, m, d := day.Date()
+	start := time.Date(y, m, d, from, 0, 0, 0, day.Location())
+	slotLen := time.Duration(to-from) * time.Hour / time.Duration(width)
+	spans := s.timelineSpans(day, now)
+	for i := range slots {
+		lo := start.Add(time.Duration(i) * slotLen)
+		hi := lo.Add(slotLen)
+		var covered [SlotInterrupted + 1]time.Duration
+		for _, sp := range spans {
+			if overlap := minTime(hi, sp.end).Sub(maxTime(lo, sp.start)); overlap > 0 {
+				covered[sp.kind] += overlap
+			}
+		}
+		total, best := time.Duration(0), SlotFree
+		for kind := SlotWork; kind <= SlotInterrupted; kind++ {
+			total += covered[kind]
+			if covered[kind] > covered[best] {
+				best = kind
+			}
+		}
+		if total*2 >= slotLen {
+			slots[i] = best
+		}
+	}
+	return slots
+}
+
+type timelineSpan struct {
+	start, end time.Time
+	kind       Slot
+}
+
+func (s *State) timelineSpans(day, now time.Time) []timelineSpan {
+	var spans []timelineSpan
+	add := func(start time.Time, end *time.Time, kind Slot) {
+		e := now
+		if end != nil {
+			e = *end
+		}
+		if e.After(start) {
+			spans = append(spans, timelineSpan{start, e, kind})
+		}
+	}
+	key := dateKey(day)
+	if log := s.Days[key]; log != nil {
+		for _, sess := range log.Sessions {
+			add(sess.Start, sess.End, SlotWork)
+		}
+		for _, b := range log.Breaks {
+			add(b.Start, b.End, SlotBreak)
+		}
+		for _, in := range log.Interruptions {
+			add(in.Start, in.End, SlotInterrupted)
+		}
+	}
+	if key == dateKey(now) {
+		if a := s.ActiveSession; a != nil {
+			add(a.Start, nil, SlotWork)
+		}
+		if b := s.ActiveBreak; b != nil {
+			add(b.Start, nil, SlotBreak)
+		}
+		if in := s.ActiveInterruption; in != nil {
+			add(in.Start, nil, SlotInterrupted)
+		}
+	}
+	return spans
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
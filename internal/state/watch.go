@@ -0,0 +1,64 @@
+package state
+
+import (
+	"path/filepath"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Watcher reports changes to the state file or its config file made by any
+// process.
+type Watcher struct {
+	w       *fsnotify.Watcher
+	changes chan struct{}
+}
+
+// Watch starts watching the state file at path. The parent directory is watched
+// because Save replaces the file via rename.
+func Watch(path string) (*Watcher, error) {
+	fw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	if err := fw.Add(filepath.Dir(path)); err != nil {
+		fw.Close()
+		return nil, err
+	}
+	w := &Watcher{w: fw, changes: make(chan struct{}, 1)}
+	go w.loop(filepath.Clean(path), filepath.Clean(ConfigPath(path)))
+	return w, nil
+}
+
+// Changes delivers a signal after either file changes. Bursts of events are
+// coalesced, and the channel is closed when the watcher stops.
+func (w *Watcher) Changes() <-chan struct{} {
+	return w.changes
+}
+
+// Close stops the watcher.
+func (w *Watcher) Close() error {
+	return w.w.Close()
+}
+
+func (w *Watcher) loop(path, config string) {
+	defer close(w.changes)
+	for {
+		select {
+		case ev, ok := <-w.w.Events:
+			if !ok {
+				return
+			}
+			if name := filepath.Clean(ev.Name); name != path && name != config || !ev.Has(fsnotify.Write|fsnotify.Create|fsnotify.Rename) {
+				continue
+			}
+			select {
+			case w.changes <- struct{}{}:
+			default:
+			}
+		case _, ok := <-w.w.Errors:
+			if !ok {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package stats
+
+import (
+	"sort"
+	"time"
+
+	"github.com/max-pantom/daily/internal/state"
+)
+
+// ShortStretch is the focus stretch length below which work counts as
+// fragmented.
+const ShortStretch = 25 * time.Minute
+
+// Focus describes how work was split into sessions over a date range. A focus
+// stretch is a run of sessions separated by less than state.ContinuousGap and
+// no logged break, as for the time worked straight.
+type Focus struct {
+	From           string  `json:"from"`
+	To             string  `json:"to"`
+	Days           int     `json:"days"`
+	DaysWorked     int     `json:"days_worked"`
+	Sessions       int     `json:"sessions"`
+	SessionsPerDay float64 `json:"sessions_per_day"` // per day worked
+	AvgSession     int     `json:"avg_session_seconds"`
+	MedianSession  int     `json:"median_session_seconds"`
+	Stretches      int     `json:"stretches"`
+	Longest        int     `json:"longest_stretch_seconds"`
+	LongestDay     string  `json:"longest_stretch_day,omitempty"`
+	Fragmentation  int     `json:"fragmentation"` // percent of work in stretches shorter than ShortStretch
+}
+
+// FocusRange measures the finished sessions matching f from from to to,
+// inclusive.
+func FocusRange(st *state.State, from, to time.Time, f state.Filter) Focus {
+	out := Focus{From: from.Format("2006-01-02"), To: to.Format("2006-01-02")}
+	var lengths []int
+	work, short := 0, 0
+	for d := state.Midnight(from); !d.After(to); d = d.AddDate(0, 0, 1) {
+		out.Days++
+		log, ok := st.Days[d.Format("2006-01-02")]
+		if !ok {
+			continue
+		}
+		var sessions []state.Session
+		for _, sess := range log.Sessions {
+			if sess.End != nil && f.Match(sess) {
+				sessions = append(sessions, sess)
+			}
+		}
+		if len(sessions) == 0 {
+			continue
+		}
+		out.DaysWorked++
+		sort.Slice(sessions, func(i, j int) bool { return sessions[i].Start.Before(sessions[j].Start) })
+		stretch := 0
+		end := func() {
+			out.Stretches++
+			work += stretch
+			if stretch < int(ShortStretch.Seconds()) {
+				short += stretch
+			}
+			if stretch > out.Longest {
+				out.Longest, out.LongestDay = stretch, log.Date
+			}
+			stretch = 0
+		}
+		for i, sess := range sessions {
+			secs := sess.Seconds(*sess.End)
+			lengths = append(lengths, secs)
+			if i > 0 && rested(log, *sessions[i-1].End, sess.Start) {
+				end()
+			}
+			stretch += secs
+		}
+		end()
+	}
+	out.Sessions = len(lengths)
+	if out.Sessions == 0 {
+		return out
+	}
+	sort.Ints(lengths)
+	out.MedianSession = lengths[len(lengths)/2]
+	if len(lengths)%2 == 0 {
+		out.MedianSession = (lengths[len(lengths)/2-1] + lengths[len(lengths)/2]) / 2
+	}
+	total := 0
+	for _, l := range lengths {
+		total += l
+	}
+	out.AvgSession = total / out.Sessions
+	out.SessionsPerDay = float64(out.Sessions) / float64(out.DaysWorked)
+	if work > 0 {
+		out.Fragmentation = (short*100 + work/2) / work
+	}
+	return out
+}
+
+// rested reports whether the gap from end to start ends a focus stretch.
+func rested(log *state.DayLog, end, start time.Time) bool {
+	if start.Sub(end) >= state.ContinuousGap {
+		return true
+	}
+	for _, b := range log.Breaks {
+		if !b.Start.Before(end) && !b.Start.After(start) {
+			return true
+		}
+	}
+	return false
+}
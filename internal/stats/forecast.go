@@ -0,0 +1,83 @@
+package stats
+
+import (
+	"time"
+
+	"github.com/max-pantom/daily/internal/state"
+)
+
+// HistoryWeeks is how many weeks before this one Forecast averages over.
+const HistoryWeeks = 4
+
+// Forecast projects this week's work to Sunday. Durations are in minutes.
+type Forecast struct {
+	Week      string `json:"week"` // its Monday
+	Goal      int    `json:"goal_minutes"`
+	Done      int    `json:"done_minutes"`
+	Pace      int    `json:"pace_minutes"`    // per workday before today this week; 0 on its first
+	History   int    `json:"history_minutes"` // per workday worked in the HistoryWeeks before
+	Projected int    `json:"projected_minutes"`
+	DaysLeft  int    `json:"days_left"`                 // workdays left, today included
+	PerDay    int    `json:"per_day_minutes,omitempty"` // needed on each of them to reach Goal
+}
+
+// Met reports whether the week's goal is already reached.
+func (f Forecast) Met() bool {
+	return f.Done >= f.Goal
+}
+
+// ForecastWeek expects each workday left this week to bring the average of
+// this week's pace and the recent weeks', or whichever of them there is, with
+// today's work counting towards today's share.
+func ForecastWeek(st *state.State, now time.Time) Forecast {
+	monday := state.Monday(now)
+	f := Forecast{Week: monday.Format("2006-01-02"), Goal: st.WeekGoal(monday)}
+	workedDays := 0
+	for d := monday; d.Before(state.Midnight(now)); d = d.AddDate(0, 0, 1) {
+		work := 0
+		if log, ok := st.Days[d.Format("2006-01-02")]; ok {
+			work = log.WorkSeconds() / 60
+		}
+		f.Done += work
+		if st.Workday(d) {
+			f.Pace += work
+			workedDays++
+		}
+	}
+	f.Pace = average(f.Pace, workedDays)
+	today, _ := st.TodaySummary(now)
+	f.Done += today
+
+	past, pastDays := 0, 0
+	for d := monday.AddDate(0, 0, -7*HistoryWeeks); d.Before(monday); d = d.AddDate(0, 0, 1) {
+		if log, ok := st.Days[d.Format("2006-01-02")]; ok && st.Workday(d) && log.TotalWorkMinutes > 0 {
+			past += log.WorkSeconds() / 60
+			pastDays++
+		}
+	}
+	f.History = average(past, pastDays)
+
+	expected := f.Pace
+	switch {
+	case f.Pace > 0 && f.History > 0:
+		expected = (f.Pace + f.History) / 2
+	case f.Pace == 0:
+		expected = f.History
+	}
+	f.Projected = f.Done
+	for d := state.Midnight(now); d.Before(monday.AddDate(0, 0, 7)); d = d.AddDate(0, 0, 1) {
+		if !st.Workday(d) {
+			continue
+		}
+		f.DaysLeft++
+		if d.Equal(state.Midnight(now)) {
+			f.Projected += max(expected-today, 0)
+		} else {
+			f.Projected += expected
+		}
+	}
+	if !f.Met() && f.DaysLeft > 0 {
+		f.PerDay = (f.Goal - f.Done + f.DaysLeft - 1) / f.DaysLeft
+	}
+	return f
+}
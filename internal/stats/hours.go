@@ -0,0 +1,104 @@
+package stats
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/max-pantom/daily/internal/state"
+)
+
+// PeakHours is the width of the block of hours Profile.Peak looks for.
+const PeakHours = 3
+
+// Profile is work by weekday and hour of day, Monday first, in seconds.
+type Profile [7][24]int
+
+// Peak is the block of PeakHours hours on one weekday with the most work.
+type Peak struct {
+	Weekday time.Weekday
+	From    int // hour of day; the block ends at From+PeakHours
+	Seconds int
+}
+
+func (p Peak) String() string {
+	return fmt.Sprintf("%s %02d–%02d", p.Weekday.String()[:3], p.From, p.From+PeakHours)
+}
+
+// Hours spreads the finished sessions matching f from from to to, inclusive,
+// over the hours of the week they ran in.
+func Hours(st *state.State, from, to time.Time, f state.Filter) Profile {
+	var p Profile
+	for d := state.Midnight(from); !d.After(to); d = d.AddDate(0, 0, 1) {
+		log, ok := st.Days[d.Format("2006-01-02")]
+		if !ok {
+			continue
+		}
+		for _, sess := range log.Sessions {
+			if sess.End == nil || !f.Match(sess) {
+				continue
+			}
+			start := sess.Start
+			end := start.Add(time.Duration(sess.Seconds(*sess.End)) * time.Second)
+			for start.Before(end) {
+				// Not Truncate, which rounds in UTC: zones such as +05:30
+				// have hours that start at half past.
+				y, m, day := start.Date()
+				next := time.Date(y, m, day, start.Hour()+1, 0, 0, 0, start.Location())
+				if next.After(end) || !next.After(start) {
+					next = end
+				}
+				p[(start.Weekday()+6)%7][start.Hour()] += int(next.Sub(start).Seconds())
+				start = next
+			}
+		}
+	}
+	return p
+}
+
+// Day returns row i of the profile, Monday being 0, as a weekday.
+func (p Profile) Day(i int) time.Weekday {
+	return time.Weekday((i + 1) % 7)
+}
+
+// Max is the most work in any one hour of the week.
+func (p Profile) Max() int {
+	m := 0
+	for _, row := range p {
+		for _, v := range row {
+			m = max(m, v)
+		}
+	}
+	return m
+}
+
+// Peak finds the block of PeakHours hours with the most work, reporting false
+// when there is none. Of blocks with as much, it takes one starting with work
+// rather than an idle hour.
+func (p Profile) Peak() (Peak, bool) {
+	var best Peak
+	for i, row := range p {
+		for h := 0; h+PeakHours <= 24; h++ {
+			if row[h] == 0 && h+PeakHours < 24 {
+				continue
+			}
+			sum := 0
+			for _, v := range row[h : h+PeakHours] {
+				sum += v
+			}
+			if sum > best.Seconds {
+				best = Peak{Weekday: p.Day(i), From: h, Seconds: sum}
+			}
+		}
+	}
+	return best, best.Seconds > 0
+}
+
+// Level scales the work of weekday row i at hour h to 0..levels-1 against
+// the busiest hour, keeping any work at all above 0.
+func (p Profile) Level(i, h, levels int) int {
+	m, v := p.Max(), p[i][h]
+	if m == 0 || v == 0 {
+		return 0
+	}
+	return max(1, v*(levels-1)/m)
+}
@@ -0,0 +1,145 @@
+package stats
+
+import (
+	"time"
+
+	"github.com/max-pantom/daily/internal/i18n"
+	"github.com/max-pantom/daily/internal/state"
+)
+
+// Summary describes work habits over a date range.
+type Summary struct {
+	From              string
+	To                string
+	Days              int // calendar days in the range
+	DaysWorked        int
+	WorkMinutes       int
+	BreakMinutes      int
+	AvgDailyMinutes   int // per day worked
+	BestDay           string
+	BestDayMinutes    int
+	AvgStart          time.Duration // wall-clock time of day of the first session
+	HasStart          bool
+	Sessions          int
+	AvgSessionMinutes int
+	BreakRatio        float64 // break share of work+break time, 0..1
+	Weekly            []int   // average minutes per worked day, per 7-day block, oldest first
+	Streak            int     // workdays in a row up to To that met their goal
+}
+
+// Window summarizes the days days ending with now.
+func Window(st *state.State, now time.Time, days int) Summary {
+	if days <= 0 {
+		days = 30
+	}
+	return Range(st, now.AddDate(0, 0, -(days-1)), now)
+}
+
+// Range summarizes every day from from to to, inclusive.
+func Range(st *state.State, from, to time.Time) Summary {
+	sum := Summary{From: from.Format("2006-01-02"), To: to.Format("2006-01-02")}
+	var startTotal time.Duration
+	starts := 0
+	sessionSeconds := 0
+	weekMinutes, weekDays := 0, 0
+
+	for d := state.Midnight(from); !d.After(to); d = d.AddDate(0, 0, 1) {
+		sum.Days++
+		key := d.Format("2006-01-02")
+		if log, ok := st.Days[key]; ok {
+			work := log.TotalWorkMinutes
+			sum.WorkMinutes += work
+			sum.BreakMinutes += log.TotalBreakMinutes
+			if work > 0 {
+				sum.DaysWorked++
+				weekMinutes += work
+				weekDays++
+			}
+			if work > sum.BestDayMinutes {
+				sum.BestDay, sum.BestDayMinutes = key, work
+			}
+			for i, sess := range log.Sessions {
+				if i == 0 {
+					startTotal += state.ClockOffset(sess.Start)
+					starts++
+				}
+				sum.Sessions++
+				sessionSeconds += sess.Seconds(to)
+			}
+		}
+		if sum.Days%7 == 0 {
+			sum.Weekly = append(sum.Weekly, average(weekMinutes, weekDays))
+			weekMinutes, weekDays = 0, 0
+		}
+	}
+	if sum.Days%7 != 0 {
+		sum.Weekly = append(sum.Weekly, average(weekMinutes, weekDays))
+	}
+
+	sum.AvgDailyMinutes = average(sum.WorkMinutes, sum.DaysWorked)
+	if starts > 0 {
+		sum.AvgStart = startTotal / time.Duration(starts)
+		sum.HasStart = true
+	}
+	if sum.Sessions > 0 {
+		sum.AvgSessionMinutes = sessionSeconds / sum.Sessions / 60
+	}
+	if total := sum.WorkMinutes + sum.BreakMinutes; total > 0 {
+		sum.BreakRatio = float64(sum.BreakMinutes) / float64(total)
+	}
+	sum.Streak = Streak(st, to)
+	return sum
+}
+
+// Streak counts the workdays in a row, back from to, whose work met their
+// goal after carry-over. Days off and to itself, which may still be under
+// way, only count when met and never break the streak.
+func Streak(st *state.State, to time.Time) int {
+	first := ""
+	for key := range st.Days {
+		if first == "" || key < first {
+			first = key
+		}
+	}
+	n := 0
+	for d := state.Midnight(to); ; d = d.AddDate(0, 0, -1) {
+		key := d.Format("2006-01-02")
+		if first == "" || key < first {
+			return n
+		}
+		work := 0
+		if log, ok := st.Days[key]; ok {
+			work = log.TotalWorkMinutes
+		}
+		goal, _ := st.Goal(d)
+		switch {
+		case goal > 0 && work >= goal:
+			n++
+		case st.Workday(d) && key != to.Format("2006-01-02"):
+			return n
+		}
+	}
+}
+
+// AvgStartLabel renders the average start time as a clock time.
+func (s Summary) AvgStartLabel() string {
+	if !s.HasStart {
+		return "--"
+	}
+	return i18n.Time(time.Date(2000, 1, 1, 0, 0, 0, 0, time.Local).Add(s.AvgStart))
+}
+
+// Trend compares the latest weekly average with the one before it, in minutes.
+func (s Summary) Trend() int {
+	if len(s.Weekly) < 2 {
+		return 0
+	}
+	return s.Weekly[len(s.Weekly)-1] - s.Weekly[len(s.Weekly)-2]
+}
+
+func average(total, n int) int {
+	if n == 0 {
+		return 0
+	}
+	return total / n
+}
@@ -0,0 +1,345 @@
+// Package team aggregates daily totals across a small team. Members push
+// their work and break seconds per day to a shared server under a random
+// member secret; no notes, tags, projects or times of day leave their
+// machines. The server keeps the latest totals per member and day, filed
+// under a hash of the secret so only its holder can replace them, and
+// reports the team's capacity from them.
+package team
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Day is one member's totals for one date.
+type Day struct {
+	Date         string `json:"date"` // YYYY-MM-DD
+	WorkSeconds  int    `json:"work_seconds"`
+	BreakSeconds int    `json:"break_seconds"`
+	Sessions     int    `json:"sessions"`
+}
+
+// Totals is what a member pushes. Days replace those the server already has
+// for the member.
+type Totals struct {
+	Member   string `json:"member"`             // the member's secret
+	Previous string `json:"previous,omitempty"` // an older member ID whose totals move to Member
+	Days     []Day  `json:"days"`
+}
+
+// DayTotal aggregates one date across the members who pushed it.
+type DayTotal struct {
+	Date        string `json:"date"`
+	Members     int    `json:"members"`
+	WorkSeconds int    `json:"work_seconds"`
+	AvgSeconds  int    `json:"avg_seconds"` // per member who worked
+	MinSeconds  int    `json:"min_seconds"`
+	MaxSeconds  int    `json:"max_seconds"`
+}
+
+// MemberTotal is one member's sum over the report's range.
+type MemberTotal struct {
+	Member      string `json:"member"` // the Label of the member's secret
+	WorkSeconds int    `json:"work_seconds"`
+	DaysWorked  int    `json:"days_worked"`
+}
+
+// Report is the team's totals over an inclusive date range.
+type Report struct {
+	From        string        `json:"from"`
+	To          string        `json:"to"`
+	Members     int           `json:"members"`
+	WorkSeconds int           `json:"work_seconds"`
+	Days        []DayTotal    `json:"days"`
+	ByMember    []MemberTotal `json:"by_member"` // most work first
+}
+
+// MinSecret is the length of the shortest member secret a server accepts.
+// Member IDs from before secrets were 8 characters and were shown in reports,
+// so they may only be given as Totals.Previous.
+const MinSecret = 32
+
+// NewMemberSecret makes the random secret a member pushes under.
+func NewMemberSecret() string {
+	b := make([]byte, 32)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// key is what the server files a member's totals under.
+func key(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// Label is how reports name the member with the given secret: short, and no
+// help in pushing as them.
+func Label(secret string) string {
+	return key(secret)[:8]
+}
+
+// isKey reports whether k is a hashed secret rather than a member ID from
+// before secrets.
+func isKey(k string) bool {
+	_, err := hex.DecodeString(k)
+	return len(k) == sha256.Size*2 && err == nil
+}
+
+// Server stores pushed totals in a JSON file. A non-empty Token must be sent
+// as a bearer token with every request.
+type Server struct {
+	path  string
+	token string
+
+	mu   sync.Mutex
+	days map[string]map[string]Day // key of the member's secret -> date -> totals
+}
+
+// NewServer loads the totals kept at path, if any.
+func NewServer(path, token string) (*Server, error) {
+	s := &Server{path: path, token: token, days: map[string]map[string]Day{}}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &s.days); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	// Totals kept under a plain member ID are filed under its hash, where a
+	// push naming the ID as Previous finds them.
+	for member, days := range s.days {
+		if !isKey(member) {
+			delete(s.days, member)
+			s.days[key(member)] = days
+		}
+	}
+	return s, nil
+}
+
+// Handler serves POST /v1/totals and GET /v1/report?from=&to=.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/totals", s.authorized(s.handlePush))
+	mux.HandleFunc("GET /v1/report", s.authorized(s.handleReport))
+	return mux
+}
+
+func (s *Server) authorized(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		got := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if s.token != "" && subtle.ConstantTimeCompare([]byte(got), []byte(s.token)) != 1 {
+			http.Error(w, "bad or missing team token", http.StatusUnauthorized)
+			return
+		}
+		h(w, r)
+	}
+}
+
+// maxPush bounds a push body; a year of days is well under it.
+const maxPush = 1 << 20
+
+func (s *Server) handlePush(w http.ResponseWriter, r *http.Request) {
+	var t Totals
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxPush)).Decode(&t); err != nil {
+		http.Error(w, "bad totals: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := t.check(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := s.store(t); err != nil {
+		http.Error(w, "save: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (t Totals) check() error {
+	if len(t.Member) < MinSecret || len(t.Member) > 128 || len(t.Previous) > 128 {
+		return fmt.Errorf("member secret missing, shorter than %d or too long", MinSecret)
+	}
+	for _, d := range t.Days {
+		if _, err := time.Parse("2006-01-02", d.Date); err != nil {
+			return fmt.Errorf("invalid date %q", d.Date)
+		}
+		if d.WorkSeconds < 0 || d.WorkSeconds > 86400 || d.BreakSeconds < 0 || d.BreakSeconds > 86400 || d.Sessions < 0 {
+			return fmt.Errorf("implausible totals for %s", d.Date)
+		}
+	}
+	return nil
+}
+
+func (s *Server) store(t Totals) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	k := key(t.Member)
+	days := s.days[k]
+	if old, ok := s.days[key(t.Previous)]; t.Previous != "" && ok && days == nil {
+		days = old
+		delete(s.days, key(t.Previous))
+	}
+	if days == nil {
+		days = map[string]Day{}
+	}
+	s.days[k] = days
+	for _, d := range t.Days {
+		days[d.Date] = d
+	}
+	data, err := json.MarshalIndent(s.days, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+func (s *Server) handleReport(w http.ResponseWriter, r *http.Request) {
+	from, to := r.URL.Query().Get("from"), r.URL.Query().Get("to")
+	if from == "" || to == "" {
+		http.Error(w, "from and to are required", http.StatusBadRequest)
+		return
+	}
+	s.mu.Lock()
+	rep := s.report(from, to)
+	s.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(rep)
+}
+
+// report must be called with s.mu held.
+func (s *Server) report(from, to string) Report {
+	rep := Report{From: from, To: to}
+	byDate := map[string]*DayTotal{}
+	for k, days := range s.days {
+		mt := MemberTotal{Member: k[:8]}
+		for date, d := range days {
+			if date < from || date > to || d.WorkSeconds <= 0 {
+				continue
+			}
+			mt.WorkSeconds += d.WorkSeconds
+			mt.DaysWorked++
+			dt := byDate[date]
+			if dt == nil {
+				dt = &DayTotal{Date: date, MinSeconds: d.WorkSeconds}
+				byDate[date] = dt
+			}
+			dt.Members++
+			dt.WorkSeconds += d.WorkSeconds
+			dt.MinSeconds = min(dt.MinSeconds, d.WorkSeconds)
+			dt.MaxSeconds = max(dt.MaxSeconds, d.WorkSeconds)
+		}
+		if mt.DaysWorked > 0 {
+			rep.ByMember = append(rep.ByMember, mt)
+			rep.WorkSeconds += mt.WorkSeconds
+		}
+	}
+	rep.Members = len(rep.ByMember)
+	for _, dt := range byDate {
+		dt.AvgSeconds = dt.WorkSeconds / dt.Members
+		rep.Days = append(rep.Days, *dt)
+	}
+	sort.Slice(rep.Days, func(i, j int) bool { return rep.Days[i].Date < rep.Days[j].Date })
+	sort.Slice(rep.ByMember, func(i, j int) bool {
+		a, b := rep.ByMember[i], rep.ByMember[j]
+		if a.WorkSeconds != b.WorkSeconds {
+			return a.WorkSeconds > b.WorkSeconds
+		}
+		return a.Member < b.Member
+	})
+	return rep
+}
+
+// Client pushes to and reads from a team server.
+type Client struct {
+	URL   string // e.g. https://team.example.com:8787
+	Token string
+	HTTP  *http.Client
+}
+
+// Push sends the member's totals.
+func (c Client) Push(t Totals) error {
+	data, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+	req, err := c.request("POST", "/v1/totals", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return c.do(req, nil)
+}
+
+// Report fetches the team's totals from from to to, inclusive.
+func (c Client) Report(from, to string) (*Report, error) {
+	q := url.Values{"from": {from}, "to": {to}}
+	req, err := c.request("GET", "/v1/report?"+q.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	var rep Report
+	if err := c.do(req, &rep); err != nil {
+		return nil, err
+	}
+	return &rep, nil
+}
+
+func (c Client) request(method, path string, body io.Reader) (*http.Request, error) {
+	if c.URL == "" {
+		return nil, errors.New("no team server (pass --team or daily config set team.url <url>)")
+	}
+	req, err := http.NewRequest(method, strings.TrimRight(c.URL, "/")+path, body)
+	if err != nil {
+		return nil, err
+	}
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	return req, nil
+}
+
+// do sends req and decodes a 2xx reply into out when it is not nil.
+func (c Client) do(req *http.Request, out any) error {
+	client := c.HTTP
+	if client == nil {
+		client = &http.Client{Timeout: 20 * time.Second}
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+		return fmt.Errorf("%s: %s", res.Status, strings.TrimSpace(string(msg)))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(res.Body).Decode(out)
+}
@@ -0,0 +1,94 @@
+package team
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func serve(t *testing.T, path string) Client {
+	t.Helper()
+	srv, err := NewServer(path, "team-token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	hs := httptest.NewServer(srv.Handler())
+	t.Cleanup(hs.Close)
+	return Client{URL: hs.URL, Token: "team-token"}
+}
+
+func push(t *testing.T, c Client, member, previous string, work int) error {
+	t.Helper()
+	return c.Push(Totals{Member: member, Previous: previous, Days: []Day{{Date: "2026-03-02", WorkSeconds: work}}})
+}
+
+func TestMembersCannotReplaceEachOther(t *testing.T) {
+	c := serve(t, filepath.Join(t.TempDir(), "team.json"))
+	alice, bob := NewMemberSecret(), NewMemberSecret()
+	if err := push(t, c, alice, "", 3600); err != nil {
+		t.Fatal(err)
+	}
+	if err := push(t, c, bob, "", 7200); err != nil {
+		t.Fatal(err)
+	}
+	rep, err := c.Report("2026-03-01", "2026-03-07")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rep.Members != 2 || rep.WorkSeconds != 3*3600 {
+		t.Fatalf("report: %d members, %ds; want 2, 3h", rep.Members, rep.WorkSeconds)
+	}
+	for _, m := range rep.ByMember {
+		if m.Member != Label(alice) && m.Member != Label(bob) {
+			t.Errorf("report names member %q, want a label", m.Member)
+		}
+		for _, secret := range []string{alice, bob, key(alice), key(bob)} {
+			if m.Member == secret {
+				t.Errorf("report gives away a member's secret or key: %q", m.Member)
+			}
+		}
+	}
+
+	// Pushing under the label the report shows starts a new member at best.
+	if err := push(t, c, Label(bob), "", 0); err == nil || !strings.Contains(err.Error(), "secret") {
+		t.Errorf("push under a label: %v, want it refused as too short", err)
+	}
+	if err := push(t, c, strings.Repeat(Label(bob), 4), Label(bob), 0); err != nil {
+		t.Fatal(err)
+	}
+	rep, _ = c.Report("2026-03-01", "2026-03-07")
+	if rep.WorkSeconds != 3*3600 {
+		t.Errorf("after pushing as someone else's label the team worked %ds, want 3h", rep.WorkSeconds)
+	}
+}
+
+func TestLegacyMemberIDs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "team.json")
+	legacy := `{"3f9a21c4": {"2026-03-02": {"date": "2026-03-02", "work_seconds": 3600, "break_seconds": 0, "sessions": 1}}}`
+	if err := os.WriteFile(path, []byte(legacy), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c := serve(t, path)
+	if err := push(t, c, "3f9a21c4", "", 1800); err == nil {
+		t.Error("a push under an old member ID was accepted")
+	}
+	rep, err := c.Report("2026-03-01", "2026-03-07")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rep.ByMember) != 1 || rep.ByMember[0].Member == "3f9a21c4" {
+		t.Fatalf("report by member %+v, want one member without the old ID", rep.ByMember)
+	}
+
+	// The member moves to a secret, taking the old totals along.
+	secret := NewMemberSecret()
+	if err := c.Push(Totals{Member: secret, Previous: "3f9a21c4", Days: []Day{{Date: "2026-03-03", WorkSeconds: 1800}}}); err != nil {
+		t.Fatal(err)
+	}
+	rep, _ = c.Report("2026-03-01", "2026-03-07")
+	if len(rep.ByMember) != 1 || rep.ByMember[0].Member != Label(secret) || rep.WorkSeconds != 5400 {
+		t.Errorf("after moving: %+v, %ds; want one member with 1h30m", rep.ByMember, rep.WorkSeconds)
+	}
+}
@@ -0,0 +1,69 @@
+package tray
+
+import (
+	"bytes"
+	"embed"
+	"encoding/binary"
+	"image/png"
+	"runtime"
+
+	"github.com/getlantern/systray"
+)
+
+// Icon names; each has a colored PNG and a black "_template" PNG that macOS
+// recolors for light and dark menu bars.
+const (
+	iconRunning = "running"
+	iconPaused  = "paused"
+	iconBreak   = "break"
+)
+
+//go:embed icons/*.png
+var icons embed.FS
+
+var currentIcon string
+
+// setIcon switches the tray icon, skipping the call when it is unchanged.
+func setIcon(name string) {
+	if name == currentIcon {
+		return
+	}
+	regular, err := icons.ReadFile("icons/" + name + ".png")
+	if err != nil {
+		return
+	}
+	currentIcon = name
+	switch runtime.GOOS {
+	case "darwin":
+		template, err := icons.ReadFile("icons/" + name + "_template.png")
+		if err != nil {
+			template = regular
+		}
+		systray.SetTemplateIcon(template, regular)
+	case "windows":
+		systray.SetIcon(pngToICO(regular))
+	default:
+		systray.SetIcon(regular)
+	}
+}
+
+// pngToICO wraps a PNG in a single-image ICO container, which Windows accepts
+// for icons since Vista.
+func pngToICO(data []byte) []byte {
+	cfg, err := png.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return data
+	}
+	var buf bytes.Buffer
+	// ICONDIR: reserved, type 1 (icon), one image.
+	binary.Write(&buf, binary.LittleEndian, [3]uint16{0, 1, 1})
+	// ICONDIRENTRY: width and height (0 means 256), colors, reserved,
+	// planes, bits per pixel, data size, data offset.
+	binary.Write(&buf, binary.LittleEndian, struct {
+		Width, Height, Colors, Reserved uint8
+		Planes, BitCount                uint16
+		Size, Offset                    uint32
+	}{uint8(cfg.Width % 256), uint8(cfg.Height % 256), 0, 0, 1, 32, uint32(len(data)), 6 + 16})
+	buf.Write(data)
+	return buf.Bytes()
+}
@@ -2,68 +2,179 @@ package tray
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/getlantern/systray"
 
+	"github.com/max-pantom/daily/internal/autostart"
+	"github.com/max-pantom/daily/internal/daemon"
+	"github.com/max-pantom/daily/internal/hotkey"
+	"github.com/max-pantom/daily/internal/i18n"
+	"github.com/max-pantom/daily/internal/logging"
+	"github.com/max-pantom/daily/internal/notify"
 	"github.com/max-pantom/daily/internal/state"
 )
 
 // Run starts a macOS/Linux system tray with quick actions.
 func Run(statePath string) error {
 	done := make(chan struct{})
+	releaseKeys := func() {}
 
 	systray.Run(func() {
-		st, _ := state.Load(statePath)
-		title, tip := statusInfo(statePath)
-		systray.SetTitle(title)
-		systray.SetTooltip(tip)
+		st, _ := daemon.Load(statePath)
 
 		mStart := systray.AddMenuItem("Start", "Start tracking")
 		mStop := systray.AddMenuItem("Stop", "Stop tracking")
 		mBreak := systray.AddMenuItem("Break", "Start/stop break")
+		mFocus := systray.AddMenuItem("Focus block", "Start a labelled countdown from focus_blocks")
+		var focusItems [focusSlots]*systray.MenuItem
+		focusClicks := make(chan int)
+		for i := range focusItems {
+			focusItems[i] = mFocus.AddSubMenuItem("", "")
+			go func(i int, item *systray.MenuItem) {
+				for range item.ClickedCh {
+					focusClicks <- i
+				}
+			}(i, focusItems[i])
+		}
 		mStatus := systray.AddMenuItem("Status", "Show current status")
 		nNotify := "Notifications"
 		mNotify := systray.AddMenuItemCheckbox(nNotify, "Toggle notifications", st != nil && st.NotificationsOn())
+		mLogin := systray.AddMenuItemCheckbox("Launch at login", "Start the Daily tray when you log in", autostart.Enabled())
+		systray.AddSeparator()
+		mGoal := systray.AddMenuItem("Goal", "Adjust the daily goal")
+		mGoalUp := mGoal.AddSubMenuItem(fmt.Sprintf("+%dm goal", state.GoalStepMinutes), "Raise the daily goal")
+		mGoalDown := mGoal.AddSubMenuItem(fmt.Sprintf("-%dm goal", state.GoalStepMinutes), "Lower the daily goal")
+		mBreaks := systray.AddMenuItem("Breaks", "Adjust the break reminder interval")
+		mBreaksUp := mBreaks.AddSubMenuItem(fmt.Sprintf("+%dm between breaks", state.BreakStepMinutes), "Remind less often")
+		mBreaksDown := mBreaks.AddSubMenuItem(fmt.Sprintf("-%dm between breaks", state.BreakStepMinutes), "Remind more often")
+		mWeek := systray.AddMenuItem("Last 7 days", "Daily totals of the last week")
+		var weekItems [weekDays]*systray.MenuItem
+		for i := range weekItems {
+			weekItems[i] = mWeek.AddSubMenuItem("", "")
+			weekItems[i].Disable()
+		}
 		systray.AddSeparator()
 		mQuit := systray.AddMenuItem("Quit", "Quit Daily tray")
 
+		// Changes from the CLI or TUI refresh the title right away; the timer
+		// only keeps the running totals moving.
+		var changes <-chan struct{}
+		if w, err := daemon.Watch(statePath); err == nil {
+			changes = w.Changes()
+		}
+
+		log := logging.New(statePath, "tray")
+		logErr := func(action string, err error) {
+			if err != nil {
+				log.Error(action, "err", err)
+			}
+		}
+
+		// Global shortcuts: Windows delivers them here, other desktops run
+		// `daily toggle` or `daily break` themselves.
+		keys := make(chan string, 4)
+		if st != nil {
+			bindings, errs := hotkey.Bindings(st.Hotkeys)
+			for _, err := range errs {
+				logErr("hotkey", err)
+			}
+			if exe, err := executable(); err == nil {
+				release, err := hotkey.Register(bindings, exe, func(action string) {
+					select {
+					case keys <- action:
+					default:
+					}
+				})
+				releaseKeys = release
+				logErr("hotkey", err)
+			}
+		}
+
+		// refresh redraws the title and reports how soon it should be redrawn:
+		// every second while a countdown is shown, otherwise every 20s.
+		refresh := func() time.Duration {
+			info := statusInfo(statePath)
+			setIcon(info.icon)
+			systray.SetTitle(info.title)
+			systray.SetTooltip(info.tip)
+			mGoal.SetTitle(info.goal)
+			mBreaks.SetTitle(info.breaks)
+			for i, line := range info.week {
+				weekItems[i].SetTitle(line)
+			}
+			showFocusBlocks(focusItems, info.blocks)
+			if info.counting {
+				return time.Second
+			}
+			return 20 * time.Second
+		}
+
 		go func() {
-			ticker := time.NewTicker(20 * time.Second)
-			defer ticker.Stop()
+			timer := time.NewTimer(refresh())
+			defer timer.Stop()
 			for {
 				select {
-				case <-ticker.C:
-					title, tip := statusInfo(statePath)
-					systray.SetTitle(title)
-					systray.SetTooltip(tip)
+				case <-timer.C:
+					logErr("heartbeat", daemon.Beat(statePath, time.Now()))
+					finishCountdown(statePath)
+					timer.Reset(refresh())
+				case _, ok := <-changes:
+					if !ok {
+						changes = nil
+						continue
+					}
+					timer.Reset(refresh())
 				case <-mStart.ClickedCh:
-					_ = start(statePath)
-					title, tip := statusInfo(statePath)
-					systray.SetTitle(title)
-					systray.SetTooltip(tip)
+					logErr("start", start(statePath))
+					timer.Reset(refresh())
 				case <-mStop.ClickedCh:
-					_ = stop(statePath)
-					title, tip := statusInfo(statePath)
-					systray.SetTitle(title)
-					systray.SetTooltip(tip)
+					logErr("stop", stop(statePath))
+					timer.Reset(refresh())
 				case <-mBreak.ClickedCh:
-					_ = toggleBreak(statePath)
-					title, tip := statusInfo(statePath)
-					systray.SetTitle(title)
-					systray.SetTooltip(tip)
+					logErr("toggleBreak", toggleBreak(statePath))
+					timer.Reset(refresh())
+				case i := <-focusClicks:
+					logErr("focus", startFocus(statePath, i))
+					timer.Reset(refresh())
+				case action := <-keys:
+					if action == hotkey.Break {
+						logErr("toggleBreak", toggleBreak(statePath))
+					} else {
+						logErr("toggle", toggle(statePath))
+					}
+					timer.Reset(refresh())
 				case <-mNotify.ClickedCh:
 					on := toggleNotify(statePath)
 					mNotify.Check()
 					if !on {
 						mNotify.Uncheck()
 					}
-					title, tip := statusInfo(statePath)
-					systray.SetTitle(title)
-					systray.SetTooltip(tip)
+					timer.Reset(refresh())
+				case <-mLogin.ClickedCh:
+					logErr("autostart", toggleAutostart())
+					if autostart.Enabled() {
+						mLogin.Check()
+					} else {
+						mLogin.Uncheck()
+					}
+				case <-mGoalUp.ClickedCh:
+					logErr("adjust", adjust(statePath, state.GoalStepMinutes, 0))
+					timer.Reset(refresh())
+				case <-mGoalDown.ClickedCh:
+					logErr("adjust", adjust(statePath, -state.GoalStepMinutes, 0))
+					timer.Reset(refresh())
+				case <-mBreaksUp.ClickedCh:
+					logErr("adjust", adjust(statePath, 0, state.BreakStepMinutes))
+					timer.Reset(refresh())
+				case <-mBreaksDown.ClickedCh:
+					logErr("adjust", adjust(statePath, 0, -state.BreakStepMinutes))
+					timer.Reset(refresh())
 				case <-mStatus.ClickedCh:
-					_, tip := statusInfo(statePath)
-					systray.SetTooltip(tip)
+					systray.SetTooltip(statusInfo(statePath).tip)
 				case <-mQuit.ClickedCh:
 					systray.Quit()
 					return
@@ -71,6 +182,8 @@ func Run(statePath string) error {
 			}
 		}()
 	}, func() {
+		releaseKeys()
+		_ = daemon.EndHeartbeat(statePath)
 		close(done)
 	})
 
@@ -78,16 +191,30 @@ func Run(statePath string) error {
 	return nil
 }
 
-func statusInfo(path string) (string, string) {
-	st, err := state.Load(path)
+// trayStatus is what the tray displays for the current state.
+type trayStatus struct {
+	title    string
+	tip      string
+	goal     string // label of the goal submenu
+	breaks   string // label of the break-interval submenu
+	icon     string // iconRunning, iconPaused or iconBreak
+	counting bool   // the title shows a running countdown
+	week     [weekDays]string
+	blocks   []state.FocusBlock
+}
+
+func statusInfo(path string) trayStatus {
+	st, err := daemon.Load(path)
 	if err != nil {
-		return "Daily", "Daily Work Tracker"
+		return trayStatus{title: "Daily", tip: "Daily Work Tracker", goal: "Goal", breaks: "Breaks", icon: iconPaused}
 	}
+	i18n.Use(st.Language, st.Clock)
+	notify.Use(st.NotifyConfig())
 	now := time.Now()
 	st.Normalize(now)
 	work, active := st.TodaySummary(now)
 
-	goal := st.GoalMinutes
+	goal, carried := st.Goal(now)
 	percent := 0
 	if goal > 0 {
 		percent = (work * 100) / goal
@@ -106,9 +233,34 @@ func statusInfo(path string) (string, string) {
 		mins := int(now.Sub(st.ActiveBreak.Start).Minutes())
 		title += fmt.Sprintf(" [break %s]", state.HumanMinutes(mins))
 	}
+	counting := false
+	label, focusing := st.FocusLabel()
+	if left, ok := st.PhaseRemaining(now); ok {
+		switch {
+		case st.TrayTitle == "total" && focusing:
+			title += fmt.Sprintf(" ⏳%s %s", state.ShortLabel(label), state.HumanRemaining(left))
+		case st.TrayTitle == "total":
+			title += fmt.Sprintf(" ⏳%s", state.HumanRemaining(left))
+		case focusing:
+			title = fmt.Sprintf("%s %s %s", statusGlyph, state.ShortLabel(label), state.Clock(left))
+			counting = true
+		default:
+			title = fmt.Sprintf("%s %s", statusGlyph, state.Clock(left))
+			counting = true
+		}
+	}
+	over, overBreak := st.OverBreak(now)
+	if overBreak {
+		if st.TrayTitle == "total" {
+			title += fmt.Sprintf(" +%s over", state.HumanMinutes(int(over.Minutes())))
+		} else {
+			title = fmt.Sprintf("%s +%s", statusGlyph, state.Clock(over))
+			counting = true
+		}
+	}
 
 	nextLabel, nextETA := nextMilestone(work, goal)
-	goalStr := state.HumanMinutes(goal)
+	goalStr := state.HumanMinutes(goal) + state.CarriedLabel(carried)
 	tip := fmt.Sprintf("Work: %s | Goal: %s | %d%%", state.HumanMinutes(work), goalStr, percent)
 	if nextLabel != "" && nextETA != "" {
 		tip += fmt.Sprintf(" | Next: %s in %s", nextLabel, nextETA)
@@ -117,10 +269,120 @@ func statusInfo(path string) (string, string) {
 		mins := int(now.Sub(st.ActiveBreak.Start).Minutes())
 		tip += fmt.Sprintf(" | Break: %s", state.HumanMinutes(mins))
 	}
+	if overBreak {
+		tip += fmt.Sprintf(" | Over break: %s", state.HumanMinutes(int(over.Minutes())))
+	}
+	if left, ok := st.PhaseRemaining(now); ok {
+		if focusing {
+			tip += fmt.Sprintf(" | %s: %s left", label, state.HumanRemaining(left))
+		} else {
+			tip += fmt.Sprintf(" | Countdown: %s left", state.HumanRemaining(left))
+		}
+	}
+	if eta, ok := st.GoalETA(now); ok {
+		tip += fmt.Sprintf(" | Goal at ~%s", i18n.Time(eta))
+	}
 	if !st.NotificationsOn() {
 		tip += " | Notifications: off"
 	}
-	return title, tip
+	icon := iconPaused
+	if st.ActiveBreak != nil {
+		icon = iconBreak
+	} else if st.ActiveSession != nil {
+		icon = iconRunning
+	}
+	return trayStatus{
+		title:    title,
+		tip:      tip,
+		goal:     "Goal: " + goalStr,
+		breaks:   "Breaks: every " + state.HumanMinutes(st.BreakIntervalMinutes),
+		icon:     icon,
+		counting: counting,
+		week:     weekLines(st, now),
+		blocks:   st.FocusBlocks,
+	}
+}
+
+// focusSlots is how many of the focus_blocks the Focus block menu offers.
+const focusSlots = 8
+
+// showFocusBlocks fills the Focus block menu from focus_blocks, hiding the
+// slots left over; without any, the first slot says how to add them.
+func showFocusBlocks(items [focusSlots]*systray.MenuItem, blocks []state.FocusBlock) {
+	for i, item := range items {
+		switch {
+		case i < len(blocks):
+			item.SetTitle(fmt.Sprintf("%s (%s)", blocks[i].Label, state.HumanMinutes(blocks[i].Minutes)))
+			item.Enable()
+			item.Show()
+		case i == 0:
+			item.SetTitle("Add blocks with daily config set focus_blocks")
+			item.Disable()
+			item.Show()
+		default:
+			item.Hide()
+		}
+	}
+}
+
+// startFocus starts focus block i of focus_blocks, ending any session or break
+// first.
+func startFocus(path string, i int) error {
+	return daemon.Update(path, func(st *state.State) error {
+		if i >= len(st.FocusBlocks) {
+			return nil // the blocks changed since the menu was drawn
+		}
+		now := time.Now()
+		if st.ActiveBreak != nil {
+			if _, err := st.StopBreak(now); err != nil {
+				return err
+			}
+		}
+		if st.ActiveSession != nil {
+			if _, err := st.StopSession(now); err != nil {
+				return err
+			}
+		}
+		if err := st.CheckCap(now); err != nil {
+			return err
+		}
+		b := st.FocusBlocks[i]
+		return st.StartFocus(now, b.Label, time.Duration(b.Minutes)*time.Minute, nil)
+	})
+}
+
+const (
+	weekDays     = 7
+	weekBarWidth = 10
+)
+
+// weekLines renders the last seven days, oldest first, as "Mon 14  ▓▓▓▓▓▓░░░░  6h ✓"
+// with each bar filled against the day's goal.
+func weekLines(st *state.State, now time.Time) [weekDays]string {
+	var lines [weekDays]string
+	for i := range lines {
+		day := now.AddDate(0, 0, i-(weekDays-1))
+		work := 0
+		goal, _ := st.Goal(day)
+		if i == weekDays-1 {
+			work, _ = st.TodaySummary(now)
+		} else if log, ok := st.Days[day.Format("2006-01-02")]; ok {
+			work = log.WorkSeconds() / 60
+		}
+		filled := 0
+		if goal > 0 {
+			filled = min(work*weekBarWidth/goal, weekBarWidth)
+		}
+		if filled == 0 && work > 0 {
+			filled = 1
+		}
+		bar := strings.Repeat("▓", filled) + strings.Repeat("░", weekBarWidth-filled)
+		lines[i] = fmt.Sprintf("%s  %s  %s", day.Format("Mon 02"), bar, state.HumanMinutes(work))
+		if goal > 0 && work >= goal {
+			lines[i] += " ✓"
+		}
+	}
+	return lines
 }
 
 func progressGlyph(percent int) string {
@@ -160,15 +422,65 @@ func nextMilestone(workMin, goalMin int) (string, string) {
 	return state.HumanMinutes(best), state.HumanMinutes(etaMin)
 }
 
+// finishCountdown stops an expired countdown session and notifies once.
+func finishCountdown(path string) {
+	finished, notifyOn, notice := false, false, ""
+	err := daemon.Update(path, func(st *state.State) error {
+		notice = st.CountdownNotice()
+		_, finished = st.FinishCountdown(time.Now())
+		notifyOn = st.NotificationsOn()
+		return nil
+	})
+	if err == nil && finished && notifyOn {
+		notify.Send("Daily", notice)
+	}
+}
+
+// adjust nudges the goal and break interval, mirroring the TUI's +/- and [/] keys.
+func adjust(path string, goalDelta, breakDelta int) error {
+	return daemon.Update(path, func(st *state.State) error {
+		if goalDelta != 0 {
+			st.AdjustGoal(goalDelta)
+		}
+		if breakDelta != 0 {
+			st.AdjustBreakInterval(breakDelta)
+		}
+		return nil
+	})
+}
+
 func toggleNotify(path string) bool {
-	st, err := state.Load(path)
+	on := false
+	err := daemon.Update(path, func(st *state.State) error {
+		on = !st.NotificationsOn()
+		st.NotificationsEnabled = newBool(on)
+		return nil
+	})
+	return err == nil && on
+}
+
+// toggleAutostart adds or removes the login entry that runs this binary's tray.
+func toggleAutostart() error {
+	if autostart.Enabled() {
+		return autostart.Disable()
+	}
+	exe, err := executable()
 	if err != nil {
-		return false
+		return err
+	}
+	return autostart.Enable(exe)
+}
+
+// executable is this binary's path with symlinks resolved.
+func executable() (string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
 	}
-	on := !st.NotificationsOn()
-	st.NotificationsEnabled = newBool(on)
-	_ = st.Save(path)
-	return on
+	return exe, nil
 }
 
 func newBool(v bool) *bool {
@@ -176,55 +488,62 @@ func newBool(v bool) *bool {
 }
 
 func start(path string) error {
-	st, err := state.Load(path)
-	if err != nil {
-		return err
-	}
-	if st.ActiveBreak != nil {
-		if _, err := st.StopBreak(time.Now()); err != nil {
+	return daemon.Update(path, func(st *state.State) error {
+		if st.ActiveBreak != nil {
+			if _, err := st.StopBreak(time.Now()); err != nil {
+				return err
+			}
+		}
+		if err := st.CheckCap(time.Now()); err != nil {
 			return err
 		}
-	}
-	if err := st.StartSession(time.Now(), nil, ""); err != nil {
-		return err
-	}
-	return st.Save(path)
+		return st.StartSession(time.Now(), nil, "")
+	})
 }
 
 func stop(path string) error {
-	st, err := state.Load(path)
-	if err != nil {
-		return err
-	}
-	if st.ActiveSession == nil {
-		return nil
-	}
-	if _, err := st.StopSession(time.Now()); err != nil {
+	return daemon.Update(path, func(st *state.State) error {
+		if st.ActiveSession == nil {
+			return nil
+		}
+		_, err := st.StopSession(time.Now())
 		return err
-	}
-	return st.Save(path)
+	})
+}
+
+// toggle stops the running session or starts one.
+func toggle(path string) error {
+	return daemon.Update(path, func(st *state.State) error {
+		now := time.Now()
+		if st.ActiveSession != nil {
+			_, err := st.StopSession(now)
+			return err
+		}
+		if st.ActiveBreak != nil {
+			if _, err := st.StopBreak(now); err != nil {
+				return err
+			}
+		}
+		if err := st.CheckCap(now); err != nil {
+			return err
+		}
+		return st.StartSession(now, nil, "")
+	})
 }
 
 func toggleBreak(path string) error {
-	st, err := state.Load(path)
-	if err != nil {
-		return err
-	}
-	now := time.Now()
-	if st.ActiveBreak != nil {
-		if _, err := st.StopBreak(now); err != nil {
+	return daemon.Update(path, func(st *state.State) error {
+		now := time.Now()
+		if st.ActiveBreak != nil {
+			_, err := st.StopBreak(now)
 			return err
 		}
-	} else {
 		// End any running session before break.
 		if st.ActiveSession != nil {
 			if _, err := st.StopSession(now); err != nil {
 				return err
 			}
 		}
-		if err := st.StartBreak(now); err != nil {
-			return err
-		}
-	}
-	return st.Save(path)
+		return st.StartBreak(now)
+	})
 }
@@ -0,0 +1,86 @@
+package tui
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/max-pantom/daily/internal/i18n"
+	"github.com/max-pantom/daily/internal/state"
+)
+
+// Below these sizes the big title and wide status bar no longer fit
+// (tmux splits, phone SSH sessions), so views collapse to a single panel.
+const (
+	compactWidth  = 60
+	compactHeight = 22
+)
+
+func (m model) compact() bool {
+	return m.width > 0 && m.height > 0 && (m.width < compactWidth || m.height < compactHeight)
+}
+
+// renderCompact is the single-panel main view for narrow or short terminals.
+func (m model) renderCompact() string {
+	th := themeForMinutes(m.summary.workMinutes)
+	accent := lipgloss.NewStyle().Foreground(th.Accent).Bold(true)
+
+	barWidth := clampInt(m.width-4, 8, progressBarWidth)
+	progress := renderProgressBar(m.summary.workMinutes, m.summary.goalMinutes, th, barWidth)
+
+	lines := []string{accent.Render("DAILY"), progress}
+	if m.err != nil {
+		lines = append(lines, errorStyle.MarginBottom(0).Width(m.width).Render(fmt.Sprintf("error: %v", m.err)))
+	} else if m.notice != "" {
+		lines = append(lines, noticeStyle.Foreground(th.Accent).MarginBottom(0).Width(m.width).Align(lipgloss.Center).Render(m.notice))
+	}
+
+	// Drop the menu entirely when even that does not fit.
+	if m.height >= len(m.actions)+7 {
+		for i, act := range m.actions {
+			label := actionLabel(act)
+			if i == m.selected {
+				lines = append(lines, accent.Render("▶ "+label))
+			} else {
+				lines = append(lines, menuStyle.PaddingLeft(0).Render("  "+label))
+			}
+		}
+	} else {
+		lines = append(lines, accent.Render("▶ "+actionLabel(m.actions[m.selected])))
+	}
+	lines = append(lines, hintStyle.Foreground(th.Muted).MarginTop(0).Render("? help  q quit"))
+
+	body := lipgloss.JoinVertical(lipgloss.Center, lines...)
+	body = lipgloss.Place(m.width, m.height-1, lipgloss.Center, lipgloss.Center, body)
+	status := lipgloss.PlaceHorizontal(m.width, lipgloss.Center, m.renderCompactStatus())
+	return baseStyle.Width(m.width).Height(m.height).Render(lipgloss.JoinVertical(lipgloss.Left, body, status))
+}
+
+// renderCompactStatus is a one-line status that fits in about 30 columns.
+func (m model) renderCompactStatus() string {
+	status := statusDim.Render(i18n.T("PAUSED"))
+	if m.summary.onBreak {
+		status = statusBreak.Render(i18n.T("BREAK"))
+	} else if m.summary.activeSince != nil {
+		status = statusRun.Render(i18n.T("RUN"))
+	}
+	text := fmt.Sprintf(" %s  %db", state.HumanSeconds(m.summary.workSeconds), m.summary.breaksCount)
+	if m.summary.countdown != nil {
+		left := int(m.summary.countdown.Seconds())
+		text += fmt.Sprintf("  %02d:%02d", left/60, left%60)
+		if m.summary.focusLabel != "" {
+			text += " " + state.ShortLabel(m.summary.focusLabel)
+		}
+	} else if m.sprint.running {
+		left := int(m.sprint.phaseEnd.Sub(time.Now()).Seconds())
+		if left < 0 {
+			left = 0
+		}
+		text += fmt.Sprintf("  %s %02d:%02d", m.sprint.phase, left/60, left%60)
+	}
+	if m.summary.overBreak != nil {
+		text += "  +" + state.Clock(*m.summary.overBreak) + " over"
+	}
+	return status + statusDim.Render(text)
+}
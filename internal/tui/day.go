@@ -0,0 +1,129 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/max-pantom/daily/internal/i18n"
+	"github.com/max-pantom/daily/internal/state"
+)
+
+// shiftDay pages the day view, never past today.
+func (m *model) shiftDay(delta int) {
+	m.dayOffset += delta
+	if m.dayOffset > 0 {
+		m.dayOffset = 0
+	}
+}
+
+// renderDay lists the sessions and breaks of the day dayOffset days from today.
+func (m model) renderDay() string {
+	th := themeForMinutes(m.summary.workMinutes)
+	now := time.Now()
+	day := now.AddDate(0, 0, m.dayOffset)
+	key := day.Format("2006-01-02")
+
+	heading := fmt.Sprintf("%s · %s", strings.ToUpper(day.Format("Monday")), key)
+	if m.dayOffset == 0 {
+		heading = "TODAY · " + key
+	}
+	title := titleStyle.Foreground(th.Accent).Render(heading)
+	muted := lipgloss.NewStyle().Foreground(th.Muted)
+	value := weekValueStyle.Foreground(th.Accent)
+
+	var sessions []state.Session
+	var log *state.DayLog
+	if m.st != nil {
+		log = m.st.Days[key]
+		if log != nil {
+			sessions = append(sessions, log.Sessions...)
+		}
+		if m.dayOffset == 0 && m.st.ActiveSession != nil {
+			sessions = append(sessions, *m.st.ActiveSession)
+		}
+	}
+
+	lines := make([]string, 0, len(sessions)+2)
+	work := 0
+	for _, sess := range sessions {
+		secs := sess.Seconds(now)
+		work += secs
+		end := "now"
+		if sess.End != nil {
+			end = i18n.Time(*sess.End)
+		}
+		span := muted.Copy().Width(20).Render(fmt.Sprintf("%s → %s", i18n.Time(sess.Start), end))
+		var detail []string
+		if sess.Project != "" {
+			detail = append(detail, sess.Project)
+		}
+		if len(sess.Tags) > 0 {
+			detail = append(detail, "#"+strings.Join(sess.Tags, " #"))
+		}
+		if sess.Note != "" {
+			detail = append(detail, sess.Note)
+		}
+		lines = append(lines, lipgloss.JoinHorizontal(lipgloss.Left,
+			span,
+			value.Copy().Width(8).Render(state.HumanSeconds(secs)),
+			muted.Render(strings.Join(detail, "  ")),
+		))
+	}
+	if len(lines) == 0 {
+		lines = append(lines, muted.Render("no sessions"))
+	}
+	total := fmt.Sprintf("work %s", state.HumanSeconds(work))
+	if log != nil && log.BreakCount > 0 {
+		total += fmt.Sprintf("   %d breaks %s", log.BreakCount, state.HumanSeconds(log.BreakSeconds()))
+	}
+	lines = append(lines, "", value.Render(total))
+
+	hints := hintStyle.Foreground(th.Muted).Render("←/→ previous/next day   t back   q quit")
+	sections := []string{title}
+	if m.st != nil {
+		sections = append(sections, m.renderTimeline(day, now, th), "")
+	}
+	sections = append(sections, lipgloss.JoinVertical(lipgloss.Left, lines...), hints)
+	body := lipgloss.JoinVertical(lipgloss.Left, sections...)
+	if m.width > 0 && m.height > 0 {
+		body = lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, body)
+		return baseStyle.Width(m.width).Height(m.height).Render(body)
+	}
+	return baseStyle.Render(body)
+}
+
+// renderTimeline draws day as a row of coloured slots under an hour ruler,
+// as fine as the terminal allows up to 15 minutes a slot.
+func (m model) renderTimeline(day, now time.Time, th milestoneTheme) string {
+	from, to := m.st.TimelineHours(day, now)
+	hours := to - from
+	perHour := 4
+	if m.width > 0 {
+		perHour = clampInt((m.width-8)/hours, 1, 4)
+	}
+	every := (3 + perHour - 1) / perHour // hours between ruler labels, so they don't collide
+	var ruler strings.Builder
+	for h := from; h < to; h += every {
+		ruler.WriteString(fmt.Sprintf("%-*s", min(every, to-h)*perHour, fmt.Sprintf("%02d", h)))
+	}
+
+	styles := map[state.Slot]lipgloss.Style{
+		state.SlotFree:        statusHalf,
+		state.SlotWork:        lipgloss.NewStyle().Foreground(th.Accent),
+		state.SlotBreak:       lipgloss.NewStyle().Foreground(th.Muted),
+		state.SlotInterrupted: statusRun,
+	}
+	glyphs := map[state.Slot]string{state.SlotFree: "·", state.SlotWork: "█", state.SlotBreak: "░", state.SlotInterrupted: "▒"}
+	var row strings.Builder
+	for _, slot := range m.st.Timeline(day, from, to, hours*perHour, now) {
+		row.WriteString(styles[slot].Render(glyphs[slot]))
+	}
+	legend := fmt.Sprintf("%s work  %s break  %s interrupted",
+		styles[state.SlotWork].Render(glyphs[state.SlotWork]),
+		styles[state.SlotBreak].Render(glyphs[state.SlotBreak]),
+		styles[state.SlotInterrupted].Render(glyphs[state.SlotInterrupted]))
+	return lipgloss.JoinVertical(lipgloss.Left, statusDim.Render(ruler.String()), row.String(), statusDim.Render(legend))
+}
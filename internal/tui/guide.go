@@ -0,0 +1,195 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/max-pantom/daily/internal/state"
+)
+
+// breakGuide is the screen that opens when a break begins, pacing box
+// breathing or walking through a few stretches so the break is spent resting.
+type breakGuide struct {
+	seen    time.Time // start of the last break the guide opened for, or skipped
+	stretch bool      // the stretch checklist instead of box breathing
+	back    string    // view to return to when the break ends
+}
+
+// boxPhases are the four sides of box breathing, boxSide seconds each.
+var boxPhases = []string{"Breathe in", "Hold", "Breathe out", "Hold"}
+
+const boxSide = 4 * time.Second
+
+// boxSize is the number of steps along each side of the drawn box.
+const boxSize = 8
+
+type stretch struct {
+	text string
+	secs int
+}
+
+var stretches = []stretch{
+	{"Stand up and reach for the ceiling", 30},
+	{"Roll your shoulders back, slowly", 30},
+	{"Tilt your head, ear towards each shoulder", 30},
+	{"Clasp your hands behind you and open your chest", 30},
+	{"Twist gently at the waist, both sides", 30},
+	{"Shake out your wrists and hands", 20},
+	{"Look at something far away", 20},
+	{"Walk around and get a glass of water", 60},
+}
+
+// followBreak opens the guide when a break begins and closes it when the break
+// ends. A break already running when the dashboard starts, or started for
+// relax mode, is left alone.
+func (m *model) followBreak() {
+	b := m.st.ActiveBreak
+	if b == nil {
+		if m.view == "guide" {
+			m.view = m.guide.back
+		}
+		return
+	}
+	if b.Start.Equal(m.guide.seen) {
+		return
+	}
+	m.guide.seen = b.Start
+	if !m.loaded || m.st.BreakGuide == state.GuideOff || m.relaxing() || m.view == "help" || m.view == "guide" {
+		return
+	}
+	m.guide.stretch = m.st.BreakGuide == state.GuideStretch
+	m.guide.back = m.view
+	m.view = "guide"
+}
+
+func (m model) renderGuide() string {
+	th := themeForMinutes(m.summary.workMinutes)
+	now := time.Now()
+	var since time.Time
+	if m.st != nil && m.st.ActiveBreak != nil {
+		since = m.st.ActiveBreak.Start
+	}
+	elapsed := max(now.Sub(since), 0)
+
+	title := titleStyle.Foreground(th.Accent).Render("BREAK")
+	status := hintStyle.Foreground(th.Muted).Render(m.breakLeft(now, elapsed))
+	var guide string
+	if m.guide.stretch {
+		guide = renderStretches(elapsed, th)
+	} else {
+		guide = renderBox(elapsed, th)
+	}
+	hints := hintStyle.Foreground(th.Muted).Render("←/→ breathing or stretches   esc back   ? help")
+
+	body := lipgloss.JoinVertical(lipgloss.Center, title, status, guide, hints)
+	if m.width > 0 && m.height > 0 {
+		body = lipgloss.Place(m.width, m.height-statusBarHeight, lipgloss.Center, lipgloss.Center, body)
+	}
+	bottom := m.renderStatusBar()
+	if m.width > 0 {
+		bottom = lipgloss.Place(m.width, statusBarHeight, lipgloss.Center, lipgloss.Center, bottom)
+	}
+	view := lipgloss.JoinVertical(lipgloss.Left, body, bottom)
+	if m.width > 0 && m.height > 0 {
+		return baseStyle.Width(m.width).Height(m.height).Render(view)
+	}
+	return baseStyle.Render(view)
+}
+
+// breakLeft says how long the break still has to run, or how long it has.
+func (m model) breakLeft(now time.Time, elapsed time.Duration) string {
+	if m.st != nil {
+		if due, ok := m.st.BreakDue(); ok {
+			if left := due.Sub(now); left > 0 {
+				return "Back to work in " + state.Clock(left)
+			}
+			return "Break over by " + state.Clock(now.Sub(due))
+		}
+	}
+	return "On a break for " + state.Clock(elapsed)
+}
+
+// renderBox traces a square, one side per phase: up the left while breathing
+// in, across the top holding, down the right breathing out and back along the
+// bottom holding.
+func renderBox(elapsed time.Duration, th milestoneTheme) string {
+	cycle := elapsed % (4 * boxSide)
+	phase := int(cycle / boxSide)
+	within := cycle % boxSide
+	step := int(within * boxSize / boxSide)
+
+	// corner returns the point after i steps along side.
+	corner := func(side, i int) (row, col int) {
+		switch side {
+		case 0:
+			return boxSize - i, 0
+		case 1:
+			return 0, i
+		case 2:
+			return i, boxSize
+		}
+		return boxSize, boxSize - i
+	}
+	lit := map[[2]int]bool{}
+	for i := 0; i <= step; i++ {
+		r, c := corner(phase, i)
+		lit[[2]int{r, c}] = true
+	}
+	headR, headC := corner(phase, step)
+
+	dim := lipgloss.NewStyle().Foreground(th.Muted)
+	trail := lipgloss.NewStyle().Foreground(th.Accent)
+	head := lipgloss.NewStyle().Foreground(th.Accent).Bold(true)
+	var rows []string
+	for r := 0; r <= boxSize; r++ {
+		var b strings.Builder
+		for c := 0; c <= boxSize; c++ {
+			if c > 0 {
+				b.WriteString(" ")
+			}
+			switch {
+			case r == headR && c == headC:
+				b.WriteString(head.Render("●"))
+			case lit[[2]int{r, c}]:
+				b.WriteString(trail.Render("•"))
+			case r == 0 || r == boxSize || c == 0 || c == boxSize:
+				b.WriteString(dim.Render("·"))
+			default:
+				b.WriteString(" ")
+			}
+		}
+		rows = append(rows, b.String())
+	}
+	count := int((boxSide - within + time.Second - 1) / time.Second)
+	label := noticeStyle.Foreground(th.Accent).Render(fmt.Sprintf("%s  %d", boxPhases[phase], count))
+	return lipgloss.JoinVertical(lipgloss.Center, lipgloss.NewStyle().Margin(1, 0).Render(strings.Join(rows, "\n")), label)
+}
+
+// renderStretches ticks off the stretches as their time passes, counting down
+// the current one.
+func renderStretches(elapsed time.Duration, th milestoneTheme) string {
+	done := lipgloss.NewStyle().Foreground(th.Muted)
+	current := lipgloss.NewStyle().Foreground(th.Accent).Bold(true)
+	next := lipgloss.NewStyle().Foreground(lipgloss.Color("#e4e4e4"))
+
+	left := int(elapsed.Seconds())
+	var lines []string
+	for _, s := range stretches {
+		switch {
+		case left >= s.secs:
+			lines = append(lines, done.Render("✓ "+s.text))
+		case left >= 0:
+			lines = append(lines, current.Render(fmt.Sprintf("▶ %s  %s", s.text, state.Clock(time.Duration(s.secs-left)*time.Second))))
+		default:
+			lines = append(lines, next.Render("○ "+s.text))
+		}
+		left -= s.secs
+	}
+	if left >= 0 {
+		lines = append(lines, "", current.Render("All done: enjoy the rest of your break"))
+	}
+	return lipgloss.NewStyle().Margin(1, 0).Render(strings.Join(lines, "\n"))
+}
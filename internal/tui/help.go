@@ -0,0 +1,132 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/max-pantom/daily/internal/state"
+)
+
+type helpEntry struct {
+	keys string
+	desc string
+}
+
+type helpSection struct {
+	title   string
+	entries []helpEntry
+}
+
+var helpSections = []helpSection{
+	{"Global", []helpEntry{
+		{"?", "toggle this help"},
+		{"q / ctrl+c", "quit"},
+		{"esc", "back to the main view"},
+		{"TAB / w", "toggle the week view (←/→ a week, PgUp/PgDn a month, Home back)"},
+		{"p", "sprint panel"},
+		{"s", "stats view"},
+		{"t", "day view (←/→ page through earlier days)"},
+		{"r", "relax mode (Block Breaker)"},
+	}},
+	{"Main view", []helpEntry{
+		{"↑/↓ or k/j", "move through START, STOP, STATUS, BREAK, RELAX"},
+		{"ENTER / SPACE", "run the selected action"},
+		{"+ / -", "raise or lower the daily goal by 30m"},
+		{"[ / ]", "shorten or lengthen the break interval by 5m"},
+		{"o", "switch between progress bar and ring"},
+	}},
+	{"Sprint panel", []helpEntry{
+		{"↑/↓", "pick profile, work, break or cycles"},
+		{"←/→", "adjust the selected value"},
+		{"ENTER", "start or stop the sprint (keeps running in other views)"},
+		{"n", "skip to the next phase while running"},
+		{"+", "add 5 minutes to the running phase"},
+	}},
+	{"Relax mode", []helpEntry{
+		{"←/→ or a/d", "move the paddle"},
+		{"SPACE", "launch the ball"},
+		{"r", "reset the game"},
+	}},
+	{"Break guide", []helpEntry{
+		{"←/→", "switch between box breathing and stretches"},
+		{"esc", "leave the guide; the break goes on"},
+	}},
+	{"Status bar", []helpEntry{
+		{"RUNNING/PAUSED/BREAK", "current tracking state"},
+		{"HOURS / MIN / SEC", "work logged today"},
+		{"1H20M STRAIGHT", "work since your last break (gaps under 5m don't count)"},
+		{"GOAL ~time", "when the goal is met if you keep going"},
+		{"mm:ss LEFT", "countdown session remaining (daily start --for), after a focus block's label"},
+	}},
+}
+
+// helpLines renders the help content, including the milestone color legend.
+func helpLines(th milestoneTheme) []string {
+	heading := lipgloss.NewStyle().Foreground(th.Accent).Bold(true)
+	key := lipgloss.NewStyle().Foreground(lipgloss.Color("#e4e4e4")).Width(22)
+	desc := lipgloss.NewStyle().Foreground(th.Muted)
+
+	var lines []string
+	for _, sec := range helpSections {
+		lines = append(lines, heading.Render(sec.title))
+		for _, e := range sec.entries {
+			lines = append(lines, "  "+key.Render(e.keys)+desc.Render(e.desc))
+		}
+		lines = append(lines, "")
+	}
+	lines = append(lines, heading.Render("Milestone colors"))
+	lines = append(lines, "  "+desc.Render("The accent color shifts as today's work crosses each threshold:"))
+	for _, mt := range milestoneThemes {
+		swatch := lipgloss.NewStyle().Foreground(mt.Accent).Render("████")
+		label := fmt.Sprintf("from %s", state.HumanMinutes(mt.ThresholdMin))
+		lines = append(lines, "  "+swatch+" "+key.Render(mt.Name)+desc.Render(label))
+	}
+	return lines
+}
+
+// helpPageSize is how many help lines fit between the title and hints.
+func (m model) helpPageSize() int {
+	if m.height <= 0 {
+		return 20
+	}
+	n := m.height - 6
+	if n < 3 {
+		n = 3
+	}
+	return n
+}
+
+func (m *model) scrollHelp(delta int) {
+	total := len(helpLines(themeForMinutes(m.summary.workMinutes)))
+	maxOffset := total - m.helpPageSize()
+	if maxOffset < 0 {
+		maxOffset = 0
+	}
+	m.helpOffset = clampInt(m.helpOffset+delta, 0, maxOffset)
+}
+
+func (m model) renderHelp() string {
+	th := themeForMinutes(m.summary.workMinutes)
+	lines := helpLines(th)
+	page := m.helpPageSize()
+	start := clampInt(m.helpOffset, 0, len(lines))
+	end := start + page
+	if end > len(lines) {
+		end = len(lines)
+	}
+
+	title := titleStyle.Foreground(th.Accent).MarginBottom(1).Render("HELP")
+	more := ""
+	if end < len(lines) {
+		more = fmt.Sprintf("  (%d more)", len(lines)-end)
+	}
+	hints := hintStyle.Foreground(th.Muted).Render("↑/↓ scroll   PgUp/PgDn page   ? or esc close" + more)
+	body := lipgloss.JoinVertical(lipgloss.Left, title, strings.Join(lines[start:end], "\n"), hints)
+	if m.width > 0 && m.height > 0 {
+		body = lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, body)
+		return baseStyle.Width(m.width).Height(m.height).Render(body)
+	}
+	return baseStyle.Render(body)
+}
@@ -2,18 +2,26 @@ package tui
 
 import (
 	"fmt"
-	"sort"
+	"os"
 	"strings"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 
+	"github.com/max-pantom/daily/internal/calendar"
+	"github.com/max-pantom/daily/internal/daemon"
+	"github.com/max-pantom/daily/internal/i18n"
+	"github.com/max-pantom/daily/internal/notify"
 	"github.com/max-pantom/daily/internal/state"
 )
 
 type model struct {
 	statePath string
+	st        *state.State
+	loadedAt  time.Time
+	stamp     [2]time.Time // state.ModTimes at the last reload
+	changes   <-chan struct{}
 	loaded    bool
 	err       error
 	notice    string
@@ -34,7 +42,19 @@ type model struct {
 	lastMilestone int
 	lastDay       string
 
-	game gameState
+	game       gameState
+	relax      relaxMode
+	guide      breakGuide
+	showRing   bool
+	sprint     sprintPanel
+	helpOffset int
+	prevView   string
+	dayOffset  int // days before today shown in the day view
+	weekOffset int // weeks before this one shown in the week view
+
+	calendarSource string
+	events         []calendar.Event
+	eventsAt       time.Time
 }
 
 type summary struct {
@@ -43,11 +63,17 @@ type summary struct {
 	activeMinutes int
 	activeSeconds int
 	goalMinutes   int
+	carried       int // minutes carried into today's goal, banked when positive
 	breakMinutes  int
 	breaksCount   int
 	activeSince   *time.Time
+	countdown     *time.Duration
+	focusLabel    string // of the running focus block
+	goalETA       *time.Time
 	onBreak       bool
+	overBreak     *time.Duration // how long the break has run past its length
 	sessions      []state.Session
+	continuous    time.Duration // work since the last rest
 }
 
 // milestoneTheme controls palette shifts at certain work thresholds.
@@ -62,21 +88,35 @@ type milestoneTheme struct {
 
 type tickMsg time.Time
 
+// stateChangedMsg is sent when another process rewrites the state file.
+type stateChangedMsg struct{}
+
+type eventsMsg struct {
+	source string
+	events []calendar.Event
+	err    error
+}
+
 const (
 	actionStart  = "start"
 	actionStop   = "stop"
 	actionStatus = "status"
 	actionBreak  = "break"
 	actionRelax  = "relax"
-
-	goalStepMinutes  = 30
-	breakStepMinutes = 5
-	minGoalMinutes   = 30
-	minBreakMinutes  = 5
 )
 
 const statusBarHeight = 2
 
+// fallbackReload bounds how stale the cached state may get when file change
+// events are missed.
+const fallbackReload = 30 * time.Second
+
+const (
+	calendarRefresh  = 10 * time.Minute
+	agendaLookahead  = 4 * time.Hour
+	agendaMaxEntries = 3
+)
+
 // milestoneThemes defines color themes by work-time thresholds (minutes).
 // Customize the colors here to update the TUI look at each milestone.
 var milestoneThemes = []milestoneTheme{
@@ -125,23 +165,118 @@ func newModel(path string) model {
 		view:      "main",
 	}
 	m.game = newGameState()
+	m.sprint = newSprintPanel()
 	m.reload(time.Now())
+	// Init fetches the calendar; the first tick must not fetch it again.
+	m.eventsAt = time.Now()
 	return m
 }
 
 func (m model) Init() tea.Cmd {
-	return tick(m.tickRate)
+	return tea.Batch(tick(m.tickRate), m.loadEvents(), waitForChange(m.changes))
 }
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	relaxing := m.relaxing()
+	next, cmd := m.update(msg)
+	if n, ok := next.(model); ok && n.relaxing() != relaxing {
+		if relaxing {
+			n.leaveRelax(time.Now())
+		} else {
+			n.enterRelax(time.Now())
+		}
+		return n, cmd
+	}
+	return next, cmd
+}
+
+func (m model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		if m.view == "help" {
+			switch msg.String() {
+			case "up", "k":
+				m.scrollHelp(-1)
+			case "down", "j":
+				m.scrollHelp(1)
+			case "pgup":
+				m.scrollHelp(-m.helpPageSize())
+			case "pgdown":
+				m.scrollHelp(m.helpPageSize())
+			case "?", "esc":
+				m.view = m.prevView
+			case "q", "ctrl+c":
+				return m, tea.Quit
+			}
+			return m, nil
+		}
+		if msg.String() == "?" {
+			m.prevView = m.view
+			m.helpOffset = 0
+			m.view = "help"
+			return m, nil
+		}
+		if m.view == "sprint" {
+			switch msg.String() {
+			case "up", "k":
+				m.sprint.moveField(-1)
+				return m, nil
+			case "down", "j":
+				m.sprint.moveField(1)
+				return m, nil
+			case "left", "h":
+				if !m.sprint.running {
+					m.sprint.adjust(-1)
+				}
+				return m, nil
+			case "right", "l":
+				if !m.sprint.running {
+					m.sprint.adjust(1)
+				}
+				return m, nil
+			case "enter", " ":
+				m.toggleSprint(time.Now())
+				return m, nil
+			case "n":
+				if m.sprint.running {
+					m.skipPhase(time.Now())
+				}
+				return m, nil
+			case "+", "=":
+				if m.sprint.running {
+					m.extendPhase(time.Now(), sprintExtendStep)
+				}
+				return m, nil
+			case "p":
+				m.view = "main"
+				return m, nil
+			}
+		}
 		switch msg.String() {
 		case "q", "ctrl+c":
 			return m, tea.Quit
+		case "p":
+			m.view = "sprint"
+			return m, nil
+		case "s":
+			if m.view == "stats" {
+				m.view = "main"
+			} else {
+				m.view = "stats"
+			}
+			return m, nil
+		case "t":
+			if m.view == "day" {
+				m.view = "main"
+			} else {
+				m.view = "day"
+				m.dayOffset = 0
+			}
+			return m, nil
 		case "tab", "w":
 			if m.view == "main" {
 				m.view = "week"
+				m.weekOffset = 0
 			} else {
 				m.view = "main"
 			}
@@ -165,15 +300,51 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			return m, tick(m.tickRate)
 		case "left", "h", "a":
+			if m.view == "guide" {
+				m.guide.stretch = !m.guide.stretch
+				return m, nil
+			}
 			if m.view == "game" {
 				m.game.movePaddle(-1)
 				return m, nil
 			}
+			if m.view == "day" {
+				m.shiftDay(-1)
+				return m, nil
+			}
+			if m.view == "week" {
+				m.shiftWeek(-1)
+				return m, nil
+			}
 		case "right", "l", "d":
+			if m.view == "guide" {
+				m.guide.stretch = !m.guide.stretch
+				return m, nil
+			}
 			if m.view == "game" {
 				m.game.movePaddle(1)
 				return m, nil
 			}
+			if m.view == "day" {
+				m.shiftDay(1)
+				return m, nil
+			}
+			if m.view == "week" {
+				m.shiftWeek(1)
+				return m, nil
+			}
+		case "pgup", "pgdown", "home":
+			if m.view == "week" {
+				switch msg.String() {
+				case "pgup":
+					m.shiftWeek(-weeksPerPage)
+				case "pgdown":
+					m.shiftWeek(weeksPerPage)
+				default:
+					m.weekOffset = 0
+				}
+				return m, nil
+			}
 		case "r":
 			if m.view == "game" {
 				m.game.reset()
@@ -185,20 +356,23 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.game.reset()
 			return m, nil
 
+		case "o":
+			m.showRing = !m.showRing
+			return m, nil
 		case "+":
-			m.notice, m.err = changeGoal(m.statePath, goalStepMinutes)
+			m.notice, m.err = changeGoal(m.statePath, state.GoalStepMinutes)
 			m.reload(time.Now())
 			return m, tick(m.tickRate)
 		case "-":
-			m.notice, m.err = changeGoal(m.statePath, -goalStepMinutes)
+			m.notice, m.err = changeGoal(m.statePath, -state.GoalStepMinutes)
 			m.reload(time.Now())
 			return m, tick(m.tickRate)
 		case "[":
-			m.notice, m.err = changeBreak(m.statePath, -breakStepMinutes)
+			m.notice, m.err = changeBreak(m.statePath, -state.BreakStepMinutes)
 			m.reload(time.Now())
 			return m, tick(m.tickRate)
 		case "]":
-			m.notice, m.err = changeBreak(m.statePath, breakStepMinutes)
+			m.notice, m.err = changeBreak(m.statePath, state.BreakStepMinutes)
 			m.reload(time.Now())
 			return m, tick(m.tickRate)
 		}
@@ -207,12 +381,37 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.height = msg.Height
 	case tickMsg:
 		m.spin = (m.spin + 1) % len(spinnerRunFrames)
+		if m.st != nil && m.st.HeartbeatDue(time.Time(msg)) {
+			_ = daemon.Beat(m.statePath, time.Time(msg))
+			m.reload(time.Time(msg))
+		}
+		m.advanceSprint(time.Time(msg))
 		if m.view == "game" {
 			m.game.tick()
-		} else {
+		} else if m.changes == nil && state.ModTimes(m.statePath) != m.stamp || time.Time(msg).Sub(m.loadedAt) >= fallbackReload {
 			m.reload(time.Time(msg))
+		} else {
+			m.refresh(time.Time(msg))
+		}
+		if cmd := m.refreshEvents(time.Time(msg)); cmd != nil {
+			m.eventsAt = time.Time(msg)
+			return m, tea.Batch(tick(m.tickRate), cmd)
 		}
 		return m, tick(m.tickRate)
+	case eventsMsg:
+		if msg.source == m.calendarSource {
+			if msg.err != nil {
+				m.notice = fmt.Sprintf("calendar: %v", msg.err)
+			}
+			// A calendar with an unknown time zone still has its other events.
+			if msg.err == nil || msg.events != nil {
+				m.events = msg.events
+			}
+		}
+		return m, nil
+	case stateChangedMsg:
+		m.reload(time.Now())
+		return m, waitForChange(m.changes)
 	}
 	return m, nil
 }
@@ -236,13 +435,13 @@ func (m *model) execute(now time.Time) {
 				m.notice = note
 			}
 		}
-		note, err := startSession(m.statePath, now, nil, "")
+		note, err := startSession(m.statePath, now, nil, "", m.ruleEnv())
 		m.err = err
 		if note != "" {
 			m.notice = note
 		}
 	case actionStop:
-		note, err := stopSession(m.statePath, now)
+		note, err := stopSession(m.statePath, now, m.ruleEnv())
 		m.err = err
 		m.notice = note
 	case actionStatus:
@@ -266,14 +465,51 @@ func (m *model) execute(now time.Time) {
 	m.reload(now)
 }
 
+// reload reads the state file and recomputes the summary from it.
 func (m *model) reload(now time.Time) {
-	st, err := state.Load(m.statePath)
+	m.stamp = state.ModTimes(m.statePath)
+	st, err := daemon.Load(m.statePath)
 	if err != nil {
 		m.err = err
 		return
 	}
+	m.st = st
+	m.loadedAt = now
+	i18n.Use(st.Language, st.Clock)
+	notify.Use(st.NotifyConfig())
+	// `daily sprint skip` and `extend` move the published deadline.
+	if m.sprint.running && st.SprintPhaseEnd != nil && !st.SprintPhaseEnd.Equal(m.sprint.phaseEnd) {
+		m.sprint.phaseEnd = st.SprintPhaseEnd.Round(0)
+	}
+	m.refresh(now)
+}
+
+// refresh recomputes the summary from the cached state without touching disk
+// unless a countdown has to be finished.
+func (m *model) refresh(now time.Time) {
+	st := m.st
+	if st == nil {
+		return
+	}
+	countdownDone := ""
+	notice := st.CountdownNotice()
+	if _, ok := st.FinishCountdown(now); ok {
+		if err := daemon.Save(m.statePath, st); err != nil {
+			m.err = err
+			return
+		}
+		countdownDone = notice
+		if st.NotificationsOn() {
+			notify.Send("Daily", notice)
+		}
+	}
 	st.Normalize(now)
 	work, active := st.TodaySummary(now)
+	if st.CalendarSource != m.calendarSource {
+		m.calendarSource = st.CalendarSource
+		m.events = nil
+		m.eventsAt = time.Time{}
+	}
 	m.dayKey = now.Format("2006-01-02")
 	if m.dayKey != m.lastDay {
 		m.lastDay = m.dayKey
@@ -282,28 +518,35 @@ func (m *model) reload(now time.Time) {
 	m.summary = summary{
 		workMinutes:   work,
 		activeMinutes: active,
-		goalMinutes:   st.GoalMinutes,
 		breakMinutes:  st.BreakIntervalMinutes,
 	}
+	m.summary.goalMinutes, m.summary.carried = st.Goal(now)
 	if st.ActiveSession != nil {
 		m.summary.activeSince = &st.ActiveSession.Start
+		m.summary.continuous = st.ContinuousWork(now)
 		m.summary.activeSeconds = int(now.Sub(st.ActiveSession.Start).Seconds()) % 60
 	}
+	if left, ok := st.Remaining(now); ok {
+		m.summary.countdown = &left
+		m.summary.focusLabel, _ = st.FocusLabel()
+	}
+	if eta, ok := st.GoalETA(now); ok {
+		m.summary.goalETA = &eta
+	}
 	if st.ActiveBreak != nil {
 		m.summary.onBreak = true
 		breakDuration := now.Sub(st.ActiveBreak.Start)
 		m.summary.activeMinutes = int(breakDuration.Minutes())
 		m.summary.activeSeconds = int(breakDuration.Seconds()) % 60
+		if over, ok := st.OverBreak(now); ok {
+			m.summary.overBreak = &over
+		}
 	}
 	if log, ok := st.Days[m.dayKey]; ok {
 		m.summary.sessions = log.Sessions
 		m.summary.breakMinutes = log.TotalBreakMinutes
 		m.summary.breaksCount = log.BreakCount
-		if log.TotalWorkSeconds > 0 {
-			m.summary.workSeconds = log.TotalWorkSeconds
-		} else if log.TotalWorkMinutes > 0 {
-			m.summary.workSeconds = log.TotalWorkMinutes * 60
-		}
+		m.summary.workSeconds = log.WorkSeconds()
 	}
 	if m.summary.activeSince != nil {
 		m.summary.workSeconds += int(now.Sub(*m.summary.activeSince).Seconds())
@@ -315,6 +558,10 @@ func (m *model) reload(now time.Time) {
 			m.notice = fmt.Sprintf("Milestone reached: %s (%s)", state.HumanMinutes(theme.ThresholdMin), theme.Name)
 		}
 	}
+	if countdownDone != "" {
+		m.notice = countdownDone
+	}
+	m.followBreak()
 	m.loaded = true
 	m.err = nil
 }
@@ -330,6 +577,25 @@ func (m model) View() string {
 	if m.view == "game" {
 		return m.renderGame()
 	}
+	if m.view == "sprint" {
+		return m.renderSprint()
+	}
+	if m.view == "stats" {
+		return m.renderStats()
+	}
+	if m.view == "day" {
+		return m.renderDay()
+	}
+	if m.view == "help" {
+		return m.renderHelp()
+	}
+	if m.view == "guide" {
+		return m.renderGuide()
+	}
+
+	if m.compact() {
+		return m.renderCompact()
+	}
 
 	th := themeForMinutes(m.summary.workMinutes)
 
@@ -350,6 +616,14 @@ func (m model) View() string {
 		noticeLine = ""
 	}
 
+	agendaLine := m.renderAgenda(time.Now(), localHint)
+
+	progress := renderProgressBar(m.summary.workMinutes, m.summary.goalMinutes, th, progressBarWidth)
+	if m.showRing {
+		progress = renderRing(m.summary.workMinutes, m.summary.goalMinutes, th)
+	}
+	progress = lipgloss.NewStyle().MarginBottom(1).Render(progress)
+
 	// Fixed label width for alignment; arrows only on the selected row.
 	maxLabel := 0
 	for _, act := range m.actions {
@@ -376,11 +650,13 @@ func (m model) View() string {
 		}
 	}
 
-	hints := localHint.Render("+/- goal   [/] break   r relax   TAB week   ENTER select   q quit")
+	hints := localHint.Render(i18n.T("+/- goal   [/] break   p sprint   s stats   t day   TAB week   ENTER select   ? help   q quit"))
 
 	body := lipgloss.JoinVertical(lipgloss.Center,
 		title,
+		progress,
 		noticeLine,
+		agendaLine,
 		lipgloss.JoinVertical(lipgloss.Center, menuLines...),
 		hints,
 	)
@@ -402,13 +678,54 @@ func (m model) View() string {
 	return baseStyle.Render(view)
 }
 
+// renderAgenda lists the next few calendar events, if a calendar is configured.
+func (m model) renderAgenda(now time.Time, style lipgloss.Style) string {
+	upcoming := calendar.Upcoming(m.events, now, agendaLookahead)
+	if len(upcoming) == 0 {
+		return ""
+	}
+	if len(upcoming) > agendaMaxEntries {
+		upcoming = upcoming[:agendaMaxEntries]
+	}
+	parts := make([]string, 0, len(upcoming))
+	for _, ev := range upcoming {
+		label := i18n.Time(ev.Start)
+		if !ev.Start.After(now) {
+			label = "now"
+		}
+		parts = append(parts, fmt.Sprintf("%s %s", label, ev.Summary))
+	}
+	return style.MarginTop(0).MarginBottom(1).Render("Next: " + strings.Join(parts, "  ·  "))
+}
+
+// refreshEvents returns a command that reloads the calendar when it is stale.
+func (m model) refreshEvents(now time.Time) tea.Cmd {
+	if now.Sub(m.eventsAt) < calendarRefresh {
+		return nil
+	}
+	return m.loadEvents()
+}
+
+// loadEvents returns a command that loads the calendar, if one is set.
+func (m model) loadEvents() tea.Cmd {
+	if m.calendarSource == "" {
+		return nil
+	}
+	source := m.calendarSource
+	return func() tea.Msg {
+		events, err := calendar.Load(source)
+		return eventsMsg{source: source, events: events, err: err}
+	}
+}
+
 func (m model) renderGame() string {
 	th := themeForMinutes(m.summary.workMinutes)
 	title := titleStyle.Foreground(th.Accent).Render("BLOCK BREAKER")
 	subtitle := hintStyle.Foreground(th.Muted).Render("←/→ move  SPACE launch  r reset  esc back")
+	counter := hintStyle.Foreground(th.Muted).Render(m.relaxStatus(time.Now()))
 
 	gameBoard := m.game.render()
-	body := lipgloss.JoinVertical(lipgloss.Center, title, subtitle, gameBoard)
+	body := lipgloss.JoinVertical(lipgloss.Center, title, subtitle, gameBoard, counter)
 	if m.width > 0 && m.height > 0 {
 		body = lipgloss.Place(m.width, m.height-statusBarHeight, lipgloss.Center, lipgloss.Center, body)
 	}
@@ -476,17 +793,20 @@ func renderSpinnerGrid(colors [4]lipgloss.Color) string {
 }
 
 func (m model) renderStatusBar() string {
+	if m.compact() {
+		return m.renderCompactStatus()
+	}
 	running := m.summary.activeMinutes > 0 || m.summary.activeSince != nil
-	statusText := "PAUSED"
+	statusText := i18n.T("PAUSED")
 	statusStyle := statusDim
 	spin := spinnerDimFrame
 
 	if m.summary.onBreak {
-		statusText = "BREAK"
+		statusText = i18n.T("BREAK")
 		statusStyle = statusBreak
 		spin = spinnerDimFrame
 	} else if running {
-		statusText = "RUNNING"
+		statusText = i18n.T("RUNNING")
 		statusStyle = statusRun
 		spin = spinnerRunFrames[m.spin]
 	}
@@ -498,9 +818,9 @@ func (m model) renderStatusBar() string {
 	activeStr := fmt.Sprintf("^ %d MIN", workMinutes)
 	secText := fmt.Sprintf("~ %02d SEC", seconds)
 	secStr := statusHalf.Render(secText)
-	breakStr := fmt.Sprintf("%d BREAKS", m.summary.breaksCount)
+	breakStr := i18n.T("%d BREAKS", m.summary.breaksCount)
 
-	return lipgloss.JoinHorizontal(lipgloss.Center,
+	parts := []string{
 		spin,
 		" ",
 		statusStyle.Render(statusText),
@@ -512,70 +832,45 @@ func (m model) renderStatusBar() string {
 		secStr,
 		"  ",
 		statusDim.Render(breakStr),
-	)
-}
-
-func (m model) renderWeek() string {
-	st, err := state.Load(m.statePath)
-	if err != nil {
-		return baseStyle.Render(errorStyle.Render(err.Error()))
 	}
-	keys := make([]string, 0, len(st.Days))
-	for k := range st.Days {
-		keys = append(keys, k)
+	if m.summary.continuous >= time.Minute {
+		parts = append(parts, "  ", statusDim.Render(i18n.T("%s STRAIGHT", strings.ToUpper(state.HumanMinutes(int(m.summary.continuous.Minutes()))))))
 	}
-	if len(keys) == 0 {
-		return baseStyle.Render("no history yet (TAB to main)")
+	if label := m.sprintStatus(time.Now()); label != "" {
+		parts = append(parts, "  ", statusRun.Render(label))
 	}
-	sort.Strings(keys)
-	if len(keys) > 7 {
-		keys = keys[len(keys)-7:]
+	if m.summary.overBreak != nil {
+		parts = append(parts, "  ", statusBreak.Render(i18n.T("+%s OVER BREAK", state.Clock(*m.summary.overBreak))))
 	}
-
-	maxWork := 0
-	for _, k := range keys {
-		if st.Days[k].TotalWorkMinutes > maxWork {
-			maxWork = st.Days[k].TotalWorkMinutes
-		}
+	if c := m.summary.carried; c > 0 {
+		parts = append(parts, "  ", statusDim.Render(i18n.T("%s BANKED", strings.ToUpper(state.HumanMinutes(c)))))
+	} else if c < 0 {
+		parts = append(parts, "  ", statusDim.Render(i18n.T("%s TO MAKE UP", strings.ToUpper(state.HumanMinutes(-c)))))
 	}
-	if maxWork == 0 {
-		maxWork = 1
+	if m.summary.goalETA != nil {
+		parts = append(parts, "  ", statusDim.Render(i18n.T("GOAL ~%s", i18n.Time(*m.summary.goalETA))))
 	}
+	if m.summary.countdown != nil {
+		left := int(m.summary.countdown.Seconds())
+		if label := m.summary.focusLabel; label != "" {
+			parts = append(parts, "  ", statusRun.Render(strings.ToUpper(state.ShortLabel(label))))
+		}
+		parts = append(parts, "  ", statusRun.Render(fmt.Sprintf("%02d:%02d LEFT", left/60, left%60)))
+	}
+	return lipgloss.JoinHorizontal(lipgloss.Center, parts...)
+}
 
-	barWidth := 24
-	lines := make([]string, 0, len(keys))
-	for _, k := range keys {
-		log := st.Days[k]
-		th := themeForMinutes(log.TotalWorkMinutes)
-		dateStyle := weekDateStyle.Foreground(th.Accent)
-		barStyle := weekBarStyle.Foreground(th.Accent)
-		valueStyle := weekValueStyle.Foreground(th.Muted)
-
-		barLen := int(float64(log.TotalWorkMinutes) / float64(maxWork) * float64(barWidth))
-		if barLen < 1 && log.TotalWorkMinutes > 0 {
-			barLen = 1
+// waitForChange returns a command that blocks until the state file changes.
+func waitForChange(changes <-chan struct{}) tea.Cmd {
+	if changes == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		if _, ok := <-changes; !ok {
+			return nil
 		}
-		bar := barStyle.Render(strings.Repeat("█", barLen))
-		info := valueStyle.Render(fmt.Sprintf("%s  %d breaks  %s brk",
-			state.HumanMinutes(log.TotalWorkMinutes),
-			log.BreakCount,
-			state.HumanMinutes(log.TotalBreakMinutes),
-		))
-		line := lipgloss.JoinHorizontal(lipgloss.Left,
-			dateStyle.Render(k),
-			bar,
-			info,
-		)
-		lines = append(lines, line)
-	}
-
-	hints := hintStyle.Render("TAB back   q quit")
-	body := lipgloss.JoinVertical(lipgloss.Left, lines...)
-	if m.width > 0 && m.height > 0 {
-		body = lipgloss.Place(m.width, m.height-statusBarHeight, lipgloss.Center, lipgloss.Center, body)
+		return stateChangedMsg{}
 	}
-	view := lipgloss.JoinVertical(lipgloss.Left, body, hints)
-	return baseStyle.Render(view)
 }
 
 func tick(d time.Duration) tea.Cmd {
@@ -584,62 +879,81 @@ func tick(d time.Duration) tea.Cmd {
 	})
 }
 
-func startSession(path string, now time.Time, tags []string, note string) (string, error) {
-	st, err := state.Load(path)
+func startSession(path string, now time.Time, tags []string, note string, env state.RuleEnv) (string, error) {
+	st, err := daemon.Load(path)
 	if err != nil {
 		return "", err
 	}
+	st.RuleEnv = env
+	if err := st.CheckCap(now); err != nil {
+		return "", err
+	}
 	if err := st.StartSession(now, tags, note); err != nil {
 		return "", err
 	}
-	if err := st.Save(path); err != nil {
+	if err := daemon.Save(path, st); err != nil {
 		return "", err
 	}
-	return fmt.Sprintf("Started at %s", now.Format(time.Kitchen)), nil
+	return i18n.T("Started at %s", i18n.Time(now)), nil
 }
 
-func stopSession(path string, now time.Time) (string, error) {
-	st, err := state.Load(path)
+func stopSession(path string, now time.Time, env state.RuleEnv) (string, error) {
+	st, err := daemon.Load(path)
 	if err != nil {
 		return "", err
 	}
-	mins, err := st.StopSession(now)
-	if err != nil {
+	st.RuleEnv = env
+	var secs int
+	if st.ActiveSession != nil {
+		secs = st.ActiveSession.Seconds(now)
+	}
+	if _, err := st.StopSession(now); err != nil {
 		return "", err
 	}
-	if err := st.Save(path); err != nil {
+	if err := daemon.Save(path, st); err != nil {
 		return "", err
 	}
-	return fmt.Sprintf("Stopped (%s)", state.HumanMinutes(mins)), nil
+	return fmt.Sprintf("Stopped (%s)", state.HumanSeconds(secs)), nil
 }
 
 func startBreak(path string, now time.Time) (string, error) {
-	st, err := state.Load(path)
+	st, err := daemon.Load(path)
 	if err != nil {
 		return "", err
 	}
 	if err := st.StartBreak(now); err != nil {
 		return "", err
 	}
-	if err := st.Save(path); err != nil {
+	if err := daemon.Save(path, st); err != nil {
 		return "", err
 	}
-	return fmt.Sprintf("Break started %s", now.Format(time.Kitchen)), nil
+	return i18n.T("Break started %s", i18n.Time(now)), nil
 }
 
 func stopBreak(path string, now time.Time) (string, error) {
-	st, err := state.Load(path)
+	st, err := daemon.Load(path)
 	if err != nil {
 		return "", err
 	}
-	mins, err := st.StopBreak(now)
-	if err != nil {
+	var secs int
+	if st.ActiveBreak != nil {
+		secs = st.ActiveBreak.Seconds(now)
+	}
+	if _, err := st.StopBreak(now); err != nil {
 		return "", err
 	}
-	if err := st.Save(path); err != nil {
+	if err := daemon.Save(path, st); err != nil {
 		return "", err
 	}
-	return fmt.Sprintf("Break ended (%s)", state.HumanMinutes(mins)), nil
+	return fmt.Sprintf("Break ended (%s)", state.HumanSeconds(secs)), nil
+}
+
+// ruleEnv gives tag rules the directory the dashboard was started in and the
+// calendar as last fetched.
+func (m model) ruleEnv() state.RuleEnv {
+	dir, _ := os.Getwd()
+	events := m.events
+	return state.RuleEnv{Dir: dir, Events: func() []calendar.Event { return events }}
 }
 
 // themeForMinutes selects the active theme based on minutes worked.
@@ -655,32 +969,24 @@ func themeForMinutes(mins int) milestoneTheme {
 }
 
 func changeGoal(path string, delta int) (string, error) {
-	st, err := state.Load(path)
+	st, err := daemon.Load(path)
 	if err != nil {
 		return "", err
 	}
-	newVal := st.GoalMinutes + delta
-	if newVal < minGoalMinutes {
-		newVal = minGoalMinutes
-	}
-	st.GoalMinutes = newVal
-	if err := st.Save(path); err != nil {
+	newVal := st.AdjustGoal(delta)
+	if err := daemon.Save(path, st); err != nil {
 		return "", err
 	}
 	return fmt.Sprintf("Goal set to %s", state.HumanMinutes(newVal)), nil
 }
 
 func changeBreak(path string, delta int) (string, error) {
-	st, err := state.Load(path)
+	st, err := daemon.Load(path)
 	if err != nil {
 		return "", err
 	}
-	newVal := st.BreakIntervalMinutes + delta
-	if newVal < minBreakMinutes {
-		newVal = minBreakMinutes
-	}
-	st.BreakIntervalMinutes = newVal
-	if err := st.Save(path); err != nil {
+	newVal := st.AdjustBreakInterval(delta)
+	if err := daemon.Save(path, st); err != nil {
 		return "", err
 	}
 	return fmt.Sprintf("Break every %s", state.HumanMinutes(newVal)), nil
@@ -689,15 +995,15 @@ func changeBreak(path string, delta int) (string, error) {
 func actionLabel(action string) string {
 	switch action {
 	case actionStart:
-		return "START"
+		return i18n.T("START")
 	case actionStop:
-		return "STOP"
+		return i18n.T("STOP")
 	case actionStatus:
-		return "STATUS"
+		return i18n.T("STATUS")
 	case actionBreak:
-		return "BREAK"
+		return i18n.T("BREAK")
 	case actionRelax:
-		return "RELAX"
+		return i18n.T("RELAX")
 	default:
 		return action
 	}
@@ -715,6 +1021,7 @@ type gameState struct {
 	ballLaunched bool
 	bricks       [][]bool
 	score        int
+	best         int // best score since relax mode was entered
 	lives        int
 	message      string
 }
@@ -842,6 +1149,7 @@ func (g *gameState) checkBrickCollision(x, y int) bool {
 			if x >= bx && x < bx+brickWidth && y == by {
 				g.bricks[r][c] = false
 				g.score += 10
+				g.best = max(g.best, g.score)
 				if g.allBricksCleared() {
 					g.message = "You cleared all bricks! Press r"
 					g.ballLaunched = false
@@ -0,0 +1,79 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/max-pantom/daily/internal/state"
+)
+
+const progressBarWidth = 32
+
+// goalPercent returns work as a percentage of the goal, capped at 100.
+func goalPercent(work, goal int) int {
+	if goal <= 0 {
+		return 0
+	}
+	p := work * 100 / goal
+	if p > 100 {
+		p = 100
+	}
+	return p
+}
+
+// renderProgressBar draws a horizontal bar of the daily goal with a caption.
+func renderProgressBar(work, goal int, th milestoneTheme, width int) string {
+	percent := goalPercent(work, goal)
+	filled := percent * width / 100
+	if filled == 0 && work > 0 {
+		filled = 1
+	}
+	bar := lipgloss.NewStyle().Foreground(th.Accent).Render(strings.Repeat("█", filled)) +
+		lipgloss.NewStyle().Foreground(th.SelectedBg).Render(strings.Repeat("█", width-filled))
+	caption := lipgloss.NewStyle().Foreground(th.Muted).Render(
+		fmt.Sprintf("%s / %s  %d%%", state.HumanMinutes(work), state.HumanMinutes(goal), percent))
+	return lipgloss.JoinVertical(lipgloss.Center, bar, caption)
+}
+
+// ringPoints are the 12 clock positions (row, col) on a 5x11 grid, starting at 12 o'clock.
+var ringPoints = [12][2]int{
+	{0, 5}, {0, 8}, {1, 10}, {2, 10}, {3, 10}, {4, 8},
+	{4, 5}, {4, 2}, {3, 0}, {2, 0}, {1, 0}, {0, 2},
+}
+
+// renderRing draws the goal as a clock-style ring that fills clockwise.
+func renderRing(work, goal int, th milestoneTheme) string {
+	percent := goalPercent(work, goal)
+	filled := (percent*len(ringPoints) + 99) / 100
+	on := lipgloss.NewStyle().Foreground(th.Accent)
+	off := lipgloss.NewStyle().Foreground(th.SelectedBg)
+
+	grid := make([][]string, 5)
+	for r := range grid {
+		grid[r] = make([]string, 11)
+		for c := range grid[r] {
+			grid[r][c] = " "
+		}
+	}
+	for i, pt := range ringPoints {
+		if i < filled {
+			grid[pt[0]][pt[1]] = on.Render("●")
+		} else {
+			grid[pt[0]][pt[1]] = off.Render("●")
+		}
+	}
+	label := fmt.Sprintf("%3d%%", percent)
+	for i, ch := range label {
+		grid[2][3+i] = on.Render(string(ch))
+	}
+
+	lines := make([]string, len(grid))
+	for r, row := range grid {
+		lines[r] = strings.Join(row, "")
+	}
+	caption := lipgloss.NewStyle().Foreground(th.Muted).Render(
+		fmt.Sprintf("%s / %s", state.HumanMinutes(work), state.HumanMinutes(goal)))
+	return lipgloss.JoinVertical(lipgloss.Center, strings.Join(lines, "\n"), caption)
+}
@@ -0,0 +1,102 @@
+package tui
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/max-pantom/daily/internal/daemon"
+	"github.com/max-pantom/daily/internal/state"
+)
+
+// relaxMode is a visit to the Block Breaker screen. A session running when it
+// began is turned into a break for its length, so playing doesn't count as
+// work, and resumed afterwards.
+type relaxMode struct {
+	since  time.Time      // zero when not relaxing
+	resume *state.Session // the session the break interrupted, if any
+}
+
+// relaxing reports whether the game is on screen, or behind the help.
+func (m model) relaxing() bool {
+	return m.view == "game" || m.view == "help" && m.prevView == "game"
+}
+
+// enterRelax starts counting relax time, and puts a running session on a
+// break unless something else, such as a sprint, controls it.
+func (m *model) enterRelax(now time.Time) {
+	now = now.Round(0)
+	m.relax = relaxMode{since: now}
+	m.game.best = 0
+	if m.st == nil || m.st.ActiveSession == nil || m.st.Holder(now) != "" {
+		return
+	}
+	var resume *state.Session
+	err := daemon.Update(m.statePath, func(st *state.State) error {
+		resume = nil
+		if st.ActiveSession == nil || st.Holder(now) != "" {
+			return nil
+		}
+		s := *st.ActiveSession
+		resume = &s
+		return st.StartBreak(now)
+	})
+	if err != nil {
+		m.err = err
+		return
+	}
+	m.relax.resume = resume
+	if resume != nil {
+		m.notice = "Relax mode: Block Breaker (on a break until you leave)"
+	}
+	m.reload(now)
+}
+
+// leaveRelax records the relax time and the best score, and resumes the
+// session enterRelax paused if its break is still the one running.
+func (m *model) leaveRelax(now time.Time) {
+	r := m.relax
+	m.relax = relaxMode{}
+	if r.since.IsZero() {
+		return
+	}
+	resumed := false
+	err := daemon.Update(m.statePath, func(st *state.State) error {
+		resumed = false
+		st.RecordRelax(r.since, int(now.Sub(r.since).Seconds()), m.game.best)
+		b := st.ActiveBreak
+		if r.resume == nil || b == nil || !b.Start.Equal(r.since) || st.ActiveSession != nil {
+			return nil
+		}
+		if _, err := st.StopBreak(now); err != nil {
+			return err
+		}
+		if err := st.StartSession(now, r.resume.Tags, r.resume.Note); err != nil {
+			return err
+		}
+		st.ActiveSession.Project = r.resume.Project
+		resumed = true
+		return nil
+	})
+	if err != nil {
+		m.err = err
+		return
+	}
+	if resumed {
+		m.notice = fmt.Sprintf("Back to work after %s of relax", state.HumanSeconds(int(now.Sub(r.since).Seconds())))
+	}
+	m.reload(now)
+}
+
+// relaxStatus is the counter under the game: today's relax time, including
+// this visit, and the day's best score.
+func (m model) relaxStatus(now time.Time) string {
+	var secs, best int
+	if m.st != nil {
+		secs, best = m.st.Relax(now)
+	}
+	if !m.relax.since.IsZero() {
+		secs += int(now.Sub(m.relax.since).Seconds())
+	}
+	best = max(best, m.game.best)
+	return fmt.Sprintf("Relaxed %s today  ·  Best today %d", state.HumanSeconds(secs), best)
+}
@@ -0,0 +1,286 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/max-pantom/daily/internal/daemon"
+	"github.com/max-pantom/daily/internal/i18n"
+	"github.com/max-pantom/daily/internal/state"
+)
+
+// reviewRow is one session listed by the review, addressed by day and index.
+type reviewRow struct {
+	day   string
+	index int
+	start time.Time
+}
+
+// reviewModel walks through the last few days so sessions can be tagged,
+// annotated and assigned to projects after the fact.
+type reviewModel struct {
+	statePath string
+	st        *state.State
+	keys      []string // days under review, oldest first
+	rows      []reviewRow
+	cursor    int
+
+	field string // "tags", "note" or "project" while editing
+	input []rune
+
+	notice string
+	err    error
+	width  int
+	height int
+}
+
+// RunReview opens the weekly review form for the days days before now.
+func RunReview(statePath string, days int, now time.Time) error {
+	if days < 1 {
+		days = 7
+	}
+	m := reviewModel{statePath: statePath}
+	for i := days; i >= 1; i-- {
+		m.keys = append(m.keys, now.AddDate(0, 0, -i).Format("2006-01-02"))
+	}
+	if err := m.reload(); err != nil {
+		return err
+	}
+	useTheme(m.st.Theme)
+	_, err := tea.NewProgram(m, tea.WithAltScreen()).Run()
+	return err
+}
+
+func (m *reviewModel) reload() error {
+	st, err := daemon.Load(m.statePath)
+	if err != nil {
+		return err
+	}
+	m.st = st
+	m.rows = m.rows[:0]
+	for _, key := range m.keys {
+		if log, ok := st.Days[key]; ok {
+			for i, sess := range log.Sessions {
+				m.rows = append(m.rows, reviewRow{day: key, index: i, start: sess.Start})
+			}
+		}
+	}
+	m.cursor = clampInt(m.cursor, 0, max(len(m.rows)-1, 0))
+	return nil
+}
+
+func (m reviewModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m reviewModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+	case tea.KeyMsg:
+		if m.field != "" {
+			m.edit(msg)
+			return m, nil
+		}
+		switch msg.String() {
+		case "q", "ctrl+c", "esc":
+			return m, tea.Quit
+		case "up", "k":
+			m.cursor = clampInt(m.cursor-1, 0, max(len(m.rows)-1, 0))
+		case "down", "j":
+			m.cursor = clampInt(m.cursor+1, 0, max(len(m.rows)-1, 0))
+		case "t":
+			m.startEdit("tags")
+		case "n":
+			m.startEdit("note")
+		case "p":
+			m.startEdit("project")
+		}
+	}
+	return m, nil
+}
+
+func (m *reviewModel) session() (state.Session, bool) {
+	if m.cursor >= len(m.rows) {
+		return state.Session{}, false
+	}
+	row := m.rows[m.cursor]
+	return m.st.Days[row.day].Sessions[row.index], true
+}
+
+func (m *reviewModel) startEdit(field string) {
+	sess, ok := m.session()
+	if !ok {
+		return
+	}
+	m.notice, m.err = "", nil
+	m.field = field
+	switch field {
+	case "tags":
+		m.input = []rune(strings.Join(sess.Tags, ", "))
+	case "note":
+		m.input = []rune(sess.Note)
+	case "project":
+		m.input = []rune(sess.Project)
+	}
+}
+
+func (m *reviewModel) edit(msg tea.KeyMsg) {
+	switch msg.Type {
+	case tea.KeyEsc, tea.KeyCtrlC:
+		m.field = ""
+	case tea.KeyEnter:
+		m.err = m.save()
+		if m.err == nil {
+			m.notice = "Saved " + m.field
+			m.err = m.reload()
+		}
+		m.field = ""
+	case tea.KeyBackspace:
+		if len(m.input) > 0 {
+			m.input = m.input[:len(m.input)-1]
+		}
+	case tea.KeyCtrlU:
+		m.input = nil
+	case tea.KeySpace:
+		m.input = append(m.input, ' ')
+	case tea.KeyRunes:
+		m.input = append(m.input, msg.Runes...)
+	}
+}
+
+// save writes the edited field back, refusing if the session moved meanwhile.
+func (m *reviewModel) save() error {
+	row := m.rows[m.cursor]
+	value := strings.TrimSpace(string(m.input))
+	field := m.field
+	return daemon.Update(m.statePath, func(st *state.State) error {
+		log, ok := st.Days[row.day]
+		if !ok || row.index >= len(log.Sessions) || !log.Sessions[row.index].Start.Equal(row.start) {
+			return errors.New("session changed elsewhere; review again")
+		}
+		sess := &log.Sessions[row.index]
+		log.AutoStopped = nil // the day has been looked at
+		switch field {
+		case "tags":
+			tags := splitTags(value)
+			if err := st.CheckTags(tags); err != nil {
+				return err
+			}
+			sess.Tags = tags
+		case "note":
+			sess.Note = value
+		case "project":
+			sess.Project = value
+		}
+		return nil
+	})
+}
+
+// splitTags reads a comma or space separated tag list.
+func splitTags(v string) []string {
+	return strings.FieldsFunc(v, func(r rune) bool { return r == ',' || r == ' ' })
+}
+
+func (m reviewModel) View() string {
+	th := milestoneThemes[0]
+	muted := lipgloss.NewStyle().Foreground(th.Muted)
+	accent := lipgloss.NewStyle().Foreground(th.Accent)
+	value := lipgloss.NewStyle().Foreground(lipgloss.Color("#e4e4e4"))
+
+	met, untagged := 0, 0
+	var lines []string
+	cursorLine := 0
+	row := 0
+	for _, key := range m.keys {
+		log := m.st.Days[key]
+		goal := m.st.DayGoal(key)
+		work := 0
+		if log != nil {
+			work = log.WorkSeconds()
+		}
+		dayUntagged := 0
+		if log != nil {
+			for _, sess := range log.Sessions {
+				if len(sess.Tags) == 0 {
+					dayUntagged += sess.Seconds(time.Now())
+				}
+			}
+		}
+		untagged += dayUntagged
+		mark := ""
+		if goal > 0 && work >= goal*60 {
+			met++
+			mark = " ✓"
+		}
+		t, _ := time.ParseInLocation("2006-01-02", key, time.Local)
+		header := fmt.Sprintf("%s %s  %s / %s%s", t.Format("Mon"), key, state.HumanMinutes(work/60), state.HumanMinutes(goal), mark)
+		if dayUntagged > 0 {
+			header += "  untagged " + state.HumanMinutes(dayUntagged/60)
+		}
+		if log != nil && log.AutoStopped != nil {
+			header += "  ⚑ auto-stopped " + i18n.Time(*log.AutoStopped)
+		}
+		lines = append(lines, "", accent.Render(header))
+		if log == nil || len(log.Sessions) == 0 {
+			lines = append(lines, muted.Render("    no sessions"))
+			continue
+		}
+		for _, sess := range log.Sessions {
+			end := "--"
+			if sess.End != nil {
+				end = i18n.Time(*sess.End)
+			}
+			text := fmt.Sprintf("%s–%s  %-6s", i18n.Time(sess.Start), end, state.HumanSeconds(sess.Seconds(time.Now())))
+			if sess.Project != "" {
+				text += "  [" + sess.Project + "]"
+			}
+			if len(sess.Tags) > 0 {
+				text += "  #" + strings.Join(sess.Tags, " #")
+			} else {
+				text += "  (untagged)"
+			}
+			if sess.Note != "" {
+				text += "  " + sess.Note
+			}
+			if row == m.cursor {
+				cursorLine = len(lines)
+				lines = append(lines, accent.Render("  ▶ ")+value.Render(text))
+			} else {
+				lines = append(lines, "    "+muted.Render(text))
+			}
+			row++
+		}
+	}
+
+	title := titleStyle.Copy().MarginBottom(0).Render(fmt.Sprintf("WEEKLY REVIEW · %s – %s", m.keys[0], m.keys[len(m.keys)-1]))
+	summary := muted.Render(fmt.Sprintf("goal met %d/%d days · untagged %s", met, len(m.keys), state.HumanMinutes(untagged/60)))
+
+	footer := hintStyle.Render("↑/↓ session   t tags   n note   p project   q quit")
+	if m.field != "" {
+		footer = accent.Render(fmt.Sprintf("%s: %s█", m.field, string(m.input))) + "\n" +
+			hintStyle.Copy().MarginTop(0).Render("ENTER save   esc cancel   ctrl+u clear")
+	}
+	status := ""
+	if m.err != nil {
+		status = errorStyle.Render("error: " + m.err.Error())
+	} else if m.notice != "" {
+		status = noticeStyle.Render(m.notice)
+	}
+
+	// Keep the selected session on screen when the list is taller than the window.
+	if avail := m.height - 8; avail > 0 && len(lines) > avail {
+		start := clampInt(cursorLine-avail/2, 0, len(lines)-avail)
+		lines = lines[start : start+avail]
+	}
+	body := lipgloss.JoinVertical(lipgloss.Left, title, summary, strings.Join(lines, "\n"), status, footer)
+	if m.width > 0 && m.height > 0 {
+		return baseStyle.Width(m.width).Height(m.height).Render(body)
+	}
+	return baseStyle.Render(body)
+}
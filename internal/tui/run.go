@@ -1,12 +1,35 @@
 package tui
 
 import (
+	"time"
+
 	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/max-pantom/daily/internal/daemon"
 )
 
 // Run launches the TUI dashboard.
 func Run(statePath string) error {
-	p := tea.NewProgram(newModel(statePath), tea.WithAltScreen())
-	_, err := p.Run()
+	m := newModel(statePath)
+	if m.st != nil {
+		useTheme(m.st.Theme)
+	}
+	if w, err := daemon.Watch(statePath); err == nil {
+		defer w.Close()
+		m.changes = w.Changes()
+	}
+	p := tea.NewProgram(m, tea.WithAltScreen())
+	final, err := p.Run()
+	if fm, ok := final.(model); ok {
+		if fm.relaxing() {
+			fm.leaveRelax(time.Now())
+		}
+		// A sprint ends with the dashboard; don't leave the session leased to it.
+		if fm.sprint.running {
+			fm.sprint.running = false
+			fm.publishPhase()
+		}
+	}
+	_ = daemon.EndHeartbeat(statePath)
 	return err
 }
@@ -0,0 +1,408 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/max-pantom/daily/internal/daemon"
+	"github.com/max-pantom/daily/internal/i18n"
+	"github.com/max-pantom/daily/internal/notify"
+	"github.com/max-pantom/daily/internal/sound"
+	"github.com/max-pantom/daily/internal/state"
+)
+
+type sprintProfile struct {
+	name   string
+	work   int
+	brk    int
+	cycles int
+}
+
+var sprintProfiles = []sprintProfile{
+	{name: "classic", work: 25, brk: 5, cycles: 4},
+	{name: "deep", work: 50, brk: 10, cycles: 4},
+	{name: "long", work: 90, brk: 15, cycles: 2},
+}
+
+const (
+	sprintFieldProfile = iota
+	sprintFieldWork
+	sprintFieldBreak
+	sprintFieldCycles
+	sprintFieldCount
+)
+
+const (
+	phaseWork  = "work"
+	phaseBreak = "break"
+)
+
+// sprintPanel holds the picker values and, once launched, the running sprint.
+type sprintPanel struct {
+	field   int
+	profile int // index into sprintProfiles, -1 for custom
+	work    int
+	brk     int
+	cycles  int
+
+	running  bool
+	cycle    int
+	phase    string
+	phaseEnd time.Time
+	owner    string    // lease holder name while running, e.g. "ui sprint 4242"
+	renewed  time.Time // when the lease was last renewed
+}
+
+// sprintLeaseRenew is how often a running sprint renews its hold on the
+// session, well within state.LeaseTTL.
+const sprintLeaseRenew = time.Minute
+
+func newSprintPanel() sprintPanel {
+	p := sprintPanel{}
+	p.applyProfile(1)
+	return p
+}
+
+func (p *sprintPanel) applyProfile(i int) {
+	n := len(sprintProfiles)
+	i = (i + n) % n
+	p.profile = i
+	p.work = sprintProfiles[i].work
+	p.brk = sprintProfiles[i].brk
+	p.cycles = sprintProfiles[i].cycles
+}
+
+func (p *sprintPanel) moveField(delta int) {
+	p.field = (p.field + delta + sprintFieldCount) % sprintFieldCount
+}
+
+func (p *sprintPanel) adjust(delta int) {
+	switch p.field {
+	case sprintFieldProfile:
+		start := p.profile
+		if start < 0 {
+			start = 0
+		}
+		p.applyProfile(start + delta)
+		return
+	case sprintFieldWork:
+		p.work = clampInt(p.work+delta*5, 5, 180)
+	case sprintFieldBreak:
+		p.brk = clampInt(p.brk+delta, 1, 60)
+	case sprintFieldCycles:
+		p.cycles = clampInt(p.cycles+delta, 1, 12)
+	}
+	p.profile = -1
+	for i, pr := range sprintProfiles {
+		if pr.work == p.work && pr.brk == p.brk && pr.cycles == p.cycles {
+			p.profile = i
+		}
+	}
+}
+
+func (p sprintPanel) profileName() string {
+	if p.profile < 0 {
+		return "custom"
+	}
+	return sprintProfiles[p.profile].name
+}
+
+func clampInt(v, lo, hi int) int {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
+
+// toggleSprint launches the configured sprint, or stops the running one.
+func (m *model) toggleSprint(now time.Time) {
+	m.notice = ""
+	m.err = nil
+	if m.sprint.running {
+		if m.summary.onBreak {
+			_, m.err = stopBreak(m.statePath, now)
+		} else if m.summary.activeSince != nil {
+			_, m.err = stopSession(m.statePath, now, m.ruleEnv())
+		}
+		m.sprint.running = false
+		m.publishPhase()
+		m.notice = "Sprint stopped"
+		m.reload(now)
+		return
+	}
+	owner := fmt.Sprintf("ui sprint %d", os.Getpid())
+	if err := daemon.Update(m.statePath, func(st *state.State) error { return st.Acquire(owner, now) }); err != nil {
+		m.err = err
+		return
+	}
+	m.sprint.owner, m.sprint.renewed = owner, now
+	if m.summary.onBreak {
+		if _, err := stopBreak(m.statePath, now); err != nil {
+			m.err = err
+			m.publishPhase()
+			return
+		}
+	}
+	if m.summary.activeSince == nil {
+		if _, err := startSession(m.statePath, now, nil, "", m.ruleEnv()); err != nil {
+			m.err = err
+			m.publishPhase()
+			return
+		}
+	}
+	m.sprint.running = true
+	m.sprint.cycle = 1
+	m.sprint.phase = phaseWork
+	// Deadlines carry no monotonic reading, so they are compared with the wall
+	// clock, which keeps running while the machine sleeps.
+	m.sprint.phaseEnd = now.Round(0).Add(time.Duration(m.sprint.work) * time.Minute)
+	m.publishPhase()
+	m.notice = fmt.Sprintf("Sprint started: %d×%dm work / %dm break", m.sprint.cycles, m.sprint.work, m.sprint.brk)
+	m.sprintNotify("Cycle 1 work started")
+	m.reload(now)
+}
+
+// advanceSprint moves the running sprint to its next phase when the current one is over.
+func (m *model) advanceSprint(now time.Time) {
+	if !m.sprint.running || m.holdSprint(now) || now.Before(m.sprint.phaseEnd) {
+		return
+	}
+	// After a suspend the phase is closed when it was due, not on waking; the
+	// break then starts there too, as the time asleep was time away.
+	end := m.sprint.phaseEnd
+	switch m.sprint.phase {
+	case phaseWork:
+		if _, err := startBreak(m.statePath, end); err != nil {
+			m.err = err
+		}
+		m.sprint.phase = phaseBreak
+		m.sprint.phaseEnd = end.Add(time.Duration(m.sprint.brk) * time.Minute)
+		m.notice = fmt.Sprintf("Cycle %d break", m.sprint.cycle)
+		m.sprintNotify(m.notice)
+		m.sprintSound(sound.WorkEnd)
+	case phaseBreak:
+		if _, err := stopBreak(m.statePath, end); err != nil {
+			m.err = err
+		}
+		m.sprintSound(sound.BreakEnd)
+		if m.sprint.cycle >= m.sprint.cycles {
+			m.sprint.running = false
+			m.notice = "Sprint finished"
+			m.sprintNotify(m.notice)
+			break
+		}
+		m.sprint.cycle++
+		if _, err := startSession(m.statePath, now, nil, "", m.ruleEnv()); err != nil {
+			m.err = err
+		}
+		m.sprint.phase = phaseWork
+		m.sprint.phaseEnd = now.Round(0).Add(time.Duration(m.sprint.work) * time.Minute)
+		m.notice = fmt.Sprintf("Cycle %d work started", m.sprint.cycle)
+		m.sprintNotify(m.notice)
+	}
+	m.publishPhase()
+	m.reload(now)
+}
+
+// holdSprint renews the running sprint's lease when due and acts on what
+// others asked of it: when watch wants the session paused for idleness, or
+// something else took the session over, the sprint ends. It reports whether
+// it did.
+func (m *model) holdSprint(now time.Time) bool {
+	owner := m.sprint.owner
+	if m.st == nil {
+		return false
+	}
+	if h := m.st.Holder(now); h != "" && h != owner {
+		m.sprint.running = false
+		m.notice = fmt.Sprintf("Sprint stopped: %s took over the session", h)
+		return true
+	}
+	asked := m.st.Lease != nil && m.st.Lease.Owner == owner && m.st.Lease.Pause != nil
+	if !asked && now.Sub(m.sprint.renewed) < sprintLeaseRenew {
+		return false
+	}
+	var pausedAt time.Time
+	err := daemon.Update(m.statePath, func(st *state.State) error {
+		if err := st.Acquire(owner, now); err != nil {
+			return err
+		}
+		at, ok := st.TakePause(owner)
+		if !ok {
+			return nil
+		}
+		pausedAt = at
+		if a := st.ActiveSession; a != nil {
+			if a.Start.After(at) {
+				at = a.Start
+			}
+			if _, err := st.StopSessionAt(at); err != nil {
+				return err
+			}
+		}
+		st.Release(owner)
+		st.SprintPhaseEnd = nil
+		return nil
+	})
+	if err != nil {
+		m.err = err
+		return false
+	}
+	m.sprint.renewed = now
+	if pausedAt.IsZero() {
+		return false
+	}
+	m.sprint.running = false
+	m.notice = fmt.Sprintf("Idle since %s: session paused and sprint stopped", i18n.Time(pausedAt))
+	m.sprintNotify(m.notice)
+	m.reload(now)
+	return true
+}
+
+// sprintExtendStep is how much + adds to the running phase.
+const sprintExtendStep = 5 * time.Minute
+
+// skipPhase ends the running phase now and moves on to the next one.
+func (m *model) skipPhase(now time.Time) {
+	m.notice = ""
+	m.err = nil
+	m.sprint.phaseEnd = now.Round(0)
+	m.advanceSprint(now)
+}
+
+// extendPhase pushes the end of the running phase back by d.
+func (m *model) extendPhase(now time.Time, d time.Duration) {
+	m.err = nil
+	m.sprint.phaseEnd = m.sprint.phaseEnd.Add(d)
+	m.publishPhase()
+	m.notice = fmt.Sprintf("%s phase extended to %s", strings.ToUpper(m.sprint.phase[:1])+m.sprint.phase[1:], i18n.Time(m.sprint.phaseEnd))
+	m.reload(now)
+}
+
+// publishPhase records the current phase deadline in the state so the tray can
+// count it down; it clears it, and gives up the session, once the sprint is
+// over.
+func (m *model) publishPhase() {
+	var end *time.Time
+	if m.sprint.running {
+		t := m.sprint.phaseEnd
+		end = &t
+	}
+	err := daemon.Update(m.statePath, func(st *state.State) error {
+		st.SprintPhaseEnd = end
+		if end == nil {
+			st.Release(m.sprint.owner)
+		}
+		return nil
+	})
+	if err != nil {
+		m.err = err
+	}
+}
+
+func (m *model) sprintNotify(msg string) {
+	if m.st == nil || !m.st.NotificationsOn() {
+		return
+	}
+	notify.Send("Daily Sprint", msg)
+}
+
+func (m *model) sprintSound(event string) {
+	if m.st == nil {
+		return
+	}
+	sound.Play(event, m.st.Sounds[event])
+}
+
+// sprintStatus is the compact status-bar label for a running sprint.
+func (m model) sprintStatus(now time.Time) string {
+	if !m.sprint.running {
+		return ""
+	}
+	left := int(m.sprint.phaseEnd.Sub(now).Seconds())
+	if left < 0 {
+		left = 0
+	}
+	return fmt.Sprintf("SPRINT %d/%d %s %02d:%02d", m.sprint.cycle, m.sprint.cycles, strings.ToUpper(m.sprint.phase), left/60, left%60)
+}
+
+func (m model) renderSprint() string {
+	th := themeForMinutes(m.summary.workMinutes)
+	title := titleStyle.Foreground(th.Accent).Render("SPRINT")
+	muted := lipgloss.NewStyle().Foreground(th.Muted)
+
+	var panel string
+	if m.sprint.running {
+		now := time.Now()
+		phaseColor := statusRun
+		total := m.sprint.work
+		if m.sprint.phase == phaseBreak {
+			phaseColor = lipgloss.NewStyle().Foreground(th.Accent)
+			total = m.sprint.brk
+		}
+		left := m.sprint.phaseEnd.Sub(now)
+		if left < 0 {
+			left = 0
+		}
+		secs := int(left.Seconds())
+		clock := phaseColor.Bold(true).Render(fmt.Sprintf("%s  %02d:%02d", strings.ToUpper(m.sprint.phase), secs/60, secs%60))
+		elapsed := total*60 - secs
+		done := 0
+		if total > 0 {
+			done = clampInt(elapsed*progressBarWidth/(total*60), 0, progressBarWidth)
+		}
+		bar := phaseColor.Render(strings.Repeat("█", done)) +
+			lipgloss.NewStyle().Foreground(th.SelectedBg).Render(strings.Repeat("█", progressBarWidth-done))
+		info := muted.Render(fmt.Sprintf("cycle %d of %d  ·  %dm work / %dm break", m.sprint.cycle, m.sprint.cycles, m.sprint.work, m.sprint.brk))
+		panel = lipgloss.JoinVertical(lipgloss.Center, clock, "", bar, info)
+	} else {
+		rows := []struct {
+			label string
+			value string
+		}{
+			{"profile", m.sprint.profileName()},
+			{"work", fmt.Sprintf("%dm", m.sprint.work)},
+			{"break", fmt.Sprintf("%dm", m.sprint.brk)},
+			{"cycles", fmt.Sprintf("%d", m.sprint.cycles)},
+		}
+		lines := make([]string, 0, len(rows))
+		for i, r := range rows {
+			value := menuStyle.Width(14).Align(lipgloss.Center).Render(r.value)
+			left, right := "  ", "  "
+			if i == m.sprint.field {
+				value = selectedStyle.Background(th.SelectedBg).Width(14).Align(lipgloss.Center).Render(r.value)
+				left, right = arrowStyle.Foreground(th.Accent).Render("◀"), arrowStyle.Foreground(th.Accent).Render("▶")
+			}
+			label := muted.Width(9).Render(r.label)
+			lines = append(lines, lipgloss.JoinHorizontal(lipgloss.Center, label, left, value, right))
+		}
+		panel = lipgloss.JoinVertical(lipgloss.Left, lines...)
+	}
+
+	hintText := "↑/↓ select   ←/→ adjust   ENTER start   esc back"
+	if m.sprint.running {
+		hintText = "n next phase   + 5 more min   ENTER stop sprint   esc back (sprint keeps running)"
+	}
+	hints := hintStyle.Foreground(th.Muted).Render(hintText)
+
+	body := lipgloss.JoinVertical(lipgloss.Center, title, panel, hints)
+	if m.width > 0 && m.height > 0 {
+		body = lipgloss.Place(m.width, m.height-statusBarHeight, lipgloss.Center, lipgloss.Center, body)
+	}
+	bottom := m.renderStatusBar()
+	if m.width > 0 {
+		bottom = lipgloss.Place(m.width, statusBarHeight, lipgloss.Center, lipgloss.Center, bottom)
+	}
+	view := lipgloss.JoinVertical(lipgloss.Left, body, bottom)
+	if m.width > 0 && m.height > 0 {
+		return baseStyle.Width(m.width).Height(m.height).Render(view)
+	}
+	return baseStyle.Render(view)
+}
@@ -0,0 +1,113 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/max-pantom/daily/internal/state"
+	"github.com/max-pantom/daily/internal/stats"
+)
+
+const statsWindowDays = 30
+
+var sparkRunes = []rune("▁▂▃▄▅▆▇█")
+
+func sparkline(values []int) string {
+	max := 0
+	for _, v := range values {
+		if v > max {
+			max = v
+		}
+	}
+	var b strings.Builder
+	for _, v := range values {
+		i := 0
+		if max > 0 {
+			i = v * (len(sparkRunes) - 1) / max
+		}
+		b.WriteRune(sparkRunes[i])
+	}
+	return b.String()
+}
+
+func (m model) renderStats() string {
+	th := themeForMinutes(m.summary.workMinutes)
+	if m.st == nil {
+		return baseStyle.Render(errorStyle.Render(fmt.Sprint(m.err)))
+	}
+	sum := stats.Window(m.st, time.Now(), statsWindowDays)
+
+	title := titleStyle.Foreground(th.Accent).Render(fmt.Sprintf("STATS · %d DAYS", statsWindowDays))
+	label := lipgloss.NewStyle().Foreground(th.Muted).Width(18)
+	value := weekValueStyle.Foreground(th.Accent)
+
+	best := "--"
+	if sum.BestDay != "" {
+		best = fmt.Sprintf("%s (%s)", sum.BestDay, state.HumanMinutes(sum.BestDayMinutes))
+	}
+	trend := sparkline(sum.Weekly)
+	if d := sum.Trend(); d > 0 {
+		trend += fmt.Sprintf("  +%s vs prior week", state.HumanMinutes(d))
+	} else if d < 0 {
+		trend += fmt.Sprintf("  -%s vs prior week", state.HumanMinutes(-d))
+	}
+
+	rows := [][2]string{
+		{"avg / day worked", fmt.Sprintf("%s (%d days)", state.HumanMinutes(sum.AvgDailyMinutes), sum.DaysWorked)},
+		{"best day", best},
+		{"avg start", sum.AvgStartLabel()},
+		{"avg session", fmt.Sprintf("%s (%d sessions)", state.HumanMinutes(sum.AvgSessionMinutes), sum.Sessions)},
+		{"break ratio", fmt.Sprintf("%d%%", int(sum.BreakRatio*100+0.5))},
+		{"goal streak", fmt.Sprintf("%d days", sum.Streak)},
+		{"weekly trend", trend},
+	}
+	lines := make([]string, 0, len(rows))
+	for _, r := range rows {
+		lines = append(lines, lipgloss.JoinHorizontal(lipgloss.Left, label.Render(r[0]), value.Render(r[1])))
+	}
+
+	now := time.Now()
+	hours := renderHours(stats.Hours(m.st, now.AddDate(0, 0, -(statsWindowDays-1)), now, state.Filter{}), th)
+
+	hints := hintStyle.Foreground(th.Muted).Render("s back   TAB week   q quit")
+	body := lipgloss.JoinVertical(lipgloss.Left, title, lipgloss.JoinVertical(lipgloss.Left, lines...), hours, hints)
+	if m.width > 0 && m.height > 0 {
+		body = lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, body)
+		return baseStyle.Width(m.width).Height(m.height).Render(body)
+	}
+	return baseStyle.Render(body)
+}
+
+var hourShades = []string{"·", "░", "▒", "▓", "█"}
+
+// renderHours draws work by weekday and hour of day, an hour per two cells,
+// with the most focused block of hours under it.
+func renderHours(p stats.Profile, th milestoneTheme) string {
+	peak, ok := p.Peak()
+	if !ok {
+		return ""
+	}
+	muted := lipgloss.NewStyle().Foreground(th.Muted)
+	accent := lipgloss.NewStyle().Foreground(th.Accent)
+	var b strings.Builder
+	b.WriteString(muted.Render("    "))
+	for h := 0; h < 24; h += 3 {
+		b.WriteString(muted.Render(fmt.Sprintf("%-6s", fmt.Sprintf("%02d", h))))
+	}
+	for i := range p {
+		b.WriteString("\n" + muted.Render(p.Day(i).String()[:3]+" "))
+		for h := range p[i] {
+			lvl := p.Level(i, h, len(hourShades))
+			style := accent
+			if lvl == 0 {
+				style = muted
+			}
+			b.WriteString(style.Render(strings.Repeat(hourShades[lvl], 2)))
+		}
+	}
+	b.WriteString("\n" + muted.Render("most focused ") + accent.Render(peak.String()))
+	return lipgloss.NewStyle().MarginTop(1).MarginBottom(1).Render(b.String())
+}
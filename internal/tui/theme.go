@@ -0,0 +1,36 @@
+package tui
+
+import (
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/max-pantom/daily/internal/state"
+)
+
+// colorblindMilestones replace milestoneThemes for the colorblind theme. They
+// use the Okabe–Ito palette, so the later milestones shift from blue through
+// orange to vermillion rather than from green to red.
+var colorblindMilestones = []milestoneTheme{
+	{Name: "base", ThresholdMin: 0, Accent: lipgloss.Color("#56B4E9"), Muted: lipgloss.Color("#6f7880"), SelectedBg: lipgloss.Color("#1f2b3a")},
+	{Name: "deep-blue", ThresholdMin: 240, Accent: lipgloss.Color("#0072B2"), Muted: lipgloss.Color("#5c6b80"), SelectedBg: lipgloss.Color("#1a2433")},
+	{Name: "night-mode", ThresholdMin: 360, Accent: lipgloss.Color("#dfe5dd"), Muted: lipgloss.Color("#4a4f4a"), SelectedBg: lipgloss.Color("#151515")},
+	{Name: "deep-amber", ThresholdMin: 480, Accent: lipgloss.Color("#E69F00"), Muted: lipgloss.Color("#6f7880"), SelectedBg: lipgloss.Color("#3a2b1f")},
+	{Name: "alert-vermillion", ThresholdMin: 600, Accent: lipgloss.Color("#D55E00"), Muted: lipgloss.Color("#6f7880"), SelectedBg: lipgloss.Color("#3a241a")},
+}
+
+// useTheme switches the TUI's colours to the state's theme. With NO_COLOR set
+// lipgloss draws no colour at all, and the views rely on text and glyphs.
+func useTheme(theme string) {
+	if theme != state.ThemeColorblind {
+		return
+	}
+	milestoneThemes = colorblindMilestones
+	accent := colorblindMilestones[0].Accent
+	titleStyle = titleStyle.Foreground(accent)
+	arrowStyle = arrowStyle.Foreground(accent)
+	noticeStyle = noticeStyle.Foreground(accent)
+	weekDateStyle = weekDateStyle.Foreground(accent)
+	weekBarStyle = weekBarStyle.Foreground(accent)
+	selectedStyle = selectedStyle.Background(colorblindMilestones[0].SelectedBg)
+	errorStyle = errorStyle.Foreground(lipgloss.Color("#D55E00"))
+	statusRun = statusRun.Foreground(lipgloss.Color("#E69F00"))
+}
@@ -0,0 +1,167 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/max-pantom/daily/internal/state"
+)
+
+const (
+	weekLen      = 7
+	weeksPerPage = 4 // PgUp/PgDn in the week view move about a month
+)
+
+// shiftWeek pages the week view, never past the current week.
+func (m *model) shiftWeek(delta int) {
+	m.weekOffset = min(m.weekOffset+delta, 0)
+}
+
+// weekRow is one day of the week view.
+type weekRow struct {
+	day       time.Time
+	work      int // minutes
+	goal      int
+	breaks    int
+	breakMins int
+}
+
+// weekRows returns the seven days ending on end, oldest first, including days
+// with nothing logged. Today's row counts the running session.
+func weekRows(st *state.State, end, now time.Time) []weekRow {
+	today := now.Format("2006-01-02")
+	rows := make([]weekRow, weekLen)
+	for i := range rows {
+		day := end.AddDate(0, 0, i-(weekLen-1))
+		r := weekRow{day: day}
+		r.goal, _ = st.Goal(day)
+		if log, ok := st.Days[day.Format("2006-01-02")]; ok {
+			r.work = log.WorkSeconds() / 60
+			r.breaks, r.breakMins = log.BreakCount, log.TotalBreakMinutes
+		}
+		if day.Format("2006-01-02") == today {
+			r.work, _ = st.TodaySummary(now)
+		}
+		rows[i] = r
+	}
+	return rows
+}
+
+func weekend(day time.Time) bool {
+	return day.Weekday() == time.Saturday || day.Weekday() == time.Sunday
+}
+
+// weekBar draws work against scale in width cells, with a marker in the cell
+// where the goal is reached; a goal that was met is crossed by the bar, so it
+// shows without colour too.
+func weekBar(work, goal, scale, width int, fill, marker lipgloss.Style) string {
+	filled := work * width / scale
+	if filled == 0 && work > 0 {
+		filled = 1
+	}
+	mark := -1
+	if goal > 0 {
+		mark = clampInt(goal*width/scale-1, 0, width-1)
+	}
+	var b strings.Builder
+	for i := 0; i < width; i++ {
+		switch {
+		case i == mark && i < filled:
+			b.WriteString(marker.Render("╂"))
+		case i == mark:
+			b.WriteString(marker.Render("│"))
+		case i < filled:
+			b.WriteString(fill.Render("█"))
+		default:
+			b.WriteString(" ")
+		}
+	}
+	return b.String()
+}
+
+// renderWeek charts seven days against their goals, weekends dimmed, with the
+// week's total and the average per day worked. It ends today, or weekOffset
+// weeks earlier.
+func (m model) renderWeek() string {
+	st := m.st
+	if st == nil || len(st.Days) == 0 {
+		return baseStyle.Render("no history yet (TAB to main)")
+	}
+	now := time.Now()
+	end := now.AddDate(0, 0, weekLen*m.weekOffset)
+	rows := weekRows(st, end, now)
+
+	heading := "LAST 7 DAYS"
+	if m.weekOffset < 0 {
+		heading = fmt.Sprintf("%s – %s", rows[0].day.Format("Jan 2"), end.Format("Jan 2 2006"))
+	}
+	title := titleStyle.Foreground(themeForMinutes(m.summary.workMinutes).Accent).Render(heading)
+
+	scale := 1
+	for _, r := range rows {
+		scale = max(scale, r.work, r.goal)
+	}
+	barWidth := 24
+	if m.compact() {
+		barWidth = clampInt(m.width-24, 4, 24)
+	}
+	dateFormat := "Mon 2006-01-02"
+	if m.compact() {
+		dateFormat = "Mon 02"
+	}
+
+	lines := make([]string, 0, len(rows)+2)
+	total, worked := 0, 0
+	for _, r := range rows {
+		total += r.work
+		if r.work > 0 {
+			worked++
+		}
+		th := themeForMinutes(r.work)
+		dateStyle := weekDateStyle.Foreground(th.Accent)
+		barStyle := weekBarStyle.Foreground(th.Accent)
+		valueStyle := weekValueStyle.Foreground(th.Muted)
+		marker := statusDim
+		if r.goal > 0 && r.work >= r.goal {
+			marker = statusRun
+		}
+		if weekend(r.day) {
+			dateStyle = dateStyle.Foreground(statusDim.GetForeground())
+			barStyle = barStyle.Foreground(statusHalf.GetForeground())
+			valueStyle = valueStyle.Foreground(statusDim.GetForeground())
+		}
+
+		info := fmt.Sprintf("%s  %d breaks  %s brk", state.HumanMinutes(r.work), r.breaks, state.HumanMinutes(r.breakMins))
+		if m.compact() {
+			info = state.HumanMinutes(r.work)
+		}
+		lines = append(lines, lipgloss.JoinHorizontal(lipgloss.Left,
+			dateStyle.Render(r.day.Format(dateFormat)),
+			weekBar(r.work, r.goal, scale, barWidth, barStyle, marker),
+			valueStyle.Render(info),
+		))
+	}
+
+	summary := "total " + state.HumanMinutes(total)
+	if worked > 0 {
+		summary += fmt.Sprintf("   avg %s over %d days worked", state.HumanMinutes(total/worked), worked)
+	}
+	if m.compact() && worked > 0 {
+		summary = fmt.Sprintf("%s  avg %s", state.HumanMinutes(total), state.HumanMinutes(total/worked))
+	}
+	lines = append(lines, "", weekValueStyle.Foreground(themeForMinutes(m.summary.workMinutes).Accent).Render(summary))
+
+	hints := hintStyle.Render("│ goal   ←/→ week   PgUp/PgDn month   TAB back   q quit")
+	if m.compact() {
+		hints = hintStyle.Render("←/→ week   TAB back")
+	}
+	body := lipgloss.JoinVertical(lipgloss.Center, title, lipgloss.JoinVertical(lipgloss.Left, lines...))
+	if m.width > 0 && m.height > 0 {
+		body = lipgloss.Place(m.width, m.height-statusBarHeight, lipgloss.Center, lipgloss.Center, body)
+	}
+	view := lipgloss.JoinVertical(lipgloss.Left, body, hints)
+	return baseStyle.Render(view)
+}
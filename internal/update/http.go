@@ -0,0 +1,123 @@
+package update
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+)
+
+// Requests for release metadata get requestTimeout each, the tarball
+// downloadTimeout. Failed attempts are retried up to attempts times, waiting
+// retryDelay, then twice as long, in between.
+const (
+	requestTimeout  = 30 * time.Second
+	downloadTimeout = 10 * time.Minute
+	attempts        = 3
+	retryDelay      = time.Second
+)
+
+// StatusError is a response other than 2xx.
+type StatusError struct {
+	URL    string
+	Status string
+	Code   int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s: %s", e.URL, e.Status)
+}
+
+// retryable reports whether another attempt may succeed: network errors,
+// timeouts and server-side statuses, but not a missing file or a cancel.
+func retryable(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	var status *StatusError
+	if errors.As(err, &status) {
+		return status.Code >= 500 || status.Code == http.StatusTooManyRequests
+	}
+	return true
+}
+
+// retry runs fn until it succeeds, fails for good, or runs out of attempts,
+// backing off between tries.
+func retry(ctx context.Context, fn func(ctx context.Context) error) error {
+	delay := retryDelay
+	var err error
+	for i := 0; i < attempts; i++ {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return fmt.Errorf("%w (after: %v)", ctx.Err(), err)
+			case <-time.After(delay):
+			}
+			delay *= 2
+		}
+		if err = fn(ctx); err == nil || !retryable(err) {
+			return err
+		}
+	}
+	return fmt.Errorf("gave up after %d attempts: %w", attempts, err)
+}
+
+// get sends one GET, bounded by timeout, and hands the body to read.
+func get(ctx context.Context, url string, timeout time.Duration, read func(io.Reader) error) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", "daily-updater")
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return &StatusError{URL: url, Status: res.Status, Code: res.StatusCode}
+	}
+	return read(res.Body)
+}
+
+// fetch downloads a small file, retrying on network and server errors.
+func fetch(ctx context.Context, url string) ([]byte, error) {
+	var data []byte
+	err := retry(ctx, func(ctx context.Context) error {
+		return get(ctx, url, requestTimeout, func(body io.Reader) error {
+			var err error
+			data, err = io.ReadAll(io.LimitReader(body, 1<<20))
+			return err
+		})
+	})
+	return data, err
+}
+
+// downloadTo writes url into f, starting over on each retry, and returns the
+// SHA-256 of what it wrote.
+func downloadTo(ctx context.Context, url string, f *os.File) ([]byte, error) {
+	var sum []byte
+	err := retry(ctx, func(ctx context.Context) error {
+		if err := f.Truncate(0); err != nil {
+			return err
+		}
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		return get(ctx, url, downloadTimeout, func(body io.Reader) error {
+			h := sha256.New()
+			if _, err := io.Copy(io.MultiWriter(f, h), body); err != nil {
+				return fmt.Errorf("downloading %s: %w", url, err)
+			}
+			sum = h.Sum(nil)
+			return nil
+		})
+	})
+	return sum, err
+}
@@ -2,21 +2,98 @@ package update
 
 import (
 	"archive/tar"
+	"bufio"
+	"bytes"
+	"cmp"
 	"compress/gzip"
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
-	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+)
+
+// Version is the release this binary was built as, set at build time with
+// -ldflags "-X github.com/max-pantom/daily/internal/update.Version=v0.1.3".
+var Version string
+
+// Current is Version, or the module version go install recorded, or "devel"
+// for a plain source build.
+func Current() string {
+	if Version != "" {
+		return Version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+	return "devel"
+}
+
+// Check looks up the latest release and reports whether it is newer than
+// the running one. A devel build is never up to date.
+func Check(ctx context.Context) (latest string, newer bool, err error) {
+	latest, err = latestTag(ctx)
+	if err != nil {
+		return "", false, err
+	}
+	cur := Current()
+	return latest, cur == "devel" || compareVersions(latest, cur) > 0, nil
+}
+
+// compareVersions orders release tags such as v0.1.3 and v0.1.4.1 by their
+// numeric parts; a pre-release (v1.2.0-rc1) comes before its release.
+func compareVersions(a, b string) int {
+	a, preA, _ := strings.Cut(strings.TrimPrefix(a, "v"), "-")
+	b, preB, _ := strings.Cut(strings.TrimPrefix(b, "v"), "-")
+	pa, pb := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < max(len(pa), len(pb)); i++ {
+		var x, y int
+		if i < len(pa) {
+			x, _ = strconv.Atoi(pa[i])
+		}
+		if i < len(pb) {
+			y, _ = strconv.Atoi(pb[i])
+		}
+		if x != y {
+			return cmp.Compare(x, y)
+		}
+	}
+	switch {
+	case preA == preB:
+		return 0
+	case preA == "":
+		return 1
+	case preB == "":
+		return -1
+	}
+	return strings.Compare(preA, preB)
+}
+
+// SigningKey is the base64 Ed25519 public key that release checksums are
+// signed with, set at build time with
+// -ldflags "-X github.com/max-pantom/daily/internal/update.SigningKey=...".
+// When set, checksums.txt must come with a valid checksums.txt.sig.
+var SigningKey string
+
+// ChecksumsFile and SignatureFile are published with every release.
+const (
+	ChecksumsFile = "checksums.txt"
+	SignatureFile = "checksums.txt.sig"
 )
 
 // GoInstall installs via `go install github.com/max-pantom/daily/cmd/daily@version`.
-func GoInstall(version string, stdout, stderr io.Writer) error {
-	cmd := exec.Command("go", "install", "github.com/max-pantom/daily/cmd/daily@"+version)
+func GoInstall(ctx context.Context, version string, stdout, stderr io.Writer) error {
+	cmd := exec.CommandContext(ctx, "go", "install", "github.com/max-pantom/daily/cmd/daily@"+version)
 	cmd.Stdout = stdout
 	cmd.Stderr = stderr
 	return cmd.Run()
@@ -25,12 +102,17 @@ func GoInstall(version string, stdout, stderr io.Writer) error {
 // BinaryInstall downloads a release tarball from GitHub and installs the binary to dest.
 // Asset naming convention: daily_<tag>_<os>_<arch>.tar.gz (e.g., daily_v0.1.3_darwin_arm64.tar.gz).
 // If version == "latest", it resolves the latest release tag via GitHub API.
-func BinaryInstall(version, dest string, stdout, stderr io.Writer) error {
+// The tarball must match the release's checksums (see Verify); insecure
+// installs it with a warning when they cannot be checked, never when they
+// disagree.
+// Downloads are retried with backoff on network and server errors, and stop
+// when ctx is done.
+func BinaryInstall(ctx context.Context, version, dest string, insecure bool, stdout, stderr io.Writer) error {
 	if version == "" {
 		version = "latest"
 	}
 	if version == "latest" {
-		v, err := latestTag()
+		v, err := latestTag(ctx)
 		if err != nil {
 			return err
 		}
@@ -43,16 +125,25 @@ func BinaryInstall(version, dest string, stdout, stderr io.Writer) error {
 	}
 
 	asset := fmt.Sprintf("daily_%s_%s_%s.tar.gz", version, osName, archName)
-	url := fmt.Sprintf("https://github.com/max-pantom/daily/releases/download/%s/%s", version, asset)
+	url := releaseURL(version) + asset
 
 	tmp, err := os.CreateTemp("", "daily_dl_*.tar.gz")
 	if err != nil {
 		return err
 	}
 	defer os.Remove(tmp.Name())
+	defer tmp.Close()
 
-	if _, err := io.Copy(tmp, httpResp(url)); err != nil {
-		return err
+	sum, err := downloadTo(ctx, url, tmp)
+	if err != nil {
+		return fmt.Errorf("downloading daily %s: %w", version, err)
+	}
+	if err := Verify(ctx, version, asset, sum); err != nil {
+		var unverified *UnverifiedError
+		if !insecure || !errors.As(err, &unverified) {
+			return err
+		}
+		_, _ = fmt.Fprintf(stderr, "warning: installing anyway (--insecure): %v\n", err)
 	}
 	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
 		return err
@@ -63,16 +154,73 @@ func BinaryInstall(version, dest string, stdout, stderr io.Writer) error {
 		return err
 	}
 
-	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
-		return err
-	}
-	if err := copyFile(binPath, dest); err != nil {
+	if err := Install(binPath, dest); err != nil {
 		return err
 	}
 	_, _ = fmt.Fprintf(stdout, "installed daily %s to %s\n", version, dest)
 	return nil
 }
 
+func releaseURL(version string) string {
+	return fmt.Sprintf("https://github.com/max-pantom/daily/releases/download/%s/", version)
+}
+
+// UnverifiedError is returned by Verify when the release's checksums could
+// not be checked at all, as opposed to disagreeing with the download.
+type UnverifiedError struct {
+	Reason string
+}
+
+func (e *UnverifiedError) Error() string {
+	return "cannot verify the download: " + e.Reason + " (--insecure installs it anyway)"
+}
+
+// Verify checks sum, the SHA-256 of the downloaded asset, against the
+// release's checksums file, after checking that file's signature when
+// SigningKey is set.
+func Verify(ctx context.Context, version, asset string, sum []byte) error {
+	sums, err := fetch(ctx, releaseURL(version)+ChecksumsFile)
+	if err != nil {
+		return &UnverifiedError{Reason: fmt.Sprintf("no %s: %v", ChecksumsFile, err)}
+	}
+	if SigningKey != "" {
+		key, err := base64.StdEncoding.DecodeString(SigningKey)
+		if err != nil || len(key) != ed25519.PublicKeySize {
+			return errors.New("this build's signing key is malformed")
+		}
+		sig, err := fetch(ctx, releaseURL(version)+SignatureFile)
+		if err != nil {
+			return &UnverifiedError{Reason: fmt.Sprintf("no %s: %v", SignatureFile, err)}
+		}
+		if decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(sig))); err == nil {
+			sig = decoded
+		}
+		if !ed25519.Verify(ed25519.PublicKey(key), sums, sig) {
+			return fmt.Errorf("%s of %s is not signed by the release key; not installing", ChecksumsFile, version)
+		}
+	}
+	want, ok := checksumFor(sums, asset)
+	if !ok {
+		return &UnverifiedError{Reason: fmt.Sprintf("%s has no entry for %s", ChecksumsFile, asset)}
+	}
+	if got := hex.EncodeToString(sum); got != want {
+		return fmt.Errorf("%s does not match %s (sha256 %s, want %s); not installing", asset, ChecksumsFile, got, want)
+	}
+	return nil
+}
+
+// checksumFor finds asset's hex SHA-256 in a sha256sum/shasum listing.
+func checksumFor(sums []byte, asset string) (string, bool) {
+	sc := bufio.NewScanner(bytes.NewReader(sums))
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == asset {
+			return strings.ToLower(fields[0]), true
+		}
+	}
+	return "", false
+}
+
 func platform() (string, string, error) {
 	osName := runtime.GOOS
 	arch := runtime.GOARCH
@@ -89,14 +237,15 @@ func platform() (string, string, error) {
 	return osName, arch, nil
 }
 
-func latestTag() (string, error) {
-	url := "https://api.github.com/repos/max-pantom/daily/releases/latest"
-	resp := httpResp(url)
-	defer resp.Close()
+func latestTag(ctx context.Context) (string, error) {
+	body, err := fetch(ctx, "https://api.github.com/repos/max-pantom/daily/releases/latest")
+	if err != nil {
+		return "", fmt.Errorf("looking up the latest release: %w", err)
+	}
 	var data struct {
 		TagName string `json:"tag_name"`
 	}
-	if err := json.NewDecoder(resp).Decode(&data); err != nil {
+	if err := json.Unmarshal(body, &data); err != nil {
 		return "", err
 	}
 	if data.TagName == "" {
@@ -105,20 +254,6 @@ func latestTag() (string, error) {
 	return data.TagName, nil
 }
 
-func httpResp(url string) io.ReadCloser {
-	req, _ := http.NewRequest("GET", url, nil)
-	req.Header.Set("User-Agent", "daily-updater")
-	res, err := http.DefaultClient.Do(req)
-	if err != nil {
-		panic(err)
-	}
-	if res.StatusCode < 200 || res.StatusCode >= 300 {
-		res.Body.Close()
-		panic(fmt.Errorf("download failed %s: %s", url, res.Status))
-	}
-	return res.Body
-}
-
 func untarSingle(r io.Reader, want string) (string, error) {
 	gz, err := gzip.NewReader(r)
 	if err != nil {
@@ -159,6 +294,85 @@ func untarSingle(r io.Reader, want string) (string, error) {
 	return "", fmt.Errorf("binary %s not found in archive", want)
 }
 
+// BackupPath is where the binary replaced by an update is kept: daily.bak
+// next to daily (daily.exe.bak on Windows).
+func BackupPath(dest string) string {
+	return dest + ".bak"
+}
+
+// Install puts the binary src at dest, moving the one there to
+// BackupPath(dest) first so Rollback can bring it back. The new binary is
+// written beside dest and renamed over it, so a failed copy leaves the old
+// one in place; renaming also works while dest is running on Windows.
+func Install(src, dest string) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return err
+	}
+	tmp := dest + ".new"
+	if err := copyFile(src, tmp); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	bak := BackupPath(dest)
+	kept := false
+	if _, err := os.Stat(dest); err == nil {
+		os.Remove(bak)
+		if err := os.Rename(dest, bak); err != nil {
+			os.Remove(tmp)
+			return fmt.Errorf("keeping the previous binary: %w", err)
+		}
+		kept = true
+	}
+	if err := os.Rename(tmp, dest); err != nil {
+		if kept {
+			os.Rename(bak, dest)
+		}
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// Keep copies the binary at dest to BackupPath(dest), for updates that
+// replace it in place, such as go install into $GOBIN. A missing dest is
+// not an error.
+func Keep(dest string) error {
+	if _, err := os.Stat(dest); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	bak := BackupPath(dest)
+	if err := copyFile(dest, bak+".new"); err != nil {
+		os.Remove(bak + ".new")
+		return fmt.Errorf("keeping the previous binary: %w", err)
+	}
+	return os.Rename(bak+".new", bak)
+}
+
+// Rollback swaps dest with the binary kept by the last update, so running
+// it again returns to the newer one.
+func Rollback(dest string) error {
+	bak := BackupPath(dest)
+	if _, err := os.Stat(bak); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("no previous binary to roll back to (%s is kept by daily update)", bak)
+		}
+		return err
+	}
+	tmp := dest + ".old"
+	os.Remove(tmp)
+	if err := os.Rename(dest, tmp); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err := os.Rename(bak, dest); err != nil {
+		os.Rename(tmp, dest)
+		return err
+	}
+	if err := os.Rename(tmp, bak); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
 func copyFile(src, dst string) error {
 	data, err := os.ReadFile(src)
 	if err != nil {
@@ -0,0 +1,137 @@
+package urlscheme
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+const (
+	appName     = "Daily URL Handler.app"
+	bundleID    = "com.max-pantom.daily.url"
+	desktopName = "daily-url-handler.desktop"
+	lsregister  = "/System/Library/Frameworks/CoreServices.framework/Frameworks/LaunchServices.framework/Support/lsregister"
+)
+
+// ErrUnsupported is returned on systems daily cannot register a handler on.
+var ErrUnsupported = errors.New("registering the daily:// URL scheme is not supported on " + runtime.GOOS)
+
+// Path returns the helper that handles daily:// URLs: a small AppleScript
+// app on macOS, since URLs arrive there as Apple Events rather than
+// arguments, and a desktop entry on Linux.
+func Path() (string, error) {
+	switch runtime.GOOS {
+	case "darwin":
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(home, "Applications", appName), nil
+	case "linux", "freebsd", "openbsd", "netbsd":
+		dir := os.Getenv("XDG_DATA_HOME")
+		if dir == "" {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return "", err
+			}
+			dir = filepath.Join(home, ".local", "share")
+		}
+		return filepath.Join(dir, "applications", desktopName), nil
+	}
+	return "", ErrUnsupported
+}
+
+// Installed reports whether the helper is in place.
+func Installed() bool {
+	path, err := Path()
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(path)
+	return err == nil
+}
+
+// Install registers a helper that runs `exe url <url>` for daily:// URLs.
+func Install(exe string) error {
+	path, err := Path()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	if runtime.GOOS == "darwin" {
+		return installApp(path, exe)
+	}
+	entry := `[Desktop Entry]
+Type=Application
+Name=Daily URL Handler
+Exec="` + strings.ReplaceAll(exe, `"`, `\"`) + `" url %u
+MimeType=x-scheme-handler/` + Scheme + `;
+NoDisplay=true
+Terminal=false
+`
+	if err := os.WriteFile(path, []byte(entry), 0o644); err != nil {
+		return err
+	}
+	return run("xdg-mime", "default", desktopName, "x-scheme-handler/"+Scheme)
+}
+
+// installApp compiles an applet whose open location handler passes the URL
+// to daily, declares the scheme in its Info.plist and registers it with
+// Launch Services.
+func installApp(path, exe string) error {
+	if err := os.RemoveAll(path); err != nil {
+		return err
+	}
+	script := fmt.Sprintf(`on open location theURL
+	do shell script quoted form of %s & " url " & quoted form of theURL
+end open location`, appleString(exe))
+	if err := run("osacompile", "-o", path, "-e", script); err != nil {
+		return err
+	}
+	plist := filepath.Join(path, "Contents", "Info.plist")
+	types := fmt.Sprintf(`[{"CFBundleURLName":%q,"CFBundleURLSchemes":[%q]}]`, bundleID, Scheme)
+	for _, args := range [][]string{
+		{"-replace", "CFBundleIdentifier", "-string", bundleID, plist},
+		{"-replace", "CFBundleURLTypes", "-json", types, plist},
+		{"-replace", "LSUIElement", "-bool", "YES", plist}, // no Dock icon
+	} {
+		if err := run("plutil", args...); err != nil {
+			return err
+		}
+	}
+	return run(lsregister, "-f", path)
+}
+
+// Uninstall removes the helper; it is not an error if there is none.
+func Uninstall() error {
+	if !Installed() {
+		return nil
+	}
+	path, err := Path()
+	if err != nil {
+		return err
+	}
+	if runtime.GOOS == "darwin" {
+		_ = run(lsregister, "-u", path)
+	}
+	return os.RemoveAll(path)
+}
+
+// appleString quotes s as an AppleScript string literal.
+func appleString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+func run(name string, args ...string) error {
+	out, err := exec.Command(name, args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s: %v: %s", name, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+// Package urlscheme reads daily:// URLs, such as daily://start?tag=review,
+// and registers daily as their handler so Shortcuts, Focus Filters, NFC tags
+// and browsers can control tracking. The x-success and x-error parameters of
+// the x-callback-url convention are honoured for web and Shortcuts URLs.
+package urlscheme
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Scheme is the URL scheme daily handles.
+const Scheme = "daily"
+
+// Actions are the operations a URL can name.
+var Actions = []string{"start", "stop", "toggle", "break"}
+
+// Request is a parsed daily:// URL.
+type Request struct {
+	Action  string
+	Tags    []string
+	Note    string
+	Project string
+	For     time.Duration // start only: stop automatically after this long
+	Force   bool          // start only: ignore the strict daily cap
+
+	Success string // x-success: opened after the action succeeds
+	Error   string // x-error: opened with errorMessage when it fails
+}
+
+// Parse reads a URL such as daily://start?tag=a&tag=b&note=...&project=p&for=25m.
+// The action may also be given as the path, as in daily:///start or
+// daily://x-callback-url/start. Tags may be repeated or comma separated.
+func Parse(raw string) (Request, error) {
+	var r Request
+	u, err := url.Parse(raw)
+	if err != nil {
+		return r, err
+	}
+	if u.Scheme != Scheme {
+		return r, fmt.Errorf("not a %s:// URL: %s", Scheme, raw)
+	}
+	q := u.Query()
+	r.Success, r.Error = q.Get("x-success"), q.Get("x-error")
+	for name, cb := range map[string]string{"x-success": r.Success, "x-error": r.Error} {
+		if err := checkCallback(cb); err != nil {
+			return r, fmt.Errorf("%s: %w", name, err)
+		}
+	}
+
+	r.Action = u.Host
+	if r.Action == "" || r.Action == "x-callback-url" {
+		r.Action = strings.Trim(u.Path, "/")
+	}
+	valid := false
+	for _, a := range Actions {
+		valid = valid || a == r.Action
+	}
+	if !valid {
+		return r, fmt.Errorf("unknown action %q (want %s)", r.Action, strings.Join(Actions, ", "))
+	}
+
+	for _, v := range q["tag"] {
+		for _, t := range strings.Split(v, ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				r.Tags = append(r.Tags, t)
+			}
+		}
+	}
+	r.Note, r.Project = q.Get("note"), q.Get("project")
+	if v := q.Get("for"); v != "" {
+		if r.For, err = time.ParseDuration(v); err != nil || r.For <= 0 {
+			return r, fmt.Errorf("invalid duration for=%q", v)
+		}
+	}
+	r.Force = q.Get("force") == "1" || q.Get("force") == "true"
+	if r.Action != "start" && (len(r.Tags) > 0 || r.Note != "" || r.Project != "" || r.For > 0) {
+		return r, errors.New("tag, note, project and for only apply to start")
+	}
+	return r, nil
+}
+
+// CallbackSchemes are the schemes an x-success or x-error URL may use. Any
+// web page can open a daily:// link, so a callback must not be able to name a
+// file, an app or a command-line option for the opener.
+var CallbackSchemes = []string{"https", "http", "shortcuts"}
+
+// checkCallback accepts an empty callback or an absolute URL with one of
+// CallbackSchemes and a host.
+func checkCallback(v string) error {
+	if v == "" {
+		return nil
+	}
+	if strings.HasPrefix(v, "-") || strings.ContainsFunc(v, func(r rune) bool { return r <= ' ' || r == 0x7f }) {
+		return fmt.Errorf("invalid callback %q", v)
+	}
+	u, err := url.Parse(v)
+	if err != nil {
+		return fmt.Errorf("invalid callback %q", v)
+	}
+	if !slices.Contains(CallbackSchemes, strings.ToLower(u.Scheme)) || u.Host == "" || u.Opaque != "" {
+		return fmt.Errorf("callback %q must be an absolute %s URL", v, strings.Join(CallbackSchemes, ", "))
+	}
+	return nil
+}
+
+// Callback returns the x-callback URL to open for the outcome err, or "" if
+// the caller did not ask for one.
+func (r Request) Callback(err error) string {
+	if err == nil {
+		if checkCallback(r.Success) != nil {
+			return ""
+		}
+		return r.Success
+	}
+	if r.Error == "" || checkCallback(r.Error) != nil {
+		return ""
+	}
+	u, perr := url.Parse(r.Error)
+	if perr != nil {
+		return ""
+	}
+	q := u.Query()
+	q.Set("errorMessage", err.Error())
+	u.RawQuery = q.Encode()
+	return u.String()
+}
@@ -0,0 +1,72 @@
+package urlscheme
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestParseCallbacks(t *testing.T) {
+	for _, tc := range []struct {
+		cb string
+		ok bool
+	}{
+		{"", true},
+		{"https://example.com/done", true},
+		{"http://localhost:8080/hook?x=1", true},
+		{"shortcuts://x-callback-url/run-shortcut?name=Next", true},
+		{"HTTPS://example.com/", true},
+		{"file:///Applications/Calculator.app", false},
+		{"file:///tmp/evil.sh", false},
+		{"/Applications/Terminal.app", false},
+		{"evil.sh", false},
+		{"-a Terminal", false},
+		{"-a", false},
+		{"--args", false},
+		{"javascript:alert(1)", false},
+		{"vnc://attacker.example", false},
+		{"smb://attacker.example/share", false},
+		{"https:example.com", false},
+		{"https:///path", false},
+		{"https://example.com/\n-a", false},
+		{"https://example.com/ x", false},
+	} {
+		raw := "daily://stop?x-success=" + url.QueryEscape(tc.cb) + "&x-error=" + url.QueryEscape(tc.cb)
+		r, err := Parse(raw)
+		if (err == nil) != tc.ok {
+			t.Errorf("callback %q: Parse error %v, want ok %v", tc.cb, err, tc.ok)
+			continue
+		}
+		if !tc.ok {
+			if got := (Request{Success: tc.cb, Error: tc.cb}).Callback(nil); got != "" {
+				t.Errorf("callback %q: Callback(nil) = %q on a hand-built request, want none", tc.cb, got)
+			}
+			if got := (Request{Success: tc.cb, Error: tc.cb}).Callback(errors.New("x")); got != "" {
+				t.Errorf("callback %q: Callback(err) = %q on a hand-built request, want none", tc.cb, got)
+			}
+			continue
+		}
+		if got := r.Callback(nil); got != tc.cb {
+			t.Errorf("callback %q: Callback(nil) = %q", tc.cb, got)
+		}
+	}
+}
+
+func TestCallbackError(t *testing.T) {
+	r, err := Parse("daily://start?x-error=" + url.QueryEscape("https://example.com/failed?id=7"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := r.Callback(errors.New(`daily cap "reached" & more`))
+	u, err := url.Parse(got)
+	if err != nil || u.Scheme != "https" || u.Host != "example.com" {
+		t.Fatalf("Callback = %q, want the x-error URL", got)
+	}
+	if q := u.Query(); q.Get("id") != "7" || q.Get("errorMessage") != `daily cap "reached" & more` {
+		t.Errorf("Callback = %q, want id and errorMessage kept apart", got)
+	}
+	if strings.HasPrefix(got, "-") {
+		t.Errorf("Callback = %q starts with -", got)
+	}
+}
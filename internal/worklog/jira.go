@@ -0,0 +1,73 @@
+package worklog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// JiraClient posts worklogs with the Jira Cloud REST API, authenticating with
+// an account email and API token.
+type JiraClient struct {
+	BaseURL string // e.g. https://acme.atlassian.net
+	Email   string
+	Token   string
+	HTTP    *http.Client
+}
+
+// Push adds e as a worklog on its issue.
+func (c JiraClient) Push(e Entry) error {
+	if c.BaseURL == "" || c.Email == "" || c.Token == "" {
+		return errors.New("jira not configured (daily set-jira --url --email, token in DAILY_JIRA_TOKEN)")
+	}
+	body := map[string]any{
+		"started":          e.Start.Format("2006-01-02T15:04:05.000-0700"),
+		"timeSpentSeconds": e.Seconds,
+	}
+	if e.Comment != "" {
+		// Jira Cloud v3 takes comments as Atlassian Document Format.
+		body["comment"] = map[string]any{
+			"type":    "doc",
+			"version": 1,
+			"content": []any{map[string]any{
+				"type":    "paragraph",
+				"content": []any{map[string]any{"type": "text", "text": e.Comment}},
+			}},
+		}
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	url := fmt.Sprintf("%s/rest/api/3/issue/%s/worklog", strings.TrimRight(c.BaseURL, "/"), e.Issue)
+	req, err := http.NewRequest("POST", url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(c.Email, c.Token)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	return do(c.HTTP, req)
+}
+
+// do sends req and turns a non-2xx reply into an error carrying its body.
+func do(client *http.Client, req *http.Request) error {
+	if client == nil {
+		client = &http.Client{Timeout: 20 * time.Second}
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+		return fmt.Errorf("%s: %s", res.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package worklog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/max-pantom/daily/internal/state"
+)
+
+const linearAPI = "https://api.linear.app/graphql"
+
+// LinearClient records time on Linear issues. Linear has no worklog API, so
+// each entry becomes a comment stating the time spent.
+type LinearClient struct {
+	APIKey string
+	HTTP   *http.Client
+}
+
+// Push comments the time spent on the issue named by e.Issue (e.g. ENG-123).
+func (c LinearClient) Push(e Entry) error {
+	if c.APIKey == "" {
+		return errors.New("linear not configured (API key in DAILY_LINEAR_TOKEN)")
+	}
+	body := fmt.Sprintf("⏱ %s on %s", state.HumanMinutes(e.Seconds/60), e.Start.Format("Mon 2006-01-02 15:04"))
+	if e.Comment != "" {
+		body += ": " + e.Comment
+	}
+	var reply struct {
+		Data struct {
+			CommentCreate struct {
+				Success bool `json:"success"`
+			} `json:"commentCreate"`
+		} `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	err := c.query(`mutation($issue: String!, $body: String!) {
+		commentCreate(input: {issueId: $issue, body: $body}) { success }
+	}`, map[string]any{"issue": e.Issue, "body": body}, &reply)
+	if err != nil {
+		return err
+	}
+	if len(reply.Errors) > 0 {
+		return fmt.Errorf("linear: %s", reply.Errors[0].Message)
+	}
+	if !reply.Data.CommentCreate.Success {
+		return fmt.Errorf("linear: comment on %s was not created", e.Issue)
+	}
+	return nil
+}
+
+func (c LinearClient) query(q string, vars map[string]any, out any) error {
+	data, err := json.Marshal(map[string]any{"query": q, "variables": vars})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", linearAPI, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", c.APIKey)
+	req.Header.Set("Content-Type", "application/json")
+	client := c.HTTP
+	if client == nil {
+		client = &http.Client{Timeout: 20 * time.Second}
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode/100 != 2 {
+		return fmt.Errorf("linear: %s", res.Status)
+	}
+	return json.NewDecoder(res.Body).Decode(out)
+}
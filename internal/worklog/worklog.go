@@ -0,0 +1,131 @@
+// Package worklog turns sessions tagged with issue keys (PROJ-123) into
+// worklog entries and submits them to Jira or Linear.
+package worklog
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/max-pantom/daily/internal/state"
+)
+
+// Targets.
+const (
+	Jira   = "jira"
+	Linear = "linear"
+)
+
+var issueKey = regexp.MustCompile(`^[A-Z][A-Z0-9]+-[0-9]+$`)
+
+// Entry is the time one session contributes to one issue.
+type Entry struct {
+	Issue   string
+	Start   time.Time
+	Seconds int
+	Comment string
+
+	Day   string // session address, for marking the entry pushed
+	Index int
+}
+
+// Pusher submits a worklog entry to an issue tracker.
+type Pusher interface {
+	Push(Entry) error
+}
+
+// IssueKeys returns the session's tags that look like issue keys.
+func IssueKeys(sess state.Session) []string {
+	var keys []string
+	for _, t := range sess.Tags {
+		if k := strings.ToUpper(t); issueKey.MatchString(k) {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+// Collect lists the finished sessions matching f that carry issue keys,
+// one entry for each issue not pushed to target yet. A session tagged with
+// several issues is split evenly between them.
+func Collect(st *state.State, f state.Filter, target string) []Entry {
+	var entries []Entry
+	keys := st.DayKeys(f)
+	for i := len(keys) - 1; i >= 0; i-- {
+		day := keys[i]
+		for idx, sess := range st.Days[day].Sessions {
+			issues := IssueKeys(sess)
+			if len(issues) == 0 || sess.End == nil || !f.Match(sess) {
+				continue
+			}
+			secs := sess.Seconds(*sess.End) / len(issues)
+			if secs < 60 {
+				continue // Jira rejects worklogs under a minute
+			}
+			for _, issue := range issues {
+				if Pushed(sess, target, issue) {
+					continue
+				}
+				entries = append(entries, Entry{
+					Issue:   issue,
+					Start:   sess.Start,
+					Seconds: secs,
+					Comment: sess.Note,
+					Day:     day,
+					Index:   idx,
+				})
+			}
+		}
+	}
+	return entries
+}
+
+// pushedKey is how an issue's worklog is recorded in Session.Pushed, e.g.
+// "jira:PROJ-1".
+func pushedKey(target, issue string) string {
+	return target + ":" + issue
+}
+
+// Pushed reports whether the session's time on issue was already submitted
+// to target. A bare target, as older versions recorded, covers every issue.
+func Pushed(sess state.Session, target, issue string) bool {
+	return slices.Contains(sess.Pushed, target) || slices.Contains(sess.Pushed, pushedKey(target, issue))
+}
+
+// MarkPushed records that each entry was submitted to target, so the next
+// push skips it. Entries whose session changed or went away in the meantime
+// are left out and returned.
+func MarkPushed(st *state.State, entries []Entry, target string) []Entry {
+	var lost []Entry
+	for _, e := range entries {
+		sess := find(st, e)
+		if sess == nil {
+			lost = append(lost, e)
+			continue
+		}
+		if !Pushed(*sess, target, e.Issue) {
+			sess.Pushed = append(sess.Pushed, pushedKey(target, e.Issue))
+		}
+	}
+	return lost
+}
+
+// find returns the session e came from: the one at its address, or else the
+// one on its day starting when it did, since sessions added after the fact
+// shift the others.
+func find(st *state.State, e Entry) *state.Session {
+	log, ok := st.Days[e.Day]
+	if !ok {
+		return nil
+	}
+	if e.Index < len(log.Sessions) && log.Sessions[e.Index].Start.Equal(e.Start) {
+		return &log.Sessions[e.Index]
+	}
+	for i := range log.Sessions {
+		if log.Sessions[i].Start.Equal(e.Start) {
+			return &log.Sessions[i]
+		}
+	}
+	return nil
+}
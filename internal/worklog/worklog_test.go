@@ -0,0 +1,78 @@
+package worklog
+
+import (
+	"testing"
+	"time"
+
+	"github.com/max-pantom/daily/internal/state"
+)
+
+func session(start time.Time, d time.Duration, tags ...string) state.Session {
+	end := start.Add(d)
+	return state.Session{Start: start, End: &end, Tags: tags}
+}
+
+func issues(entries []Entry) []string {
+	var out []string
+	for _, e := range entries {
+		out = append(out, e.Issue)
+	}
+	return out
+}
+
+func TestPushedPerIssue(t *testing.T) {
+	nine := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	st := &state.State{Days: map[string]*state.DayLog{
+		"2026-03-02": {Sessions: []state.Session{
+			session(nine, 2*time.Hour, "PROJ-1", "proj-2"),
+			session(nine.Add(3*time.Hour), time.Hour, "OPS-7"),
+		}},
+	}}
+	entries := Collect(st, state.Filter{}, Jira)
+	if got := issues(entries); len(got) != 3 || got[0] != "PROJ-1" || got[1] != "PROJ-2" || got[2] != "OPS-7" {
+		t.Fatalf("Collect = %v, want PROJ-1, PROJ-2 and OPS-7", got)
+	}
+	if entries[0].Seconds != 3600 {
+		t.Errorf("PROJ-1 gets %ds, want half the session", entries[0].Seconds)
+	}
+
+	// PROJ-2 failed to send: only PROJ-1 and OPS-7 are marked.
+	if lost := MarkPushed(st, []Entry{entries[0], entries[2]}, Jira); len(lost) != 0 {
+		t.Fatalf("MarkPushed lost %v", issues(lost))
+	}
+	if got := issues(Collect(st, state.Filter{}, Jira)); len(got) != 1 || got[0] != "PROJ-2" {
+		t.Errorf("after a partial push Collect = %v, want PROJ-2 alone", got)
+	}
+	if got := issues(Collect(st, state.Filter{}, Linear)); len(got) != 3 {
+		t.Errorf("Collect for another target = %v, want all three", got)
+	}
+
+	// A bare target, as older versions recorded it, covers the whole session.
+	st.Days["2026-03-02"].Sessions[0].Pushed = []string{Jira}
+	if got := issues(Collect(st, state.Filter{}, Jira)); len(got) != 0 {
+		t.Errorf("with a legacy mark Collect = %v, want nothing", got)
+	}
+}
+
+func TestMarkPushedAfterChanges(t *testing.T) {
+	nine := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	st := &state.State{Days: map[string]*state.DayLog{
+		"2026-03-02": {Sessions: []state.Session{
+			session(nine, time.Hour, "PROJ-1"),
+			session(nine.Add(2*time.Hour), time.Hour, "PROJ-2"),
+		}},
+	}}
+	entries := Collect(st, state.Filter{}, Jira)
+
+	// Meanwhile a session was added before both and the second was deleted.
+	log := st.Days["2026-03-02"]
+	log.Sessions = []state.Session{session(nine.Add(-2*time.Hour), time.Hour), log.Sessions[0]}
+
+	lost := MarkPushed(st, entries, Jira)
+	if got := issues(lost); len(got) != 1 || got[0] != "PROJ-2" {
+		t.Fatalf("MarkPushed lost %v, want PROJ-2", got)
+	}
+	if !Pushed(log.Sessions[1], Jira, "PROJ-1") {
+		t.Errorf("the moved PROJ-1 session is not marked: %v", log.Sessions[1].Pushed)
+	}
+}
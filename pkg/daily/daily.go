@@ -0,0 +1,127 @@
+// Package daily lets other Go programs, such as bots and dashboards, read and
+// change a daily state file and build its reports and statistics without
+// running the CLI. It never prints or exits; every failure is returned.
+//
+//	store, err := daily.Open("")
+//	if err != nil {
+//		return err
+//	}
+//	err = store.Update(func(st *daily.State) error {
+//		return st.StartSession(time.Now(), []string{"bot"}, "")
+//	})
+//
+// Writes go through the daily daemon when one serves the file, so they do not
+// clobber a running TUI or tray, and are written directly otherwise.
+package daily
+
+import (
+	"errors"
+	"os"
+	"os/user"
+	"path/filepath"
+	"runtime"
+
+	"github.com/max-pantom/daily/internal/daemon"
+)
+
+// ErrConflict is returned by Store.Save when the state changed since it was
+// loaded. Store.Update retries on it.
+var ErrConflict = daemon.ErrConflict
+
+// ErrReadOnly is returned by saves while DAILY_READ_ONLY is set.
+var ErrReadOnly = daemon.ErrReadOnly
+
+// DefaultPath is the state file the CLI uses, or that of a named profile when
+// profile is set. Under sudo it is the invoking user's, as for the CLI.
+func DefaultPath(profile string) (string, error) {
+	cfgDir, err := os.UserConfigDir()
+	if dir := sudoUserConfigDir(); dir != "" {
+		cfgDir, err = dir, nil
+	}
+	if err != nil || cfgDir == "" {
+		home, hErr := os.UserHomeDir()
+		if hErr != nil {
+			return "", errors.New("cannot determine config directory")
+		}
+		cfgDir = filepath.Join(home, ".config")
+	}
+	if profile != "" {
+		return filepath.Join(cfgDir, "daily", "profiles", profile, "state.json"), nil
+	}
+	return filepath.Join(cfgDir, "daily", "state.json"), nil
+}
+
+// sudoUserConfigDir returns the invoking user's config dir when running under sudo
+// (focus mode needs root), so both share one state file.
+func sudoUserConfigDir() string {
+	name := os.Getenv("SUDO_USER")
+	if name == "" || os.Geteuid() != 0 {
+		return ""
+	}
+	u, err := user.Lookup(name)
+	if err != nil {
+		return ""
+	}
+	if runtime.GOOS == "darwin" {
+		return filepath.Join(u.HomeDir, "Library", "Application Support")
+	}
+	return filepath.Join(u.HomeDir, ".config")
+}
+
+// Store is a state file.
+type Store struct {
+	path string
+}
+
+// Open returns the store for the state file at path, or the default one when
+// path is empty. The file need not exist yet.
+func Open(path string) (*Store, error) {
+	if path == "" {
+		var err error
+		if path, err = DefaultPath(""); err != nil {
+			return nil, err
+		}
+	}
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Store{path: path}, nil
+}
+
+// Path is the state file's absolute path.
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Load reads the state, with defaults when the file does not exist yet.
+func (s *Store) Load() (*State, error) {
+	return daemon.Load(s.path)
+}
+
+// Save writes st back, returning ErrConflict if the state changed since st
+// was loaded.
+func (s *Store) Save(st *State) error {
+	return daemon.Save(s.path, st)
+}
+
+// Update loads the state, brings it up to date, applies fn and saves the
+// result, retrying when another process wrote in between. Nothing is saved if
+// fn returns an error.
+func (s *Store) Update(fn func(*State) error) error {
+	return daemon.Update(s.path, fn)
+}
+
+// Running reports whether a daily daemon serves the store.
+func (s *Store) Running() bool {
+	return daemon.Running(s.path)
+}
+
+// Watcher reports changes to a store until closed.
+type Watcher = daemon.Watcher
+
+// Watch reports each change to the state, by any process, until the watcher
+// is closed.
+func (s *Store) Watch() (Watcher, error) {
+	return daemon.Watch(s.path)
+}
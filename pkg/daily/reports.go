@@ -0,0 +1,57 @@
+package daily
+
+import (
+	"time"
+
+	"github.com/max-pantom/daily/internal/report"
+	"github.com/max-pantom/daily/internal/stats"
+)
+
+// WeekReport covers the seven days ending with now. Report.Write renders it
+// as text, md or csv, and Report.WriteHTML as a page.
+func WeekReport(st *State, now time.Time, f Filter) Report {
+	return report.Week(st, now, f)
+}
+
+// MonthReport covers the calendar month of now, up to now.
+func MonthReport(st *State, now time.Time, f Filter) Report {
+	return report.Month(st, now, f)
+}
+
+// RangeReport covers every day from from to to, inclusive.
+func RangeReport(st *State, title string, from, to time.Time, f Filter) Report {
+	return report.Build(st, title, from, to, f)
+}
+
+// Standup is the last day worked before today and today so far, from session
+// notes and tags, in Slack markdown.
+func Standup(st *State, now time.Time) string {
+	return report.Standup(st, now)
+}
+
+// Habits summarizes work habits from from to to, inclusive.
+func Habits(st *State, from, to time.Time) Summary {
+	return stats.Range(st, from, to)
+}
+
+// FocusStats measures the finished sessions matching f from from to to,
+// inclusive: session lengths, focus stretches and fragmentation.
+func FocusStats(st *State, from, to time.Time, f Filter) Focus {
+	return stats.FocusRange(st, from, to, f)
+}
+
+// Hours spreads the finished sessions matching f from from to to, inclusive,
+// over the hours of the week they ran in.
+func Hours(st *State, from, to time.Time, f Filter) Profile {
+	return stats.Hours(st, from, to, f)
+}
+
+// Streak counts the workdays in a row up to to that met their goal.
+func Streak(st *State, to time.Time) int {
+	return stats.Streak(st, to)
+}
+
+// WeekForecast projects the week of now to its Sunday against the weekly goal.
+func WeekForecast(st *State, now time.Time) Forecast {
+	return stats.ForecastWeek(st, now)
+}
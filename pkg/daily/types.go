@@ -0,0 +1,64 @@
+package daily
+
+import (
+	"github.com/max-pantom/daily/internal/report"
+	"github.com/max-pantom/daily/internal/state"
+	"github.com/max-pantom/daily/internal/stats"
+)
+
+// The types below are those of the state file and the reports built from it,
+// with their methods. They keep their JSON form, which is the state file's.
+type (
+	// State is the whole state file: settings, the running session or break
+	// and every logged day.
+	State = state.State
+	// Session is a stretch of work, or of a break in DayLog.Breaks.
+	Session = state.Session
+	// DayLog is one day's sessions, breaks and totals.
+	DayLog = state.DayLog
+	// Interruption is a pause in a session for something that came up.
+	Interruption = state.Interruption
+	// Filter narrows sessions by tag, project and date range.
+	Filter = state.Filter
+	// Interval is a span of time; see Subtract and Union.
+	Interval = state.Interval
+	// RuleEnv is what tag rules may look at beyond the session: set
+	// State.RuleEnv before starting or stopping one.
+	RuleEnv = state.RuleEnv
+	// OverlapError is returned by State.AddSession when OverlapRefuse finds
+	// a session in the way.
+	OverlapError = state.OverlapError
+
+	// Report summarizes a date range day by day, with totals by tag and
+	// interruption reason.
+	Report = report.Report
+	// ReportDay is one row of a Report.
+	ReportDay = report.Day
+	// Summary describes work habits over a date range.
+	Summary = stats.Summary
+	// Focus describes how work was split into sessions over a date range.
+	Focus = stats.Focus
+	// Profile is work by weekday and hour of day, Monday first, in seconds.
+	Profile = stats.Profile
+	// Peak is the busiest block of stats.PeakHours hours in a Profile.
+	Peak = stats.Peak
+	// Forecast projects the week's work against the weekly goal.
+	Forecast = stats.Forecast
+)
+
+// Overlap policies for State.AddSession.
+const (
+	OverlapRefuse = state.OverlapRefuse
+	OverlapTrim   = state.OverlapTrim
+	OverlapMerge  = state.OverlapMerge
+)
+
+// Subtract returns the parts of i that none of cut covers, in order.
+func Subtract(i Interval, cut []Interval) []Interval {
+	return state.Subtract(i, cut)
+}
+
+// Union merges overlapping or touching intervals, sorted.
+func Union(list []Interval) []Interval {
+	return state.Union(list)
+}
@@ -7,6 +7,9 @@ set -euo pipefail
 #   VERSION=v0.1.3 scripts/package.sh
 #   TARGETS="darwin arm64" VERSION=v0.1.3 scripts/package.sh   # override targets (requires proper SDK/CGO)
 # Outputs tarballs named daily_${VERSION}_${os}_${arch}.tar.gz and a checksums.txt.
+# With SIGNING_KEY pointing at an Ed25519 private key in PEM (openssl genpkey -algorithm ed25519),
+# the binaries embed its public key and checksums.txt.sig signs the checksums, so daily update
+# refuses releases not signed with it.
 
 VERSION=${VERSION:-}
 if [[ -z "$VERSION" ]]; then
@@ -31,6 +34,12 @@ else
   targets=("$(go env GOOS) $(go env GOARCH)")
 fi
 
+LDFLAGS="-X github.com/max-pantom/daily/internal/update.Version=$VERSION"
+if [[ -n "${SIGNING_KEY:-}" ]]; then
+  PUBKEY=$(openssl pkey -in "$SIGNING_KEY" -pubout -outform DER | tail -c 32 | base64)
+  LDFLAGS="$LDFLAGS -X github.com/max-pantom/daily/internal/update.SigningKey=$PUBKEY"
+fi
+
 for target in "${targets[@]}"; do
   read -r os arch <<<"$target"
   echo "Building $os/$arch..."
@@ -40,13 +49,16 @@ for target in "${targets[@]}"; do
   if [[ "$os" == "darwin" ]]; then
     CGO=1
   fi
-  GOOS=$os GOARCH=$arch CGO_ENABLED=$CGO go build -o "$OUT/$BIN" ./cmd/daily
+  GOOS=$os GOARCH=$arch CGO_ENABLED=$CGO go build -ldflags "$LDFLAGS" -o "$OUT/$BIN" ./cmd/daily
   tar -C "$OUT" -czf "$OUT/$TAR" "$BIN"
   rm -f "$OUT/$BIN"
 done
 
 pushd "$OUT" >/dev/null
 shasum -a 256 daily_${VERSION}_*.tar.gz > checksums.txt
+if [[ -n "${SIGNING_KEY:-}" ]]; then
+  openssl pkeyutl -sign -inkey "$SIGNING_KEY" -rawin -in checksums.txt | base64 > checksums.txt.sig
+fi
 popd >/dev/null
 
 echo "Artifacts in $OUT"
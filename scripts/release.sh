@@ -17,7 +17,11 @@ echo "Building artifacts for $VERSION ..."
 VERSION=$VERSION "$ROOT/scripts/package.sh"
 
 echo "Creating release $VERSION ..."
-gh release create "$VERSION" "$ROOT"/dist/daily_${VERSION}_*.tar.gz "$ROOT"/dist/checksums.txt \
+assets=("$ROOT"/dist/daily_${VERSION}_*.tar.gz "$ROOT"/dist/checksums.txt)
+if [[ -f "$ROOT/dist/checksums.txt.sig" ]]; then
+  assets+=("$ROOT/dist/checksums.txt.sig")
+fi
+gh release create "$VERSION" "${assets[@]}" \
   --title "$VERSION" \
   --notes "Automated release $VERSION"
 